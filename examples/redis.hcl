@@ -0,0 +1,17 @@
+# Redis Example
+# Fake Redis server speaking the RESP protocol, usable with any Redis client.
+#
+# Usage:
+#   polymorph server -c examples/redis.hcl
+#
+# Test with redis-cli:
+#   redis-cli -p 6380 SET greeting hello EX 60
+#   OK
+#   redis-cli -p 6380 GET greeting
+#   "hello"
+#   redis-cli -p 6380 INCR visits
+#   (integer) 1
+
+service "redis" "cache" {
+  listen = "0.0.0.0:6380"
+}
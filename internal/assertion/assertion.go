@@ -0,0 +1,275 @@
+// Package assertion smoke-tests running services: each assertion, declared
+// in HCL, sends one request and checks the response's status and body.
+package assertion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// File is the root of an assertions file
+type File struct {
+	Assertions []*Assertion `hcl:"assert,block"`
+}
+
+// Assertion sends one request and checks the response
+type Assertion struct {
+	Name string `hcl:"name,label"`
+	// Service names the service the request is sent to. It can be left out
+	// when there is only one http service.
+	Service string `hcl:"service,optional"`
+	// Request is the method and path, as in "GET /health"
+	Request string            `hcl:"request"`
+	Headers map[string]string `hcl:"headers,optional"`
+	Body    hcl.Expression    `hcl:"body,optional"`
+	// Status is the response status expected
+	Status *int `hcl:"status,optional"`
+	// Expect is a condition, or a list of conditions, on the response's
+	// status, headers and body
+	Expect hcl.Expression `hcl:"expect,optional"`
+	Remain hcl.Body       `hcl:",remain"`
+
+	// src is the file the assertion was parsed from, for quoting conditions
+	src []byte
+}
+
+// Load reads assertions from an HCL file
+func Load(path string) ([]*Assertion, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assertions: %w", err)
+	}
+	return Parse(src, path)
+}
+
+// Parse parses assertions from HCL source
+func Parse(src []byte, filename string) ([]*Assertion, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse assertions: %s", diags.Error())
+	}
+
+	var f File
+	if diags := gohcl.DecodeBody(file.Body, config.NewEvalContext(nil, nil), &f); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode assertions: %s", diags.Error())
+	}
+	if len(f.Assertions) == 0 {
+		return nil, fmt.Errorf("%s declares no assert blocks", filename)
+	}
+
+	for _, a := range f.Assertions {
+		if _, _, err := splitRequest(a.Request); err != nil {
+			return nil, fmt.Errorf("assert %q: %w", a.Name, err)
+		}
+		a.src = src
+	}
+	return f.Assertions, nil
+}
+
+// splitRequest splits a request such as "GET /health" into method and path
+func splitRequest(request string) (method, path string, err error) {
+	parts := strings.Fields(request)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "/") {
+		return "", "", fmt.Errorf("request %q must be a method and path, such as \"GET /health\"", request)
+	}
+	return strings.ToUpper(parts[0]), parts[1], nil
+}
+
+// Result is the outcome of one assertion
+type Result struct {
+	Name string
+	// Failures lists the checks the response failed
+	Failures []string
+	// Err is set when the request couldn't be made
+	Err error
+}
+
+// Passed reports whether the request was made and every check held
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Runner sends assertions' requests to running services
+type Runner struct {
+	// Targets maps service names to their base URLs
+	Targets map[string]string
+	Client  *http.Client
+}
+
+// NewRunner creates a runner for services at the given base URLs
+func NewRunner(targets map[string]string) *Runner {
+	return &Runner{
+		Targets: targets,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Run checks each assertion in order, calling report with each result, and
+// returns how many failed
+func (rn *Runner) Run(ctx context.Context, assertions []*Assertion, report func(Result)) int {
+	var failed int
+	for _, a := range assertions {
+		result := rn.check(ctx, a)
+		if !result.Passed() {
+			failed++
+		}
+		report(result)
+	}
+	return failed
+}
+
+// check sends an assertion's request and checks the response
+func (rn *Runner) check(ctx context.Context, a *Assertion) Result {
+	result := Result{Name: a.Name}
+
+	base, err := rn.target(a.Service)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	method, path, err := splitRequest(a.Request)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	evalCtx := config.NewEvalContext(nil, nil)
+	var body io.Reader
+	if a.Body != nil {
+		val, diags := a.Body.Value(evalCtx)
+		if diags.HasErrors() {
+			result.Err = fmt.Errorf("failed to evaluate body: %s", diags.Error())
+			return result
+		}
+		if !val.IsNull() {
+			if !val.Type().Equals(cty.String) {
+				result.Err = fmt.Errorf("body must be a string, got %s", val.Type().FriendlyName())
+				return result
+			}
+			body = strings.NewReader(val.AsString())
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, body)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	for k, v := range a.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := rn.Client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read response: %w", err)
+		return result
+	}
+
+	if a.Status != nil && resp.StatusCode != *a.Status {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected status %d, got %d", *a.Status, resp.StatusCode))
+	}
+
+	evalCtx.Variables["status"] = cty.NumberIntVal(int64(resp.StatusCode))
+	evalCtx.Variables["headers"] = headersToCty(resp.Header)
+	evalCtx.Variables["body"] = bodyToCty(data)
+	for _, expr := range conditions(a.Expect) {
+		if failure := checkCondition(expr, evalCtx, a.src); failure != "" {
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+	return result
+}
+
+// target returns the base URL of the named service. With no name, the only
+// service is used.
+func (rn *Runner) target(name string) (string, error) {
+	if name == "" {
+		if len(rn.Targets) != 1 {
+			return "", fmt.Errorf("service is required when there are %d http services", len(rn.Targets))
+		}
+		for _, base := range rn.Targets {
+			return base, nil
+		}
+	}
+	base, ok := rn.Targets[name]
+	if !ok {
+		return "", fmt.Errorf("no http service named %q", name)
+	}
+	return base, nil
+}
+
+// conditions returns the conditions of an expect attribute, which holds a
+// single condition or a list of them. An unset attribute has none.
+func conditions(expr hcl.Expression) []hcl.Expression {
+	if expr == nil {
+		return nil
+	}
+	if exprs, diags := hcl.ExprList(expr); !diags.HasErrors() {
+		return exprs
+	}
+	if val, diags := expr.Value(nil); !diags.HasErrors() && val.IsNull() {
+		return nil
+	}
+	return []hcl.Expression{expr}
+}
+
+// checkCondition evaluates one condition, returning a description of the
+// failure or "" if it holds
+func checkCondition(expr hcl.Expression, evalCtx *hcl.EvalContext, src []byte) string {
+	text := strings.TrimSpace(string(expr.Range().SliceBytes(src)))
+	val, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return fmt.Sprintf("%s: %s", text, diags.Error())
+	}
+	if val.IsNull() || !val.IsKnown() || !val.Type().Equals(cty.Bool) {
+		return fmt.Sprintf("%s: must be a bool", text)
+	}
+	if val.False() {
+		return fmt.Sprintf("expected %s", text)
+	}
+	return ""
+}
+
+// headersToCty converts response headers to a map of their first values
+func headersToCty(header http.Header) cty.Value {
+	if len(header) == 0 {
+		return cty.MapValEmpty(cty.String)
+	}
+	vals := make(map[string]cty.Value, len(header))
+	for k := range header {
+		vals[k] = cty.StringVal(header.Get(k))
+	}
+	return cty.MapVal(vals)
+}
+
+// bodyToCty converts a response body to a value, decoding it if it is JSON
+func bodyToCty(data []byte) cty.Value {
+	if json.Valid(data) {
+		if ty, err := ctyjson.ImpliedType(data); err == nil {
+			if val, err := ctyjson.Unmarshal(data, ty); err == nil {
+				return val
+			}
+		}
+	}
+	return cty.StringVal(string(bytes.TrimSpace(data)))
+}
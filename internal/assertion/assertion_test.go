@@ -0,0 +1,88 @@
+package assertion
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	servicehttp "github.com/jumppad-labs/polymorph/internal/service/http"
+	"github.com/stretchr/testify/require"
+)
+
+// startService starts the http service in testdata/config.hcl and returns a
+// runner aimed at it
+func startService(t *testing.T) *Runner {
+	t.Helper()
+	cfg, err := parser.ParseFile("testdata/config.hcl")
+	require.NoError(t, err)
+
+	svc, err := servicehttp.NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+
+	return NewRunner(map[string]string{"api": "http://" + svc.ResolvedAddress()})
+}
+
+func TestRunner_Passing(t *testing.T) {
+	runner := startService(t)
+	assertions, err := Load("testdata/passing.hcl")
+	require.NoError(t, err)
+
+	var results []Result
+	failed := runner.Run(context.Background(), assertions, func(r Result) { results = append(results, r) })
+	require.Len(t, results, 2)
+	for _, r := range results {
+		require.True(t, r.Passed(), "%s: %v %v", r.Name, r.Err, r.Failures)
+	}
+	require.Zero(t, failed)
+}
+
+func TestRunner_Failing(t *testing.T) {
+	runner := startService(t)
+	assertions, err := Load("testdata/failing.hcl")
+	require.NoError(t, err)
+
+	var results []Result
+	failed := runner.Run(context.Background(), assertions, func(r Result) { results = append(results, r) })
+	require.Equal(t, 2, failed)
+	require.Len(t, results, 3)
+
+	require.True(t, results[0].Passed())
+	require.Equal(t, []string{"expected status 200, got 404"}, results[1].Failures)
+	// Only the condition that doesn't hold is reported, quoted from the file
+	require.Equal(t, []string{`expected body.status == "degraded"`}, results[2].Failures)
+}
+
+func TestRunner_Target(t *testing.T) {
+	assertions, err := Parse([]byte(`
+assert "health" {
+  service = "web"
+  request = "GET /health"
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	// Nothing is listening: the request error is reported, not a failure
+	var result Result
+	NewRunner(map[string]string{"web": "http://127.0.0.1:1"}).Run(context.Background(), assertions, func(r Result) { result = r })
+	require.Error(t, result.Err)
+	require.False(t, result.Passed())
+
+	NewRunner(map[string]string{"api": "http://127.0.0.1:1"}).Run(context.Background(), assertions, func(r Result) { result = r })
+	require.ErrorContains(t, result.Err, `no http service named "web"`)
+
+	assertions[0].Service = ""
+	NewRunner(map[string]string{"a": "http://a", "b": "http://b"}).Run(context.Background(), assertions, func(r Result) { result = r })
+	require.ErrorContains(t, result.Err, "service is required")
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse([]byte(`assert "bad" { request = "/health" }`), "test.hcl")
+	require.ErrorContains(t, err, `must be a method and path`)
+
+	_, err = Parse([]byte(``), "test.hcl")
+	require.ErrorContains(t, err, "declares no assert blocks")
+}
@@ -0,0 +1,16 @@
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "health" {
+    route = "GET /health"
+    response {
+      body = jsonencode({ status = "ok" })
+    }
+  }
+
+  resource "user" {
+    rows = 3
+    field "id"   { type = "uuid" }
+    field "name" { type = "name" }
+  }
+}
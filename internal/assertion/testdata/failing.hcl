@@ -0,0 +1,18 @@
+assert "health" {
+  request = "GET /health"
+  status  = 200
+  expect  = body.status == "ok"
+}
+
+assert "wrong status" {
+  request = "GET /missing"
+  status  = 200
+}
+
+assert "wrong body" {
+  request = "GET /health"
+  expect = [
+    status == 200,
+    body.status == "degraded",
+  ]
+}
@@ -0,0 +1,16 @@
+assert "health" {
+  request = "GET /health"
+  status  = 200
+  expect  = body.status == "ok"
+}
+
+assert "create user" {
+  request = "POST /users"
+  headers = { "Content-Type" = "application/json" }
+  body    = jsonencode({ id = "u1", name = "Ada" })
+  status  = 201
+  expect = [
+    body.name == "Ada",
+    headers["Content-Type"] == "application/json",
+  ]
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/jumppad-labs/polymorph/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check <path>",
+	Short: "Check service references and listen addresses before a run",
+	Long: `Parse and validate a configuration, then print the upstream graph inferred
+from service references. References to services that don't exist are
+reported as errors, as are services that share a listen address.
+
+With --dial, also check that every listen address can be bound on this
+machine right now.
+
+Example:
+  polymorph check config.hcl --dial`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runCheck,
+	SilenceUsage: true,
+}
+
+var checkDial bool
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkDial, "dial", false, "check that each listen address can be bound")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	cfg, err := parser.ParseFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := parser.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	printUpstreamGraph(out, cfg.Services)
+
+	if !checkDial {
+		return nil
+	}
+
+	var problems []string
+	for _, svc := range cfg.Services {
+		if err := service.CheckListen(svc.ServiceName(), parser.ListenAddresses(svc)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	fmt.Fprintln(out)
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(out, p)
+		}
+		return fmt.Errorf("%d listen problem(s) found", len(problems))
+	}
+	fmt.Fprintln(out, "All listen addresses are free.")
+	return nil
+}
+
+// printUpstreamGraph writes each service with the services it depends on
+func printUpstreamGraph(w io.Writer, services []config.Service) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tTYPE\tLISTEN\tUPSTREAMS")
+	for _, svc := range services {
+		upstreams := "-"
+		if u := svc.GetInferredUpstreams(); len(u) > 0 {
+			upstreams = strings.Join(u, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", svc.ServiceName(), svc.ServiceType(), strings.Join(parser.ListenAddresses(svc), ", "), upstreams)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect Polymorph configuration",
+}
+
+var configDumpCmd = &cobra.Command{
+	Use:   "dump <path>",
+	Short: "Print the resolved configuration as JSON",
+	Long:  `Parse and validate a configuration file or directory, then print the resolved services, upstreams and handlers as JSON for editors and other tooling.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigDump,
+}
+
+func init() {
+	configCmd.AddCommand(configDumpCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigDump(cmd *cobra.Command, args []string) error {
+	cfg, err := parser.ParseFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if err := parser.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	out, err := parser.Dump(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
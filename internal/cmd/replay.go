@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jumppad-labs/polymorph/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay recorded requests against a target",
+	Long: `Replay recorded requests against a target as synthetic traffic, keeping the
+gaps between them. Logs are request log entries or captured exchanges from
+/debug/requests, as a JSON array or one JSON object per line.
+
+Example:
+  polymorph replay --logs requests.json --target http://localhost:8080 --speed 2x`,
+	RunE:         runReplay,
+	SilenceUsage: true,
+}
+
+var (
+	replayLogsPath    string
+	replayTarget      string
+	replaySpeed       string
+	replayConcurrency int
+)
+
+func init() {
+	replayCmd.Flags().StringVar(&replayLogsPath, "logs", "", "path to the recorded request logs (required)")
+	replayCmd.Flags().StringVar(&replayTarget, "target", "", "base URL to send requests to (required)")
+	replayCmd.Flags().StringVar(&replaySpeed, "speed", "1x", "playback speed, such as 2x for twice as fast")
+	replayCmd.Flags().IntVar(&replayConcurrency, "concurrency", replay.DefaultConcurrency, "maximum requests in flight")
+	replayCmd.MarkFlagRequired("logs")
+	replayCmd.MarkFlagRequired("target")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	speed, err := replay.ParseSpeed(replaySpeed)
+	if err != nil {
+		return err
+	}
+
+	target, err := url.Parse(replayTarget)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return fmt.Errorf("invalid target %q: must be a URL such as http://localhost:8080", replayTarget)
+	}
+	if replayConcurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d: must be at least 1", replayConcurrency)
+	}
+
+	f, err := os.Open(replayLogsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open logs: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := replay.Load(f)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	out := cmd.OutOrStdout()
+	var failed, skipped int
+	replayer := replay.NewReplayer(target, speed)
+	replayer.Concurrency = replayConcurrency
+	err = replayer.Run(ctx, entries, func(r replay.Result) {
+		path := r.Entry.Target()
+		if r.Skipped {
+			skipped++
+			fmt.Fprintf(out, "%s %s skipped: request body was truncated when captured\n", r.Entry.Method, path)
+			return
+		}
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(out, "%s %s error: %v\n", r.Entry.Method, path, r.Err)
+			return
+		}
+		fmt.Fprintf(out, "%s %s %d %dms\n", r.Entry.Method, path, r.Status, r.Duration.Milliseconds())
+	})
+	if err != nil {
+		return fmt.Errorf("replay interrupted: %w", err)
+	}
+
+	fmt.Fprintf(out, "Replayed %d requests (%d failed, %d skipped).\n", len(entries)-skipped, failed, skipped)
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	nethttp "net/http"
+	"strings"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	configproxy "github.com/jumppad-labs/polymorph/internal/config/proxy"
+	"github.com/jumppad-labs/polymorph/internal/service"
+	"github.com/jumppad-labs/polymorph/internal/service/http"
+)
+
+// startQuietServices starts a config's services with their logs discarded,
+// for commands that print their own output while the services run
+func startQuietServices(ctx context.Context, cfg *config.Config) (*service.Registry, error) {
+	for _, svc := range cfg.Services {
+		if err := service.CheckListen(svc.ServiceName(), parser.ListenAddresses(svc)); err != nil {
+			return nil, err
+		}
+	}
+
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+	loggers := make(map[string]*slog.Logger, len(cfg.Services))
+	for _, svc := range cfg.Services {
+		loggers[svc.ServiceName()] = quiet
+	}
+	services, err := service.CreateServices(cfg, loggers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create services: %w", err)
+	}
+	services, err = service.OrderByUpstreams(services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to order services: %w", err)
+	}
+
+	registry := service.NewRegistry(http.NewServiceLogRegistry())
+	for _, svc := range services {
+		registry.Register(svc)
+	}
+	if err := registry.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start services: %w", err)
+	}
+	return registry, nil
+}
+
+// mockTransport trusts any certificate, since mock services' TLS
+// certificates are usually self-signed
+func mockTransport() *nethttp.Transport {
+	return &nethttp.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+}
+
+// httpTargets returns the base URL of each running http and proxy service
+// by name. Services on Unix sockets are left out.
+func httpTargets(configs []config.Service, running []service.Service) map[string]string {
+	schemes := make(map[string]string)
+	for _, cfg := range configs {
+		switch c := cfg.(type) {
+		case *confighttp.Service:
+			schemes[c.Name] = scheme(c.TLS)
+		case *configproxy.Service:
+			schemes[c.Name] = scheme(c.TLS)
+		}
+	}
+
+	targets := make(map[string]string)
+	for _, svc := range running {
+		s, ok := schemes[svc.Name()]
+		addr := svc.ResolvedAddress()
+		if !ok || strings.HasPrefix(addr, "unix://") {
+			continue
+		}
+		targets[svc.Name()] = s + "://" + addr
+	}
+	return targets
+}
+
+// scheme returns the URL scheme of a service with the given TLS config
+func scheme(tlsCfg *config.TLSConfig) string {
+	if tlsCfg != nil {
+		return "https"
+	}
+	return "http"
+}
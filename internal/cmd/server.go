@@ -3,10 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	nethttp "net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/jumppad-labs/polymorph/internal/config/parser"
 	"github.com/jumppad-labs/polymorph/internal/logging"
@@ -16,6 +21,7 @@ import (
 	"github.com/jumppad-labs/polymorph/internal/service/http"       // Need for log registry
 	_ "github.com/jumppad-labs/polymorph/internal/service/postgres" // Register PostgreSQL service
 	_ "github.com/jumppad-labs/polymorph/internal/service/proxy"    // Register Proxy service
+	_ "github.com/jumppad-labs/polymorph/internal/service/redis"    // Register Redis service
 	_ "github.com/jumppad-labs/polymorph/internal/service/tcp"      // Register TCP service
 	"github.com/jumppad-labs/polymorph/internal/tracing"
 	"github.com/spf13/cobra"
@@ -28,10 +34,22 @@ var serverCmd = &cobra.Command{
 	RunE:  runServer,
 }
 
-var serverConfigPath string
+var (
+	serverConfigPath  string
+	serverGracePeriod time.Duration
+	serverDrainDelay  time.Duration
+	serverPortOffset  int
+	serverLogRequests bool
+	serverStatusAddr  string
+)
 
 func init() {
 	serverCmd.Flags().StringVarP(&serverConfigPath, "config", "c", "", "path to configuration file or directory (required)")
+	serverCmd.Flags().DurationVar(&serverGracePeriod, "grace-period", 30*time.Second, "how long to wait for in-flight requests to complete on shutdown")
+	serverCmd.Flags().DurationVar(&serverDrainDelay, "drain-delay", 0, "how long to keep serving with failing health checks before stopping on shutdown")
+	serverCmd.Flags().IntVar(&serverPortOffset, "port-offset", 0, "add this value to every service's listen port")
+	serverCmd.Flags().BoolVar(&serverLogRequests, "log-requests", false, "print each HTTP request to stdout as it is served")
+	serverCmd.Flags().StringVar(&serverStatusAddr, "status-addr", "", "serve the aggregated status of every service on this address, such as 127.0.0.1:9000")
 	serverCmd.MarkFlagRequired("config")
 	rootCmd.AddCommand(serverCmd)
 }
@@ -43,7 +61,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}
 
 	// Parse config
-	cfg, err := parser.ParseFile(serverConfigPath)
+	cfg, err := parser.ParseFile(serverConfigPath, parser.WithPortOffset(serverPortOffset))
 	if err != nil {
 		return fmt.Errorf("failed to parse config: %w", err)
 	}
@@ -127,12 +145,25 @@ func runServer(cmd *cobra.Command, args []string) error {
 		slog.Warn("failed to initialize tracing", "error", err)
 	}
 
+	// Fail fast, before anything starts, if a listen address is taken
+	for _, svc := range cfg.Services {
+		if err := service.CheckListen(svc.ServiceName(), parser.ListenAddresses(svc)); err != nil {
+			return err
+		}
+	}
+
 	// Create services
 	services, err := service.CreateServices(cfg, serviceLoggers)
 	if err != nil {
 		return fmt.Errorf("failed to create services: %w", err)
 	}
 
+	// Start upstreams before the services that depend on them
+	services, err = service.OrderByUpstreams(services)
+	if err != nil {
+		return fmt.Errorf("failed to order services: %w", err)
+	}
+
 	// Create request log registry
 	logRegistry := http.NewServiceLogRegistry()
 
@@ -142,6 +173,16 @@ func runServer(cmd *cobra.Command, args []string) error {
 		registry.Register(svc)
 	}
 
+	// Tee request logs to the terminal
+	if serverLogRequests {
+		logWriter := http.NewRequestLogWriter(os.Stdout)
+		for _, svc := range services {
+			if hs, ok := svc.(*http.HTTPService); ok {
+				hs.GetRequestLogger().(*http.RequestLogger).Subscribe(logWriter.Subscriber(svc.Name()))
+			}
+		}
+	}
+
 	// Configure Lattice integration if specified
 	if err := registry.ConfigureLattice(cfg.Lattice, cfg.Services); err != nil {
 		return fmt.Errorf("failed to configure lattice: %w", err)
@@ -154,16 +195,29 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}
 
 	slog.Info("all services started")
+	printServiceAddresses(os.Stdout, registry.Services())
+
+	// Serve the aggregated status on its own port, kept up while draining
+	if serverStatusAddr != "" {
+		statusServer, err := startStatusServer(serverStatusAddr, registry)
+		if err != nil {
+			registry.Stop(ctx)
+			return err
+		}
+		defer statusServer.Close()
+	}
 
 	// Wait for interrupt signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 
 	<-sigCh
-	slog.Info("shutdown signal received, stopping services")
+	slog.Info("shutdown signal received, draining services", "drain_delay", serverDrainDelay, "grace_period", serverGracePeriod)
 
-	// Stop services
-	if err := registry.Stop(ctx); err != nil {
+	// Fail health checks and keep serving for the drain delay, then give
+	// in-flight requests the grace period to complete before connections
+	// are closed
+	if err := registry.Shutdown(serverDrainDelay, serverGracePeriod); err != nil {
 		return fmt.Errorf("failed to stop services: %w", err)
 	}
 
@@ -178,3 +232,31 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// startStatusServer serves the registry's aggregated status on addr
+func startStatusServer(addr string, registry *service.Registry) (*nethttp.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for status on %s: %w", addr, err)
+	}
+
+	server := &nethttp.Server{Handler: registry.StatusHandler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != nethttp.ErrServerClosed {
+			slog.Error("status server error", "error", err)
+		}
+	}()
+	slog.Info("status listening", "addr", listener.Addr().String(), "path", service.StatusPath)
+	return server, nil
+}
+
+// printServiceAddresses writes a table of each service and the address it
+// bound to, so ports chosen for :0 listeners can be discovered.
+func printServiceAddresses(w io.Writer, services []service.Service) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tTYPE\tADDRESS")
+	for _, svc := range services {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", svc.Name(), svc.Type(), svc.ResolvedAddress())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/jumppad-labs/polymorph/internal/meta"
+	"github.com/jumppad-labs/polymorph/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell <config>",
+	Short: "Start services and explore them interactively",
+	Long: `Start the services in a configuration and read commands until exit, end
+of input or Ctrl-C. Type a request such as GET /users to send it to a service and
+print the response, or resources to list generated data. Type help for the
+full list of commands.
+
+Example:
+  polymorph shell config.hcl`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runShell,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	cfg, err := parser.ParseFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := parser.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	registry, err := startQuietServices(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer registry.Stop(context.Background())
+
+	out := cmd.OutOrStdout()
+	printServiceAddresses(out, registry.Services())
+	fmt.Fprintln(out, "Type help for a list of commands.")
+
+	sh := shell.New(httpTargets(cfg.Services, registry.Services()), meta.NewMetaService(cfg.Services, nil, nil), out)
+	sh.Client.Transport = mockTransport()
+	return sh.Run(ctx, cmd.InOrStdin())
+}
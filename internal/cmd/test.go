@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jumppad-labs/polymorph/internal/assertion"
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test <config>",
+	Short: "Start services and check them against assertions",
+	Long: `Start the services in a configuration, send each request declared in an
+assertions file and check the responses, then stop the services. Each
+result is printed, and the command fails if any assertion does.
+
+Example:
+  polymorph test config.hcl --assert assertions.hcl`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runTest,
+	SilenceUsage: true,
+}
+
+var testAssertPath string
+
+func init() {
+	testCmd.Flags().StringVar(&testAssertPath, "assert", "", "path to the assertions file (required)")
+	testCmd.MarkFlagRequired("assert")
+	rootCmd.AddCommand(testCmd)
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	assertions, err := assertion.Load(testAssertPath)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := parser.ParseFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := parser.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	registry, err := startQuietServices(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer registry.Stop(context.Background())
+
+	runner := assertion.NewRunner(httpTargets(cfg.Services, registry.Services()))
+	runner.Client.Transport = mockTransport()
+
+	out := cmd.OutOrStdout()
+	failed := runner.Run(ctx, assertions, func(r assertion.Result) {
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(out, "FAIL %s: %v\n", r.Name, r.Err)
+		case len(r.Failures) > 0:
+			fmt.Fprintf(out, "FAIL %s\n", r.Name)
+			for _, f := range r.Failures {
+				fmt.Fprintf(out, "     %s\n", f)
+			}
+		default:
+			fmt.Fprintf(out, "PASS %s\n", r.Name)
+		}
+	})
+
+	fmt.Fprintf(out, "%d passed, %d failed\n", len(assertions)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d assertion(s) failed", failed)
+	}
+	return nil
+}
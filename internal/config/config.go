@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/zclconf/go-cty/cty"
@@ -13,29 +15,107 @@ type Service interface {
 	ServiceName() string
 	ServiceType() string
 	ServiceListen() string
+	SetListen(string)
 	ServiceTLS() *TLSConfig
 	ServiceLogging() *LoggingConfig
 	Validate() error
 	Expressions() []hcl.Expression
 	SetServiceVars(map[string]cty.Value)
+	SetVariables(map[string]cty.Value)
 	SetInferredUpstreams([]string)
 	GetServiceVars() map[string]cty.Value
+	GetVariables() map[string]cty.Value
 	GetInferredUpstreams() []string
 	GetHandlers() []HandlerConfig
 	GetResources() []*ResourceConfig
 }
 
-// ValidateBase checks constraints shared across all service types.
-// Each per-type Config calls this from its own Validate() method.
+// MultiListener is implemented by services that can listen on more than one
+// address. A listen list sets the service's listen address to its first
+// entry and passes the rest here.
+type MultiListener interface {
+	SetExtraListen([]string)
+	GetExtraListen() []string
+}
+
+// ValidateBase checks constraints shared across all service types,
+// returning every failure joined. Each per-type Config calls this from its
+// own Validate() method.
 func ValidateBase(s Service) error {
+	var errs []error
 	if s.ServiceName() == "" {
-		return fmt.Errorf("service name is required")
+		errs = append(errs, fmt.Errorf("service name is required"))
 	}
 	if s.ServiceListen() == "" {
-		return fmt.Errorf("service %q: listen address is required", s.ServiceName())
+		errs = append(errs, fmt.Errorf("service %q: listen address is required", s.ServiceName()))
 	}
 	if s.ServiceTLS() != nil && (s.ServiceTLS().Cert == "") != (s.ServiceTLS().Key == "") {
-		return fmt.Errorf("service %q: TLS cert and key must both be set or both empty", s.ServiceName())
+		errs = append(errs, fmt.Errorf("service %q: TLS cert and key must both be set or both empty", s.ServiceName()))
+	}
+	// Only http services model transfers with ttfb and body_time
+	for _, h := range s.GetHandlers() {
+		if err := h.Timing.Validate(s.ServiceType() == "http"); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: handler %q: %w", s.ServiceName(), h.Name, err))
+		}
+		for _, step := range h.Steps {
+			if err := step.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: handler %q: %w", s.ServiceName(), h.Name, err))
+			}
+		}
+		// Only http handlers support content_type and response templates
+		respCheck := h.Response.RejectHTTPOptions
+		if s.ServiceType() == "http" {
+			respCheck = h.Response.ValidateTemplate
+		}
+		if err := respCheck(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: handler %q: %w", s.ServiceName(), h.Name, err))
+		}
+		for _, e := range h.Errors {
+			if err := e.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: handler %q: %w", s.ServiceName(), h.Name, err))
+			}
+		}
+		if h.RateLimit != nil {
+			if err := h.RateLimit.Response.RejectHTTPOptions(); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: handler %q: rate_limit: %w", s.ServiceName(), h.Name, err))
+			}
+		}
+	}
+	resourcesValid := true
+	for _, res := range s.GetResources() {
+		if err := res.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: resource %q: %w", s.ServiceName(), res.Name, err))
+			resourcesValid = false
+		}
+	}
+	// Relations are only checked between resources that are valid
+	// themselves
+	if !resourcesValid {
+		return errors.Join(errs...)
+	}
+	if _, err := SortResources(s.GetResources()); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", s.ServiceName(), err))
+	} else if err := ValidateRelations(s.GetResources()); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", s.ServiceName(), err))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks that the step sets exactly one of its http and mock
+// blocks, and no response template
+func (s *StepConfig) Validate() error {
+	set := setHTTPOptions(s.Body)
+	if s.HTTP != nil {
+		set = append(set, setHTTPOptions(s.HTTP.Remain)...)
+	}
+	if len(set) > 0 {
+		return fmt.Errorf("step %q: %s not supported on steps", s.Name, strings.Join(set, ", "))
+	}
+	if s.HTTP != nil && s.Mock != nil {
+		return fmt.Errorf("step %q: http and mock cannot both be set", s.Name)
+	}
+	if s.HTTP == nil && s.Mock == nil {
+		return fmt.Errorf("step %q: must have an http or mock block", s.Name)
 	}
 	return nil
 }
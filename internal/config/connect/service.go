@@ -1,6 +1,7 @@
 package connect
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/hcl/v2"
@@ -27,8 +28,13 @@ type Service struct {
 	Resources []*config.ResourceConfig `hcl:"resource,block"`
 	Handlers  []*Handler               `hcl:"handle,block"`
 
+	// JSONCasing is the field name casing resource methods speak: "snake"
+	// (the default, matching stored fields) or "camel" (protobuf JSON)
+	JSONCasing string `hcl:"json_casing,optional"`
+
 	// State set by parser (not from HCL)
-	Vars      map[string]cty.Value
+	Vars      map[string]cty.Value // service.* references
+	Variables map[string]cty.Value // var.* values (global merged with per-service)
 	Upstreams []string
 }
 
@@ -43,22 +49,36 @@ func (c *Service) SetName(n string)                       { c.Name = n }
 func (c *Service) ServiceName() string                    { return c.Name }
 func (c *Service) ServiceType() string                    { return "connect" }
 func (c *Service) ServiceListen() string                  { return c.Listen }
+func (c *Service) SetListen(l string)                     { c.Listen = l }
 func (c *Service) ServiceTLS() *config.TLSConfig          { return c.TLS }
 func (c *Service) ServiceLogging() *config.LoggingConfig  { return c.Logging }
 func (c *Service) SetServiceVars(v map[string]cty.Value)  { c.Vars = v }
+func (c *Service) SetVariables(v map[string]cty.Value)    { c.Variables = v }
 func (c *Service) SetInferredUpstreams(u []string)        { c.Upstreams = u }
 func (c *Service) GetServiceVars() map[string]cty.Value   { return c.Vars }
+func (c *Service) GetVariables() map[string]cty.Value     { return c.Variables }
 func (c *Service) GetInferredUpstreams() []string         { return c.Upstreams }
 func (c *Service) GetResources() []*config.ResourceConfig { return c.Resources }
 
 func (c *Service) Validate() error {
-	if err := config.ValidateBase(c); err != nil {
-		return err
+	errs := []error{config.ValidateBase(c)}
+	if err := c.Timing.Validate(false); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	for _, e := range c.Errors {
+		if err := e.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+		}
 	}
 	if c.Package == "" {
-		return fmt.Errorf("service %q: package is required for connect services", c.Name)
+		errs = append(errs, fmt.Errorf("service %q: package is required for connect services", c.Name))
+	}
+	switch c.JSONCasing {
+	case "", "snake", "camel":
+	default:
+		errs = append(errs, fmt.Errorf("service %q: invalid json_casing %q (must be snake or camel)", c.Name, c.JSONCasing))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (c *Service) Expressions() []hcl.Expression {
@@ -68,9 +88,7 @@ func (c *Service) Expressions() []hcl.Expression {
 			exprs = append(exprs, h.Response.BodyExpr, h.Response.HeadersExpr)
 		}
 		for _, s := range h.Steps {
-			if s.HTTP != nil {
-				exprs = append(exprs, s.HTTP.URLExpr, s.HTTP.BodyExpr, s.HTTP.HeadersExpr)
-			}
+			exprs = append(exprs, s.Expressions()...)
 		}
 	}
 	return exprs
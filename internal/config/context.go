@@ -8,18 +8,39 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+// NewEvalContext creates an HCL evaluation context with functions and the
+// request-independent variables:
+// - service.<name> - service reference variables (address, host, port, type, url)
+// - var.<name> - user variables from vars blocks
+func NewEvalContext(serviceVars, vars map[string]cty.Value) *hcl.EvalContext {
+	ctx := &hcl.EvalContext{
+		Variables: make(map[string]cty.Value),
+		Functions: Functions(),
+	}
+
+	// Add service variables if available
+	if len(serviceVars) > 0 {
+		ctx.Variables["service"] = cty.ObjectVal(serviceVars)
+	}
+
+	// Add user variables if available
+	if len(vars) > 0 {
+		ctx.Variables["var"] = cty.ObjectVal(vars)
+	}
+
+	return ctx
+}
+
 // BuildEvalContext creates an HCL evaluation context from an HTTP request
 // The context includes:
 // - request.params - path parameters
 // - request.query - query parameters
 // - request.body - parsed request body
 // - service.<name> - service reference variables (address, host, port, type, url)
+// - var.<name> - user variables from vars blocks
 // - step.<name> - results from executed steps (added by executor)
-func BuildEvalContext(r *http.Request, pathParams map[string]string, serviceVars map[string]cty.Value) *hcl.EvalContext {
-	ctx := &hcl.EvalContext{
-		Variables: make(map[string]cty.Value),
-		Functions: Functions(),
-	}
+func BuildEvalContext(r *http.Request, pathParams map[string]string, serviceVars, vars map[string]cty.Value) *hcl.EvalContext {
+	ctx := NewEvalContext(serviceVars, vars)
 
 	// Build request context
 	requestVars := make(map[string]cty.Value)
@@ -58,12 +79,10 @@ func BuildEvalContext(r *http.Request, pathParams map[string]string, serviceVars
 	requestVars["method"] = cty.StringVal(r.Method)
 	requestVars["path"] = cty.StringVal(r.URL.Path)
 
-	ctx.Variables["request"] = cty.ObjectVal(requestVars)
+	// The correlation id, which http services assign to requests without one
+	requestVars["id"] = cty.StringVal(r.Header.Get("X-Request-Id"))
 
-	// Add service variables if available
-	if len(serviceVars) > 0 {
-		ctx.Variables["service"] = cty.ObjectVal(serviceVars)
-	}
+	ctx.Variables["request"] = cty.ObjectVal(requestVars)
 
 	// Initialize empty step object (will be populated by executor)
 	ctx.Variables["step"] = cty.EmptyObjectVal
@@ -75,12 +94,10 @@ func BuildEvalContext(r *http.Request, pathParams map[string]string, serviceVars
 // The context includes:
 // - request.<field> - all fields from the request map
 // - service.<name> - service reference variables (address, host, port, type, url)
+// - var.<name> - user variables from vars blocks
 // - step.<name> - results from executed steps (added by executor)
-func BuildEvalContextFromMap(reqMap map[string]any, serviceVars map[string]cty.Value) *hcl.EvalContext {
-	ctx := &hcl.EvalContext{
-		Variables: make(map[string]cty.Value),
-		Functions: Functions(),
-	}
+func BuildEvalContextFromMap(reqMap map[string]any, serviceVars, vars map[string]cty.Value) *hcl.EvalContext {
+	ctx := NewEvalContext(serviceVars, vars)
 
 	// Build request context from map
 	requestVars := make(map[string]cty.Value)
@@ -90,11 +107,6 @@ func BuildEvalContextFromMap(reqMap map[string]any, serviceVars map[string]cty.V
 
 	ctx.Variables["request"] = cty.ObjectVal(requestVars)
 
-	// Add service variables if available
-	if len(serviceVars) > 0 {
-		ctx.Variables["service"] = cty.ObjectVal(serviceVars)
-	}
-
 	// Initialize empty step object (will be populated by executor)
 	ctx.Variables["step"] = cty.EmptyObjectVal
 
@@ -0,0 +1,153 @@
+package config
+
+import "github.com/jumppad-labs/polymorph/internal/fake"
+
+// fakeOptions are the generator settings resource fields and postgres
+// columns share
+type fakeOptions struct {
+	name      string
+	typ       string
+	config    map[string]any
+	min, max  *float64
+	values    []string
+	depth     *int
+	breadth   *int
+	currency  string
+	formatted bool
+	start     *int
+	step      *int
+	pattern   string
+}
+
+// field converts the options to the generator's field config. Raw config
+// entries are overridden by the typed attributes. Currency and formatted
+// only apply to money, start and step to sequences, and pattern to regex.
+func (o fakeOptions) field() fake.FieldConfig {
+	cfg := make(map[string]any, len(o.config))
+	for k, v := range o.config {
+		cfg[k] = v
+	}
+	if o.min != nil {
+		cfg["min"] = *o.min
+	}
+	if o.max != nil {
+		cfg["max"] = *o.max
+	}
+	if o.depth != nil {
+		cfg["depth"] = *o.depth
+	}
+	if o.breadth != nil {
+		cfg["breadth"] = *o.breadth
+	}
+	if fake.FakeType(o.typ) == fake.TypeMoney {
+		if o.currency != "" {
+			cfg["currency"] = o.currency
+		}
+		if o.formatted {
+			cfg["formatted"] = true
+		}
+	}
+	if fake.FakeType(o.typ) == fake.TypeSequence {
+		if o.start != nil {
+			cfg["start"] = *o.start
+		}
+		if o.step != nil {
+			cfg["step"] = *o.step
+		}
+	}
+	if o.pattern != "" && fake.FakeType(o.typ) == fake.TypeRegex {
+		cfg["pattern"] = o.pattern
+	}
+	if len(o.values) > 0 {
+		values := make([]any, len(o.values))
+		for i, v := range o.values {
+			values[i] = v
+		}
+		cfg["values"] = values
+	}
+
+	field := fake.FieldConfig{Name: o.name, Type: fake.FakeType(o.typ)}
+	if len(cfg) > 0 {
+		field.Config = cfg
+	}
+	return field
+}
+
+// validate checks the options the generator would otherwise only reject
+// once it runs
+func (o fakeOptions) validate() error {
+	if o.step != nil && fake.FakeType(o.typ) == fake.TypeSequence {
+		if err := fake.ValidateSequenceStep(*o.step); err != nil {
+			return err
+		}
+	}
+	if fake.FakeType(o.typ) == fake.TypeRegex {
+		pattern := o.pattern
+		if pattern == "" {
+			pattern, _ = o.config["pattern"].(string)
+		}
+		if err := fake.ValidateRegexPattern(pattern); err != nil {
+			return err
+		}
+	}
+	if o.depth == nil && o.breadth == nil {
+		return nil
+	}
+	depth, breadth := 1, 1
+	if o.depth != nil {
+		depth = *o.depth
+	}
+	if o.breadth != nil {
+		breadth = *o.breadth
+	}
+	return fake.ValidateJSONShape(depth, breadth)
+}
+
+func (f *FieldConfig) fakeOptions() fakeOptions {
+	return fakeOptions{
+		name: f.Name, typ: f.Type, config: f.Config,
+		min: f.Min, max: f.Max, values: f.Values,
+		depth: f.Depth, breadth: f.Breadth,
+		currency: f.Currency, formatted: f.Formatted,
+		start: f.Start, step: f.Step, pattern: f.Pattern,
+	}
+}
+
+// FakeField converts the field to the generator's config. A ref field
+// draws from refIDs, the primary keys of the resource it references.
+func (f *FieldConfig) FakeField(refIDs []string) fake.FieldConfig {
+	field := f.fakeOptions().field()
+	if fake.FakeType(f.Type) == fake.TypeRef && f.Resource != "" {
+		if field.Config == nil {
+			field.Config = make(map[string]any)
+		}
+		field.Config["ids"] = refIDs
+		field.Config["deterministic"] = f.Deterministic
+	}
+	return field
+}
+
+// Validate checks the field's generator settings
+func (f *FieldConfig) Validate() error {
+	return f.fakeOptions().validate()
+}
+
+func (c *ColumnConfig) fakeOptions() fakeOptions {
+	return fakeOptions{
+		name: c.Name, typ: c.Type, config: c.Config,
+		min: c.Min, max: c.Max, values: c.Values,
+		depth: c.Depth, breadth: c.Breadth,
+		currency: c.Currency, formatted: c.Formatted,
+		start: c.Start, step: c.Step, pattern: c.Pattern,
+	}
+}
+
+// FakeField converts the column to the generator's config
+func (c *ColumnConfig) FakeField() fake.FieldConfig {
+	return c.fakeOptions().field()
+}
+
+// Validate checks the column's generator settings
+func (c *ColumnConfig) Validate() error {
+	return c.fakeOptions().validate()
+}
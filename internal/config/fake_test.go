@@ -0,0 +1,139 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jumppad-labs/polymorph/internal/fake"
+)
+
+func TestFieldConfig_FakeField(t *testing.T) {
+	num := func(v float64) *float64 { return &v }
+	count := func(v int) *int { return &v }
+
+	tests := []struct {
+		name   string
+		field  *FieldConfig
+		refIDs []string
+		want   map[string]any
+		errMsg string
+	}{
+		{
+			name:  "no options",
+			field: &FieldConfig{Name: "email", Type: "email"},
+		},
+		{
+			name:  "range",
+			field: &FieldConfig{Name: "age", Type: "int", Min: num(18), Max: num(99)},
+			want:  map[string]any{"min": 18.0, "max": 99.0},
+		},
+		{
+			name:  "raw config is overridden by attributes",
+			field: &FieldConfig{Name: "age", Type: "int", Config: map[string]any{"min": 1.0, "max": 5.0}, Max: num(10)},
+			want:  map[string]any{"min": 1.0, "max": 10.0},
+		},
+		{
+			name:  "enum values",
+			field: &FieldConfig{Name: "status", Type: "enum", Values: []string{"active", "inactive"}},
+			want:  map[string]any{"values": []any{"active", "inactive"}},
+		},
+		{
+			name:  "json shape",
+			field: &FieldConfig{Name: "payload", Type: "json", Depth: count(3), Breadth: count(4)},
+			want:  map[string]any{"depth": 3, "breadth": 4},
+		},
+		{
+			name:   "ref ids",
+			field:  &FieldConfig{Name: "user_id", Type: "ref", Resource: "user", Deterministic: true},
+			refIDs: []string{"u1", "u2"},
+			want:   map[string]any{"ids": []string{"u1", "u2"}, "deterministic": true},
+		},
+		{
+			name:  "money",
+			field: &FieldConfig{Name: "price", Type: "money", Currency: "EUR", Formatted: true},
+			want:  map[string]any{"currency": "EUR", "formatted": true},
+		},
+		{
+			name:  "formatted only applies to money",
+			field: &FieldConfig{Name: "total", Type: "decimal", Currency: "EUR", Formatted: true},
+		},
+		{
+			name:  "sequence",
+			field: &FieldConfig{Name: "id", Type: "sequence", Start: count(1000), Step: count(10)},
+			want:  map[string]any{"start": 1000, "step": 10},
+		},
+		{
+			name:  "start and step only apply to sequences",
+			field: &FieldConfig{Name: "age", Type: "int", Start: count(1000), Step: count(0)},
+		},
+		{
+			name:   "sequence step of 0",
+			field:  &FieldConfig{Name: "id", Type: "sequence", Step: count(0)},
+			errMsg: "sequence step cannot be 0",
+		},
+		{
+			name:  "regex",
+			field: &FieldConfig{Name: "plate", Type: "regex", Pattern: `[A-Z]{3}-\d{4}`},
+			want:  map[string]any{"pattern": `[A-Z]{3}-\d{4}`},
+		},
+		{
+			name:  "pattern only applies to regex",
+			field: &FieldConfig{Name: "name", Type: "name", Pattern: "[a-z"},
+		},
+		{
+			name:   "regex without a pattern",
+			field:  &FieldConfig{Name: "plate", Type: "regex"},
+			errMsg: "regex type requires 'pattern' configuration",
+		},
+		{
+			name:   "invalid regex pattern",
+			field:  &FieldConfig{Name: "plate", Type: "regex", Pattern: "[A-Z"},
+			errMsg: `invalid regex pattern "[A-Z"`,
+		},
+		{
+			name:   "json too deep",
+			field:  &FieldConfig{Name: "payload", Type: "json", Depth: count(fake.MaxJSONDepth + 1)},
+			errMsg: "json depth must be at most",
+		},
+		{
+			name:   "json too broad",
+			field:  &FieldConfig{Name: "payload", Type: "json", Breadth: count(fake.MaxJSONBreadth + 1)},
+			errMsg: "json breadth must be at most",
+		},
+		{
+			name:   "json breadth below 1",
+			field:  &FieldConfig{Name: "payload", Type: "json", Breadth: count(0)},
+			errMsg: "at least 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.field.Validate()
+			if tt.errMsg != "" {
+				require.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			got := tt.field.FakeField(tt.refIDs)
+			require.Equal(t, tt.field.Name, got.Name)
+			require.Equal(t, fake.FakeType(tt.field.Type), got.Type)
+			require.Equal(t, tt.want, got.Config)
+			require.Equal(t, tt.field.Type == "money" && tt.field.Formatted, got.FormattedMoney())
+
+			// Columns share the conversion
+			col := &ColumnConfig{
+				Name: tt.field.Name, Type: tt.field.Type, Config: tt.field.Config,
+				Min: tt.field.Min, Max: tt.field.Max, Values: tt.field.Values,
+				Depth: tt.field.Depth, Breadth: tt.field.Breadth,
+				Currency: tt.field.Currency, Formatted: tt.field.Formatted,
+				Start: tt.field.Start, Step: tt.field.Step, Pattern: tt.field.Pattern,
+			}
+			require.NoError(t, col.Validate())
+			if tt.field.Type != "ref" {
+				require.Equal(t, got, col.FakeField())
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,9 +14,11 @@ import (
 // Functions returns the built-in HCL functions available in config files
 func Functions() map[string]function.Function {
 	return map[string]function.Function{
-		"jsonencode": stdlib.JSONEncodeFunc,
-		"uuid":       UuidFunc,
-		"timestamp":  TimestampFunc,
+		"jsonencode":  stdlib.JSONEncodeFunc,
+		"uuid":        UuidFunc,
+		"timestamp":   TimestampFunc,
+		"env":         EnvFunc,
+		"env_default": EnvDefaultFunc,
 	}
 }
 
@@ -37,3 +41,34 @@ var TimestampFunc = function.New(&function.Spec{
 		return cty.StringVal(now), nil
 	},
 })
+
+// EnvFunc returns the value of an environment variable, failing if it is not set
+var EnvFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "name", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		name := args[0].AsString()
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return cty.UnknownVal(cty.String), fmt.Errorf("environment variable %q is not set", name)
+		}
+		return cty.StringVal(value), nil
+	},
+})
+
+// EnvDefaultFunc returns the value of an environment variable, or the default if it is not set
+var EnvDefaultFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "name", Type: cty.String},
+		{Name: "default", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if value, ok := os.LookupEnv(args[0].AsString()); ok {
+			return cty.StringVal(value), nil
+		}
+		return args[1], nil
+	},
+})
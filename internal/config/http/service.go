@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/hcl/v2"
@@ -10,7 +11,10 @@ import (
 	"github.com/jumppad-labs/polymorph/internal/config"
 )
 
-var _ config.Service = (*Service)(nil)
+var (
+	_ config.Service       = (*Service)(nil)
+	_ config.MultiListener = (*Service)(nil)
+)
 
 // Service is the per-type configuration for HTTP services.
 type Service struct {
@@ -28,12 +32,39 @@ type Service struct {
 	Load      *config.LoadConfig       `hcl:"load,block"`
 	RateLimit *config.RateLimitConfig  `hcl:"rate_limit,block"`
 	Spec      *config.SpecConfig       `hcl:"spec,block"`
+	Fallback  *config.FallbackConfig   `hcl:"fallback,block"`
+	Record    *config.RecordConfig     `hcl:"record,block"`
+	Admin     *config.AdminConfig      `hcl:"admin,block"`
+	Debug     *config.DebugConfig      `hcl:"debug,block"`
+	Socket    *config.SocketConfig     `hcl:"socket,block"`
+	NotFound  *config.ResponseConfig   `hcl:"not_found,block"`
+	Before    *config.HookConfig       `hcl:"before,block"`
+	After     *config.HookConfig       `hcl:"after,block"`
 	Resources []*config.ResourceConfig `hcl:"resource,block"`
 	Handlers  []*Handler               `hcl:"handle,block"`
 
+	// TrustedProxies lists the CIDRs whose X-Forwarded-For and X-Real-IP
+	// headers are believed when working out a request's client IP
+	TrustedProxies []string `hcl:"trusted_proxies,optional"`
+
+	// Throttle limits how fast response bodies are written, such as
+	// "100kb/s". A handler's throttle overrides it.
+	Throttle string `hcl:"throttle,optional"`
+
+	// ResponseHeaders are added to every response, such as
+	// { "Server" = "polymorph" }. Handlers can override them.
+	ResponseHeaders hcl.Expression `hcl:"response_headers,optional"`
+
+	// ErrorFormat renders the errors Polymorph generates itself, such as
+	// 404s and step failures, in the envelope of the API being mocked.
+	// error.message and error.status hold the error.
+	ErrorFormat hcl.Expression `hcl:"error_format,optional"`
+
 	// State set by parser (not from HCL)
-	Vars      map[string]cty.Value
-	Upstreams []string
+	Vars        map[string]cty.Value // service.* references
+	Variables   map[string]cty.Value // var.* values (global merged with per-service)
+	Upstreams   []string
+	ExtraListen []string // addresses after the first in a listen list
 }
 
 // Handler is an HTTP request handler with route-based matching.
@@ -44,6 +75,8 @@ type Handler struct {
 	Errors    []*config.ErrorConfig   `hcl:"error,block"`
 	RateLimit *config.RateLimitConfig `hcl:"rate_limit,block"`
 	Steps     []*config.StepConfig    `hcl:"step,block"`
+	Cache     *config.CacheConfig     `hcl:"cache,block"`
+	Throttle  string                  `hcl:"throttle,optional"`
 	Response  *config.ResponseConfig  `hcl:"response,block"`
 }
 
@@ -51,39 +84,130 @@ func (c *Service) SetName(n string)                       { c.Name = n }
 func (c *Service) ServiceName() string                    { return c.Name }
 func (c *Service) ServiceType() string                    { return "http" }
 func (c *Service) ServiceListen() string                  { return c.Listen }
+func (c *Service) SetListen(l string)                     { c.Listen = l }
 func (c *Service) ServiceTLS() *config.TLSConfig          { return c.TLS }
 func (c *Service) ServiceLogging() *config.LoggingConfig  { return c.Logging }
 func (c *Service) SetServiceVars(v map[string]cty.Value)  { c.Vars = v }
+func (c *Service) SetVariables(v map[string]cty.Value)    { c.Variables = v }
 func (c *Service) SetInferredUpstreams(u []string)        { c.Upstreams = u }
 func (c *Service) GetServiceVars() map[string]cty.Value   { return c.Vars }
+func (c *Service) GetVariables() map[string]cty.Value     { return c.Variables }
 func (c *Service) GetInferredUpstreams() []string         { return c.Upstreams }
 func (c *Service) GetResources() []*config.ResourceConfig { return c.Resources }
+func (c *Service) SetExtraListen(l []string)              { c.ExtraListen = l }
+func (c *Service) GetExtraListen() []string               { return c.ExtraListen }
 
 func (c *Service) Validate() error {
-	if err := config.ValidateBase(c); err != nil {
-		return err
+	errs := []error{config.ValidateBase(c)}
+	if err := c.Timing.Validate(true); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	for _, e := range c.Errors {
+		if err := e.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+		}
+	}
+	if err := c.Socket.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
 	}
 	if c.Spec != nil && c.Spec.Path == "" {
-		return fmt.Errorf("service %q: spec block requires a path", c.Name)
+		errs = append(errs, fmt.Errorf("service %q: spec block requires a path", c.Name))
+	}
+	if c.Spec != nil {
+		for _, o := range c.Spec.Overrides {
+			if err := o.Timing.Validate(true); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: spec override %q: %w", c.Name, o.Route, err))
+			}
+		}
+	}
+	if c.Record != nil {
+		if c.Fallback != nil {
+			errs = append(errs, fmt.Errorf("service %q: fallback and record cannot both be set", c.Name))
+		}
+		switch c.Record.Mode {
+		case "", "auto", "record", "replay":
+		default:
+			errs = append(errs, fmt.Errorf("service %q: invalid record mode %q (must be auto, record, or replay)", c.Name, c.Record.Mode))
+		}
+	}
+	if c.Debug != nil && c.Debug.Max < 0 {
+		errs = append(errs, fmt.Errorf("service %q: debug max must not be negative", c.Name))
+	}
+	if err := c.NotFound.ValidateTemplate(); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: not_found: %w", c.Name, err))
+	}
+	if c.Before != nil && c.Before.Response != nil {
+		if err := c.Before.Response.AsResponse().ValidateTemplate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: before hook: %w", c.Name, err))
+		}
+	}
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Response.RejectHTTPOptions(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: rate_limit: %w", c.Name, err))
+		}
+	}
+	if c.After != nil && c.After.Response != nil {
+		errs = append(errs, fmt.Errorf("service %q: after hook cannot set a response", c.Name))
+	}
+	for _, hook := range []*config.HookConfig{c.Before, c.After} {
+		if hook == nil {
+			continue
+		}
+		for _, step := range hook.Steps {
+			if err := step.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: hook: %w", c.Name, err))
+			}
+		}
+	}
+	if _, err := config.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	if c.Throttle != "" {
+		if _, err := config.ParseThrottle(c.Throttle); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+		}
 	}
 	for _, h := range c.Handlers {
 		if h.Route == "" {
-			return fmt.Errorf("service %q: handler %q requires a route", c.Name, h.Name)
+			errs = append(errs, fmt.Errorf("service %q: handler %q requires a route", c.Name, h.Name))
+		}
+		if h.Throttle != "" {
+			if _, err := config.ParseThrottle(h.Throttle); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: handler %q: %w", c.Name, h.Name, err))
+			}
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (c *Service) Expressions() []hcl.Expression {
 	var exprs []hcl.Expression
+	if c.Fallback != nil {
+		exprs = append(exprs, c.Fallback.TargetExpr)
+	}
+	if c.Record != nil {
+		exprs = append(exprs, c.Record.TargetExpr)
+	}
+	if c.NotFound != nil {
+		exprs = append(exprs, c.NotFound.BodyExpr, c.NotFound.HeadersExpr)
+	}
+	if c.ErrorFormat != nil {
+		exprs = append(exprs, c.ErrorFormat)
+	}
+	if c.ResponseHeaders != nil {
+		exprs = append(exprs, c.ResponseHeaders)
+	}
+	for _, hook := range []*config.HookConfig{c.Before, c.After} {
+		if hook != nil {
+			exprs = append(exprs, hook.Expressions()...)
+		}
+	}
 	for _, h := range c.Handlers {
 		if h.Response != nil {
 			exprs = append(exprs, h.Response.BodyExpr, h.Response.HeadersExpr)
 		}
 		for _, s := range h.Steps {
-			if s.HTTP != nil {
-				exprs = append(exprs, s.HTTP.URLExpr, s.HTTP.BodyExpr, s.HTTP.HeadersExpr)
-			}
+			exprs = append(exprs, s.Expressions()...)
 		}
 	}
 	return exprs
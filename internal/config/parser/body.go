@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/jumppad-labs/polymorph/internal/config/http"
+)
+
+// validateResponseBodies evaluates each http handler, not_found and before
+// hook response's headers and body ahead of any request, catching unknown functions, bad references,
+// headers that aren't an object and malformed JSON. request.* and step.* are
+// unknown at this point, so values that depend on them are only checked for
+// evaluation errors.
+func validateResponseBodies(svc config.Service) []error {
+	if svc.ServiceType() != "http" {
+		return nil
+	}
+
+	ctx := config.NewEvalContext(svc.GetServiceVars(), svc.GetVariables())
+	ctx.Variables["request"] = cty.DynamicVal
+	ctx.Variables["step"] = cty.DynamicVal
+
+	var errs []error
+	// response_headers are evaluated once at startup, without a request
+	if hs, ok := svc.(*http.Service); ok && hs.ResponseHeaders != nil {
+		startup := config.NewEvalContext(svc.GetServiceVars(), svc.GetVariables())
+		if err := validateHeaders("response_headers", hs.ResponseHeaders, startup); err != nil {
+			err = fmt.Errorf("service %q: %w", svc.ServiceName(), err)
+			errs = append(errs, withRange(err, hs.ResponseHeaders.Range()))
+		}
+	}
+	for _, h := range svc.GetHandlers() {
+		errs = append(errs, validateResponse(svc, fmt.Sprintf("handler %q", h.Name), h.Response, ctx)...)
+	}
+	if hs, ok := svc.(*http.Service); ok {
+		errs = append(errs, validateResponse(svc, "not_found", hs.NotFound, ctx)...)
+		if hs.Before != nil && hs.Before.Response != nil {
+			errs = append(errs, validateResponse(svc, "before hook", hs.Before.Response.AsResponse(), ctx)...)
+		}
+	}
+	return errs
+}
+
+// validateResponse checks one response's headers, base64_body and body,
+// labelling errors with where the response is declared
+func validateResponse(svc config.Service, label string, resp *config.ResponseConfig, ctx *hcl.EvalContext) []error {
+	if resp == nil {
+		return nil
+	}
+	var errs []error
+	if resp.HeadersExpr != nil {
+		if err := validateHeaders("headers", resp.HeadersExpr, ctx); err != nil {
+			err = fmt.Errorf("service %q %s: %w", svc.ServiceName(), label, err)
+			return append(errs, withRange(err, resp.HeadersExpr.Range()))
+		}
+	}
+	if resp.Base64Body != "" {
+		if resp.HasBody() {
+			err := fmt.Errorf("service %q %s: base64_body cannot be combined with body or template", svc.ServiceName(), label)
+			errs = append(errs, withRange(err, bodyRange(resp.Remain)))
+		}
+		if _, err := base64.StdEncoding.DecodeString(strings.TrimSpace(resp.Base64Body)); err != nil {
+			err = fmt.Errorf("service %q %s: invalid base64_body: %w", svc.ServiceName(), label, err)
+			errs = append(errs, withRange(err, bodyRange(resp.Remain)))
+		}
+		return errs
+	}
+	if resp.BodyExpr == nil {
+		return nil
+	}
+	if err := validateResponseBody(resp, ctx); err != nil {
+		err = fmt.Errorf("service %q %s: %w", svc.ServiceName(), label, err)
+		errs = append(errs, withRange(err, resp.BodyExpr.Range()))
+	}
+	return errs
+}
+
+// validateHeaders evaluates a headers attribute, checking that a known value
+// is an object or map of strings, since headers are read by name
+func validateHeaders(attr string, expr hcl.Expression, ctx *hcl.EvalContext) error {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		d := diags.Errs()[0].(*hcl.Diagnostic)
+		return fmt.Errorf("invalid %s: %s; %s", attr, d.Summary, d.Detail)
+	}
+	if val.IsNull() || !val.IsKnown() {
+		return nil
+	}
+	if ty := val.Type(); !ty.IsObjectType() && !ty.IsMapType() {
+		return fmt.Errorf("invalid %s: must be an object, got %s", attr, ty.FriendlyName())
+	}
+	for name, v := range val.AsValueMap() {
+		if v.IsKnown() && (v.IsNull() || !v.Type().Equals(cty.String)) {
+			return fmt.Errorf("invalid %s: %q must be a string", attr, name)
+		}
+	}
+	return nil
+}
+
+// validateResponseBody evaluates a response body, checking that a known
+// value that looks like JSON is valid, unless the headers declare another
+// content type. Plain text bodies such as "ok" are left alone.
+func validateResponseBody(resp *config.ResponseConfig, ctx *hcl.EvalContext) error {
+	val, diags := resp.BodyExpr.Value(ctx)
+	if diags.HasErrors() {
+		d := diags.Errs()[0].(*hcl.Diagnostic)
+		return fmt.Errorf("invalid body: %s; %s", d.Summary, d.Detail)
+	}
+	if val.IsNull() || !val.IsWhollyKnown() {
+		return nil
+	}
+	if !val.Type().Equals(cty.String) {
+		return fmt.Errorf("invalid body: must be a string, got %s; wrap it in jsonencode()", val.Type().FriendlyName())
+	}
+	if !jsonContentType(resp, ctx) {
+		return nil
+	}
+	body := strings.TrimSpace(val.AsString())
+	if (strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[")) && !json.Valid([]byte(body)) {
+		return fmt.Errorf("body is not valid JSON: %q", body)
+	}
+	return nil
+}
+
+// jsonContentType reports whether a response is served as JSON, which is
+// the default when neither content_type nor its headers set a Content-Type
+func jsonContentType(resp *config.ResponseConfig, ctx *hcl.EvalContext) bool {
+	if resp.ContentType != "" {
+		return strings.Contains(resp.ContentType, "json")
+	}
+	if resp.HeadersExpr == nil {
+		return true
+	}
+	headers, diags := resp.HeadersExpr.Value(ctx)
+	if diags.HasErrors() || !headers.IsWhollyKnown() {
+		return false
+	}
+	if headers.IsNull() || !(headers.Type().IsObjectType() || headers.Type().IsMapType()) {
+		return true
+	}
+	for name, value := range headers.AsValueMap() {
+		if strings.EqualFold(name, "Content-Type") && value.Type().Equals(cty.String) {
+			return strings.Contains(value.AsString(), "json")
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// dumpConfig is the JSON form of a resolved config written by Dump
+type dumpConfig struct {
+	Services []dumpService `json:"services"`
+}
+
+type dumpService struct {
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Listen    string        `json:"listen"`
+	Upstreams []string      `json:"upstreams"`
+	Handlers  []dumpHandler `json:"handlers,omitempty"`
+	Resources []string      `json:"resources,omitempty"`
+}
+
+type dumpHandler struct {
+	Name     string        `json:"name"`
+	Route    string        `json:"route,omitempty"`
+	Pattern  string        `json:"pattern,omitempty"`
+	Response *dumpResponse `json:"response,omitempty"`
+}
+
+type dumpResponse struct {
+	Status      *int   `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Headers     any    `json:"headers,omitempty"`
+	Body        any    `json:"body,omitempty"`
+	Base64Body  string `json:"base64_body,omitempty"`
+	Template    string `json:"template,omitempty"`
+	Engine      string `json:"engine,omitempty"`
+}
+
+// Dump serializes a parsed config to indented JSON for editors and other
+// tooling. Expressions that can be evaluated ahead of a request are written
+// as their values; the rest, such as those reading request.*, as source text.
+func Dump(cfg *config.Config) ([]byte, error) {
+	d := &dumper{sources: make(map[string][]byte)}
+
+	out := dumpConfig{Services: []dumpService{}}
+	for _, svc := range cfg.Services {
+		ds := dumpService{
+			Name:      svc.ServiceName(),
+			Type:      svc.ServiceType(),
+			Listen:    svc.ServiceListen(),
+			Upstreams: svc.GetInferredUpstreams(),
+		}
+		if ds.Upstreams == nil {
+			ds.Upstreams = []string{}
+		}
+
+		ctx := config.NewEvalContext(svc.GetServiceVars(), svc.GetVariables())
+		for _, h := range svc.GetHandlers() {
+			dh := dumpHandler{Name: h.Name, Route: h.Route, Pattern: h.Pattern}
+			if h.Response != nil {
+				dh.Response = &dumpResponse{
+					Status:      h.Response.Status,
+					ContentType: h.Response.ContentType,
+					Headers:     d.expression(h.Response.HeadersExpr, ctx),
+					Body:        d.expression(h.Response.BodyExpr, ctx),
+					Base64Body:  h.Response.Base64Body,
+					Template:    h.Response.Template,
+					Engine:      h.Response.Engine,
+				}
+			}
+			ds.Handlers = append(ds.Handlers, dh)
+		}
+
+		for _, res := range svc.GetResources() {
+			ds.Resources = append(ds.Resources, res.Name)
+		}
+		out.Services = append(out.Services, ds)
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// dumper renders expressions, caching source files read for their text
+type dumper struct {
+	sources map[string][]byte
+}
+
+// expression returns the value of expr as raw JSON, its source text when it
+// can't be evaluated yet, or nil when it is unset
+func (d *dumper) expression(expr hcl.Expression, ctx *hcl.EvalContext) any {
+	if expr == nil {
+		return nil
+	}
+
+	val, diags := expr.Value(ctx)
+	if !diags.HasErrors() && val.IsWhollyKnown() {
+		if val.IsNull() {
+			return nil
+		}
+		if raw, err := ctyjson.Marshal(val, val.Type()); err == nil {
+			return json.RawMessage(raw)
+		}
+	}
+	return d.source(expr.Range())
+}
+
+// source returns the text covered by rng, falling back to its position when
+// the file can't be read
+func (d *dumper) source(rng hcl.Range) string {
+	src, ok := d.sources[rng.Filename]
+	if !ok {
+		src, _ = os.ReadFile(rng.Filename)
+		d.sources[rng.Filename] = src
+	}
+	if rng.End.Byte > len(src) || rng.Start.Byte > rng.End.Byte {
+		return rng.String()
+	}
+	return string(src[rng.Start.Byte:rng.End.Byte])
+}
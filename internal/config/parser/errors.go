@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ValidationError is a validation failure located at the block that caused
+// it, printed as file:line:col: message
+type ValidationError struct {
+	Range hcl.Range
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Range.Filename == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Range.Filename, e.Range.Start.Line, e.Range.Start.Column, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// splitErrors flattens errors joined with errors.Join so each can be
+// located and printed on its own line
+func splitErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, splitErrors(e)...)
+	}
+	return errs
+}
+
+// withRange locates err at rng. Errors from configs built without source,
+// such as those constructed in code, are returned unchanged.
+func withRange(err error, rng hcl.Range) error {
+	if rng.Filename == "" {
+		return err
+	}
+	return &ValidationError{Range: rng, Err: err}
+}
+
+// bodyRange returns the source range of a decoded block's body
+func bodyRange(body hcl.Body) hcl.Range {
+	if b, ok := body.(*hclsyntax.Body); ok {
+		return b.SrcRange
+	}
+	return hcl.Range{}
+}
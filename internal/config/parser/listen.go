@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// ListenConflict is a pair of listen addresses that can't both be bound,
+// either in two services or twice in one
+type ListenConflict struct {
+	Address string // the address of the later service
+	First   string // the service that binds first
+	Second  string
+}
+
+func (c ListenConflict) String() string {
+	if c.First == c.Second {
+		return fmt.Sprintf("service %q listens on %s more than once", c.First, c.Address)
+	}
+	return fmt.Sprintf("services %q and %q both listen on %s", c.First, c.Second, c.Address)
+}
+
+// ListenAddresses returns every address a service listens on
+func ListenAddresses(svc config.Service) []string {
+	addrs := []string{svc.ServiceListen()}
+	if ml, ok := svc.(config.MultiListener); ok {
+		addrs = append(addrs, ml.GetExtraListen()...)
+	}
+	return addrs
+}
+
+// ListenConflicts returns each listen address that overlaps one declared
+// before it. Port 0 listeners never conflict, and a wildcard host such as
+// 0.0.0.0 or an empty host overlaps every host on the same port.
+func ListenConflicts(cfg *config.Config) []ListenConflict {
+	type bound struct {
+		service, addr string
+	}
+	var seen []bound
+	var conflicts []ListenConflict
+	for _, svc := range cfg.Services {
+		for _, addr := range ListenAddresses(svc) {
+			for _, b := range seen {
+				if listenOverlaps(b.addr, addr) {
+					conflicts = append(conflicts, ListenConflict{Address: addr, First: b.service, Second: svc.ServiceName()})
+					break
+				}
+			}
+			seen = append(seen, bound{svc.ServiceName(), addr})
+		}
+	}
+	return conflicts
+}
+
+// listenOverlaps reports whether binding both addresses would fail
+func listenOverlaps(a, b string) bool {
+	// Unix sockets clash only on the same path
+	if strings.HasPrefix(a, "unix://") || strings.HasPrefix(b, "unix://") {
+		return a == b
+	}
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if portA != portB || portA == "0" {
+		return false
+	}
+	return hostA == hostB || isWildcardHost(hostA) || isWildcardHost(hostB)
+}
+
+// isWildcardHost reports whether a listen host binds every interface
+func isWildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenConflicts(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+}
+
+service "http" "admin" {
+  listen = "127.0.0.1:8080"
+}
+
+service "proxy" "gateway" {
+  listen = ["0.0.0.0:9090", "127.0.0.1:9091", "127.0.0.1:9091"]
+  target = service.api.url
+}
+
+service "tcp" "feed" {
+  listen = "127.0.0.1:9090"
+}
+
+service "http" "ephemeral" {
+  listen = "127.0.0.1:0"
+}
+
+service "http" "also_ephemeral" {
+  listen = "127.0.0.1:0"
+}
+
+service "http" "v6" {
+  listen = "[::1]:8080"
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	conflicts := ListenConflicts(cfg)
+	require.Equal(t, []ListenConflict{
+		{Address: "127.0.0.1:8080", First: "api", Second: "admin"},
+		{Address: "127.0.0.1:9091", First: "gateway", Second: "gateway"},
+		{Address: "127.0.0.1:9090", First: "gateway", Second: "feed"},
+	}, conflicts)
+	require.Equal(t, `services "api" and "admin" both listen on 127.0.0.1:8080`, conflicts[0].String())
+	require.Equal(t, `service "gateway" listens on 127.0.0.1:9091 more than once`, conflicts[1].String())
+}
+
+func TestListenOverlaps(t *testing.T) {
+	require.True(t, listenOverlaps(":8080", "10.0.0.1:8080"))
+	require.True(t, listenOverlaps("[::]:8080", "0.0.0.0:8080"))
+	require.True(t, listenOverlaps("unix:///tmp/a.sock", "unix:///tmp/a.sock"))
+	require.False(t, listenOverlaps("127.0.0.1:8080", "[::1]:8080"))
+	require.False(t, listenOverlaps("127.0.0.1:8080", "127.0.0.1:8081"))
+	require.False(t, listenOverlaps(":0", ":0"))
+	require.False(t, listenOverlaps("unix:///tmp/a.sock", "unix:///tmp/b.sock"))
+}
@@ -1,23 +1,28 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
 
 	"github.com/jumppad-labs/polymorph/internal/config"
 	"github.com/jumppad-labs/polymorph/internal/config/connect"
 	"github.com/jumppad-labs/polymorph/internal/config/http"
 	"github.com/jumppad-labs/polymorph/internal/config/postgres"
 	"github.com/jumppad-labs/polymorph/internal/config/proxy"
+	"github.com/jumppad-labs/polymorph/internal/config/redis"
 	"github.com/jumppad-labs/polymorph/internal/config/tcp"
 )
 
@@ -28,11 +33,25 @@ var serviceDecoders = map[string]func(hcl.Body, *hcl.EvalContext) (config.Servic
 	"tcp":      tcp.Decode,
 	"connect":  connect.Decode,
 	"postgres": postgres.Decode,
+	"redis":    redis.Decode,
+}
+
+// Option adjusts how a config is parsed
+type Option func(*options)
+
+type options struct {
+	portOffset int
+}
+
+// WithPortOffset adds offset to the port of every service's listen address,
+// so two copies of a config can run side by side. Ports of 0 are left alone.
+func WithPortOffset(offset int) Option {
+	return func(o *options) { o.portOffset = offset }
 }
 
 // ParseFile reads and parses an HCL config file or directory.
 // If path is a directory, all *.hcl files in it (non-recursive) are loaded and merged.
-func ParseFile(path string) (*config.Config, error) {
+func ParseFile(path string, opts ...Option) (*config.Config, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -43,7 +62,7 @@ func ParseFile(path string) (*config.Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
-		return Parse(src, path)
+		return Parse(src, path, opts...)
 	}
 
 	// Directory: glob *.hcl, sort by name, parse each
@@ -73,56 +92,300 @@ func ParseFile(path string) (*config.Config, error) {
 		return nil, fmt.Errorf("no .hcl files found in directory %s", path)
 	}
 
-	return parseFiles(files)
+	return parseFiles(files, opts)
 }
 
 // Parse parses HCL config from a byte slice using three-phase parsing.
 // Phase A extracts service skeletons (name, type, listen) to build service.* variables.
 // Phase B decodes the root config (non-service blocks) with an enriched eval context.
 // Phase C decodes service blocks via per-type decoders.
-func Parse(src []byte, filename string) (*config.Config, error) {
+func Parse(src []byte, filename string, opts ...Option) (*config.Config, error) {
 	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("failed to parse config: %s", diags.Error())
 	}
-	return parseFiles([]*hcl.File{file})
+	return parseFiles([]*hcl.File{file}, opts)
+}
+
+// serviceMetaSchema matches the parts of a service body that are evaluated
+// ahead of decoding: the count and depends_on meta-arguments and vars blocks.
+var serviceMetaSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "count"}, {Name: "depends_on"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "vars"}},
+}
+
+// resolveImports loads files named by import blocks, resolved relative to
+// the importing file, and returns them ahead of their importers. Each file is
+// loaded once; an import that leads back to a file still being resolved is a cycle.
+func resolveImports(files []*hcl.File) ([]*hcl.File, error) {
+	var resolved []*hcl.File
+	loaded := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(file *hcl.File, path string, chain []string) error
+	visit = func(file *hcl.File, path string, chain []string) error {
+		if loaded[path] {
+			return nil
+		}
+		if visiting[path] {
+			return fmt.Errorf("import cycle detected: %s", strings.Join(append(chain, path), " -> "))
+		}
+		visiting[path] = true
+		chain = append(chain, path)
+
+		syntaxBody, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			return fmt.Errorf("unexpected body type")
+		}
+		for _, block := range syntaxBody.Blocks {
+			if block.Type != "import" {
+				continue
+			}
+			if len(block.Labels) != 1 {
+				return fmt.Errorf("%s: import requires a single file path label", block.DefRange())
+			}
+
+			importPath := filepath.Clean(block.Labels[0])
+			if !filepath.IsAbs(importPath) {
+				importPath = filepath.Join(filepath.Dir(path), importPath)
+			}
+
+			if loaded[importPath] {
+				continue
+			}
+			if visiting[importPath] {
+				return fmt.Errorf("import cycle detected: %s", strings.Join(append(chain, importPath), " -> "))
+			}
+
+			src, err := os.ReadFile(importPath)
+			if err != nil {
+				return fmt.Errorf("failed to read imported config %s: %w", importPath, err)
+			}
+			imported, diags := hclsyntax.ParseConfig(src, importPath, hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to parse imported config %s: %s", importPath, diags.Error())
+			}
+			if err := visit(imported, importPath, chain); err != nil {
+				return err
+			}
+		}
+
+		visiting[path] = false
+		loaded[path] = true
+		resolved = append(resolved, file)
+		return nil
+	}
+
+	// A sibling already pulled in by an import is skipped when its turn comes
+	for _, file := range files {
+		if err := visit(file, filePath(file), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// filePath returns the cleaned absolute path a file was parsed from.
+func filePath(file *hcl.File) string {
+	var name string
+	if syntaxBody, ok := file.Body.(*hclsyntax.Body); ok {
+		name = syntaxBody.SrcRange.Filename
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
 }
 
 // parseFiles implements the three-phase parsing pipeline over one or more HCL files.
-func parseFiles(files []*hcl.File) (*config.Config, error) {
-	// Phase A: Extract service skeletons from each file's syntax body
-	serviceVars := make(map[string]cty.Value)
+func parseFiles(files []*hcl.File, opts []Option) (*config.Config, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	files, err := resolveImports(files)
+	if err != nil {
+		return nil, err
+	}
+
+	// Evaluate global vars blocks so var.* is available to every phase
+	globalVars := make(map[string]cty.Value)
 	for _, file := range files {
-		vars, err := extractServiceVars(file.Body)
+		syntaxBody, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("unexpected body type")
+		}
+		vars, err := evalVars(syntaxBody, &hcl.EvalContext{Functions: config.Functions()})
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract service info: %w", err)
+			return nil, err
 		}
 		for k, v := range vars {
-			serviceVars[k] = v
+			if _, exists := globalVars[k]; exists {
+				return nil, fmt.Errorf("variable %q is defined more than once", k)
+			}
+			globalVars[k] = v
 		}
 	}
 
-	// Phase B: Decode root config (non-service blocks) with enriched context
-	ctx := &hcl.EvalContext{
-		Functions: config.Functions(),
-		Variables: make(map[string]cty.Value),
-	}
-	if len(serviceVars) > 0 {
-		ctx.Variables["service"] = cty.ObjectVal(serviceVars)
+	// Expand service blocks by their count meta-argument
+	instances, err := expandServices(files, globalVars)
+	if err != nil {
+		return nil, err
 	}
 
+	// Phase A: Extract service skeletons from the expanded service blocks
+	serviceVars := extractServiceVars(instances, o.portOffset)
+
+	// Phase B: Decode root config (non-service blocks) with enriched context
+	ctx := config.NewEvalContext(serviceVars, globalVars)
+
 	var cfg config.Config
 	diags := gohcl.DecodeBody(hcl.MergeFiles(files), ctx, &cfg)
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("failed to parse config: %s", diags.Error())
 	}
+	cfg.Variables = globalVars
+	cfg.ServiceRanges = make(map[string]hcl.Range, len(instances))
+
+	// Phase C: Decode service blocks via per-type decoders
+	dependsOn := make(map[string][]string)
+	for _, inst := range instances {
+		decoder, exists := serviceDecoders[inst.serviceType]
+		if !exists {
+			return nil, fmt.Errorf("service %q: unknown type %q", inst.name, inst.serviceType)
+		}
+
+		// A listen list is decoded as its first address, the rest are
+		// handed to the service afterwards
+		serviceBody, extraListen, err := splitListen(inst.block.Body, inst.evalContext(serviceVars))
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", inst.name, err)
+		}
+
+		// Strip meta-arguments and vars blocks before decoding
+		_, body, diags := serviceBody.PartialContent(serviceMetaSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("service %q: %s", inst.name, diags.Error())
+		}
+
+		svc, err := decoder(body, inst.evalContext(serviceVars))
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", inst.name, err)
+		}
+
+		if len(extraListen) > 0 {
+			ml, ok := svc.(config.MultiListener)
+			if !ok {
+				return nil, fmt.Errorf("service %q: %s services listen on a single address", inst.name, inst.serviceType)
+			}
+			for i, addr := range extraListen {
+				if extraListen[i], err = offsetPort(addr, o.portOffset); err != nil {
+					return nil, fmt.Errorf("service %q: %w", inst.name, err)
+				}
+			}
+			ml.SetExtraListen(extraListen)
+		}
+
+		if attr, ok := inst.block.Body.Attributes["depends_on"]; ok {
+			deps, err := evalDependsOn(attr, inst.evalContext(nil))
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %w", inst.name, err)
+			}
+			dependsOn[inst.name] = deps
+		}
+
+		if o.portOffset != 0 {
+			listen, err := offsetPort(svc.ServiceListen(), o.portOffset)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %w", inst.name, err)
+			}
+			svc.SetListen(listen)
+		}
+
+		svc.SetName(inst.name)
+		svc.SetServiceVars(serviceVars)
+		svc.SetVariables(inst.vars)
+		cfg.Services = append(cfg.Services, svc)
+		cfg.ServiceRanges[inst.name] = inst.block.DefRange()
+	}
+
+	if err := inferUpstreams(&cfg, serviceVars, dependsOn); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// evalVars evaluates the attributes of every vars block directly within body.
+func evalVars(body *hclsyntax.Body, ctx *hcl.EvalContext) (map[string]cty.Value, error) {
+	vars := make(map[string]cty.Value)
+	for _, block := range body.Blocks {
+		if block.Type != "vars" {
+			continue
+		}
+		for attrName, attr := range block.Body.Attributes {
+			if _, exists := vars[attrName]; exists {
+				return nil, fmt.Errorf("variable %q is defined more than once", attrName)
+			}
+			val, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("failed to evaluate variable %q: %s", attrName, diags.Error())
+			}
+			vars[attrName] = val
+		}
+	}
+	return vars, nil
+}
+
+// serviceVariables merges a service's own vars blocks over the global vars.
+func serviceVariables(body *hclsyntax.Body, globalVars map[string]cty.Value) (map[string]cty.Value, error) {
+	local, err := evalVars(body, config.NewEvalContext(nil, globalVars))
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]cty.Value, len(globalVars)+len(local))
+	for k, v := range globalVars {
+		vars[k] = v
+	}
+	for k, v := range local {
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// serviceInstance is a single service produced from a service block. A block
+// with a count meta-argument yields one instance per index.
+type serviceInstance struct {
+	block       *hclsyntax.Block
+	serviceType string
+	name        string
+	vars        map[string]cty.Value
+	// count holds the count object (index) or cty.NilVal when count is unset
+	count cty.Value
+}
+
+// evalContext builds the context used to evaluate the instance's body.
+func (inst *serviceInstance) evalContext(serviceVars map[string]cty.Value) *hcl.EvalContext {
+	ctx := config.NewEvalContext(serviceVars, inst.vars)
+	if inst.count != cty.NilVal {
+		ctx.Variables["count"] = inst.count
+	}
+	return ctx
+}
+
+// expandServices collects service blocks from every file, evaluating their
+// vars blocks and expanding count = N into N instances named <name>-<index>.
+func expandServices(files []*hcl.File, globalVars map[string]cty.Value) ([]*serviceInstance, error) {
+	var instances []*serviceInstance
 
-	// Phase C: Decode service blocks via per-type decoders (iterate each file's syntax body)
 	for _, file := range files {
 		syntaxBody, ok := file.Body.(*hclsyntax.Body)
 		if !ok {
 			return nil, fmt.Errorf("unexpected body type")
 		}
+
 		for _, block := range syntaxBody.Blocks {
 			if block.Type != "service" || len(block.Labels) < 2 {
 				continue
@@ -130,71 +393,181 @@ func parseFiles(files []*hcl.File) (*config.Config, error) {
 			serviceType := block.Labels[0]
 			name := block.Labels[1]
 
-			decoder, exists := serviceDecoders[serviceType]
-			if !exists {
-				return nil, fmt.Errorf("service %q: unknown type %q", name, serviceType)
+			// Per-service vars override globals
+			vars, err := serviceVariables(block.Body, globalVars)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %w", name, err)
+			}
+
+			attr, hasCount := block.Body.Attributes["count"]
+			if !hasCount {
+				instances = append(instances, &serviceInstance{
+					block:       block,
+					serviceType: serviceType,
+					name:        name,
+					vars:        vars,
+					count:       cty.NilVal,
+				})
+				continue
 			}
 
-			svc, err := decoder(block.Body, ctx)
+			count, err := evalCount(attr, vars)
 			if err != nil {
 				return nil, fmt.Errorf("service %q: %w", name, err)
 			}
-
-			svc.SetName(name)
-			svc.SetServiceVars(serviceVars)
-			cfg.Services = append(cfg.Services, svc)
+			for i := 0; i < count; i++ {
+				instances = append(instances, &serviceInstance{
+					block:       block,
+					serviceType: serviceType,
+					name:        fmt.Sprintf("%s-%d", name, i),
+					vars:        vars,
+					count: cty.ObjectVal(map[string]cty.Value{
+						"index": cty.NumberIntVal(int64(i)),
+					}),
+				})
+			}
 		}
 	}
 
-	if err := inferUpstreams(&cfg, serviceVars); err != nil {
-		return nil, err
+	return instances, nil
+}
+
+// evalCount evaluates a count meta-argument to a non-negative whole number.
+func evalCount(attr *hclsyntax.Attribute, vars map[string]cty.Value) (int, error) {
+	val, diags := attr.Expr.Value(config.NewEvalContext(nil, vars))
+	if diags.HasErrors() {
+		return 0, fmt.Errorf("failed to evaluate count: %s", diags.Error())
 	}
 
-	return &cfg, nil
+	var count int
+	if err := gocty.FromCtyValue(val, &count); err != nil {
+		return 0, fmt.Errorf("count must be a whole number: %w", err)
+	}
+	if count < 0 {
+		return 0, fmt.Errorf("count must be non-negative, got %d", count)
+	}
+	return count, nil
 }
 
-// extractServiceVars reads service blocks from the raw HCL body and builds
-// a map of service.* variables (address, host, port, type, url) for each service.
-func extractServiceVars(body hcl.Body) (map[string]cty.Value, error) {
-	syntaxBody, ok := body.(*hclsyntax.Body)
-	if !ok {
-		return nil, fmt.Errorf("unexpected body type")
+// evalDependsOn evaluates a depends_on meta-argument to a list of service names.
+func evalDependsOn(attr *hclsyntax.Attribute, ctx *hcl.EvalContext) ([]string, error) {
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to evaluate depends_on: %s", diags.Error())
 	}
 
-	minCtx := &hcl.EvalContext{Functions: config.Functions()}
-	serviceVars := make(map[string]cty.Value)
+	val, err := convert.Convert(val, cty.List(cty.String))
+	if err != nil {
+		return nil, fmt.Errorf("depends_on must be a list of service names: %w", err)
+	}
 
-	for _, block := range syntaxBody.Blocks {
-		if block.Type != "service" || len(block.Labels) < 2 {
-			continue
-		}
+	var deps []string
+	if err := gocty.FromCtyValue(val, &deps); err != nil {
+		return nil, fmt.Errorf("depends_on must be a list of service names: %w", err)
+	}
+	return deps, nil
+}
 
-		serviceType := block.Labels[0]
-		name := block.Labels[1]
+// extractServiceVars builds a map of service.* variables (address, host,
+// port, type, url) for each service instance, with ports shifted by portOffset.
+func extractServiceVars(instances []*serviceInstance, portOffset int) map[string]cty.Value {
+	serviceVars := make(map[string]cty.Value)
 
+	for _, inst := range instances {
+		// listen may reference var.* and count.*, but not other services
+		minCtx := inst.evalContext(nil)
+
+		// A listen list is reported by its first address
 		var listen string
-		for attrName, attr := range block.Body.Attributes {
-			if attrName == "listen" {
-				val, diags := attr.Expr.Value(minCtx)
-				if !diags.HasErrors() && val.Type() == cty.String {
-					listen = val.AsString()
-				}
+		if attr, ok := inst.block.Body.Attributes["listen"]; ok {
+			val, diags := attr.Expr.Value(minCtx)
+			if !diags.HasErrors() && isList(val) && val.LengthInt() > 0 {
+				val = val.Index(cty.NumberIntVal(0))
+			}
+			if !diags.HasErrors() && val.Type() == cty.String {
+				listen = val.AsString()
 			}
 		}
+		// An out of range port is reported when the service is decoded
+		if shifted, err := offsetPort(listen, portOffset); err == nil {
+			listen = shifted
+		}
 
 		host, port := splitHostPort(listen)
 		url := fmt.Sprintf("http://%s", listen)
 
-		serviceVars[name] = cty.ObjectVal(map[string]cty.Value{
+		serviceVars[inst.name] = cty.ObjectVal(map[string]cty.Value{
 			"address": cty.StringVal(listen),
 			"host":    cty.StringVal(host),
 			"port":    cty.StringVal(port),
-			"type":    cty.StringVal(serviceType),
+			"type":    cty.StringVal(inst.serviceType),
 			"url":     cty.StringVal(url),
 		})
 	}
 
-	return serviceVars, nil
+	return serviceVars
+}
+
+// splitListen returns the body to decode a service from and any extra listen
+// addresses. When listen is a list, the returned body has it replaced by the
+// first address so every service type decodes a single listen as usual.
+func splitListen(body *hclsyntax.Body, ctx *hcl.EvalContext) (*hclsyntax.Body, []string, error) {
+	attr, ok := body.Attributes["listen"]
+	if !ok {
+		return body, nil, nil
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() || !isList(val) {
+		// Errors and single addresses are left to the decoder
+		return body, nil, nil
+	}
+
+	converted, err := convert.Convert(val, cty.List(cty.String))
+	if err != nil || converted.IsNull() || !converted.IsWhollyKnown() {
+		return nil, nil, fmt.Errorf("listen must be an address or a list of addresses")
+	}
+	var addrs []string
+	if err := gocty.FromCtyValue(converted, &addrs); err != nil {
+		return nil, nil, fmt.Errorf("listen must be an address or a list of addresses: %w", err)
+	}
+	if len(addrs) == 0 {
+		return nil, nil, fmt.Errorf("listen list must not be empty")
+	}
+
+	first := *attr
+	first.Expr = &hclsyntax.LiteralValueExpr{Val: cty.StringVal(addrs[0]), SrcRange: attr.Expr.Range()}
+	split := *body
+	split.Attributes = make(hclsyntax.Attributes, len(body.Attributes))
+	for name, a := range body.Attributes {
+		split.Attributes[name] = a
+	}
+	split.Attributes["listen"] = &first
+	return &split, addrs[1:], nil
+}
+
+// isList reports whether v is a known list or tuple
+func isList(v cty.Value) bool {
+	return v.IsKnown() && !v.IsNull() && (v.Type().IsListType() || v.Type().IsTupleType())
+}
+
+// offsetPort adds offset to the port of a host:port address. Addresses
+// without a numeric port, and port 0, are returned unchanged.
+func offsetPort(addr string, offset int) (string, error) {
+	if offset == 0 {
+		return addr, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n == 0 {
+		return addr, nil
+	}
+	if n+offset < 1 || n+offset > 65535 {
+		return "", fmt.Errorf("port offset %d moves listen %q out of range", offset, addr)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n+offset)), nil
 }
 
 func splitHostPort(addr string) (host, port string) {
@@ -206,11 +579,22 @@ func splitHostPort(addr string) (host, port string) {
 }
 
 // inferUpstreams scans all HCL expressions in each service for service.<name>
-// references, validates they point to known services, and populates InferredUpstreams.
-func inferUpstreams(cfg *config.Config, knownServices map[string]cty.Value) error {
+// references, adds explicit depends_on entries, validates they point to known
+// services, and populates InferredUpstreams.
+func inferUpstreams(cfg *config.Config, knownServices map[string]cty.Value, dependsOn map[string][]string) error {
 	for _, svc := range cfg.Services {
 		upstreams := make(map[string]bool)
 
+		for _, name := range dependsOn[svc.ServiceName()] {
+			if _, exists := knownServices[name]; !exists {
+				return fmt.Errorf("service %q depends on unknown service %q", svc.ServiceName(), name)
+			}
+			if name == svc.ServiceName() {
+				return fmt.Errorf("service %q cannot depend on itself", svc.ServiceName())
+			}
+			upstreams[name] = true
+		}
+
 		for _, expr := range svc.Expressions() {
 			if expr == nil {
 				continue
@@ -248,31 +632,41 @@ func Validate(cfg *config.Config) error {
 		return fmt.Errorf("config is nil")
 	}
 
+	// Collect every error so they can all be fixed in one pass
+	var errs []error
 	if err := validateLogging(cfg.Logging, "logging"); err != nil {
-		return err
+		errs = append(errs, withRange(err, bodyRange(cfg.Logging.Body)))
 	}
 	if err := validateTracing(cfg.Tracing); err != nil {
-		return err
+		errs = append(errs, withRange(err, bodyRange(cfg.Tracing.Body)))
 	}
 	if err := validateMetrics(cfg.Metrics); err != nil {
-		return err
+		errs = append(errs, withRange(err, bodyRange(cfg.Metrics.Body)))
 	}
 
 	for _, svc := range cfg.Services {
-		if err := svc.Validate(); err != nil {
-			return err
+		rng := cfg.ServiceRanges[svc.ServiceName()]
+		for _, err := range splitErrors(svc.Validate()) {
+			errs = append(errs, withRange(err, rng))
 		}
 		for i, h := range svc.GetHandlers() {
 			if h.Name == "" {
-				return fmt.Errorf("service %q handler %d: name is required", svc.ServiceName(), i)
+				errs = append(errs, withRange(fmt.Errorf("service %q handler %d: name is required", svc.ServiceName(), i), rng))
 			}
 		}
+		errs = append(errs, validateResponseBodies(svc)...)
 		if err := validateLogging(svc.ServiceLogging(), fmt.Sprintf("service %q logging", svc.ServiceName())); err != nil {
-			return err
+			errs = append(errs, withRange(err, bodyRange(svc.ServiceLogging().Body)))
 		}
 	}
 
-	return nil
+	// Two services on one address would fail deep into startup
+	for _, c := range ListenConflicts(cfg) {
+		errs = append(errs, withRange(fmt.Errorf("%s", c), cfg.ServiceRanges[c.Second]))
+	}
+
+	// Each error is printed on its own line
+	return errors.Join(errs...)
 }
 
 // ValidateCLI checks CLI configuration for errors.
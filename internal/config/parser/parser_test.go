@@ -15,6 +15,7 @@ import (
 	"github.com/jumppad-labs/polymorph/internal/config"
 	"github.com/jumppad-labs/polymorph/internal/config/connect"
 	"github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/config/postgres"
 	"github.com/jumppad-labs/polymorph/internal/config/proxy"
 	"github.com/jumppad-labs/polymorph/internal/config/tcp"
 )
@@ -174,6 +175,30 @@ func TestValidate_MissingListen(t *testing.T) {
 	require.Contains(t, err.Error(), "listen address is required")
 }
 
+func TestValidate_Record(t *testing.T) {
+	tests := map[string]struct {
+		svc *http.Service
+		err string
+	}{
+		"invalid mode": {
+			svc: &http.Service{Name: "api", Listen: "0.0.0.0:8080", Record: &config.RecordConfig{Dir: "fixtures", Mode: "rewind"}},
+			err: `invalid record mode "rewind"`,
+		},
+		"with fallback": {
+			svc: &http.Service{Name: "api", Listen: "0.0.0.0:8080", Record: &config.RecordConfig{Dir: "fixtures"}, Fallback: &config.FallbackConfig{}},
+			err: "fallback and record cannot both be set",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(&config.Config{Services: []config.Service{tt.svc}})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
 func TestParse_FromBytes(t *testing.T) {
 	src := []byte(`
 service "http" "test" {
@@ -244,6 +269,66 @@ service "http" "test" {
 	}
 }
 
+func TestFunctions_Env(t *testing.T) {
+	t.Setenv("POLYMORPH_TEST_PORT", "9123")
+	t.Setenv("POLYMORPH_TEST_GREETING", "hello from env")
+
+	src := []byte(`
+service "http" "test" {
+  listen = "127.0.0.1:${env("POLYMORPH_TEST_PORT")}"
+
+  handle "test" {
+    route = "GET /test"
+    response {
+      body = jsonencode({
+        greeting = env("POLYMORPH_TEST_GREETING")
+        region   = env_default("POLYMORPH_TEST_UNSET", "us-east-1")
+      })
+    }
+  }
+}
+
+service "http" "client" {
+  listen = "127.0.0.1:9124"
+
+  handle "test" {
+    route = "GET /test"
+    response {
+      body = service.test.port
+    }
+  }
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 2)
+
+	// env() is available when listen is extracted for service.* vars
+	require.Equal(t, "127.0.0.1:9123", cfg.Services[0].ServiceListen())
+	clientCfg := cfg.Services[1].(*http.Service)
+	portVal, diags := clientCfg.Handlers[0].Response.BodyExpr.Value(config.NewEvalContext(clientCfg.Vars, nil))
+	require.False(t, diags.HasErrors())
+	require.Equal(t, "9123", portVal.AsString())
+
+	httpCfg := cfg.Services[0].(*http.Service)
+	value, diags := httpCfg.Handlers[0].Response.BodyExpr.Value(&hcl.EvalContext{Functions: config.Functions()})
+	require.False(t, diags.HasErrors())
+	require.JSONEq(t, `{"greeting":"hello from env","region":"us-east-1"}`, value.AsString())
+}
+
+func TestFunctions_EnvMissing(t *testing.T) {
+	src := []byte(`
+service "http" "test" {
+  listen = "127.0.0.1:${env("POLYMORPH_TEST_DEFINITELY_UNSET")}"
+}
+`)
+
+	_, err := Parse(src, "test.hcl")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `environment variable "POLYMORPH_TEST_DEFINITELY_UNSET" is not set`)
+}
+
 func TestParse_ServiceReferences(t *testing.T) {
 	cfg, err := ParseFile("../testdata/service_refs.hcl")
 	require.NoError(t, err)
@@ -278,127 +363,1192 @@ func TestParse_ServiceReferences(t *testing.T) {
 			"service": cty.ObjectVal(proxySvc.GetServiceVars()),
 		},
 	}
-	targetVal, diags := proxyCfg.TargetExpr.Value(evalCtx)
-	require.False(t, diags.HasErrors())
-	require.Equal(t, "http://127.0.0.1:8081", targetVal.AsString())
+	targetVal, diags := proxyCfg.TargetExpr.Value(evalCtx)
+	require.False(t, diags.HasErrors())
+	require.Equal(t, "http://127.0.0.1:8081", targetVal.AsString())
+}
+
+func TestParse_ServiceReferences_UnknownService(t *testing.T) {
+	src := []byte(`
+service "proxy" "proxy" {
+  listen = "0.0.0.0:8080"
+  target = service.nonexistent.url
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl")
+	require.Error(t, err)
+	require.Nil(t, cfg)
+	require.Contains(t, err.Error(), "service")
+}
+
+func TestParse_InferUpstreams(t *testing.T) {
+	cfg, err := ParseFile("../testdata/gateway_refs.hcl")
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Len(t, cfg.Services, 3)
+
+	// The gateway references user-service and order-service via step URLs
+	gateway := cfg.Services[2]
+	require.Equal(t, "api-gateway", gateway.ServiceName())
+	require.Len(t, gateway.GetInferredUpstreams(), 2)
+	require.Contains(t, gateway.GetInferredUpstreams(), "user-service")
+	require.Contains(t, gateway.GetInferredUpstreams(), "order-service")
+
+	// The upstream services should have no inferred upstreams
+	require.Empty(t, cfg.Services[0].GetInferredUpstreams())
+	require.Empty(t, cfg.Services[1].GetInferredUpstreams())
+}
+
+func TestParse_InferUpstreams_Proxy(t *testing.T) {
+	cfg, err := ParseFile("../testdata/service_refs.hcl")
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	// The proxy service references backend via target = service.backend.url
+	proxy := cfg.Services[1]
+	require.Equal(t, "proxy", proxy.ServiceName())
+	require.Len(t, proxy.GetInferredUpstreams(), 1)
+	require.Equal(t, "backend", proxy.GetInferredUpstreams()[0])
+
+	// The backend has no upstream references
+	require.Empty(t, cfg.Services[0].GetInferredUpstreams())
+}
+
+func TestParse_ServiceVars_AllAttributes(t *testing.T) {
+	src := []byte(`
+service "http" "my-api" {
+  listen = "10.0.0.1:9090"
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+
+	svc := cfg.Services[0]
+	require.NotNil(t, svc.GetServiceVars())
+
+	vars := svc.GetServiceVars()["my-api"].AsValueMap()
+	require.Equal(t, "10.0.0.1:9090", vars["address"].AsString())
+	require.Equal(t, "10.0.0.1", vars["host"].AsString())
+	require.Equal(t, "9090", vars["port"].AsString())
+	require.Equal(t, "http", vars["type"].AsString())
+	require.Equal(t, "http://10.0.0.1:9090", vars["url"].AsString())
+}
+
+func TestParse_PortOffset(t *testing.T) {
+	src := []byte(`
+service "http" "backend" {
+  listen = "127.0.0.1:9090"
+}
+
+service "proxy" "gateway" {
+  listen = ":8080"
+  target = service.backend.url
+}
+
+service "http" "ephemeral" {
+  listen = "127.0.0.1:0"
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl", WithPortOffset(100))
+	require.NoError(t, err)
+
+	require.Equal(t, "127.0.0.1:9190", cfg.Services[0].ServiceListen())
+	require.Equal(t, ":8180", cfg.Services[1].ServiceListen())
+	require.Equal(t, "127.0.0.1:0", cfg.Services[2].ServiceListen())
+
+	// References to other services see the shifted ports
+	vars := cfg.Services[0].GetServiceVars()["backend"].AsValueMap()
+	require.Equal(t, "9190", vars["port"].AsString())
+	require.Equal(t, "http://127.0.0.1:9190", vars["url"].AsString())
+
+	proxyCfg := cfg.Services[1].(*proxy.Service)
+	target, diags := proxyCfg.TargetExpr.Value(config.NewEvalContext(proxyCfg.Vars, nil))
+	require.False(t, diags.HasErrors())
+	require.Equal(t, "http://127.0.0.1:9190", target.AsString())
+
+	_, err = Parse(src, "test.hcl", WithPortOffset(60000))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "out of range")
+}
+
+func TestParse_ListenList(t *testing.T) {
+	src := []byte(`
+service "http" "api" {
+  listen = ["127.0.0.1:8080", "[::1]:8080", "127.0.0.1:0"]
+}
+
+service "proxy" "gateway" {
+  listen = ["0.0.0.0:9090", "[::]:9090"]
+  target = service.api.url
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl", WithPortOffset(10))
+	require.NoError(t, err)
+
+	api := cfg.Services[0].(*http.Service)
+	require.Equal(t, "127.0.0.1:8090", api.Listen)
+	require.Equal(t, []string{"[::1]:8090", "127.0.0.1:0"}, api.ExtraListen)
+
+	gateway := cfg.Services[1].(*proxy.Service)
+	require.Equal(t, "0.0.0.0:9100", gateway.Listen)
+	require.Equal(t, []string{"[::]:9100"}, gateway.ExtraListen)
+
+	// Service vars describe the first address
+	vars := api.GetServiceVars()["api"].AsValueMap()
+	require.Equal(t, "127.0.0.1:8090", vars["address"].AsString())
+	require.Equal(t, "http://127.0.0.1:8090", vars["url"].AsString())
+}
+
+func TestParse_ListenList_Invalid(t *testing.T) {
+	_, err := Parse([]byte(`
+service "http" "api" {
+  listen = []
+}
+`), "test.hcl")
+	require.ErrorContains(t, err, "listen list must not be empty")
+
+	_, err = Parse([]byte(`
+service "tcp" "raw" {
+  listen = ["127.0.0.1:7000", "127.0.0.1:7001"]
+}
+`), "test.hcl")
+	require.ErrorContains(t, err, "tcp services listen on a single address")
+}
+
+func TestParse_SocketOptions(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "tcp" "raw" {
+  listen = "127.0.0.1:7000"
+
+  socket {
+    keep_alive = "30s"
+    no_delay   = false
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	socket := cfg.Services[0].(*tcp.Service).Socket
+	require.NotNil(t, socket)
+	require.Equal(t, "30s", socket.KeepAlive)
+	require.NotNil(t, socket.NoDelay)
+	require.False(t, *socket.NoDelay)
+}
+
+func TestDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.hcl")
+	require.NoError(t, os.WriteFile(path, []byte(`
+service "http" "backend" {
+  listen = "127.0.0.1:8081"
+
+  handle "hello" {
+    route = "GET /hello"
+    response {
+      status = 201
+      body   = jsonencode({ message = "hello" })
+    }
+  }
+
+  handle "echo" {
+    route = "POST /echo"
+    response {
+      content_type = "text/plain"
+      body         = request.body
+    }
+  }
+}
+
+service "proxy" "gateway" {
+  listen = "0.0.0.0:8080"
+  target = service.backend.url
+}
+`), 0644))
+
+	cfg, err := ParseFile(path)
+	require.NoError(t, err)
+
+	out, err := Dump(cfg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "services": [
+    {
+      "name": "backend",
+      "type": "http",
+      "listen": "127.0.0.1:8081",
+      "upstreams": [],
+      "handlers": [
+        {
+          "name": "hello",
+          "route": "GET /hello",
+          "response": {"status": 201, "body": "{\"message\":\"hello\"}"}
+        },
+        {
+          "name": "echo",
+          "route": "POST /echo",
+          "response": {"content_type": "text/plain", "body": "request.body"}
+        }
+      ]
+    },
+    {
+      "name": "gateway",
+      "type": "proxy",
+      "listen": "0.0.0.0:8080",
+      "upstreams": ["backend"]
+    }
+  ]
+}`, string(out))
+}
+
+// --- Type-specific field rejection tests ---
+// With per-type config structs, gohcl rejects fields that don't belong to
+// a service type at parse time (instead of validate time).
+
+func TestParse_GlobalVars(t *testing.T) {
+	src := []byte(`
+vars {
+  env    = "staging"
+  region = "us-east-1"
+  port   = 9000
+}
+
+service "http" "api" {
+  listen = "127.0.0.1:${var.port}"
+
+  handle "info" {
+    route = "GET /info"
+    response {
+      body = jsonencode({ env = var.env, region = var.region })
+    }
+  }
+}
+
+service "http" "eu" {
+  listen = "127.0.0.1:9001"
+
+  vars {
+    region = "eu-west-1"
+  }
+
+  handle "info" {
+    route = "GET /info"
+    response {
+      body = jsonencode({ env = var.env, region = var.region })
+    }
+  }
+}
+
+service "http" "client" {
+  listen = "127.0.0.1:9002"
+
+  handle "backend" {
+    route = "GET /backend"
+    response {
+      body = service.api.address
+    }
+  }
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 3)
+	require.Equal(t, "staging", cfg.Variables["env"].AsString())
+
+	evalBody := func(svc config.Service) string {
+		httpCfg := svc.(*http.Service)
+		evalCtx := config.NewEvalContext(svc.GetServiceVars(), svc.GetVariables())
+		value, diags := httpCfg.Handlers[0].Response.BodyExpr.Value(evalCtx)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return value.AsString()
+	}
+
+	// Global vars resolve in listen and handler bodies
+	api := cfg.Services[0]
+	require.Equal(t, "127.0.0.1:9000", api.ServiceListen())
+	require.JSONEq(t, `{"env":"staging","region":"us-east-1"}`, evalBody(api))
+
+	// Per-service vars override globals
+	require.JSONEq(t, `{"env":"staging","region":"eu-west-1"}`, evalBody(cfg.Services[1]))
+
+	// service.* sees listen addresses built from vars
+	require.Equal(t, "127.0.0.1:9000", evalBody(cfg.Services[2]))
+}
+
+func TestParse_GlobalVars_Duplicate(t *testing.T) {
+	src := []byte(`
+vars {
+  env = "staging"
+}
+
+vars {
+  env = "prod"
+}
+`)
+
+	_, err := Parse(src, "test.hcl")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `variable "env" is defined more than once`)
+}
+
+func TestParse_ServiceCount(t *testing.T) {
+	src := []byte(`
+vars {
+  backends = 3
+}
+
+service "http" "backend" {
+  count  = var.backends
+  listen = "127.0.0.1:${8080 + count.index}"
+}
+
+service "proxy" "gateway" {
+  listen = "127.0.0.1:9000"
+  target = service.backend-2.url
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 4)
+
+	for i, want := range []string{"127.0.0.1:8080", "127.0.0.1:8081", "127.0.0.1:8082"} {
+		svc := cfg.Services[i]
+		require.Equal(t, fmt.Sprintf("backend-%d", i), svc.ServiceName())
+		require.Equal(t, want, svc.ServiceListen())
+	}
+
+	// Expanded services are addressable through service.<name>-<index>
+	require.Equal(t, []string{"backend-2"}, cfg.Services[3].GetInferredUpstreams())
+}
+
+func TestParse_ServiceCount_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"negative": "-1",
+		"string":   `"three"`,
+	}
+
+	for name, count := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := []byte(fmt.Sprintf(`
+service "http" "backend" {
+  count  = %s
+  listen = "127.0.0.1:8080"
+}
+`, count))
+			_, err := Parse(src, "test.hcl")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "count must be")
+		})
+	}
+}
+
+func TestParse_DependsOn(t *testing.T) {
+	src := []byte(`
+service "tcp" "cache" {
+  listen = "127.0.0.1:6379"
+}
+
+service "http" "backend" {
+  listen = "127.0.0.1:8080"
+}
+
+service "proxy" "gateway" {
+  listen     = "127.0.0.1:9000"
+  target     = service.backend.url
+  depends_on = ["cache"]
+}
+`)
+
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 3)
+
+	// Explicit dependencies are merged with inferred references
+	require.Equal(t, []string{"backend", "cache"}, cfg.Services[2].GetInferredUpstreams())
+}
+
+func TestParse_DependsOn_Unknown(t *testing.T) {
+	src := []byte(`
+service "http" "api" {
+  listen     = "127.0.0.1:8080"
+  depends_on = ["cache"]
+}
+`)
+
+	_, err := Parse(src, "test.hcl")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `service "api" depends on unknown service "cache"`)
+}
+
+func TestParse_UnknownServiceType(t *testing.T) {
+	src := []byte(`
+service "grpc" "api" {
+  listen = "0.0.0.0:8080"
+}
+`)
+	cfg, err := Parse(src, "test.hcl")
+	require.Error(t, err)
+	require.Nil(t, cfg)
+	require.Contains(t, err.Error(), "unknown type \"grpc\"")
+}
+
+func TestParse_RedisService(t *testing.T) {
+	src := []byte(`
+service "redis" "cache" {
+  listen = "0.0.0.0:6379"
+}
+`)
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 1)
+	require.Equal(t, "redis", cfg.Services[0].ServiceType())
+	require.Equal(t, "0.0.0.0:6379", cfg.Services[0].ServiceListen())
+}
+
+func TestParse_PackageOnlyForConnect(t *testing.T) {
+	for _, svcType := range []string{"http", "proxy", "tcp"} {
+		t.Run(svcType, func(t *testing.T) {
+			target := ""
+			if svcType == "proxy" {
+				target = "\n  target = \"http://localhost:8081\""
+			}
+			src := []byte(fmt.Sprintf(`
+service "%s" "api" {
+  listen  = "0.0.0.0:8080"%s
+  package = "api.v1"
+}
+`, svcType, target))
+			_, err := Parse(src, "test.hcl")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "package")
+		})
+	}
+}
+
+func TestValidate_TCPFraming(t *testing.T) {
+	cfg := &config.Config{
+		Services: []config.Service{
+			&tcp.Service{Name: "rpc", Listen: "0.0.0.0:9000", Framing: "length_prefixed"},
+		},
+	}
+	require.NoError(t, Validate(cfg))
+
+	cfg.Services[0].(*tcp.Service).Framing = "varint"
+	err := Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid framing "varint"`)
+}
+
+func TestValidate_AfterHookResponse(t *testing.T) {
+	cfg := &config.Config{
+		Services: []config.Service{
+			&http.Service{
+				Name:   "api",
+				Listen: "0.0.0.0:8080",
+				After:  &config.HookConfig{Response: &config.HookResponseConfig{Status: 500}},
+			},
+		},
+	}
+	err := Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "after hook cannot set a response")
+}
+
+func TestValidate_PostgresMaxConnections(t *testing.T) {
+	cfg := &config.Config{
+		Services: []config.Service{
+			&postgres.Service{Name: "db", Listen: "0.0.0.0:5432", MaxConnections: -1},
+		},
+	}
+	err := Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "max_connections must not be negative")
+}
+
+func TestValidate_TrustedProxies(t *testing.T) {
+	svc := &http.Service{Name: "api", Listen: "0.0.0.0:8080", TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}}
+	require.NoError(t, Validate(&config.Config{Services: []config.Service{svc}}))
+
+	svc.TrustedProxies = append(svc.TrustedProxies, "10.0.0.0/40")
+	err := Validate(&config.Config{Services: []config.Service{svc}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid trusted proxy "10.0.0.0/40"`)
+}
+
+func TestValidate_Throttle(t *testing.T) {
+	svc := &http.Service{
+		Name:     "api",
+		Listen:   "0.0.0.0:8080",
+		Throttle: "fast",
+		Handlers: []*http.Handler{{Name: "download", Route: "GET /download", Throttle: "100kb"}},
+	}
+	err := Validate(&config.Config{Services: []config.Service{svc}})
+	require.ErrorContains(t, err, `service "api": invalid throttle "fast"`)
+	require.ErrorContains(t, err, `service "api": handler "download": invalid throttle "100kb"`)
+}
+
+func TestValidate_SocketKeepAlive(t *testing.T) {
+	svc := &tcp.Service{Name: "echo", Listen: "0.0.0.0:9000", Socket: &config.SocketConfig{KeepAlive: "30s"}}
+	require.NoError(t, Validate(&config.Config{Services: []config.Service{svc}}))
+
+	for _, keepAlive := range []string{"soon", "-1s"} {
+		svc.Socket.KeepAlive = keepAlive
+		err := Validate(&config.Config{Services: []config.Service{svc}})
+		require.ErrorContains(t, err, fmt.Sprintf(`service "echo": socket: invalid keep_alive %q`, keepAlive))
+	}
+}
+
+func TestValidate_DuplicateListen(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+}
+
+service "http" "admin" {
+  listen = "127.0.0.1:8080"
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `test.hcl:6:1: services "api" and "admin" both listen on 127.0.0.1:8080`)
+
+	// Port 0 picks a free port for each service
+	cfg.Services[0].SetListen("127.0.0.1:0")
+	cfg.Services[1].SetListen("127.0.0.1:0")
+	require.NoError(t, Validate(cfg))
+}
+
+func TestValidate_ConnectRequiresPackage(t *testing.T) {
+	cfg := &config.Config{
+		Services: []config.Service{
+			&connect.Service{
+				Name:   "api",
+				Listen: "0.0.0.0:9090",
+			},
+		},
+	}
+	err := Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "package is required for connect services")
+}
+
+func TestValidate_ConnectJSONCasing(t *testing.T) {
+	svc := &connect.Service{Name: "api", Listen: "0.0.0.0:9090", Package: "api.v1", JSONCasing: "camel"}
+	require.NoError(t, Validate(&config.Config{Services: []config.Service{svc}}))
+
+	svc.JSONCasing = "kebab"
+	err := Validate(&config.Config{Services: []config.Service{svc}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid json_casing "kebab"`)
+}
+
+func TestParse_ResourceRefs(t *testing.T) {
+	src := []byte(`
+service "http" "api" {
+  listen = "0.0.0.0:8080"
+
+  resource "order" {
+    field "id"      { type = "uuid" }
+    field "user_id" {
+      type     = "ref"
+      resource = "user"
+    }
+  }
+
+  resource "user" {
+    field "id" { type = "uuid" }
+  }
+}
+`)
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+	require.NoError(t, Validate(cfg))
+
+	resources := cfg.Services[0].GetResources()
+	require.Equal(t, "user", resources[0].Fields[1].Resource)
+}
+
+func TestValidate_ResourceRefUnknown(t *testing.T) {
+	src := []byte(`
+service "http" "api" {
+  listen = "0.0.0.0:8080"
+
+  resource "order" {
+    field "id"      { type = "uuid" }
+    field "user_id" {
+      type     = "ref"
+      resource = "user"
+    }
+  }
+}
+`)
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `references unknown resource "user"`)
+}
+
+func TestValidate_ResourceRowRange(t *testing.T) {
+	src := []byte(`
+service "http" "api" {
+  listen = "0.0.0.0:8080"
+
+  resource "user" {
+    rows_min = 20
+    rows_max = 10
+
+    field "id" { type = "uuid" }
+  }
+}
+`)
+	cfg, err := Parse(src, "test.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rows_min (20) must be less than or equal to rows_max (10)")
+}
+
+func TestValidate_ProxyHostOptions(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "proxy" "gateway" {
+  listen        = "0.0.0.0:8080"
+  target        = "http://backend:8081"
+  preserve_host = true
+  host_header   = "api.internal"
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "preserve_host and host_header cannot both be set")
+
+	cfg.Services[0].(*proxy.Service).PreserveHost = false
+	require.NoError(t, Validate(cfg))
+}
+
+func TestValidate_ProxyPathRewrite(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "proxy" "gateway" {
+  listen = "0.0.0.0:8080"
+  target = "http://backend:8081"
+
+  path_rewrite {
+    strip_prefix = "api/v1"
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "path_rewrite strip_prefix must start with /")
+}
+
+func TestValidate_Timing(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "partial percentiles",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  timing {
+    p50 = "10ms"
+  }
+}`,
+			want: `service "api": timing: p50, p90 and p99 must be set together`,
+		},
+		{
+			name: "partial handler percentiles",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "slow" {
+    route = "GET /slow"
+    timing {
+      p50 = "10ms"
+      p99 = "50ms"
+    }
+  }
+}`,
+			want: `service "api": handler "slow": timing: p50, p90 and p99 must be set together`,
+		},
+		{
+			name: "transfer outside http",
+			src: `
+service "postgres" "db" {
+  listen = "127.0.0.1:5432"
+  timing {
+    ttfb = "100ms"
+  }
+}`,
+			want: `service "db": timing: ttfb and body_time are only supported by http services`,
+		},
+		{
+			name: "invalid duration",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  timing {
+    body_time = "fast"
+  }
+}`,
+			want: `service "api": timing: invalid body_time`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.src), "test.hcl")
+			require.NoError(t, err)
+			require.ErrorContains(t, Validate(cfg), tt.want)
+		})
+	}
+
+	// ttfb alone, or with every percentile, is fine on http
+	cfg, err := Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  timing {
+    ttfb = "100ms"
+  }
+  handle "slow" {
+    route = "GET /slow"
+    timing {
+      p50       = "10ms"
+      p90       = "20ms"
+      p99       = "50ms"
+      body_time = "1s"
+    }
+  }
+}`), "test.hcl")
+	require.NoError(t, err)
+	require.NoError(t, Validate(cfg))
+}
+
+func TestValidate_StepKind(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "handler step with http and mock",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "users" {
+    route = "GET /users"
+    step "user" {
+      http {
+        url = "http://users/1"
+      }
+      mock {
+        set = { id = 1 }
+      }
+    }
+  }
+}`,
+			want: `service "api": handler "users": step "user": http and mock cannot both be set`,
+		},
+		{
+			name: "hook step with neither",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  before {
+    step "auth" {}
+  }
+}`,
+			want: `service "api": hook: step "auth": must have an http or mock block`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.src), "test.hcl")
+			require.NoError(t, err)
+			require.ErrorContains(t, Validate(cfg), tt.want)
+		})
+	}
+}
+
+func TestValidate_ResponseOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "http handler template without engine",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "users" {
+    route = "GET /users"
+    response {
+      template = "{{ .request.path }}"
+    }
+  }
+}`,
+			want: `service "api": handler "users": response template requires engine = "go-template"`,
+		},
+		{
+			name: "not_found with unknown engine",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  not_found {
+    engine   = "jinja"
+    template = "missing"
+  }
+}`,
+			want: `service "api": not_found: invalid response engine "jinja"`,
+		},
+		{
+			name: "before hook with malformed template",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  before {
+    response {
+      status   = 401
+      engine   = "go-template"
+      template = "{{ .request"
+    }
+  }
+}`,
+			want: `service "api": before hook: invalid response template`,
+		},
+		{
+			name: "http error response",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  error "flaky" {
+    rate   = 0.1
+    status = 500
+    response {
+      engine   = "go-template"
+      template = "down"
+    }
+  }
+}`,
+			want: `service "api": error "flaky": response template, engine: only supported on http handler`,
+		},
+		{
+			name: "tcp handler",
+			src: `
+service "tcp" "echo" {
+  listen = "127.0.0.1:9000"
+  handle "ping" {
+    pattern = "PING"
+    response {
+      engine   = "go-template"
+      template = "PONG"
+    }
+  }
+}`,
+			want: `service "echo": handler "ping": response template, engine: only supported on http handler`,
+		},
+		{
+			name: "step",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "users" {
+    route = "GET /users"
+    step "user" {
+      http {
+        url      = "http://users/1"
+        template = "{{ .request.path }}"
+      }
+    }
+  }
+}`,
+			want: `service "api": handler "users": step "user": template not supported on steps`,
+		},
+		{
+			name: "non-HTTP content_type",
+			src: `
+service "tcp" "echo" {
+  listen = "127.0.0.1:9000"
+  handle "ping" {
+    pattern = "PING"
+    response {
+      content_type = "text/plain"
+      body         = "PONG"
+    }
+  }
+}`,
+			want: `service "echo": handler "ping": response content_type: only supported on http handler`,
+		},
+		{
+			name: "step content_type",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "users" {
+    route = "GET /users"
+    step "user" {
+      content_type = "application/json"
+      mock {
+        set = { id = 1 }
+      }
+    }
+  }
+}`,
+			want: `service "api": handler "users": step "user": content_type not supported on steps`,
+		},
+		{
+			name: "base64_body with body",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "pixel" {
+    route = "GET /pixel.png"
+    response {
+      base64_body = "aGk="
+      body        = "hi"
+    }
+  }
+}`,
+			want: `service "api" handler "pixel": base64_body cannot be combined with body or template`,
+		},
+		{
+			name: "not_found invalid base64_body",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  not_found {
+    base64_body = "not base64!"
+  }
+}`,
+			want: `service "api" not_found: invalid base64_body`,
+		},
+		{
+			name: "before hook base64_body with template",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  before {
+    response {
+      status      = 401
+      base64_body = "aGk="
+      engine      = "go-template"
+      template    = "denied"
+    }
+  }
+}`,
+			want: `service "api" before hook: base64_body cannot be combined with body or template`,
+		},
+		{
+			name: "step base64_body",
+			src: `
+service "http" "api" {
+  listen = "127.0.0.1:8080"
+  handle "users" {
+    route = "GET /users"
+    step "user" {
+      base64_body = "aGk="
+      mock {
+        set = { id = 1 }
+      }
+    }
+  }
+}`,
+			want: `service "api": handler "users": step "user": base64_body not supported on steps`,
+		},
+		{
+			name: "tcp base64_body",
+			src: `
+service "tcp" "echo" {
+  listen = "127.0.0.1:9000"
+  handle "ping" {
+    pattern = "PING"
+    response {
+      base64_body = "UE9ORw=="
+    }
+  }
+}`,
+			want: `service "echo": handler "ping": response base64_body: only supported on http handler`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.src), "test.hcl")
+			require.NoError(t, err)
+			require.ErrorContains(t, Validate(cfg), tt.want)
+		})
+	}
 }
 
-func TestParse_ServiceReferences_UnknownService(t *testing.T) {
-	src := []byte(`
-service "proxy" "proxy" {
+func TestParse_ProxyMirror(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "http" "shadow" {
+  listen = "127.0.0.1:8081"
+}
+
+service "proxy" "gateway" {
   listen = "0.0.0.0:8080"
-  target = service.nonexistent.url
+  target = "http://backend:8082"
+
+  mirror {
+    target      = service.shadow.url
+    sample_rate = 1.5
+  }
 }
-`)
+`), "test.hcl")
+	require.NoError(t, err)
+	require.Equal(t, []string{"shadow"}, cfg.Services[1].GetInferredUpstreams())
 
-	cfg, err := Parse(src, "test.hcl")
+	err = Validate(cfg)
 	require.Error(t, err)
-	require.Nil(t, cfg)
-	require.Contains(t, err.Error(), "service")
+	require.Contains(t, err.Error(), "mirror sample_rate must be between 0 and 1")
 }
 
-func TestParse_InferUpstreams(t *testing.T) {
-	cfg, err := ParseFile("../testdata/gateway_refs.hcl")
-	require.NoError(t, err)
-	require.NotNil(t, cfg)
-	require.Len(t, cfg.Services, 3)
+func TestValidate_ResponseBodies(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "http" "api" {
+  listen = "0.0.0.0:8080"
 
-	// The gateway references user-service and order-service via step URLs
-	gateway := cfg.Services[2]
-	require.Equal(t, "api-gateway", gateway.ServiceName())
-	require.Len(t, gateway.GetInferredUpstreams(), 2)
-	require.Contains(t, gateway.GetInferredUpstreams(), "user-service")
-	require.Contains(t, gateway.GetInferredUpstreams(), "order-service")
+  handle "typo" {
+    route = "GET /typo"
+    response {
+      body = jsonencod({ ok = true })
+    }
+  }
 
-	// The upstream services should have no inferred upstreams
-	require.Empty(t, cfg.Services[0].GetInferredUpstreams())
-	require.Empty(t, cfg.Services[1].GetInferredUpstreams())
-}
+  handle "malformed" {
+    route = "GET /malformed"
+    response {
+      body = "{\"ok\": }"
+    }
+  }
 
-func TestParse_InferUpstreams_Proxy(t *testing.T) {
-	cfg, err := ParseFile("../testdata/service_refs.hcl")
-	require.NoError(t, err)
-	require.NotNil(t, cfg)
+  handle "dynamic" {
+    route = "GET /users/:id"
+    response {
+      body = jsonencode({ id = request.params.id, name = step.load.body.name })
+    }
+  }
 
-	// The proxy service references backend via target = service.backend.url
-	proxy := cfg.Services[1]
-	require.Equal(t, "proxy", proxy.ServiceName())
-	require.Len(t, proxy.GetInferredUpstreams(), 1)
-	require.Equal(t, "backend", proxy.GetInferredUpstreams()[0])
+  handle "text" {
+    route = "GET /health"
+    response {
+      body = "ok"
+    }
+  }
 
-	// The backend has no upstream references
-	require.Empty(t, cfg.Services[0].GetInferredUpstreams())
-}
+  handle "html" {
+    route   = "GET /page"
+    response {
+      headers = { "Content-Type" = "text/html" }
+      body    = "[not json]"
+    }
+  }
 
-func TestParse_ServiceVars_AllAttributes(t *testing.T) {
-	src := []byte(`
-service "http" "my-api" {
-  listen = "10.0.0.1:9090"
-}
-`)
+  handle "csv" {
+    route = "GET /report"
+    response {
+      content_type = "text/csv; charset=utf-8"
+      body         = "[a],[b]"
+    }
+  }
 
-	cfg, err := Parse(src, "test.hcl")
+  handle "pixel" {
+    route = "GET /pixel.png"
+    response {
+      base64_body = "not base64!"
+    }
+  }
+}
+`), "test.hcl")
 	require.NoError(t, err)
 
-	svc := cfg.Services[0]
-	require.NotNil(t, svc.GetServiceVars())
+	err = Validate(cfg)
+	require.Error(t, err)
+	msg := err.Error()
+	require.Contains(t, msg, `test.hcl:8:14: service "api" handler "typo": invalid body: Call to unknown function`)
+	require.Contains(t, msg, `service "api" handler "malformed": body is not valid JSON`)
+	require.NotContains(t, msg, "dynamic")
+	require.NotContains(t, msg, `"text"`)
+	require.NotContains(t, msg, "html")
+	require.NotContains(t, msg, "csv")
+	require.Contains(t, msg, `service "api" handler "pixel": invalid base64_body`)
+}
+
+func TestValidate_ResponseHeaders(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:8080"
 
-	vars := svc.GetServiceVars()["my-api"].AsValueMap()
-	require.Equal(t, "10.0.0.1:9090", vars["address"].AsString())
-	require.Equal(t, "10.0.0.1", vars["host"].AsString())
-	require.Equal(t, "9090", vars["port"].AsString())
-	require.Equal(t, "http", vars["type"].AsString())
-	require.Equal(t, "http://10.0.0.1:9090", vars["url"].AsString())
-}
+  handle "list" {
+    route = "GET /list"
+    response {
+      headers = ["x"]
+      body    = jsonencode({ ok = true })
+    }
+  }
 
-// --- Type-specific field rejection tests ---
-// With per-type config structs, gohcl rejects fields that don't belong to
-// a service type at parse time (instead of validate time).
+  handle "typo" {
+    route = "GET /typo"
+    response {
+      headers = { "X-Env" = var.missing }
+    }
+  }
 
-func TestParse_UnknownServiceType(t *testing.T) {
-	src := []byte(`
-service "grpc" "api" {
-  listen = "0.0.0.0:8080"
+  handle "dynamic" {
+    route = "GET /dynamic"
+    response {
+      headers = request.headers
+      body    = jsonencode({ ok = true })
+    }
+  }
 }
-`)
-	cfg, err := Parse(src, "test.hcl")
+`), "test.hcl")
+	require.NoError(t, err)
+
+	// Reported with the others rather than panicking
+	err = Validate(cfg)
 	require.Error(t, err)
-	require.Nil(t, cfg)
-	require.Contains(t, err.Error(), "unknown type \"grpc\"")
-}
+	msg := err.Error()
+	require.Contains(t, msg, `test.hcl:8:17: service "api" handler "list": invalid headers: must be an object, got tuple`)
+	require.Contains(t, msg, `service "api" handler "typo": invalid headers`)
+	require.NotContains(t, msg, "dynamic")
 
-func TestParse_PackageOnlyForConnect(t *testing.T) {
-	for _, svcType := range []string{"http", "proxy", "tcp"} {
-		t.Run(svcType, func(t *testing.T) {
-			target := ""
-			if svcType == "proxy" {
-				target = "\n  target = \"http://localhost:8081\""
-			}
-			src := []byte(fmt.Sprintf(`
-service "%s" "api" {
-  listen  = "0.0.0.0:8080"%s
-  package = "api.v1"
-}
-`, svcType, target))
-			_, err := Parse(src, "test.hcl")
-			require.Error(t, err)
-			require.Contains(t, err.Error(), "package")
-		})
-	}
+	cfg, err = Parse([]byte(`
+service "http" "api" {
+  listen           = "127.0.0.1:8080"
+  response_headers = ["x"]
+
+  handle "count" {
+    route = "GET /count"
+    response {
+      headers = { "X-Count" = 5 }
+    }
+  }
 }
+`), "test.hcl")
+	require.NoError(t, err)
 
-func TestValidate_ConnectRequiresPackage(t *testing.T) {
-	cfg := &config.Config{
-		Services: []config.Service{
-			&connect.Service{
-				Name:   "api",
-				Listen: "0.0.0.0:9090",
-			},
-		},
-	}
-	err := Validate(cfg)
+	err = Validate(cfg)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "package is required for connect services")
+	msg = err.Error()
+	require.Contains(t, msg, `test.hcl:4:22: service "api": invalid response_headers: must be an object, got tuple`)
+	require.Contains(t, msg, `service "api" handler "count": invalid headers: "X-Count" must be a string`)
 }
 
 func TestParse_TargetOnlyForProxy(t *testing.T) {
@@ -425,14 +1575,21 @@ service "http" "api" {
 	require.Contains(t, err.Error(), "request_headers")
 }
 
-func TestParse_ResponseHeadersOnlyForProxy(t *testing.T) {
-	src := []byte(`
+func TestParse_ResponseHeadersOnlyForHTTPAndProxy(t *testing.T) {
+	_, err := Parse([]byte(`
 service "http" "api" {
   listen            = "0.0.0.0:8080"
   response_headers  = { "X-Test" = "val" }
 }
-`)
-	_, err := Parse(src, "test.hcl")
+`), "test.hcl")
+	require.NoError(t, err)
+
+	_, err = Parse([]byte(`
+service "tcp" "echo" {
+  listen            = "0.0.0.0:9000"
+  response_headers  = { "X-Test" = "val" }
+}
+`), "test.hcl")
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "response_headers")
 }
@@ -834,6 +1991,108 @@ service "http" "api" {
 	require.Equal(t, "0.0.0.0:8080", cfg.Services[0].ServiceListen())
 }
 
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg, err := Parse([]byte(`
+metrics {
+  path = "metrics"
+}
+
+service "tcp" "rpc" {
+  listen  = "0.0.0.0:9000"
+  framing = "varint"
+}
+
+service "http" "api" {
+  listen = "0.0.0.0:8080"
+
+  logging {
+    level = "verbose"
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "metrics: path must start with /")
+	require.Contains(t, err.Error(), `invalid framing "varint"`)
+	require.Contains(t, err.Error(), `invalid logging level "verbose"`)
+	require.Len(t, strings.Split(err.Error(), "\n"), 3)
+}
+
+func TestValidate_ReportsAllServiceErrors(t *testing.T) {
+	cfg, err := Parse([]byte(`
+service "connect" "api" {
+  listen      = "0.0.0.0:9000"
+  package     = ""
+  json_casing = "kebab"
+
+  timing {
+    p50 = "10ms"
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	// Every problem with one service is reported, each located at it
+	err = Validate(cfg)
+	require.Error(t, err)
+	lines := strings.Split(err.Error(), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "p50, p90 and p99 must be set together")
+	require.Contains(t, lines[1], "package is required for connect services")
+	require.Contains(t, lines[2], `invalid json_casing "kebab"`)
+	for _, line := range lines {
+		require.True(t, strings.HasPrefix(line, "test.hcl:2:"), line)
+	}
+}
+
+func TestValidate_ErrorPositions(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "01-api.hcl"), []byte(`
+service "http" "api" {
+  listen = "0.0.0.0:8080"
+}
+`), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "02-rpc.hcl"), []byte(`
+logging {
+  level = "info"
+}
+
+service "tcp" "rpc" {
+  listen  = "0.0.0.0:9000"
+  framing = "varint"
+}
+`), 0644))
+
+	cfg, err := ParseFile(dir)
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, filepath.Join(dir, "02-rpc.hcl"), verr.Range.Filename)
+	require.Equal(t, 6, verr.Range.Start.Line)
+	require.Equal(t, filepath.Join(dir, "02-rpc.hcl")+`:6:1: service "rpc": invalid framing "varint" (must be line or length_prefixed)`, err.Error())
+
+	// Blocks outside services point at their own body
+	cfg, err = Parse([]byte(`
+metrics {
+  path = "metrics"
+}
+`), "root.hcl")
+	require.NoError(t, err)
+
+	err = Validate(cfg)
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "root.hcl", verr.Range.Filename)
+	require.Equal(t, 2, verr.Range.Start.Line)
+}
+
 func TestParseFile_DirectoryEmpty(t *testing.T) {
 	dir := t.TempDir()
 
@@ -892,6 +2151,89 @@ service "proxy" "gateway" {
 	require.Equal(t, "backend", gw.GetInferredUpstreams()[0])
 }
 
+func TestParseFile_Import(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shared"), 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared", "base.hcl"), []byte(`
+service "http" "backend" {
+  listen = "127.0.0.1:8081"
+}
+`), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(`
+import "shared/base.hcl" {}
+
+service "proxy" "gateway" {
+  listen = "127.0.0.1:8080"
+  target = service.backend.url
+}
+`), 0644))
+
+	cfg, err := ParseFile(filepath.Join(dir, "main.hcl"))
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 2)
+
+	// Imported services come first
+	require.Equal(t, "backend", cfg.Services[0].ServiceName())
+	require.Equal(t, "gateway", cfg.Services[1].ServiceName())
+	require.Equal(t, []string{"backend"}, cfg.Services[1].GetInferredUpstreams())
+
+	proxyCfg := cfg.Services[1].(*proxy.Service)
+	targetVal, diags := proxyCfg.TargetExpr.Value(config.NewEvalContext(proxyCfg.Vars, nil))
+	require.False(t, diags.HasErrors())
+	require.Equal(t, "http://127.0.0.1:8081", targetVal.AsString())
+}
+
+func TestParseFile_ImportSiblingInDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.hcl"), []byte(`
+import "b.hcl" {}
+
+service "http" "a" {
+  listen = "127.0.0.1:8080"
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.hcl"), []byte(`
+service "http" "b" {
+  listen = "127.0.0.1:8081"
+}
+`), 0644))
+
+	// b.hcl is both imported and in the directory, but loaded once
+	cfg, err := ParseFile(dir)
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 2)
+}
+
+func TestParseFile_ImportCycle(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.hcl"), []byte(`
+import "b.hcl" {}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.hcl"), []byte(`
+import "a.hcl" {}
+`), 0644))
+
+	_, err := ParseFile(filepath.Join(dir, "a.hcl"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "import cycle detected")
+}
+
+func TestParseFile_ImportMissing(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(`
+import "nope.hcl" {}
+`), 0644))
+
+	_, err := ParseFile(filepath.Join(dir, "main.hcl"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read imported config")
+}
+
 // TestMain ensures tests run from the correct directory
 func TestMain(m *testing.M) {
 	os.Exit(m.Run())
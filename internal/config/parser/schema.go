@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/jumppad-labs/polymorph/internal/config/connect"
+	"github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/config/postgres"
+	"github.com/jumppad-labs/polymorph/internal/config/proxy"
+	"github.com/jumppad-labs/polymorph/internal/config/redis"
+	"github.com/jumppad-labs/polymorph/internal/config/tcp"
+)
+
+// serviceTypes maps service type labels to their config structs, matching
+// serviceDecoders
+var serviceTypes = map[string]any{
+	"http":     http.Service{},
+	"proxy":    proxy.Service{},
+	"tcp":      tcp.Service{},
+	"connect":  connect.Service{},
+	"postgres": postgres.Service{},
+	"redis":    redis.Service{},
+}
+
+var (
+	expressionType    = reflect.TypeOf((*hcl.Expression)(nil)).Elem()
+	bodyType          = reflect.TypeOf((*hcl.Body)(nil)).Elem()
+	ctyValueType      = reflect.TypeOf(cty.Value{})
+	multiListenerType = reflect.TypeOf((*config.MultiListener)(nil)).Elem()
+)
+
+// Schema returns a JSON Schema describing config files in HCL's JSON
+// syntax, for editors to offer completion and validation. It is derived
+// from the hcl tags on the config structs: each block is an object, with
+// one level of nesting per block label, so service "http" "api" { ... } is
+// described under service.http.<name>.
+func Schema() ([]byte, error) {
+	root := bodySchema(reflect.TypeOf(config.Config{}), map[reflect.Type]bool{})
+	props := root["properties"].(map[string]any)
+
+	types := make([]string, 0, len(serviceTypes))
+	for name := range serviceTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	services := map[string]any{}
+	for _, name := range types {
+		services[name] = labelled(serviceSchema(reflect.TypeOf(serviceTypes[name])), 1)
+	}
+	props["service"] = map[string]any{
+		"type":                 "object",
+		"properties":           services,
+		"additionalProperties": false,
+	}
+	props["vars"] = map[string]any{"type": "object"}
+	props["import"] = labelled(map[string]any{"type": "object"}, 1)
+
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "Polymorph configuration"
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// serviceSchema describes a service block: its type's fields plus the
+// meta-arguments every service accepts
+func serviceSchema(t reflect.Type) map[string]any {
+	schema := bodySchema(t, map[reflect.Type]bool{})
+	props := schema["properties"].(map[string]any)
+	props["count"] = map[string]any{"type": "integer"}
+	props["depends_on"] = map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	props["vars"] = map[string]any{"type": "object"}
+
+	// Services that bind several addresses take a list as well
+	if reflect.PointerTo(t).Implements(multiListenerType) {
+		props["listen"] = map[string]any{"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "minItems": 1},
+		}}
+	}
+	return schema
+}
+
+// bodySchema describes the attributes and blocks of a struct decoded by
+// gohcl. visiting guards against recursive block types.
+func bodySchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	props := map[string]any{}
+	var required []string
+
+	if visiting[t] {
+		return map[string]any{"type": "object", "properties": props}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, kind, _ := strings.Cut(f.Tag.Get("hcl"), ",")
+		if name == "" || kind == "label" || kind == "remain" {
+			continue
+		}
+
+		if kind == "block" {
+			props[name] = blockSchema(f.Type, visiting)
+			continue
+		}
+		props[name] = attributeSchema(f.Type, visiting)
+		if kind != "optional" {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// blockSchema describes a block field. Labelled blocks nest one object per
+// label; unlabelled repeated blocks are arrays.
+func blockSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	repeated := t.Kind() == reflect.Slice
+	if repeated {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	body := bodySchema(t, visiting)
+	labels := labelCount(t)
+	if labels > 0 {
+		return labelled(body, labels)
+	}
+	if repeated {
+		return map[string]any{"type": "array", "items": body}
+	}
+	return body
+}
+
+// labelled wraps a block body in one object level per label
+func labelled(body map[string]any, labels int) map[string]any {
+	for range labels {
+		body = map[string]any{"type": "object", "additionalProperties": body}
+	}
+	return body
+}
+
+// labelCount returns how many labels a block struct takes
+func labelCount(t reflect.Type) int {
+	n := 0
+	for i := 0; i < t.NumField(); i++ {
+		if _, kind, _ := strings.Cut(t.Field(i).Tag.Get("hcl"), ","); kind == "label" {
+			n++
+		}
+	}
+	return n
+}
+
+// attributeSchema describes an attribute's value. Expressions and cty
+// values accept anything.
+func attributeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	if t == expressionType || t == bodyType || t == ctyValueType {
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return attributeSchema(t.Elem(), visiting)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": attributeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": attributeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		return bodySchema(t, visiting)
+	}
+	return map[string]any{}
+}
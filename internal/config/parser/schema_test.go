@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// schemaPath walks a schema through the named properties, stepping into
+// additionalProperties for each "*"
+func schemaPath(t *testing.T, schema map[string]any, path ...string) map[string]any {
+	t.Helper()
+	for _, key := range path {
+		var next any
+		if key == "*" {
+			next = schema["additionalProperties"]
+		} else {
+			props, _ := schema["properties"].(map[string]any)
+			next = props[key]
+		}
+		obj, ok := next.(map[string]any)
+		require.True(t, ok, "no schema at %q in %v", key, path)
+		schema = obj
+	}
+	return schema
+}
+
+func TestSchema(t *testing.T) {
+	out, err := Schema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(out, &schema))
+	require.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+
+	// service "http" "<name>" with its required listen and meta-arguments
+	httpSvc := schemaPath(t, schema, "service", "http", "*")
+	require.Contains(t, httpSvc["required"], "listen")
+	require.Contains(t, schemaPath(t, httpSvc, "listen"), "oneOf")
+	require.Equal(t, "integer", schemaPath(t, httpSvc, "count")["type"])
+
+	// handle "<name>" with route, timing, errors and rate limits
+	handle := schemaPath(t, httpSvc, "handle", "*")
+	require.Contains(t, handle["required"], "route")
+	timing := schemaPath(t, handle, "timing")
+	for _, attr := range []string{"p50", "p90", "p99", "variance", "ttfb", "body_time"} {
+		schemaPath(t, timing, attr)
+	}
+	require.Equal(t, "number", schemaPath(t, handle, "error", "*", "rate")["type"])
+	require.Equal(t, "boolean", schemaPath(t, handle, "rate_limit", "per_ip")["type"])
+
+	// Shared and type-specific blocks
+	schemaPath(t, httpSvc, "tls", "cert")
+	schemaPath(t, schema, "service", "tcp", "*", "socket", "keep_alive")
+	require.Equal(t, "string", schemaPath(t, schema, "service", "tcp", "*", "listen")["type"])
+	schemaPath(t, schema, "service", "postgres", "*", "table", "*")
+	schemaPath(t, schema, "logging", "level")
+}
@@ -1,6 +1,9 @@
 package postgres
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/zclconf/go-cty/cty"
@@ -21,13 +24,17 @@ type Service struct {
 	Logging *config.LoggingConfig `hcl:"logging,block"`
 
 	// Postgres-specific fields
-	Auth     *config.AuthConfig    `hcl:"auth,block"`
-	Tables   []*config.TableConfig `hcl:"table,block"`
-	Queries  []*config.QueryConfig `hcl:"query,block"`
-	Handlers []*Handler            `hcl:"handle,block"`
+	Auth           *config.AuthConfig       `hcl:"auth,block"`
+	MaxConnections int                      `hcl:"max_connections,optional"` // 0 means unlimited
+	Socket         *config.SocketConfig     `hcl:"socket,block"`
+	QueryErrors    *config.QueryErrorConfig `hcl:"errors,block"`
+	Tables         []*config.TableConfig    `hcl:"table,block"`
+	Queries        []*config.QueryConfig    `hcl:"query,block"`
+	Handlers       []*Handler               `hcl:"handle,block"`
 
 	// State set by parser (not from HCL)
-	Vars      map[string]cty.Value
+	Vars      map[string]cty.Value // service.* references
+	Variables map[string]cty.Value // var.* values (global merged with per-service)
 	Upstreams []string
 }
 
@@ -42,16 +49,47 @@ func (c *Service) SetName(n string)                       { c.Name = n }
 func (c *Service) ServiceName() string                    { return c.Name }
 func (c *Service) ServiceType() string                    { return "postgres" }
 func (c *Service) ServiceListen() string                  { return c.Listen }
+func (c *Service) SetListen(l string)                     { c.Listen = l }
 func (c *Service) ServiceTLS() *config.TLSConfig          { return c.TLS }
 func (c *Service) ServiceLogging() *config.LoggingConfig  { return c.Logging }
 func (c *Service) SetServiceVars(v map[string]cty.Value)  { c.Vars = v }
+func (c *Service) SetVariables(v map[string]cty.Value)    { c.Variables = v }
 func (c *Service) SetInferredUpstreams(u []string)        { c.Upstreams = u }
 func (c *Service) GetServiceVars() map[string]cty.Value   { return c.Vars }
+func (c *Service) GetVariables() map[string]cty.Value     { return c.Variables }
 func (c *Service) GetInferredUpstreams() []string         { return c.Upstreams }
 func (c *Service) GetResources() []*config.ResourceConfig { return nil }
 
 func (c *Service) Validate() error {
-	return config.ValidateBase(c)
+	errs := []error{config.ValidateBase(c)}
+	if err := c.Timing.Validate(false); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	for _, e := range c.Errors {
+		if err := e.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+		}
+	}
+	if err := c.Socket.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	if c.MaxConnections < 0 {
+		errs = append(errs, fmt.Errorf("service %q: max_connections must not be negative", c.Name))
+	}
+	if c.QueryErrors != nil && (c.QueryErrors.Rate < 0 || c.QueryErrors.Rate > 1) {
+		errs = append(errs, fmt.Errorf("service %q: errors rate must be between 0 and 1", c.Name))
+	}
+	for _, tbl := range c.Tables {
+		if err := config.ValidateRowRange(tbl.RowsMin, tbl.RowsMax); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: table %q: %w", c.Name, tbl.Name, err))
+		}
+		for _, col := range tbl.Columns {
+			if err := col.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: table %q: column %q: %w", c.Name, tbl.Name, col.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (c *Service) Expressions() []hcl.Expression {
@@ -61,9 +99,7 @@ func (c *Service) Expressions() []hcl.Expression {
 			exprs = append(exprs, h.Response.BodyExpr, h.Response.HeadersExpr)
 		}
 		for _, s := range h.Steps {
-			if s.HTTP != nil {
-				exprs = append(exprs, s.HTTP.URLExpr, s.HTTP.BodyExpr, s.HTTP.HeadersExpr)
-			}
+			exprs = append(exprs, s.Expressions()...)
 		}
 	}
 	return exprs
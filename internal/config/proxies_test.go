@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"})
+	require.NoError(t, err)
+	require.Len(t, prefixes, 3)
+	require.Equal(t, "192.168.1.7/32", prefixes[1].String())
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.ErrorContains(t, err, `invalid trusted proxy "10.0.0.0/33"`)
+
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	require.ErrorContains(t, err, `invalid trusted proxy "proxy.local"`)
+}
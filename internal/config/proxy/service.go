@@ -1,7 +1,9 @@
 package proxy
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -10,7 +12,10 @@ import (
 	"github.com/jumppad-labs/polymorph/internal/config"
 )
 
-var _ config.Service = (*Service)(nil)
+var (
+	_ config.Service       = (*Service)(nil)
+	_ config.MultiListener = (*Service)(nil)
+)
 
 // Service is the per-type configuration for reverse proxy services.
 type Service struct {
@@ -28,10 +33,37 @@ type Service struct {
 	ResponseHeaders hcl.Expression     `hcl:"response_headers,optional"`
 	CORS            *config.CORSConfig `hcl:"cors,block"`
 	Handlers        []*Handler         `hcl:"handle,block"`
+	// LogBodies logs forwarded requests and upstream responses at debug level
+	LogBodies bool `hcl:"log_bodies,optional"`
+	// PreserveHost forwards the client's Host header instead of the target's
+	PreserveHost bool `hcl:"preserve_host,optional"`
+	// HostHeaderExpr overrides the Host header sent upstream
+	HostHeaderExpr hcl.Expression `hcl:"host_header,optional"`
+	PathRewrite    *PathRewrite   `hcl:"path_rewrite,block"`
+	Mirror         *Mirror        `hcl:"mirror,block"`
+	// HealthPath serves the proxy's own readiness instead of forwarding
+	HealthPath string `hcl:"health_path,optional"`
 
 	// State set by parser (not from HCL)
-	Vars      map[string]cty.Value
-	Upstreams []string
+	Vars        map[string]cty.Value // service.* references
+	Variables   map[string]cty.Value // var.* values (global merged with per-service)
+	Upstreams   []string
+	ExtraListen []string // addresses after the first in a listen list
+}
+
+// PathRewrite maps request paths onto the target's. StripPrefix is removed
+// first, then AddPrefix is prepended.
+type PathRewrite struct {
+	StripPrefix string `hcl:"strip_prefix,optional"`
+	AddPrefix   string `hcl:"add_prefix,optional"`
+}
+
+// Mirror copies a sample of proxied requests to a second target in the
+// background. Its responses are discarded.
+type Mirror struct {
+	TargetExpr hcl.Expression `hcl:"target"`
+	// SampleRate is the fraction of requests mirrored, 1.0 when unset
+	SampleRate *float64 `hcl:"sample_rate,optional"`
 }
 
 // Handler is a proxy request handler with route-based matching.
@@ -46,36 +78,72 @@ func (c *Service) SetName(n string)                       { c.Name = n }
 func (c *Service) ServiceName() string                    { return c.Name }
 func (c *Service) ServiceType() string                    { return "proxy" }
 func (c *Service) ServiceListen() string                  { return c.Listen }
+func (c *Service) SetListen(l string)                     { c.Listen = l }
 func (c *Service) ServiceTLS() *config.TLSConfig          { return c.TLS }
 func (c *Service) ServiceLogging() *config.LoggingConfig  { return c.Logging }
 func (c *Service) SetServiceVars(v map[string]cty.Value)  { c.Vars = v }
+func (c *Service) SetVariables(v map[string]cty.Value)    { c.Variables = v }
 func (c *Service) SetInferredUpstreams(u []string)        { c.Upstreams = u }
 func (c *Service) GetServiceVars() map[string]cty.Value   { return c.Vars }
+func (c *Service) GetVariables() map[string]cty.Value     { return c.Variables }
 func (c *Service) GetInferredUpstreams() []string         { return c.Upstreams }
 func (c *Service) GetResources() []*config.ResourceConfig { return nil }
+func (c *Service) SetExtraListen(l []string)              { c.ExtraListen = l }
+func (c *Service) GetExtraListen() []string               { return c.ExtraListen }
 
 func (c *Service) Validate() error {
-	if err := config.ValidateBase(c); err != nil {
-		return err
+	errs := []error{config.ValidateBase(c)}
+	if err := c.Timing.Validate(false); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	for _, e := range c.Errors {
+		if err := e.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+		}
+	}
+	if c.PreserveHost && !exprEmpty(c.HostHeaderExpr) {
+		errs = append(errs, fmt.Errorf("service %q: preserve_host and host_header cannot both be set", c.Name))
+	}
+	if rw := c.PathRewrite; rw != nil {
+		if rw.StripPrefix != "" && !strings.HasPrefix(rw.StripPrefix, "/") {
+			errs = append(errs, fmt.Errorf("service %q: path_rewrite strip_prefix must start with /", c.Name))
+		}
+		if rw.AddPrefix != "" && !strings.HasPrefix(rw.AddPrefix, "/") {
+			errs = append(errs, fmt.Errorf("service %q: path_rewrite add_prefix must start with /", c.Name))
+		}
+	}
+	if c.Mirror != nil && c.Mirror.SampleRate != nil && (*c.Mirror.SampleRate < 0 || *c.Mirror.SampleRate > 1) {
+		errs = append(errs, fmt.Errorf("service %q: mirror sample_rate must be between 0 and 1", c.Name))
 	}
 	for _, h := range c.Handlers {
 		if h.Route == "" {
-			return fmt.Errorf("service %q: handler %q requires a route", c.Name, h.Name)
+			errs = append(errs, fmt.Errorf("service %q: handler %q requires a route", c.Name, h.Name))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
+}
+
+// exprEmpty reports whether an optional expression was left unset; gohcl
+// fills omitted attributes with a null literal
+func exprEmpty(expr hcl.Expression) bool {
+	if expr == nil {
+		return true
+	}
+	val, diags := expr.Value(nil)
+	return !diags.HasErrors() && val.IsNull()
 }
 
 func (c *Service) Expressions() []hcl.Expression {
-	exprs := []hcl.Expression{c.TargetExpr, c.RequestHeaders, c.ResponseHeaders}
+	exprs := []hcl.Expression{c.TargetExpr, c.RequestHeaders, c.ResponseHeaders, c.HostHeaderExpr}
+	if c.Mirror != nil {
+		exprs = append(exprs, c.Mirror.TargetExpr)
+	}
 	for _, h := range c.Handlers {
 		if h.Response != nil {
 			exprs = append(exprs, h.Response.BodyExpr, h.Response.HeadersExpr)
 		}
 		for _, s := range h.Steps {
-			if s.HTTP != nil {
-				exprs = append(exprs, s.HTTP.URLExpr, s.HTTP.BodyExpr, s.HTTP.HeadersExpr)
-			}
+			exprs = append(exprs, s.Expressions()...)
 		}
 	}
 	return exprs
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+var _ config.Service = (*Service)(nil)
+
+// Service is the per-type configuration for Redis (RESP) services.
+type Service struct {
+	// Shared fields
+	Name    string
+	Listen  string                `hcl:"listen"`
+	TLS     *config.TLSConfig     `hcl:"tls,block"`
+	Logging *config.LoggingConfig `hcl:"logging,block"`
+
+	// State set by parser (not from HCL)
+	Vars      map[string]cty.Value // service.* references
+	Variables map[string]cty.Value // var.* values (global merged with per-service)
+	Upstreams []string
+}
+
+func (c *Service) SetName(n string)                       { c.Name = n }
+func (c *Service) ServiceName() string                    { return c.Name }
+func (c *Service) ServiceType() string                    { return "redis" }
+func (c *Service) ServiceListen() string                  { return c.Listen }
+func (c *Service) SetListen(l string)                     { c.Listen = l }
+func (c *Service) ServiceTLS() *config.TLSConfig          { return c.TLS }
+func (c *Service) ServiceLogging() *config.LoggingConfig  { return c.Logging }
+func (c *Service) SetServiceVars(v map[string]cty.Value)  { c.Vars = v }
+func (c *Service) SetVariables(v map[string]cty.Value)    { c.Variables = v }
+func (c *Service) SetInferredUpstreams(u []string)        { c.Upstreams = u }
+func (c *Service) GetServiceVars() map[string]cty.Value   { return c.Vars }
+func (c *Service) GetVariables() map[string]cty.Value     { return c.Variables }
+func (c *Service) GetInferredUpstreams() []string         { return c.Upstreams }
+func (c *Service) GetResources() []*config.ResourceConfig { return nil }
+func (c *Service) GetHandlers() []config.HandlerConfig    { return nil }
+func (c *Service) Expressions() []hcl.Expression          { return nil }
+
+func (c *Service) Validate() error {
+	return config.ValidateBase(c)
+}
+
+// Decode decodes an HCL block body into a Redis Config.
+func Decode(body hcl.Body, ctx *hcl.EvalContext) (config.Service, error) {
+	var cfg Service
+	diags := gohcl.DecodeBody(body, ctx, &cfg)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return &cfg, nil
+}
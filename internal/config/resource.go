@@ -1,14 +1,86 @@
 package config
 
-import "github.com/hashicorp/hcl/v2"
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+)
 
 // ResourceConfig defines a resource that auto-generates REST endpoints
 type ResourceConfig struct {
-	Name   string         `hcl:"name,label"`
-	Rows   int            `hcl:"rows,optional"`
-	Seed   *int64         `hcl:"seed,optional"`
-	Fields []*FieldConfig `hcl:"field,block"`
-	Body   hcl.Body       `hcl:",remain"`
+	Name    string         `hcl:"name,label"`
+	Rows    int            `hcl:"rows,optional"`
+	RowsMin *int           `hcl:"rows_min,optional"`
+	RowsMax *int           `hcl:"rows_max,optional"`
+	Seed    *int64         `hcl:"seed,optional"`
+	// SoftDelete stamps deleted_at on delete instead of removing the row
+	SoftDelete bool           `hcl:"soft_delete,optional"`
+	// Versioned maintains a version field checked by If-Match on updates
+	Versioned bool           `hcl:"versioned,optional"`
+	// Timestamps maintains created_at and updated_at fields
+	Timestamps bool           `hcl:"timestamps,optional"`
+	// StrictFields rejects unknown names in a ?fields projection
+	StrictFields bool           `hcl:"strict_fields,optional"`
+	// Stream writes list responses an item at a time with chunked encoding
+	Stream     bool           `hcl:"stream,optional"`
+	// IdempotencyTTL is how long a create's Idempotency-Key is remembered
+	IdempotencyTTL string       `hcl:"idempotency_ttl,optional"`
+	// IDStrategy generates the primary key of items created without one:
+	// uuid, ulid or sequence
+	IDStrategy string         `hcl:"id_strategy,optional"`
+	Fields     []*FieldConfig `hcl:"field,block"`
+	Relations  []*RelationConfig `hcl:"relation,block"`
+	Body       hcl.Body       `hcl:",remain"`
+}
+
+// Validate checks the resource configuration
+func (r *ResourceConfig) Validate() error {
+	switch r.IDStrategy {
+	case "", "uuid", "ulid", "sequence":
+	default:
+		return fmt.Errorf("invalid id_strategy %q (must be uuid, ulid or sequence)", r.IDStrategy)
+	}
+	if _, err := r.IdempotencyKeyTTL(); err != nil {
+		return err
+	}
+	for _, f := range r.Fields {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("field %q: %w", f.Name, err)
+		}
+	}
+	return ValidateRowRange(r.RowsMin, r.RowsMax)
+}
+
+// IdempotencyKeyTTL parses idempotency_ttl, returning zero when it is unset
+func (r *ResourceConfig) IdempotencyKeyTTL() (time.Duration, error) {
+	if r.IdempotencyTTL == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(r.IdempotencyTTL)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid idempotency_ttl %q: must be a positive duration such as 10m", r.IdempotencyTTL)
+	}
+	return d, nil
+}
+
+// ValidateRowRange checks an optional rows_min/rows_max pair. When set,
+// the range overrides a fixed rows count.
+func ValidateRowRange(min, max *int) error {
+	if min == nil && max == nil {
+		return nil
+	}
+	if min == nil || max == nil {
+		return fmt.Errorf("rows_min and rows_max must be set together")
+	}
+	if *min < 0 {
+		return fmt.Errorf("rows_min must be non-negative")
+	}
+	if *min > *max {
+		return fmt.Errorf("rows_min (%d) must be less than or equal to rows_max (%d)", *min, *max)
+	}
+	return nil
 }
 
 // FieldConfig defines a field in a resource
@@ -19,5 +91,106 @@ type FieldConfig struct {
 	Min    *float64          `hcl:"min,optional"`
 	Max    *float64          `hcl:"max,optional"`
 	Values []string          `hcl:"values,optional"`
+	// Depth and Breadth shape the objects a json field generates
+	Depth   *int             `hcl:"depth,optional"`
+	Breadth *int             `hcl:"breadth,optional"`
+	// Currency and Formatted shape the amounts a money field generates
+	Currency  string         `hcl:"currency,optional"`
+	Formatted bool           `hcl:"formatted,optional"`
+	// Start and Step set the values a sequence field counts through
+	Start *int               `hcl:"start,optional"`
+	Step  *int               `hcl:"step,optional"`
+	// Pattern is the regular expression a regex field's values match
+	Pattern string           `hcl:"pattern,optional"`
+	// Resource names the resource a ref field draws its ids from
+	Resource string          `hcl:"resource,optional"`
+	// Deterministic picks ref ids by hashing the row index with the seed
+	// rather than at random
+	Deterministic bool       `hcl:"deterministic,optional"`
 	Body   hcl.Body          `hcl:",remain"`
 }
+
+// RelationConfig declares a one-to-many relationship that reads can embed
+// with ?expand=<name>. Rows of Resource whose Field equals the item's
+// primary key belong to the item.
+type RelationConfig struct {
+	Name     string `hcl:"name,label"`
+	Resource string `hcl:"resource"`
+	Field    string `hcl:"field"`
+}
+
+// ValidateRelations checks that every relation points at a field of a
+// resource in the same service
+func ValidateRelations(resources []*ResourceConfig) error {
+	byName := make(map[string]*ResourceConfig, len(resources))
+	for _, res := range resources {
+		byName[res.Name] = res
+	}
+
+	for _, res := range resources {
+		for _, rel := range res.Relations {
+			target, ok := byName[rel.Resource]
+			if !ok {
+				return fmt.Errorf("resource %q relation %q references unknown resource %q", res.Name, rel.Name, rel.Resource)
+			}
+			if !slices.ContainsFunc(target.Fields, func(f *FieldConfig) bool { return f.Name == rel.Field }) {
+				return fmt.Errorf("resource %q relation %q references unknown field %q on resource %q", res.Name, rel.Name, rel.Field, rel.Resource)
+			}
+		}
+	}
+	return nil
+}
+
+// SortResources orders resources so that every resource referenced by a
+// ref field is generated before the resources that reference it.
+// Declaration order is preserved where there is no dependency.
+func SortResources(resources []*ResourceConfig) ([]*ResourceConfig, error) {
+	byName := make(map[string]*ResourceConfig, len(resources))
+	for _, res := range resources {
+		byName[res.Name] = res
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(resources))
+	sorted := make([]*ResourceConfig, 0, len(resources))
+
+	var visit func(res *ResourceConfig) error
+	visit = func(res *ResourceConfig) error {
+		switch state[res.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("resource %q has a circular ref dependency", res.Name)
+		}
+
+		state[res.Name] = visiting
+		for _, field := range res.Fields {
+			if field.Type != "ref" || field.Resource == "" {
+				continue
+			}
+			dep, ok := byName[field.Resource]
+			if !ok {
+				return fmt.Errorf("resource %q field %q references unknown resource %q", res.Name, field.Name, field.Resource)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[res.Name] = visited
+
+		sorted = append(sorted, res)
+		return nil
+	}
+
+	for _, res := range resources {
+		if err := visit(res); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func resourceNames(resources []*ResourceConfig) []string {
+	names := make([]string, len(resources))
+	for i, res := range resources {
+		names[i] = res.Name
+	}
+	return names
+}
+
+func TestSortResources(t *testing.T) {
+	resources := []*ResourceConfig{
+		{
+			Name: "order",
+			Fields: []*FieldConfig{
+				{Name: "id", Type: "uuid"},
+				{Name: "user_id", Type: "ref", Resource: "user"},
+				{Name: "product_id", Type: "ref", Resource: "product"},
+			},
+		},
+		{
+			Name:   "product",
+			Fields: []*FieldConfig{{Name: "id", Type: "uuid"}},
+		},
+		{
+			Name:   "user",
+			Fields: []*FieldConfig{{Name: "id", Type: "uuid"}},
+		},
+		{
+			Name:   "tag",
+			Fields: []*FieldConfig{{Name: "id", Type: "uuid"}},
+		},
+	}
+
+	sorted, err := SortResources(resources)
+	require.NoError(t, err)
+	require.Equal(t, []string{"user", "product", "order", "tag"}, resourceNames(sorted))
+}
+
+func TestSortResources_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*ResourceConfig
+		errMsg    string
+	}{
+		{
+			name: "unknown resource",
+			resources: []*ResourceConfig{
+				{
+					Name:   "order",
+					Fields: []*FieldConfig{{Name: "user_id", Type: "ref", Resource: "user"}},
+				},
+			},
+			errMsg: `references unknown resource "user"`,
+		},
+		{
+			name: "cycle",
+			resources: []*ResourceConfig{
+				{
+					Name:   "a",
+					Fields: []*FieldConfig{{Name: "b_id", Type: "ref", Resource: "b"}},
+				},
+				{
+					Name:   "b",
+					Fields: []*FieldConfig{{Name: "a_id", Type: "ref", Resource: "a"}},
+				},
+			},
+			errMsg: "circular ref dependency",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SortResources(tt.resources)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestValidateRelations(t *testing.T) {
+	user := &ResourceConfig{
+		Name:      "user",
+		Fields:    []*FieldConfig{{Name: "id", Type: "uuid"}},
+		Relations: []*RelationConfig{{Name: "orders", Resource: "order", Field: "user_id"}},
+	}
+	order := &ResourceConfig{
+		Name: "order",
+		Fields: []*FieldConfig{
+			{Name: "id", Type: "uuid"},
+			{Name: "user_id", Type: "ref", Resource: "user"},
+		},
+	}
+	require.NoError(t, ValidateRelations([]*ResourceConfig{user, order}))
+
+	err := ValidateRelations([]*ResourceConfig{user})
+	require.ErrorContains(t, err, `references unknown resource "order"`)
+
+	user.Relations[0].Field = "owner_id"
+	err = ValidateRelations([]*ResourceConfig{user, order})
+	require.ErrorContains(t, err, `references unknown field "owner_id" on resource "order"`)
+}
+
+func TestValidateRowRange(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name   string
+		min    *int
+		max    *int
+		errMsg string
+	}{
+		{name: "unset"},
+		{name: "valid", min: intPtr(1), max: intPtr(10)},
+		{name: "equal", min: intPtr(5), max: intPtr(5)},
+		{name: "min only", min: intPtr(1), errMsg: "must be set together"},
+		{name: "max only", max: intPtr(1), errMsg: "must be set together"},
+		{name: "negative", min: intPtr(-1), max: intPtr(1), errMsg: "non-negative"},
+		{name: "min greater than max", min: intPtr(10), max: intPtr(1), errMsg: "less than or equal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRowRange(tt.min, tt.max)
+			if tt.errMsg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestResourceConfig_ValidateIDStrategy(t *testing.T) {
+	for _, strategy := range []string{"", "uuid", "ulid", "sequence"} {
+		require.NoError(t, (&ResourceConfig{Name: "user", IDStrategy: strategy}).Validate(), strategy)
+	}
+	err := (&ResourceConfig{Name: "user", IDStrategy: "snowflake"}).Validate()
+	require.ErrorContains(t, err, `invalid id_strategy "snowflake"`)
+}
+
+func TestResourceConfig_ValidateIdempotencyTTL(t *testing.T) {
+	for _, ttl := range []string{"", "10m", "24h"} {
+		require.NoError(t, (&ResourceConfig{Name: "payment", IdempotencyTTL: ttl}).Validate(), ttl)
+	}
+	for _, ttl := range []string{"soon", "0s", "-1m"} {
+		err := (&ResourceConfig{Name: "payment", IdempotencyTTL: ttl}).Validate()
+		require.ErrorContains(t, err, fmt.Sprintf("invalid idempotency_ttl %q", ttl))
+	}
+}
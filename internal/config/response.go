@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Response template engines
+const (
+	EngineHCL        = "hcl"
+	EngineGoTemplate = "go-template"
+)
+
+// ValidateTemplate checks a response's template and engine. Only http
+// handler, not_found and before hook responses render templates.
+func (r *ResponseConfig) ValidateTemplate() error {
+	if r == nil {
+		return nil
+	}
+	switch r.Engine {
+	case "", EngineHCL:
+		if r.Template != "" {
+			return fmt.Errorf("response template requires engine = %q", EngineGoTemplate)
+		}
+		return nil
+	case EngineGoTemplate:
+		if r.Template == "" {
+			return fmt.Errorf("response engine %q requires a template", EngineGoTemplate)
+		}
+		if !exprEmpty(r.BodyExpr) {
+			return fmt.Errorf("response body and template cannot both be set")
+		}
+		if _, err := template.New("response").Parse(r.Template); err != nil {
+			return fmt.Errorf("invalid response template: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid response engine %q (must be %s or %s)", r.Engine, EngineHCL, EngineGoTemplate)
+	}
+}
+
+// HasBody reports whether the response sets a body or template, which
+// base64_body cannot be combined with
+func (r *ResponseConfig) HasBody() bool {
+	return r != nil && (!exprEmpty(r.BodyExpr) || r.Template != "")
+}
+
+// httpOptions are the response attributes that only http handler,
+// not_found and before hook responses support
+var httpOptions = []string{"content_type", "template", "engine", "base64_body"}
+
+// RejectHTTPOptions errors when a response that is written as configured,
+// such as an error rule's or a tcp handler's, sets content_type, template,
+// engine or base64_body, rather than silently ignoring them
+func (r *ResponseConfig) RejectHTTPOptions() error {
+	if r == nil {
+		return nil
+	}
+	var set []string
+	for i, value := range []string{r.ContentType, r.Template, r.Engine, r.Base64Body} {
+		if value != "" {
+			set = append(set, httpOptions[i])
+		}
+	}
+	if len(set) > 0 {
+		return fmt.Errorf("response %s: only supported on http handler, not_found and before hook responses", strings.Join(set, ", "))
+	}
+	return nil
+}
+
+// Validate checks an error injection rule's response, which is written
+// without templates
+func (e *ErrorConfig) Validate() error {
+	if err := e.Response.RejectHTTPOptions(); err != nil {
+		return fmt.Errorf("error %q: %w", e.Name, err)
+	}
+	return nil
+}
+
+// exprEmpty reports whether an optional expression was left unset; gohcl
+// fills omitted attributes with a null literal
+func exprEmpty(expr hcl.Expression) bool {
+	if expr == nil {
+		return true
+	}
+	val, diags := expr.Value(nil)
+	return !diags.HasErrors() && val.IsNull()
+}
+
+// setHTTPOptions returns the response attributes a block's leftover body
+// sets, for blocks such as steps that have no response to write
+func setHTTPOptions(body hcl.Body) []string {
+	if body == nil {
+		return nil
+	}
+	schema := &hcl.BodySchema{}
+	for _, name := range httpOptions {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+	content, _, _ := body.PartialContent(schema)
+	if content == nil {
+		return nil
+	}
+	var set []string
+	for _, name := range httpOptions {
+		if _, ok := content.Attributes[name]; ok {
+			set = append(set, name)
+		}
+	}
+	return set
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseMemorySize parses a human-readable memory size string (e.g., "256MB", "1GB", "512KB").
+func ParseMemorySize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	s = strings.ToUpper(s)
+
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			numStr := strings.TrimSuffix(s, m.suffix)
+			num, err := strconv.ParseFloat(strings.TrimSpace(numStr), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
+			}
+			return int64(num * float64(m.mult)), nil
+		}
+	}
+
+	// Plain number = bytes
+	num, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
+	}
+	return num, nil
+}
+
+// ParseThrottle parses a bandwidth such as "100kb/s" or "1.5MB/s" into
+// bytes per second
+func ParseThrottle(s string) (int64, error) {
+	size, ok := strings.CutSuffix(strings.ToLower(strings.TrimSpace(s)), "/s")
+	if !ok {
+		return 0, fmt.Errorf("invalid throttle %q: must be a rate such as 100kb/s", s)
+	}
+	rate, err := ParseMemorySize(size)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid throttle %q: must be a rate such as 100kb/s", s)
+	}
+	return rate, nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"1KB", 1024, false},
+		{"1MB", 1 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"512KB", 512 * 1024, false},
+		{"256MB", 256 * (1 << 20), false},
+		{"100B", 100, false},
+		{"1024", 1024, false},
+		{"", 0, false},
+		{"1kb", 1024, false},
+		{"bad", 0, true},
+		{"MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMemorySize(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseThrottle(t *testing.T) {
+	for in, want := range map[string]int64{"100kb/s": 100 << 10, "1.5MB/s": 1536 << 10, "512/s": 512} {
+		rate, err := ParseThrottle(in)
+		require.NoError(t, err)
+		require.Equal(t, want, rate, in)
+	}
+	for _, in := range []string{"100kb", "0kb/s", "fast/s"} {
+		_, err := ParseThrottle(in)
+		require.ErrorContains(t, err, "invalid throttle", in)
+	}
+}
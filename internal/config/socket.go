@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// KeepAliveInterval parses keep_alive, returning nil when it is unset. Zero
+// disables keep-alive.
+func (s *SocketConfig) KeepAliveInterval() (*time.Duration, error) {
+	if s == nil || s.KeepAlive == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(s.KeepAlive)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("socket: invalid keep_alive %q: must be a duration such as 30s", s.KeepAlive)
+	}
+	return &d, nil
+}
+
+// Validate checks a socket block ahead of startup. A nil block is valid.
+func (s *SocketConfig) Validate() error {
+	_, err := s.KeepAliveInterval()
+	return err
+}
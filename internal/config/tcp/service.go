@@ -1,6 +1,9 @@
 package tcp
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/zclconf/go-cty/cty"
@@ -21,10 +24,22 @@ type Service struct {
 	Logging *config.LoggingConfig `hcl:"logging,block"`
 
 	// TCP-specific fields
-	Handlers []*Handler `hcl:"handle,block"`
+	// Framing is "line" (default) or "length_prefixed" (4-byte big-endian
+	// length header before each message)
+	Framing string `hcl:"framing,optional"`
+	// ReadTimeout closes a connection that sends nothing for this long
+	ReadTimeout string `hcl:"read_timeout,optional"`
+	// MaxMessageSize closes a connection that sends a longer message
+	MaxMessageSize string `hcl:"max_message_size,optional"`
+	// LimitResponse is written before closing a connection for exceeding
+	// either limit
+	LimitResponse string               `hcl:"limit_response,optional"`
+	Socket        *config.SocketConfig `hcl:"socket,block"`
+	Handlers      []*Handler           `hcl:"handle,block"`
 
 	// State set by parser (not from HCL)
-	Vars      map[string]cty.Value
+	Vars      map[string]cty.Value // service.* references
+	Variables map[string]cty.Value // var.* values (global merged with per-service)
 	Upstreams []string
 }
 
@@ -40,16 +55,36 @@ func (c *Service) SetName(n string)                       { c.Name = n }
 func (c *Service) ServiceName() string                    { return c.Name }
 func (c *Service) ServiceType() string                    { return "tcp" }
 func (c *Service) ServiceListen() string                  { return c.Listen }
+func (c *Service) SetListen(l string)                     { c.Listen = l }
 func (c *Service) ServiceTLS() *config.TLSConfig          { return c.TLS }
 func (c *Service) ServiceLogging() *config.LoggingConfig  { return c.Logging }
 func (c *Service) SetServiceVars(v map[string]cty.Value)  { c.Vars = v }
+func (c *Service) SetVariables(v map[string]cty.Value)    { c.Variables = v }
 func (c *Service) SetInferredUpstreams(u []string)        { c.Upstreams = u }
 func (c *Service) GetServiceVars() map[string]cty.Value   { return c.Vars }
+func (c *Service) GetVariables() map[string]cty.Value     { return c.Variables }
 func (c *Service) GetInferredUpstreams() []string         { return c.Upstreams }
 func (c *Service) GetResources() []*config.ResourceConfig { return nil }
 
 func (c *Service) Validate() error {
-	return config.ValidateBase(c)
+	errs := []error{config.ValidateBase(c)}
+	if err := c.Timing.Validate(false); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	for _, e := range c.Errors {
+		if err := e.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+		}
+	}
+	if err := c.Socket.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+	}
+	switch c.Framing {
+	case "", "line", "length_prefixed":
+	default:
+		errs = append(errs, fmt.Errorf("service %q: invalid framing %q (must be line or length_prefixed)", c.Name, c.Framing))
+	}
+	return errors.Join(errs...)
 }
 
 func (c *Service) Expressions() []hcl.Expression {
@@ -59,9 +94,7 @@ func (c *Service) Expressions() []hcl.Expression {
 			exprs = append(exprs, h.Response.BodyExpr, h.Response.HeadersExpr)
 		}
 		for _, s := range h.Steps {
-			if s.HTTP != nil {
-				exprs = append(exprs, s.HTTP.URLExpr, s.HTTP.BodyExpr, s.HTTP.HeadersExpr)
-			}
+			exprs = append(exprs, s.Expressions()...)
 		}
 	}
 	return exprs
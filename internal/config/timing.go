@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Validate checks a timing block ahead of startup: p50, p90 and p99 must be
+// set together, and every duration must parse. transfer reports whether the
+// service models transfers, as only http services apply ttfb and body_time.
+// A nil block is valid.
+func (t *TimingConfig) Validate(transfer bool) error {
+	if t == nil {
+		return nil
+	}
+	if t.HasPercentiles() && (t.P50 == "" || t.P90 == "" || t.P99 == "") {
+		return fmt.Errorf("timing: p50, p90 and p99 must be set together")
+	}
+	if !transfer && (t.TTFB != "" || t.BodyTime != "") {
+		return fmt.Errorf("timing: ttfb and body_time are only supported by http services")
+	}
+	for _, d := range []struct{ name, value string }{
+		{"p50", t.P50}, {"p90", t.P90}, {"p99", t.P99}, {"ttfb", t.TTFB}, {"body_time", t.BodyTime},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d.value); err != nil {
+			return fmt.Errorf("timing: invalid %s: %w", d.name, err)
+		}
+	}
+	return nil
+}
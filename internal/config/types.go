@@ -2,12 +2,16 @@ package config
 
 import (
 	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // Config is the root configuration structure
 type Config struct {
 	Lattice *LatticeConfig `hcl:"lattice,block"`
 	Services []Service
+	Variables map[string]cty.Value // Global var.* values from vars blocks
+	// ServiceRanges holds where each service block is defined, for errors
+	ServiceRanges map[string]hcl.Range
 	CLI      *CLIConfig       `hcl:"cli,block"`
 	Logging  *LoggingConfig   `hcl:"logging,block"`
 	Tracing  *TracingConfig   `hcl:"tracing,block"`
@@ -60,9 +64,26 @@ type HandlerConfig struct {
 
 // StepConfig defines a step to execute before returning response
 type StepConfig struct {
-	Name string          `hcl:"name,label"`
-	HTTP *HTTPStepConfig `hcl:"http,block"`
-	Body hcl.Body        `hcl:",remain"`
+	Name string `hcl:"name,label"`
+	// WhenExpr skips the step when it evaluates to false
+	WhenExpr hcl.Expression  `hcl:"when,optional"`
+	// Parallel runs the step concurrently with adjacent parallel steps
+	Parallel bool            `hcl:"parallel,optional"`
+	HTTP     *HTTPStepConfig `hcl:"http,block"`
+	Mock     *MockStepConfig `hcl:"mock,block"`
+	Body     hcl.Body        `hcl:",remain"`
+}
+
+// Expressions returns the step's expressions, for reference scanning
+func (s *StepConfig) Expressions() []hcl.Expression {
+	exprs := []hcl.Expression{s.WhenExpr}
+	if s.HTTP != nil {
+		exprs = append(exprs, s.HTTP.URLExpr, s.HTTP.BodyExpr, s.HTTP.HeadersExpr)
+	}
+	if s.Mock != nil {
+		exprs = append(exprs, s.Mock.SetExpr)
+	}
+	return exprs
 }
 
 // HTTPStepConfig defines an HTTP step
@@ -74,23 +95,98 @@ type HTTPStepConfig struct {
 	Remain      hcl.Body       `hcl:",remain"`
 }
 
+// MockStepConfig defines a step that produces its result from an expression
+// instead of making a request
+type MockStepConfig struct {
+	SetExpr hcl.Expression `hcl:"set"`
+	Remain  hcl.Body       `hcl:",remain"`
+}
+
+// HookConfig defines steps that run around every handler in a service,
+// sharing the handler's evaluation context
+type HookConfig struct {
+	Steps    []*StepConfig       `hcl:"step,block"`
+	Response *HookResponseConfig `hcl:"response,block"`
+	Body     hcl.Body            `hcl:",remain"`
+}
+
+// Expressions returns the hook's expressions, for reference scanning
+func (h *HookConfig) Expressions() []hcl.Expression {
+	var exprs []hcl.Expression
+	for _, s := range h.Steps {
+		exprs = append(exprs, s.Expressions()...)
+	}
+	if h.Response != nil {
+		exprs = append(exprs, h.Response.WhenExpr, h.Response.HeadersExpr, h.Response.BodyExpr)
+	}
+	return exprs
+}
+
+// HookResponseConfig is a before hook's response. When its guard holds it
+// is written instead of running the handler.
+type HookResponseConfig struct {
+	WhenExpr    hcl.Expression `hcl:"when,optional"`
+	Status      int            `hcl:"status"`
+	HeadersExpr hcl.Expression `hcl:"headers,optional"`
+	BodyExpr    hcl.Expression `hcl:"body,optional"`
+	ContentType string         `hcl:"content_type,optional"`
+	Base64Body  string         `hcl:"base64_body,optional"`
+	Template    string         `hcl:"template,optional"`
+	Engine      string         `hcl:"engine,optional"`
+	Remain      hcl.Body       `hcl:",remain"`
+}
+
+// AsResponse returns the hook's response as a response block, which a
+// handler's response is written from too
+func (h *HookResponseConfig) AsResponse() *ResponseConfig {
+	status := h.Status
+	return &ResponseConfig{
+		Status:      &status,
+		HeadersExpr: h.HeadersExpr,
+		BodyExpr:    h.BodyExpr,
+		ContentType: h.ContentType,
+		Base64Body:  h.Base64Body,
+		Template:    h.Template,
+		Engine:      h.Engine,
+		Remain:      h.Remain,
+	}
+}
+
 // ResponseConfig defines a response
 type ResponseConfig struct {
 	Status      *int           `hcl:"status,optional"`
 	HeadersExpr hcl.Expression `hcl:"headers,optional"`
 	BodyExpr    hcl.Expression `hcl:"body,optional"`
+	// ContentType is sent verbatim as the Content-Type header, charset
+	// included, instead of detecting one from the body
+	ContentType string `hcl:"content_type,optional"`
+	// Base64Body is decoded and written as raw bytes, for binary payloads
+	Base64Body string `hcl:"base64_body,optional"`
+	// Template is rendered as the body when Engine is "go-template"
+	Template string         `hcl:"template,optional"`
+	Engine   string         `hcl:"engine,optional"`
 	Remain      hcl.Body       `hcl:",remain"`
 }
 
 // TimingConfig defines latency injection parameters
 type TimingConfig struct {
-	P50      string  `hcl:"p50"`
-	P90      string  `hcl:"p90"`
-	P99      string  `hcl:"p99"`
+	P50      string  `hcl:"p50,optional"`
+	P90      string  `hcl:"p90,optional"`
+	P99      string  `hcl:"p99,optional"`
 	Variance float64 `hcl:"variance,optional"`
+	// TTFB delays the response headers and BodyTime spreads the body write
+	// over a duration, modelling a slow transfer (HTTP only)
+	TTFB     string   `hcl:"ttfb,optional"`
+	BodyTime string   `hcl:"body_time,optional"`
 	Body     hcl.Body `hcl:",remain"`
 }
 
+// HasPercentiles reports whether the block sets latency percentiles, as
+// opposed to only ttfb and body_time
+func (t *TimingConfig) HasPercentiles() bool {
+	return t.P50 != "" || t.P90 != "" || t.P99 != ""
+}
+
 // ErrorConfig defines an error injection rule
 type ErrorConfig struct {
 	Name     string          `hcl:"name,label"`
@@ -104,16 +200,61 @@ type ErrorConfig struct {
 type RateLimitConfig struct {
 	RPS      float64         `hcl:"rps"`
 	Status   int             `hcl:"status,optional"`
+	PerIP    bool            `hcl:"per_ip,optional"`
 	Response *ResponseConfig `hcl:"response,block"`
 	Body     hcl.Body        `hcl:",remain"`
 }
 
+// FallbackConfig proxies requests that match no route to a target
+type FallbackConfig struct {
+	TargetExpr hcl.Expression `hcl:"target"`
+	Body       hcl.Body       `hcl:",remain"`
+}
+
+// RecordConfig proxies unmatched requests to a real API, saving responses
+// as fixture files that are replayed on later requests
+type RecordConfig struct {
+	TargetExpr hcl.Expression `hcl:"target"`
+	Dir        string         `hcl:"dir"`
+	// Mode is auto (replay if recorded, else record), record or replay
+	Mode string   `hcl:"mode,optional"`
+	Body hcl.Body `hcl:",remain"`
+}
+
+// AdminConfig enables the /admin endpoints used to control a running
+// service, such as re-seeding resource data
+type AdminConfig struct {
+	Body hcl.Body `hcl:",remain"`
+}
+
+// DebugConfig enables debugging aids for a service
+type DebugConfig struct {
+	// CaptureBodies records full request/response pairs at /debug/requests
+	CaptureBodies bool `hcl:"capture_bodies,optional"`
+	// Max is the number of pairs kept, default 50
+	Max int `hcl:"max,optional"`
+	// MaxBodySize caps each captured body, e.g. "64KB" (the default)
+	MaxBodySize string   `hcl:"max_body_size,optional"`
+	Body        hcl.Body `hcl:",remain"`
+}
+
+// CacheConfig defines response caching for a handler
+type CacheConfig struct {
+	TTL string `hcl:"ttl"`
+	// Key lists the request parts, joined by "+", that identify a cached
+	// response: path and query (default "path+query")
+	Key  string   `hcl:"key,optional"`
+	Body hcl.Body `hcl:",remain"`
+}
+
 // CORSConfig defines CORS settings for HTTP services
 type CORSConfig struct {
 	AllowedOrigins   []string `hcl:"allowed_origins"`
 	AllowedMethods   []string `hcl:"allowed_methods,optional"`
 	AllowedHeaders   []string `hcl:"allowed_headers,optional"`
 	AllowCredentials *bool    `hcl:"allow_credentials,optional"`
+	ExposedHeaders   []string `hcl:"exposed_headers,optional"`
+	MaxAge           string   `hcl:"max_age,optional"` // Preflight cache duration (e.g., "10m")
 	Body             hcl.Body `hcl:",remain"`
 }
 
@@ -127,9 +268,16 @@ type LoadConfig struct {
 
 // StaticConfig defines a static file server
 type StaticConfig struct {
-	Route string   `hcl:"route,optional"`
-	Root  string   `hcl:"root"`
-	Body  hcl.Body `hcl:",remain"`
+	Route string `hcl:"route,optional"`
+	Root  string `hcl:"root"`
+	// Index is the file served for directories, default index.html
+	Index string `hcl:"index,optional"`
+	// SPA serves the root index for paths that match no file
+	SPA          bool   `hcl:"spa,optional"`
+	CacheControl string `hcl:"cache_control,optional"`
+	// DirectoryListing lists directories without an index, default true
+	DirectoryListing *bool    `hcl:"directory_listing,optional"`
+	Body             hcl.Body `hcl:",remain"`
 }
 
 // TLSConfig defines TLS settings for services.
@@ -141,12 +289,29 @@ type TLSConfig struct {
 	Body hcl.Body `hcl:",remain"`
 }
 
+// SocketConfig sets TCP options on every accepted connection. Unset
+// options keep the Go defaults: keep-alive every 15s and Nagle disabled.
+type SocketConfig struct {
+	KeepAlive string `hcl:"keep_alive,optional"` // probe interval, "0s" disables keep-alive
+	NoDelay   *bool  `hcl:"no_delay,optional"`   // false enables Nagle's algorithm
+}
+
 // SpecConfig defines an OpenAPI spec to serve fake responses from
 type SpecConfig struct {
-	Path string   `hcl:"path"`
-	Rows *int     `hcl:"rows,optional"`
-	Seed *int64   `hcl:"seed,optional"`
-	Body hcl.Body `hcl:",remain"`
+	Path      string                `hcl:"path"`
+	Rows      *int                  `hcl:"rows,optional"`
+	Seed      *int64                `hcl:"seed,optional"`
+	Overrides []*SpecOverrideConfig `hcl:"override,block"`
+	Body      hcl.Body              `hcl:",remain"`
+}
+
+// SpecOverrideConfig replaces the service's timing and error injection for
+// the spec operation matching Route, e.g. "GET /pets/:id"
+type SpecOverrideConfig struct {
+	Route  string         `hcl:"route,label"`
+	Timing *TimingConfig  `hcl:"timing,block"`
+	Errors []*ErrorConfig `hcl:"error,block"`
+	Body   hcl.Body       `hcl:",remain"`
 }
 
 // AuthConfig defines authentication for postgres services
@@ -160,6 +325,8 @@ type AuthConfig struct {
 type TableConfig struct {
 	Name    string          `hcl:"name,label"`
 	Rows    int             `hcl:"rows,optional"`
+	RowsMin *int            `hcl:"rows_min,optional"`
+	RowsMax *int            `hcl:"rows_max,optional"`
 	Seed    *int64          `hcl:"seed,optional"`
 	Columns []*ColumnConfig `hcl:"column,block"`
 	Body    hcl.Body        `hcl:",remain"`
@@ -173,7 +340,28 @@ type ColumnConfig struct {
 	Min    *float64       `hcl:"min,optional"`
 	Max    *float64       `hcl:"max,optional"`
 	Values []string       `hcl:"values,optional"`
-	Body   hcl.Body       `hcl:",remain"`
+	// Depth and Breadth shape the objects a json column generates
+	Depth   *int     `hcl:"depth,optional"`
+	Breadth *int     `hcl:"breadth,optional"`
+	// Currency and Formatted shape the amounts a money column generates
+	Currency  string   `hcl:"currency,optional"`
+	Formatted bool     `hcl:"formatted,optional"`
+	// Start and Step set the values a sequence column counts through
+	Start *int     `hcl:"start,optional"`
+	Step  *int     `hcl:"step,optional"`
+	// Pattern is the regular expression a regex column's values match
+	Pattern string   `hcl:"pattern,optional"`
+	Body    hcl.Body `hcl:",remain"`
+}
+
+// QueryErrorConfig injects ErrorResponses into postgres queries
+type QueryErrorConfig struct {
+	Rate     float64 `hcl:"rate"`
+	SQLState string  `hcl:"sqlstate,optional"`
+	Message  string  `hcl:"message,optional"`
+	// Fatal sends the error with FATAL severity and closes the connection
+	Fatal bool     `hcl:"fatal,optional"`
+	Body  hcl.Body `hcl:",remain"`
 }
 
 // QueryConfig defines a custom query pattern for postgres services
@@ -2,6 +2,7 @@ package fake
 
 import (
 	"fmt"
+	"hash/fnv"
 
 	"github.com/brianvoe/gofakeit/v6"
 )
@@ -9,6 +10,12 @@ import (
 // Generator generates fake data based on field configurations
 type Generator struct {
 	faker *gofakeit.Faker
+	seed  int64
+
+	// sequences holds the next value of each sequence field, by field name
+	sequences map[string]int
+	// refRows counts the values generated for each deterministic ref field
+	refRows map[string]int
 }
 
 // NewGenerator creates a new fake data generator
@@ -23,11 +30,19 @@ func NewGenerator() *Generator {
 func NewSeededGenerator(seed int64) *Generator {
 	return &Generator{
 		faker: gofakeit.New(seed),
+		seed:  seed,
 	}
 }
 
 // Generate generates fake data for a single field
 func (g *Generator) Generate(field FieldConfig) (any, error) {
+	if field.Type == TypeSequence {
+		return g.nextSequence(field)
+	}
+	if deterministic, _ := field.Config["deterministic"].(bool); field.Type == TypeRef && deterministic {
+		return g.hashRef(field)
+	}
+
 	handler, ok := typeHandlers[field.Type]
 	if !ok {
 		return nil, fmt.Errorf("unsupported fake type: %s", field.Type)
@@ -36,6 +51,61 @@ func (g *Generator) Generate(field FieldConfig) (any, error) {
 	return handler(g.faker, field.Config)
 }
 
+// ValidateSequenceStep checks the step a sequence counts by
+func ValidateSequenceStep(step int) error {
+	if step == 0 {
+		return fmt.Errorf("sequence step cannot be 0")
+	}
+	return nil
+}
+
+// nextSequence returns a sequence field's next value, counting from start by
+// step across the rows this generator makes
+func (g *Generator) nextSequence(field FieldConfig) (any, error) {
+	start, err := intConfig(field.Config, "start", 1)
+	if err != nil {
+		return nil, err
+	}
+	step, err := intConfig(field.Config, "step", 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateSequenceStep(step); err != nil {
+		return nil, err
+	}
+
+	if g.sequences == nil {
+		g.sequences = make(map[string]int)
+	}
+	next, ok := g.sequences[field.Name]
+	if !ok {
+		next = start
+	}
+	g.sequences[field.Name] = next + step
+	return next, nil
+}
+
+// hashRef picks a ref id by hashing the seed, field name and row index
+// rather than drawing from the faker. A row points at the same target
+// whenever both resources are generated with the same seed, however much
+// randomness the row's other fields use.
+func (g *Generator) hashRef(field FieldConfig) (any, error) {
+	ids, _ := field.Config["ids"].([]string)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ref ids cannot be empty")
+	}
+
+	if g.refRows == nil {
+		g.refRows = make(map[string]int)
+	}
+	row := g.refRows[field.Name]
+	g.refRows[field.Name] = row + 1
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%d", g.seed, field.Name, row)
+	return ids[h.Sum64()%uint64(len(ids))], nil
+}
+
 // GenerateRow generates a complete row of fake data
 func (g *Generator) GenerateRow(fields []FieldConfig) (map[string]any, error) {
 	row := make(map[string]any)
@@ -69,7 +139,16 @@ func (g *Generator) GenerateRows(fields []FieldConfig, count int) ([]map[string]
 	return rows, nil
 }
 
-// SetSeed sets the random seed for reproducible generation
+// RowCount picks a row count in [min, max] inclusive
+func (g *Generator) RowCount(min, max int) int {
+	return g.faker.IntRange(min, max)
+}
+
+// SetSeed sets the random seed for reproducible generation. Sequences and
+// deterministic refs start over.
 func (g *Generator) SetSeed(seed int64) {
 	g.faker = gofakeit.New(seed)
+	g.seed = seed
+	g.sequences = nil
+	g.refRows = nil
 }
@@ -1,6 +1,11 @@
 package fake
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -298,6 +303,47 @@ func TestGenerateRef(t *testing.T) {
 	require.Contains(t, []string{"id1", "id2", "id3"}, id)
 }
 
+func TestGenerateDeterministicRef(t *testing.T) {
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user-%d", i)
+	}
+	ref := FieldConfig{
+		Name:   "user_id",
+		Type:   TypeRef,
+		Config: map[string]any{"ids": ids, "deterministic": true},
+	}
+
+	// Two independent generations with the same seed, the second with an
+	// extra field drawing randomness ahead of the ref
+	first, err := NewSeededGenerator(42).GenerateRows([]FieldConfig{ref}, 20)
+	require.NoError(t, err)
+	second, err := NewSeededGenerator(42).GenerateRows([]FieldConfig{{Name: "note", Type: TypeSentence}, ref}, 20)
+	require.NoError(t, err)
+
+	picked := make(map[any]bool)
+	for i := range first {
+		require.Equal(t, first[i]["user_id"], second[i]["user_id"], "row %d", i)
+		require.Contains(t, ids, first[i]["user_id"])
+		picked[first[i]["user_id"]] = true
+	}
+	require.Greater(t, len(picked), 1)
+
+	// A different seed relates the rows differently
+	other, err := NewSeededGenerator(7).GenerateRows([]FieldConfig{ref}, 20)
+	require.NoError(t, err)
+	require.NotEqual(t, first, other)
+
+	// Reseeding starts the rows over
+	gen := NewSeededGenerator(1)
+	_, err = gen.GenerateRows([]FieldConfig{ref}, 5)
+	require.NoError(t, err)
+	gen.SetSeed(42)
+	again, err := gen.GenerateRows([]FieldConfig{ref}, 20)
+	require.NoError(t, err)
+	require.Equal(t, first, again)
+}
+
 func TestGenerateRefErrors(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -335,6 +381,174 @@ func TestGenerateRefErrors(t *testing.T) {
 	}
 }
 
+func TestGenerateJSON(t *testing.T) {
+	gen := NewSeededGenerator(42)
+
+	// nesting counts how many levels of objects and arrays v has
+	var nesting func(v any) int
+	nesting = func(v any) int {
+		deepest := 0
+		switch val := v.(type) {
+		case map[string]any:
+			for _, item := range val {
+				deepest = max(deepest, nesting(item))
+			}
+		case []any:
+			for _, item := range val {
+				deepest = max(deepest, nesting(item))
+			}
+		default:
+			return 0
+		}
+		return deepest + 1
+	}
+
+	for _, depth := range []int{1, 2, 4} {
+		for i := 0; i < 50; i++ {
+			value, err := gen.Generate(FieldConfig{
+				Name:   "payload",
+				Type:   TypeJSON,
+				Config: map[string]any{"depth": float64(depth), "breadth": float64(2)},
+			})
+			require.NoError(t, err)
+
+			obj, ok := value.(map[string]any)
+			require.True(t, ok)
+			require.Len(t, obj, 2)
+			require.LessOrEqual(t, nesting(obj), depth)
+
+			data, err := json.Marshal(obj)
+			require.NoError(t, err)
+			require.True(t, json.Valid(data))
+		}
+	}
+
+	// Defaults apply without configuration
+	value, err := gen.Generate(FieldConfig{Name: "payload", Type: TypeJSON})
+	require.NoError(t, err)
+	require.Len(t, value, defaultJSONBreadth)
+
+	_, err = gen.Generate(FieldConfig{Name: "payload", Type: TypeJSON, Config: map[string]any{"depth": float64(0)}})
+	require.ErrorContains(t, err, "at least 1")
+
+	_, err = gen.Generate(FieldConfig{Name: "payload", Type: TypeJSON, Config: map[string]any{"depth": float64(MaxJSONDepth + 1)}})
+	require.ErrorContains(t, err, "json depth must be at most")
+	_, err = gen.Generate(FieldConfig{Name: "payload", Type: TypeJSON, Config: map[string]any{"breadth": float64(MaxJSONBreadth + 1)}})
+	require.ErrorContains(t, err, "json breadth must be at most")
+}
+
+func TestGenerateMoney(t *testing.T) {
+	gen := NewSeededGenerator(42)
+
+	for i := 0; i < 200; i++ {
+		value, err := gen.Generate(FieldConfig{
+			Name:   "price",
+			Type:   TypeMoney,
+			Config: map[string]any{"min": float64(1), "max": float64(20)},
+		})
+		require.NoError(t, err)
+
+		amount, ok := value.(float64)
+		require.True(t, ok)
+		require.GreaterOrEqual(t, amount, 1.0)
+		require.LessOrEqual(t, amount, 20.0)
+
+		// Two decimal places, with no float noise such as 19.999999
+		text := strconv.FormatFloat(amount, 'f', -1, 64)
+		if _, frac, ok := strings.Cut(text, "."); ok {
+			require.LessOrEqual(t, len(frac), 2, text)
+		}
+	}
+
+	// Currencies without cents are whole amounts
+	value, err := gen.Generate(FieldConfig{Name: "price", Type: TypeMoney, Config: map[string]any{"currency": "jpy"}})
+	require.NoError(t, err)
+	require.Equal(t, math.Trunc(value.(float64)), value)
+
+	// Formatted amounts carry the currency
+	value, err = gen.Generate(FieldConfig{
+		Name:   "price",
+		Type:   TypeMoney,
+		Config: map[string]any{"min": float64(5), "max": float64(5), "formatted": true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "$5.00", value)
+
+	value, err = gen.Generate(FieldConfig{
+		Name:   "price",
+		Type:   TypeMoney,
+		Config: map[string]any{"min": 12.5, "max": 12.5, "currency": "CHF", "formatted": true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "12.50 CHF", value)
+
+	_, err = gen.Generate(FieldConfig{
+		Name:   "price",
+		Type:   TypeMoney,
+		Config: map[string]any{"min": 0.001, "max": 0.009},
+	})
+	require.ErrorContains(t, err, "has no USD amounts")
+}
+
+func TestGenerateSequence(t *testing.T) {
+	gen := NewGenerator()
+
+	fields := []FieldConfig{
+		{Name: "id", Type: TypeSequence},
+		{Name: "legacy_id", Type: TypeSequence, Config: map[string]any{"start": float64(1000), "step": float64(10)}},
+	}
+	rows, err := gen.GenerateRows(fields, 5)
+	require.NoError(t, err)
+
+	// Each field counts on its own, one step per row
+	for i, row := range rows {
+		require.Equal(t, i+1, row["id"])
+		require.Equal(t, 1000+i*10, row["legacy_id"])
+	}
+
+	// Later rows carry on from where the last ones stopped
+	row, err := gen.GenerateRow(fields)
+	require.NoError(t, err)
+	require.Equal(t, 6, row["id"])
+
+	// Reseeding starts over
+	gen.SetSeed(1)
+	row, err = gen.GenerateRow(fields)
+	require.NoError(t, err)
+	require.Equal(t, 1, row["id"])
+
+	_, err = gen.Generate(FieldConfig{Name: "n", Type: TypeSequence, Config: map[string]any{"step": float64(0)}})
+	require.ErrorContains(t, err, "step cannot be 0")
+}
+
+func TestGenerateRegex(t *testing.T) {
+	gen := NewSeededGenerator(42)
+
+	for _, pattern := range []string{
+		`[A-Z]{3}-\d{4}`,
+		`(ORD|INV)-[0-9a-f]{8}`,
+		`[a-z]+@example\.(com|org)`,
+		`\+44 7\d{3} \d{6}`,
+	} {
+		re := regexp.MustCompile(`^(?:` + pattern + `)$`)
+		for i := 0; i < 50; i++ {
+			value, err := gen.Generate(FieldConfig{Name: "code", Type: TypeRegex, Config: map[string]any{"pattern": pattern}})
+			require.NoError(t, err)
+			require.Regexp(t, re, value)
+		}
+	}
+}
+
+func TestGenerateRegexErrors(t *testing.T) {
+	gen := NewGenerator()
+
+	_, err := gen.Generate(FieldConfig{Name: "code", Type: TypeRegex})
+	require.ErrorContains(t, err, "requires 'pattern'")
+
+	_, err = gen.Generate(FieldConfig{Name: "code", Type: TypeRegex, Config: map[string]any{"pattern": `[A-Z`}})
+	require.ErrorContains(t, err, "invalid regex pattern")
+}
+
 func TestGenerateRow(t *testing.T) {
 	gen := NewGenerator()
 
@@ -472,3 +686,41 @@ func TestUnsupportedType(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "unsupported fake type")
 }
+
+func TestRefRegistry(t *testing.T) {
+	refs := NewRefRegistry()
+	require.Empty(t, refs.IDs("user"))
+
+	refs.Register("user", "u1", "u2")
+	refs.Register("user", "u3")
+	require.Equal(t, []string{"u1", "u2", "u3"}, refs.IDs("user"))
+
+	// Returned slice is a copy
+	ids := refs.IDs("user")
+	ids[0] = "changed"
+	require.Equal(t, "u1", refs.IDs("user")[0])
+
+	// Ref fields can draw from the registered ids
+	gen := NewGenerator()
+	for i := 0; i < 20; i++ {
+		value, err := gen.Generate(FieldConfig{
+			Name:   "user_id",
+			Type:   TypeRef,
+			Config: map[string]any{"ids": refs.IDs("user")},
+		})
+		require.NoError(t, err)
+		require.Contains(t, []string{"u1", "u2", "u3"}, value)
+	}
+}
+
+func TestRowCount(t *testing.T) {
+	gen := NewSeededGenerator(12345)
+	first := gen.RowCount(5, 50)
+	require.GreaterOrEqual(t, first, 5)
+	require.LessOrEqual(t, first, 50)
+
+	gen2 := NewSeededGenerator(12345)
+	require.Equal(t, first, gen2.RowCount(5, 50))
+
+	require.Equal(t, 3, gen.RowCount(3, 3))
+}
@@ -0,0 +1,43 @@
+package fake
+
+import "sync"
+
+// RefRegistry holds the generated primary keys of each resource so that
+// ref fields in other resources can point at ids that actually exist
+type RefRegistry struct {
+	mu  sync.RWMutex
+	ids map[string][]string
+}
+
+// NewRefRegistry creates an empty ref registry
+func NewRefRegistry() *RefRegistry {
+	return &RefRegistry{
+		ids: make(map[string][]string),
+	}
+}
+
+// Register records ids generated for the named resource
+func (r *RefRegistry) Register(resource string, ids ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ids[resource] = append(r.ids[resource], ids...)
+}
+
+// IDs returns a copy of the ids registered for the named resource
+func (r *RefRegistry) IDs(resource string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, len(r.ids[resource]))
+	copy(ids, r.ids[resource])
+	return ids
+}
+
+// Reset forgets the ids registered for the named resource
+func (r *RefRegistry) Reset(resource string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.ids, resource)
+}
@@ -2,6 +2,10 @@ package fake
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -22,6 +26,10 @@ const (
 	TypeDateTime FakeType = "datetime"
 	TypeEnum     FakeType = "enum"
 	TypeRef      FakeType = "ref"
+	TypeJSON     FakeType = "json"
+	TypeMoney    FakeType = "money"
+	TypeSequence FakeType = "sequence"
+	TypeRegex    FakeType = "regex"
 
 	// Person
 	TypeFirstName FakeType = "firstname"
@@ -97,6 +105,13 @@ type FieldConfig struct {
 	Config map[string]any // Type-specific configuration
 }
 
+// FormattedMoney reports whether the field generates money amounts as
+// strings such as "$12.34" rather than numbers
+func (f FieldConfig) FormattedMoney() bool {
+	formatted, _ := f.Config["formatted"].(bool)
+	return f.Type == TypeMoney && formatted
+}
+
 // RangeConfig defines min/max range for numeric types
 type RangeConfig struct {
 	Min float64
@@ -222,6 +237,203 @@ func generateRef(faker *gofakeit.Faker, config map[string]any) (any, error) {
 	return idsSlice[idx], nil
 }
 
+// ValidateRegexPattern checks the pattern a regex field's values match
+func ValidateRegexPattern(pattern string) error {
+	_, err := compileRegexPattern(pattern)
+	return err
+}
+
+// compileRegexPattern compiles a regex field's pattern, anchored so it
+// matches whole values
+func compileRegexPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("regex type requires 'pattern' configuration")
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// generateRegex generates a string matching the RE2 pattern in its config
+func generateRegex(faker *gofakeit.Faker, config map[string]any) (any, error) {
+	pattern, _ := config["pattern"].(string)
+	re, err := compileRegexPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// gofakeit reports patterns it can't expand, such as unbounded
+	// repetition that runs too long, as the generated string itself
+	value := faker.Regex(pattern)
+	if !re.MatchString(value) {
+		return nil, fmt.Errorf("can't generate a value matching pattern %q", pattern)
+	}
+	return value, nil
+}
+
+// defaultCurrency is the currency of money values that don't set one
+const defaultCurrency = "USD"
+
+// currencyDecimals lists currencies whose minor unit isn't a hundredth
+var currencyDecimals = map[string]int{
+	"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3, "JOD": 3, "TND": 3,
+}
+
+// currencySymbols prefixes formatted amounts; other currencies are
+// formatted with their code, as in "12.34 CHF"
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "INR": "₹", "KRW": "₩",
+}
+
+// generateMoney generates an amount in [min, max] rounded to the minor unit
+// of its currency, two decimal places for most. With formatted set it is a
+// string such as "$12.34" rather than a number.
+func generateMoney(faker *gofakeit.Faker, config map[string]any) (any, error) {
+	currency := defaultCurrency
+	if c, ok := config["currency"].(string); ok && c != "" {
+		currency = strings.ToUpper(c)
+	}
+	decimals, ok := currencyDecimals[currency]
+	if !ok {
+		decimals = 2
+	}
+
+	min, max := 0.0, 1000.0
+	if v, ok := config["min"].(float64); ok {
+		min = v
+	}
+	if v, ok := config["max"].(float64); ok {
+		max = v
+	}
+
+	// Pick a whole number of minor units so the amount is exact and in range
+	scale := math.Pow10(decimals)
+	lo := int(math.Ceil(min*scale - 1e-6))
+	hi := int(math.Floor(max*scale + 1e-6))
+	if lo > hi {
+		return nil, fmt.Errorf("money range %v to %v has no %s amounts", min, max, currency)
+	}
+	amount := float64(faker.IntRange(lo, hi)) / scale
+
+	if formatted, _ := config["formatted"].(bool); formatted {
+		return formatMoney(amount, currency, decimals), nil
+	}
+	return amount, nil
+}
+
+// formatMoney writes an amount with its currency symbol or code
+func formatMoney(amount float64, currency string, decimals int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	number := strconv.FormatFloat(amount, 'f', decimals, 64)
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + number
+	}
+	return sign + number + " " + currency
+}
+
+// Default shape of generated json values
+const (
+	defaultJSONDepth   = 2
+	defaultJSONBreadth = 3
+)
+
+// Largest shape of generated json values. A value can hold up to
+// breadth^depth scalars, so these keep a row to at most 100,000.
+const (
+	MaxJSONDepth   = 5
+	MaxJSONBreadth = 10
+)
+
+// ValidateJSONShape checks the depth and breadth of json values
+func ValidateJSONShape(depth, breadth int) error {
+	if depth < 1 || breadth < 1 {
+		return fmt.Errorf("json depth and breadth must be at least 1")
+	}
+	if depth > MaxJSONDepth {
+		return fmt.Errorf("json depth must be at most %d", MaxJSONDepth)
+	}
+	if breadth > MaxJSONBreadth {
+		return fmt.Errorf("json breadth must be at most %d", MaxJSONBreadth)
+	}
+	return nil
+}
+
+// generateJSON generates a random JSON object. depth bounds how deeply
+// objects and arrays nest, counting the top-level object, and breadth sets
+// how many keys or items each one has.
+func generateJSON(faker *gofakeit.Faker, config map[string]any) (any, error) {
+	depth, err := intConfig(config, "depth", defaultJSONDepth)
+	if err != nil {
+		return nil, err
+	}
+	breadth, err := intConfig(config, "breadth", defaultJSONBreadth)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateJSONShape(depth, breadth); err != nil {
+		return nil, err
+	}
+	return jsonObject(faker, depth, breadth), nil
+}
+
+// jsonObject generates an object of breadth keys nesting at most depth
+// levels
+func jsonObject(faker *gofakeit.Faker, depth, breadth int) map[string]any {
+	obj := make(map[string]any, breadth)
+	for i := 0; i < breadth; i++ {
+		key := strings.ToLower(faker.Word())
+		if _, taken := obj[key]; taken {
+			key = fmt.Sprintf("%s_%d", key, i)
+		}
+		obj[key] = jsonValue(faker, depth-1, breadth)
+	}
+	return obj
+}
+
+// jsonValue generates a scalar, or while depth remains, an object or array
+func jsonValue(faker *gofakeit.Faker, depth, breadth int) any {
+	kinds := 3
+	if depth > 0 {
+		kinds = 5
+	}
+	switch faker.IntRange(0, kinds-1) {
+	case 0:
+		return faker.Word()
+	case 1:
+		return faker.IntRange(0, 1000)
+	case 2:
+		return faker.Bool()
+	case 3:
+		return jsonObject(faker, depth, breadth)
+	default:
+		items := make([]any, breadth)
+		for i := range items {
+			items[i] = jsonValue(faker, depth-1, breadth)
+		}
+		return items
+	}
+}
+
+// intConfig reads an integer setting, which HCL numbers deliver as float64
+func intConfig(config map[string]any, key string, def int) (int, error) {
+	switch v := config[key].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+}
+
 // typeHandlers maps fake types to their generator functions
 var typeHandlers = map[FakeType]func(*gofakeit.Faker, map[string]any) (any, error){
 	// Existing
@@ -235,6 +447,9 @@ var typeHandlers = map[FakeType]func(*gofakeit.Faker, map[string]any) (any, erro
 	TypeDateTime: generateDateTime,
 	TypeEnum:     generateEnum,
 	TypeRef:      generateRef,
+	TypeJSON:     generateJSON,
+	TypeMoney:    generateMoney,
+	TypeRegex:    generateRegex,
 
 	// Person
 	TypeFirstName: func(f *gofakeit.Faker, _ map[string]any) (any, error) { return f.FirstName(), nil },
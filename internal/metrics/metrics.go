@@ -7,6 +7,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 var (
@@ -27,6 +28,23 @@ var (
 		[]string{"service", "handler"},
 	)
 
+	InFlightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "polymorph_in_flight_requests",
+			Help: "Number of requests currently being handled",
+		},
+		[]string{"service"},
+	)
+
+	ResponseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "polymorph_response_size_bytes",
+			Help:    "Response body size in bytes",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 6), // 100B to 10MB
+		},
+		[]string{"service", "handler"},
+	)
+
 	StepDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "polymorph_step_duration_seconds",
@@ -36,6 +54,14 @@ var (
 		[]string{"service", "handler", "step"},
 	)
 
+	PanicsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "polymorph_panics_total",
+			Help: "Total number of requests that panicked",
+		},
+		[]string{"service"},
+	)
+
 	ErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "polymorph_errors_total",
@@ -64,7 +90,7 @@ func Init(cfg Config) {
 	if !enabled {
 		return
 	}
-	prometheus.MustRegister(RequestsTotal, RequestDuration, StepDuration, ErrorsTotal)
+	prometheus.MustRegister(RequestsTotal, RequestDuration, InFlightRequests, ResponseSize, StepDuration, ErrorsTotal, PanicsTotal)
 }
 
 // IsEnabled returns whether metrics collection is active.
@@ -83,6 +109,28 @@ func RecordRequest(serviceName, handler string, status int, duration time.Durati
 	RequestDuration.WithLabelValues(serviceName, handler).Observe(duration.Seconds())
 }
 
+// TrackInFlight counts a request as in flight until the returned function
+// is called.
+func TrackInFlight(serviceName string) func() {
+	gauge := InFlightRequests.WithLabelValues(serviceName)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// InFlight returns the number of requests the service is handling now
+func InFlight(serviceName string) int {
+	var m dto.Metric
+	if err := InFlightRequests.WithLabelValues(serviceName).Write(&m); err != nil {
+		return 0
+	}
+	return int(m.GetGauge().GetValue())
+}
+
+// RecordResponseSize records the size of a response body.
+func RecordResponseSize(serviceName, handler string, size int) {
+	ResponseSize.WithLabelValues(serviceName, handler).Observe(float64(size))
+}
+
 // RecordStep records metrics for a completed step execution.
 func RecordStep(serviceName, handler, stepName string, duration time.Duration) {
 	StepDuration.WithLabelValues(serviceName, handler, stepName).Observe(duration.Seconds())
@@ -93,6 +141,11 @@ func RecordError(serviceName, handler, errorType string) {
 	ErrorsTotal.WithLabelValues(serviceName, handler, errorType).Inc()
 }
 
+// RecordPanic records a request that panicked.
+func RecordPanic(serviceName string) {
+	PanicsTotal.WithLabelValues(serviceName).Inc()
+}
+
 // Handler returns the Prometheus metrics HTTP handler.
 func Handler() http.Handler {
 	return promhttp.Handler()
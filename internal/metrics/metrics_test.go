@@ -54,6 +54,48 @@ func TestRecordStep(t *testing.T) {
 	require.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
 }
 
+func TestTrackInFlight(t *testing.T) {
+	InFlightRequests.Reset()
+
+	gauge := func() float64 {
+		m := &dto.Metric{}
+		require.NoError(t, InFlightRequests.WithLabelValues("api").Write(m))
+		return m.GetGauge().GetValue()
+	}
+
+	done1 := TrackInFlight("api")
+	done2 := TrackInFlight("api")
+	require.Equal(t, 2.0, gauge())
+
+	done1()
+	require.Equal(t, 1.0, gauge())
+
+	// A panicking handler still releases its slot through defer
+	require.Panics(t, func() {
+		defer TrackInFlight("api")()
+		panic("boom")
+	})
+	require.Equal(t, 1.0, gauge())
+
+	done2()
+	require.Equal(t, 0.0, gauge())
+}
+
+func TestRecordResponseSize(t *testing.T) {
+	ResponseSize.Reset()
+
+	RecordResponseSize("api", "hello", 150)
+	RecordResponseSize("api", "hello", 50)
+
+	observer, err := ResponseSize.GetMetricWithLabelValues("api", "hello")
+	require.NoError(t, err)
+
+	m := &dto.Metric{}
+	require.NoError(t, observer.(prometheus.Metric).Write(m))
+	require.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+	require.Equal(t, 200.0, m.GetHistogram().GetSampleSum())
+}
+
 func TestRecordError(t *testing.T) {
 	ErrorsTotal.Reset()
 
@@ -68,6 +110,17 @@ func TestRecordError(t *testing.T) {
 	require.Equal(t, 2.0, m.GetCounter().GetValue())
 }
 
+func TestRecordPanic(t *testing.T) {
+	PanicsTotal.Reset()
+
+	RecordPanic("api")
+	RecordPanic("api")
+
+	m := &dto.Metric{}
+	require.NoError(t, PanicsTotal.WithLabelValues("api").Write(m))
+	require.Equal(t, 2.0, m.GetCounter().GetValue())
+}
+
 func TestHandler(t *testing.T) {
 	h := Handler()
 	require.NotNil(t, h)
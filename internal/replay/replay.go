@@ -0,0 +1,247 @@
+// Package replay sends recorded requests back to a target as synthetic
+// traffic, keeping the gaps between them.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one recorded request. It decodes both request log entries, which
+// carry a path, and captured exchanges from /debug/requests, which carry a
+// URL, headers and body.
+type Entry struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Method    string      `json:"method"`
+	Path      string      `json:"path"`
+	URL       string      `json:"url"`
+	Headers   http.Header `json:"request_headers"`
+	Body      string      `json:"request_body"`
+	// Truncated is set on captures whose body was cut at the capture size
+	// limit; they are skipped rather than replayed with a partial body
+	Truncated bool `json:"request_truncated"`
+}
+
+// Target returns the path and query the entry was sent to
+func (e Entry) Target() string {
+	if e.URL != "" {
+		return e.URL
+	}
+	return e.Path
+}
+
+// Result is the outcome of replaying one entry
+type Result struct {
+	Entry    Entry
+	Status   int
+	Duration time.Duration
+	Err      error
+	// Skipped is set when the entry was not sent, because its captured
+	// body was truncated
+	Skipped bool
+}
+
+// Load reads entries from a JSON array or from JSON lines, one entry per
+// line, and orders them by timestamp
+func Load(r io.Reader) ([]Entry, error) {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	var entries []Entry
+	if first == '[' {
+		if err := json.NewDecoder(br).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to decode logs: %w", err)
+		}
+	} else {
+		dec := json.NewDecoder(br)
+		for line := 1; ; line++ {
+			var e Entry
+			err := dec.Decode(&e)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode log entry %d: %w", line, err)
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	for i, e := range entries {
+		if e.Method == "" || e.Target() == "" {
+			return nil, fmt.Errorf("log entry %d: method and path are required", i+1)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// ParseSpeed parses a replay speed such as "2x", "0.5x" or "3"
+func ParseSpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed %q: must be a positive multiplier such as 2x", s)
+	}
+	return speed, nil
+}
+
+// DefaultConcurrency is the number of requests a replayer keeps in flight
+// unless told otherwise
+const DefaultConcurrency = 32
+
+// Replayer sends entries to a target. Each request is started at its
+// original offset from the first entry divided by Speed, without waiting
+// for earlier responses, so a slow response doesn't push later requests
+// back. At most Concurrency requests are in flight; beyond that, requests
+// wait for a slot.
+type Replayer struct {
+	Target      *url.URL
+	Speed       float64
+	Concurrency int
+	Client      *http.Client
+
+	// now and sleep are swapped out by tests to run without waiting
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewReplayer creates a replayer for the given target and speed
+func NewReplayer(target *url.URL, speed float64) *Replayer {
+	return &Replayer{
+		Target:      target,
+		Speed:       speed,
+		Concurrency: DefaultConcurrency,
+		Client:      &http.Client{Timeout: 30 * time.Second},
+		now:         time.Now,
+		sleep:       sleepContext,
+	}
+}
+
+// Run replays the entries, calling report with each result as its request
+// completes, so results can arrive out of order. report is never called
+// concurrently. Run stops early only if ctx is cancelled, after the
+// requests in flight finish; failed requests are reported and the replay
+// carries on.
+func (rp *Replayer) Run(ctx context.Context, entries []Entry, report func(Result)) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	defer wg.Wait()
+	done := func(r Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		report(r)
+	}
+
+	slots := make(chan struct{}, max(rp.Concurrency, 1))
+	start := rp.now()
+	first := entries[0].Timestamp
+	for _, e := range entries {
+		if e.Truncated {
+			done(Result{Entry: e, Skipped: true})
+			continue
+		}
+
+		offset := time.Duration(float64(e.Timestamp.Sub(first)) / rp.Speed)
+		if wait := offset - rp.now().Sub(start); wait > 0 {
+			if err := rp.sleep(ctx, wait); err != nil {
+				return err
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			done(rp.send(ctx, e))
+		}()
+	}
+	return nil
+}
+
+// send issues one entry against the target
+func (rp *Replayer) send(ctx context.Context, e Entry) Result {
+	result := Result{Entry: e}
+
+	ref, err := url.Parse(e.Target())
+	if err != nil {
+		result.Err = fmt.Errorf("invalid path %q: %w", e.Target(), err)
+		return result
+	}
+	u := *rp.Target
+	u.Path = strings.TrimSuffix(rp.Target.Path, "/") + ref.Path
+	u.RawPath = ""
+	u.RawQuery = ref.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, e.Method, u.String(), bytes.NewReader([]byte(e.Body)))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	for k, v := range e.Headers {
+		req.Header[k] = v
+	}
+
+	start := rp.now()
+	resp, err := rp.Client.Do(req)
+	result.Duration = rp.now().Sub(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	result.Status = resp.StatusCode
+	return result
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,186 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock stands in for wall time: sleeping advances it instantly
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	// Request log entries as JSON lines, out of order
+	entries, err := Load(strings.NewReader(`
+{"sequence":2,"timestamp":"2026-01-01T10:00:01Z","method":"POST","path":"/users","status":201,"duration_ms":3}
+{"sequence":1,"timestamp":"2026-01-01T10:00:00Z","method":"GET","path":"/users","status":200,"duration_ms":1}
+`))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "GET", entries[0].Method)
+	require.Equal(t, "POST", entries[1].Method)
+
+	// Captured exchanges from /debug/requests, as a JSON array
+	entries, err = Load(strings.NewReader(`[
+  {"timestamp":"2026-01-01T10:00:00Z","method":"PUT","url":"/users/1?dry=1","request_headers":{"X-Test":["yes"]},"request_body":"{}"}
+]`))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "/users/1?dry=1", entries[0].Target())
+	require.Equal(t, "yes", entries[0].Headers.Get("X-Test"))
+
+	_, err = Load(strings.NewReader(`{"timestamp":"2026-01-01T10:00:00Z","path":"/users"}`))
+	require.ErrorContains(t, err, "log entry 1: method and path are required")
+
+	_, err = Load(strings.NewReader(`{"method":`))
+	require.ErrorContains(t, err, "failed to decode log entry 1")
+}
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"2x": 2, "0.5x": 0.5, "3": 3} {
+		speed, err := ParseSpeed(in)
+		require.NoError(t, err)
+		require.Equal(t, want, speed)
+	}
+	for _, in := range []string{"0x", "-1", "fast"} {
+		_, err := ParseSpeed(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestReplayer_Run(t *testing.T) {
+	type received struct {
+		method, uri, header, body string
+	}
+	var mu sync.Mutex
+	var got []received
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, received{r.Method, r.URL.RequestURI(), r.Header.Get("X-Test"), string(body)})
+		mu.Unlock()
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer target.Close()
+
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Timestamp: base, Method: "GET", Path: "/users"},
+		{Timestamp: base.Add(2 * time.Second), Method: "POST", URL: "/users?notify=1", Headers: http.Header{"X-Test": {"yes"}}, Body: `{"name":"ada"}`},
+		{Timestamp: base.Add(2 * time.Second), Method: "GET", Path: "/users/1"},
+		{Timestamp: base.Add(5 * time.Second), Method: "DELETE", Path: "/users/1"},
+	}
+
+	u, err := url.Parse(target.URL + "/api")
+	require.NoError(t, err)
+	clock := &fakeClock{now: time.Now()}
+	rp := NewReplayer(u, 2)
+	rp.now, rp.sleep = clock.Now, clock.Sleep
+
+	var statuses []int
+	require.NoError(t, rp.Run(context.Background(), entries, func(r Result) {
+		require.NoError(t, r.Err)
+		statuses = append(statuses, r.Status)
+	}))
+
+	// Requests run concurrently, so they can arrive in any order
+	require.ElementsMatch(t, []received{
+		{"GET", "/api/users", "", ""},
+		{"POST", "/api/users?notify=1", "yes", `{"name":"ada"}`},
+		{"GET", "/api/users/1", "", ""},
+		{"DELETE", "/api/users/1", "", ""},
+	}, got)
+	require.ElementsMatch(t, []int{200, 200, 200, 204}, statuses)
+
+	// Gaps of 2s and 3s at 2x speed; simultaneous entries don't wait
+	require.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond}, clock.sleeps)
+}
+
+func TestReplayer_RunConcurrent(t *testing.T) {
+	// The first request is held until the second arrives, which only
+	// happens if the second doesn't wait for the first's response
+	second := make(chan struct{})
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-second:
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}
+		case "/fast":
+			close(second)
+		}
+	}))
+	defer target.Close()
+	u, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	var results []Result
+	rp := NewReplayer(u, 1)
+	require.NoError(t, rp.Run(context.Background(), []Entry{
+		{Timestamp: base, Method: "GET", Path: "/slow"},
+		{Timestamp: base, Method: "GET", Path: "/fast"},
+		{Timestamp: base, Method: "POST", Path: "/upload", Truncated: true},
+	}, func(r Result) { results = append(results, r) }))
+
+	// The slow request was released by the fast one, and the truncated
+	// capture is skipped
+	require.Len(t, results, 3)
+	byPath := make(map[string]Result)
+	for _, r := range results {
+		byPath[r.Entry.Path] = r
+	}
+	require.Equal(t, http.StatusOK, byPath["/slow"].Status)
+	require.Equal(t, http.StatusOK, byPath["/fast"].Status)
+	require.True(t, byPath["/upload"].Skipped)
+}
+
+func TestReplayer_Failure(t *testing.T) {
+	// Nothing listens on the target
+	target := httptest.NewServer(http.NotFoundHandler())
+	target.Close()
+	u, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	var results []Result
+	rp := NewReplayer(u, 1)
+	require.NoError(t, rp.Run(context.Background(), []Entry{
+		{Method: "GET", Path: "/a"},
+		{Method: "GET", Path: "/b"},
+	}, func(r Result) { results = append(results, r) }))
+
+	// Every entry is still attempted
+	require.Len(t, results, 2)
+	require.Error(t, results[0].Err)
+	require.Error(t, results[1].Err)
+}
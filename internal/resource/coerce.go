@@ -0,0 +1,84 @@
+package resource
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// FieldError reports a value that can't be converted to its field's type
+type FieldError struct {
+	Field string
+	Type  FieldType
+	Value any
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid value for field %s: expected %s, got %T", e.Field, e.Type, e.Value)
+}
+
+// coerce converts the values of item to the types declared by the schema,
+// in place, so user-supplied rows match generated ones. String and any
+// fields, nulls and fields outside the schema are left as they are.
+func (s *Schema) coerce(item map[string]any) error {
+	for _, field := range s.Fields {
+		value, ok := item[field.Name]
+		if !ok || value == nil {
+			continue
+		}
+		converted, ok := coerceValue(value, field.Type)
+		if !ok {
+			return &FieldError{Field: field.Name, Type: field.Type, Value: value}
+		}
+		item[field.Name] = converted
+	}
+	return nil
+}
+
+// coerceValue converts value to typ, reporting whether it could
+func coerceValue(value any, typ FieldType) (any, bool) {
+	switch typ {
+	case FieldTypeInt:
+		switch v := value.(type) {
+		case int, int64:
+			return v, true
+		case float64:
+			// Only whole values within int64's range convert exactly; NaN
+			// fails the whole check. float64(math.MaxInt64) rounds up to
+			// 2^63, which is already out of range.
+			if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+				return nil, false
+			}
+			return int64(v), true
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			return i, err == nil
+		}
+		return nil, false
+
+	case FieldTypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, true
+		case int:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			return f, err == nil
+		}
+		return nil, false
+
+	case FieldTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(v)
+			return b, err == nil
+		}
+		return nil, false
+	}
+	return value, true
+}
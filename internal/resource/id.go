@@ -0,0 +1,93 @@
+package resource
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator produces primary keys for items created without one
+type IDGenerator interface {
+	NextID() string
+}
+
+// idStrategies maps each id_strategy name to a constructor for its generator
+var idStrategies = map[string]func() IDGenerator{
+	"uuid":     func() IDGenerator { return uuidGenerator{} },
+	"ulid":     func() IDGenerator { return ulidGenerator{now: time.Now} },
+	"sequence": func() IDGenerator { return &sequenceGenerator{} },
+}
+
+// NewIDGenerator returns a generator for a named strategy: uuid for random
+// UUIDs, ulid for time-ordered ULIDs, or sequence for 1, 2, 3, ...
+func NewIDGenerator(strategy string) (IDGenerator, error) {
+	newGenerator, ok := idStrategies[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown id strategy %q (must be uuid, ulid or sequence)", strategy)
+	}
+	return newGenerator(), nil
+}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NextID() string { return uuid.NewString() }
+
+// crockford is the base32 alphabet ULIDs are written in
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator produces ULIDs: a 48-bit millisecond timestamp followed by
+// 80 random bits, so ids sort by creation time
+type ulidGenerator struct {
+	now func() time.Time
+}
+
+func (g ulidGenerator) NextID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(g.now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	n := new(big.Int).SetBytes(b[:])
+	mask := big.NewInt(31)
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(out)
+}
+
+// IDSeeder is implemented by generators whose ids count on from those
+// already in use, such as generated rows or ids set by clients
+type IDSeeder interface {
+	// SeedFrom moves the generator past id, if it is one it would produce
+	SeedFrom(id string)
+}
+
+// sequenceGenerator counts up from 1, or from the largest id it was seeded
+// with
+type sequenceGenerator struct {
+	last atomic.Int64
+}
+
+func (g *sequenceGenerator) NextID() string {
+	return strconv.FormatInt(g.last.Add(1), 10)
+}
+
+func (g *sequenceGenerator) SeedFrom(id string) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return
+	}
+	for {
+		last := g.last.Load()
+		if n <= last || g.last.CompareAndSwap(last, n) {
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package resource
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIDGenerator(t *testing.T) {
+	gen, err := NewIDGenerator("uuid")
+	require.NoError(t, err)
+	_, err = uuid.Parse(gen.NextID())
+	require.NoError(t, err)
+
+	gen, err = NewIDGenerator("sequence")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2", "3"}, []string{gen.NextID(), gen.NextID(), gen.NextID()})
+
+	// Seeding moves the sequence past larger ids and ignores the rest
+	seeder := gen.(IDSeeder)
+	seeder.SeedFrom("10")
+	seeder.SeedFrom("5")
+	seeder.SeedFrom("abc")
+	require.Equal(t, "11", gen.NextID())
+
+	_, err = NewIDGenerator("snowflake")
+	require.ErrorContains(t, err, `unknown id strategy "snowflake"`)
+}
+
+func TestULIDGenerator(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	gen := ulidGenerator{now: func() time.Time { return now }}
+
+	// The timestamp from the ULID spec's example encodes to its first ten
+	// characters
+	id := gen.NextID()
+	require.Len(t, id, 26)
+	require.Equal(t, "01ARYZ6S41", id[:10])
+	require.NotEqual(t, id, gen.NextID())
+
+	// Later ids sort after earlier ones
+	var ids []string
+	for i := range 3 {
+		ids = append(ids, ulidGenerator{now: func() time.Time { return now.Add(time.Duration(i) * time.Millisecond) }}.NextID())
+	}
+	require.True(t, sort.StringsAreSorted(ids))
+}
@@ -0,0 +1,44 @@
+package resource
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// DecodeObject decodes a JSON object for storage. Unlike encoding/json,
+// whole numbers decode to int64 rather than float64, so they keep their
+// precision and serialize back without an exponent. Other numbers decode
+// to float64.
+func DecodeObject(r io.Reader) (map[string]any, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	normalizeNumbers(obj)
+	return obj, nil
+}
+
+// normalizeNumbers replaces json.Number values nested in v with int64 or
+// float64, returning the replacement for v itself
+func normalizeNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		for k, e := range val {
+			val[k] = normalizeNumbers(e)
+		}
+	case []any:
+		for i, e := range val {
+			val[i] = normalizeNumbers(e)
+		}
+	}
+	return v
+}
@@ -10,6 +10,14 @@ import (
 type Schema struct {
 	Name   string
 	Fields []Field
+	// SoftDelete makes Delete stamp DeletedAtField instead of removing the
+	// item, and hides stamped items from reads
+	SoftDelete bool
+	// Versioned maintains VersionField on every insert and update
+	Versioned bool
+	// Timestamps stamps CreatedAtField on insert and UpdatedAtField on
+	// every insert and update
+	Timestamps bool
 }
 
 // Field defines a single field in a resource schema
@@ -89,6 +97,11 @@ func (s *Schema) ToMemDBSchema() (*memdb.TableSchema, error) {
 	}, nil
 }
 
+// deleted reports whether item has been soft-deleted
+func (s *Schema) deleted(item map[string]any) bool {
+	return s.SoftDelete && item[DeletedAtField] != nil
+}
+
 // createIndexer creates an appropriate indexer for the field type
 func (s *Schema) createIndexer(field *Field) (memdb.Indexer, error) {
 	// Use custom map indexer for all field types
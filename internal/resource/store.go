@@ -1,8 +1,12 @@
 package resource
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 )
@@ -128,6 +132,19 @@ func (s *Store) Insert(table string, item map[string]any) error {
 		return fmt.Errorf("item missing primary key field: %s", pkField.Name)
 	}
 
+	if err := schema.coerce(item); err != nil {
+		return err
+	}
+
+	if schema.Versioned {
+		item[VersionField] = int64(1)
+	}
+	if schema.Timestamps {
+		now := timestamp()
+		item[CreatedAtField] = now
+		item[UpdatedAtField] = now
+	}
+
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
@@ -139,8 +156,43 @@ func (s *Store) Insert(table string, item map[string]any) error {
 	return nil
 }
 
-// Get retrieves a single item by its ID
+// DeletedAtField holds the time an item in a soft-delete table was deleted
+const DeletedAtField = "deleted_at"
+
+// VersionField holds an item's version in a versioned table. It starts at 1
+// and increases by one on every update.
+const VersionField = "version"
+
+// CreatedAtField holds the time an item in a table with timestamps was
+// inserted
+const CreatedAtField = "created_at"
+
+// UpdatedAtField holds the time an item last changed, as an RFC 3339
+// timestamp. Reads of an item that has one support If-Modified-Since.
+const UpdatedAtField = "updated_at"
+
+// timestamp returns the current time as stored in CreatedAtField and
+// UpdatedAtField: RFC 3339 in UTC with milliseconds, fixed width so the
+// values sort as strings
+func timestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// ErrVersionConflict is returned when an update names a stale version
+var ErrVersionConflict = errors.New("version conflict")
+
+// Get retrieves a single item by its ID. Soft-deleted items are not found.
 func (s *Store) Get(table, id string) (map[string]any, error) {
+	return s.get(table, id, false)
+}
+
+// GetWithDeleted retrieves a single item by its ID, including soft-deleted
+// items
+func (s *Store) GetWithDeleted(table, id string) (map[string]any, error) {
+	return s.get(table, id, true)
+}
+
+func (s *Store) get(table, id string, includeDeleted bool) (map[string]any, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -148,7 +200,8 @@ func (s *Store) Get(table, id string) (map[string]any, error) {
 		return nil, fmt.Errorf("no tables created")
 	}
 
-	if _, exists := s.schemas[table]; !exists {
+	schema, exists := s.schemas[table]
+	if !exists {
 		return nil, fmt.Errorf("table %s does not exist", table)
 	}
 
@@ -169,11 +222,25 @@ func (s *Store) Get(table, id string) (map[string]any, error) {
 		return nil, fmt.Errorf("invalid item type")
 	}
 
+	if !includeDeleted && schema.deleted(item) {
+		return nil, fmt.Errorf("item not found")
+	}
+
 	return item, nil
 }
 
-// List retrieves all items from a table
+// List retrieves all items from a table, excluding soft-deleted items
 func (s *Store) List(table string) ([]map[string]any, error) {
+	return s.list(table, false)
+}
+
+// ListWithDeleted retrieves all items from a table, including soft-deleted
+// items
+func (s *Store) ListWithDeleted(table string) ([]map[string]any, error) {
+	return s.list(table, true)
+}
+
+func (s *Store) list(table string, includeDeleted bool) ([]map[string]any, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -181,7 +248,8 @@ func (s *Store) List(table string) ([]map[string]any, error) {
 		return nil, fmt.Errorf("no tables created")
 	}
 
-	if _, exists := s.schemas[table]; !exists {
+	schema, exists := s.schemas[table]
+	if !exists {
 		return nil, fmt.Errorf("table %s does not exist", table)
 	}
 
@@ -199,6 +267,9 @@ func (s *Store) List(table string) ([]map[string]any, error) {
 		if !ok {
 			return nil, fmt.Errorf("invalid item type")
 		}
+		if !includeDeleted && schema.deleted(item) {
+			continue
+		}
 		items = append(items, item)
 	}
 
@@ -269,6 +340,9 @@ func (s *Store) Where(table, field string, value any) ([]map[string]any, error)
 				continue
 			}
 		}
+		if schema.deleted(item) {
+			continue
+		}
 
 		items = append(items, item)
 	}
@@ -278,6 +352,17 @@ func (s *Store) Where(table, field string, value any) ([]map[string]any, error)
 
 // Update modifies an existing item
 func (s *Store) Update(table, id string, item map[string]any) error {
+	return s.update(table, id, item, false, nil)
+}
+
+// UpdateIfVersion modifies an existing item in a versioned table only if
+// its current version is one of versions, returning ErrVersionConflict
+// otherwise
+func (s *Store) UpdateIfVersion(table, id string, item map[string]any, versions ...int64) error {
+	return s.update(table, id, item, true, versions)
+}
+
+func (s *Store) update(table, id string, item map[string]any, conditional bool, versions []int64) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -306,6 +391,10 @@ func (s *Store) Update(table, id string, item map[string]any) error {
 	// Ensure item has the correct ID
 	item[pkField.Name] = id
 
+	if err := schema.coerce(item); err != nil {
+		return err
+	}
+
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
@@ -315,10 +404,22 @@ func (s *Store) Update(table, id string, item map[string]any) error {
 		return fmt.Errorf("failed to check for existing item: %w", err)
 	}
 
-	if existing == nil {
+	if existing == nil || schema.deleted(existing.(map[string]any)) {
 		return fmt.Errorf("item not found")
 	}
 
+	if schema.Versioned {
+		current, _ := existing.(map[string]any)[VersionField].(int64)
+		if conditional && !slices.Contains(versions, current) {
+			return ErrVersionConflict
+		}
+		item[VersionField] = current + 1
+	}
+	if schema.Timestamps {
+		item[CreatedAtField] = existing.(map[string]any)[CreatedAtField]
+		item[UpdatedAtField] = timestamp()
+	}
+
 	// Delete old version
 	if err := txn.Delete(table, existing); err != nil {
 		return fmt.Errorf("failed to delete old item: %w", err)
@@ -333,7 +434,8 @@ func (s *Store) Update(table, id string, item map[string]any) error {
 	return nil
 }
 
-// Delete removes an item from the table
+// Delete removes an item from the table. In a soft-delete table the item
+// is kept and stamped with DeletedAtField instead.
 func (s *Store) Delete(table, id string) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -342,7 +444,8 @@ func (s *Store) Delete(table, id string) error {
 		return fmt.Errorf("no tables created")
 	}
 
-	if _, exists := s.schemas[table]; !exists {
+	schema, exists := s.schemas[table]
+	if !exists {
 		return fmt.Errorf("table %s does not exist", table)
 	}
 
@@ -355,10 +458,21 @@ func (s *Store) Delete(table, id string) error {
 		return fmt.Errorf("failed to get item: %w", err)
 	}
 
-	if obj == nil {
+	if obj == nil || schema.deleted(obj.(map[string]any)) {
 		return fmt.Errorf("item not found")
 	}
 
+	if schema.SoftDelete {
+		// Stored items are shared with readers, so stamp a copy
+		item := maps.Clone(obj.(map[string]any))
+		item[DeletedAtField] = time.Now().UTC().Format(time.RFC3339)
+		if err := txn.Insert(table, item); err != nil {
+			return fmt.Errorf("failed to delete item: %w", err)
+		}
+		txn.Commit()
+		return nil
+	}
+
 	// Delete it
 	if err := txn.Delete(table, obj); err != nil {
 		return fmt.Errorf("failed to delete item: %w", err)
@@ -367,3 +481,27 @@ func (s *Store) Delete(table, id string) error {
 	txn.Commit()
 	return nil
 }
+
+// Truncate removes every item from the table
+func (s *Store) Truncate(table string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return fmt.Errorf("no tables created")
+	}
+
+	if _, exists := s.schemas[table]; !exists {
+		return fmt.Errorf("table %s does not exist", table)
+	}
+
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	if _, err := txn.DeleteAll(table, "id"); err != nil {
+		return fmt.Errorf("failed to truncate table: %w", err)
+	}
+
+	txn.Commit()
+	return nil
+}
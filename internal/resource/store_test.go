@@ -1,9 +1,13 @@
 package resource
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -89,6 +93,54 @@ func TestInsert(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestInsertCoercesTypes(t *testing.T) {
+	store := NewStore()
+	require.NoError(t, store.CreateTable("users", Schema{
+		Name: "users",
+		Fields: []Field{
+			{Name: "id", Type: FieldTypeString, PrimaryKey: true},
+			{Name: "age", Type: FieldTypeInt},
+			{Name: "score", Type: FieldTypeFloat},
+			{Name: "active", Type: FieldTypeBool},
+			{Name: "meta", Type: FieldTypeAny},
+		},
+	}))
+
+	require.NoError(t, store.Insert("users", map[string]any{
+		"id": "user-1", "age": "30", "score": "4.5", "active": "true", "meta": "30",
+	}))
+
+	item, err := store.Get("users", "user-1")
+	require.NoError(t, err)
+	require.Equal(t, int64(30), item["age"])
+	require.Equal(t, 4.5, item["score"])
+	require.Equal(t, true, item["active"])
+	require.Equal(t, "30", item["meta"])
+
+	// Whole floats from JSON become ints; updates are coerced too
+	require.NoError(t, store.Update("users", "user-1", map[string]any{"age": float64(31), "score": int64(5)}))
+	item, err = store.Get("users", "user-1")
+	require.NoError(t, err)
+	require.Equal(t, int64(31), item["age"])
+	require.Equal(t, float64(5), item["score"])
+
+	err = store.Insert("users", map[string]any{"id": "user-2", "age": "thirty"})
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	require.Equal(t, "age", fieldErr.Field)
+	require.Equal(t, "invalid value for field age: expected int, got string", err.Error())
+
+	// Fractional, out of range and non-finite floats are rejected
+	for _, age := range []float64{1.5, 1e19, -1e19, math.Exp2(63), math.Inf(1), math.NaN()} {
+		err = store.Update("users", "user-1", map[string]any{"age": age})
+		require.ErrorAs(t, err, &fieldErr, "%v", age)
+	}
+	require.NoError(t, store.Update("users", "user-1", map[string]any{"age": -math.Exp2(63)}))
+
+	_, err = store.Get("users", "user-2")
+	require.Error(t, err)
+}
+
 func TestInsertMissingPrimaryKey(t *testing.T) {
 	store := NewStore()
 
@@ -413,6 +465,36 @@ func TestDeleteNotFound(t *testing.T) {
 	require.Contains(t, err.Error(), "not found")
 }
 
+func TestTruncate(t *testing.T) {
+	store := NewStore()
+
+	schema := Schema{
+		Name: "users",
+		Fields: []Field{
+			{Name: "id", Type: FieldTypeString, PrimaryKey: true},
+			{Name: "name", Type: FieldTypeString},
+		},
+	}
+
+	err := store.CreateTable("users", schema)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = store.Insert("users", map[string]any{"id": fmt.Sprintf("user-%d", i), "name": "Alice"})
+		require.NoError(t, err)
+	}
+
+	err = store.Truncate("users")
+	require.NoError(t, err)
+
+	items, err := store.List("users")
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	err = store.Truncate("orders")
+	require.Error(t, err)
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	store := NewStore()
 
@@ -553,3 +635,122 @@ func TestMultipleTables(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, userOrders, 1)
 }
+
+func TestDecodeObject(t *testing.T) {
+	obj, err := DecodeObject(strings.NewReader(`{"count":42,"big":9007199254740993,"ratio":1.5,"nested":{"n":7},"list":[1,2.5]}`))
+	require.NoError(t, err)
+
+	require.Equal(t, int64(42), obj["count"])
+	require.Equal(t, int64(9007199254740993), obj["big"])
+	require.Equal(t, 1.5, obj["ratio"])
+	require.Equal(t, map[string]any{"n": int64(7)}, obj["nested"])
+	require.Equal(t, []any{int64(1), 2.5}, obj["list"])
+
+	out, err := json.Marshal(obj)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"count":42,"big":9007199254740993,"ratio":1.5,"nested":{"n":7},"list":[1,2.5]}`, string(out))
+	require.Contains(t, string(out), `"big":9007199254740993`)
+
+	_, err = DecodeObject(strings.NewReader(`[1]`))
+	require.Error(t, err)
+}
+
+func TestSoftDelete(t *testing.T) {
+	store := NewStore()
+	require.NoError(t, store.CreateTable("users", Schema{
+		Name: "users",
+		Fields: []Field{
+			{Name: "id", Type: FieldTypeString, PrimaryKey: true},
+			{Name: "name", Type: FieldTypeString},
+		},
+		SoftDelete: true,
+	}))
+	require.NoError(t, store.Insert("users", map[string]any{"id": "1", "name": "Alice"}))
+	require.NoError(t, store.Insert("users", map[string]any{"id": "2", "name": "Bob"}))
+
+	require.NoError(t, store.Delete("users", "1"))
+
+	items, err := store.List("users")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "2", items[0]["id"])
+
+	_, err = store.Get("users", "1")
+	require.ErrorContains(t, err, "not found")
+
+	items, err = store.ListWithDeleted("users")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	item, err := store.GetWithDeleted("users", "1")
+	require.NoError(t, err)
+	require.Equal(t, "Alice", item["name"])
+	require.NotEmpty(t, item[DeletedAtField])
+
+	// A soft-deleted item can't be deleted or updated again
+	require.ErrorContains(t, store.Delete("users", "1"), "not found")
+	require.ErrorContains(t, store.Update("users", "1", map[string]any{"name": "Alicia"}), "not found")
+}
+
+func TestVersionedUpdates(t *testing.T) {
+	store := NewStore()
+	require.NoError(t, store.CreateTable("users", Schema{
+		Name: "users",
+		Fields: []Field{
+			{Name: "id", Type: FieldTypeString, PrimaryKey: true},
+			{Name: "name", Type: FieldTypeString},
+		},
+		Versioned: true,
+	}))
+	require.NoError(t, store.Insert("users", map[string]any{"id": "1", "name": "Alice"}))
+
+	item, err := store.Get("users", "1")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), item[VersionField])
+
+	require.NoError(t, store.UpdateIfVersion("users", "1", map[string]any{"name": "Alicia"}, 1))
+	item, err = store.Get("users", "1")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), item[VersionField])
+	require.Equal(t, "Alicia", item["name"])
+
+	err = store.UpdateIfVersion("users", "1", map[string]any{"name": "Al"}, 1)
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	// Unconditional updates still bump the version
+	require.NoError(t, store.Update("users", "1", map[string]any{"name": "Al"}))
+	item, err = store.Get("users", "1")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), item[VersionField])
+}
+
+func TestTimestamps(t *testing.T) {
+	store := NewStore()
+	require.NoError(t, store.CreateTable("users", Schema{
+		Name: "users",
+		Fields: []Field{
+			{Name: "id", Type: FieldTypeString, PrimaryKey: true},
+			{Name: "name", Type: FieldTypeString},
+		},
+		Timestamps: true,
+	}))
+
+	// Client-supplied timestamps are replaced
+	require.NoError(t, store.Insert("users", map[string]any{"id": "1", "name": "Alice", "created_at": "2000-01-01T00:00:00Z"}))
+	item, err := store.Get("users", "1")
+	require.NoError(t, err)
+	created := item[CreatedAtField].(string)
+	createdAt, err := time.Parse(time.RFC3339, created)
+	require.NoError(t, err)
+	require.NotEqual(t, "2000-01-01T00:00:00Z", created)
+	require.Equal(t, created, item[UpdatedAtField])
+
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, store.Update("users", "1", map[string]any{"name": "Alicia", "created_at": "2000-01-01T00:00:00Z"}))
+	item, err = store.Get("users", "1")
+	require.NoError(t, err)
+	require.Equal(t, created, item[CreatedAtField])
+	updatedAt, err := time.Parse(time.RFC3339, item[UpdatedAtField].(string))
+	require.NoError(t, err)
+	require.True(t, updatedAt.After(createdAt))
+}
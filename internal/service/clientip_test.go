@@ -0,0 +1,93 @@
+package service
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := config.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		trusted bool
+		want    string
+	}{
+		{
+			name:   "no proxy",
+			remote: "203.0.113.5:4000",
+			want:   "203.0.113.5",
+		},
+		{
+			name:    "untrusted peer is not believed",
+			remote:  "203.0.113.5:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"},
+			trusted: true,
+			want:    "203.0.113.5",
+		},
+		{
+			name:    "no trusted proxies configured",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "trusted peer",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			trusted: true,
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "spoofed hops left of the real client are ignored",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.9"},
+			trusted: true,
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "all hops trusted",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.9"},
+			trusted: true,
+			want:    "10.1.1.1",
+		},
+		{
+			name:    "x-real-ip",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Real-IP": "198.51.100.3"},
+			trusted: true,
+			want:    "198.51.100.3",
+		},
+		{
+			name:    "malformed header falls back to peer",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "unknown"},
+			trusted: true,
+			want:    "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			if tt.trusted {
+				require.Equal(t, tt.want, ClientIP(r, trusted))
+			} else {
+				require.Equal(t, tt.want, ClientIP(r, nil))
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// casingCamel is the json_casing that converts field names to camelCase.
+// The default, snake, uses stored field names as they are.
+const casingCamel = "camel"
+
+// fieldCasing maps a resource's fields between their stored names and the
+// lowerCamelCase names clients send. Only the resource's own top-level
+// fields are renamed, by name, so the keys inside object values are left as
+// stored and every name round-trips exactly.
+type fieldCasing struct {
+	resource string            // request key holding an item
+	plural   string            // list response key holding items
+	toStored map[string]string // camelCase name to stored name
+	toCamel  map[string]string // stored name to camelCase name
+}
+
+// newFieldCasing builds the field name mapping for a resource. A field whose
+// camelCase name would collide with another field's, such as _id with id,
+// keeps its stored name.
+func newFieldCasing(res *config.ResourceConfig, plural string) *fieldCasing {
+	c := &fieldCasing{
+		resource: res.Name,
+		plural:   plural,
+		toStored: make(map[string]string, len(res.Fields)),
+		toCamel:  make(map[string]string, len(res.Fields)),
+	}
+	stored := make(map[string]bool, len(res.Fields))
+	camels := make(map[string]int, len(res.Fields))
+	for _, f := range res.Fields {
+		stored[f.Name] = true
+		camels[toCamelCase(f.Name)]++
+	}
+	for _, f := range res.Fields {
+		camel := toCamelCase(f.Name)
+		if camel != f.Name && (camels[camel] > 1 || stored[camel]) {
+			continue
+		}
+		c.toStored[camel] = f.Name
+		c.toCamel[f.Name] = camel
+	}
+	return c
+}
+
+// camelCaseHandler lets clients speak protobuf's camelCase JSON to resource
+// methods whose fields are stored snake_case. Request fields are renamed to
+// their stored names, so userId reaches the store as user_id, and fields in
+// successful responses are renamed back to camelCase.
+func camelCaseHandler(next http.Handler, casing *fieldCasing) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err == nil {
+			body = convertJSON(body, casing.request)
+		}
+		req := r.Clone(r.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+
+		rec := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+
+		out := rec.body.Bytes()
+		if rec.status == http.StatusOK {
+			convert := casing.item
+			if strings.HasPrefix(path.Base(r.URL.Path), "List") {
+				convert = casing.list
+			}
+			out = convertJSON(out, convert)
+		}
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.status)
+		w.Write(out)
+	})
+}
+
+// convertJSON renames the keys of a JSON object. Anything that isn't a JSON
+// object is returned unchanged for the handler to reject.
+func convertJSON(data []byte, convert func(map[string]any) map[string]any) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v map[string]any
+	if err := dec.Decode(&v); err != nil || v == nil {
+		return data
+	}
+	out, err := json.Marshal(convert(v))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// request renames a request's envelope keys, such as pageSize, to
+// snake_case and the fields of its item and filter to their stored names
+func (c *fieldCasing) request(req map[string]any) map[string]any {
+	renamed := make(map[string]any, len(req))
+	for k, v := range req {
+		k = toSnakeCase(k)
+		if obj, ok := v.(map[string]any); ok && (k == c.resource || k == "filter") {
+			v = renameFields(obj, c.toStored)
+		}
+		renamed[k] = v
+	}
+	return renamed
+}
+
+// item renames the fields of a single item response to camelCase
+func (c *fieldCasing) item(resp map[string]any) map[string]any {
+	return renameFields(resp, c.toCamel)
+}
+
+// list renames a list response's envelope keys, such as next_page_token,
+// and the fields of each of its items to camelCase
+func (c *fieldCasing) list(resp map[string]any) map[string]any {
+	renamed := make(map[string]any, len(resp))
+	for k, v := range resp {
+		if items, ok := v.([]any); ok && k == c.plural {
+			for i, item := range items {
+				if obj, ok := item.(map[string]any); ok {
+					items[i] = c.item(obj)
+				}
+			}
+		}
+		renamed[toCamelCase(k)] = v
+	}
+	return renamed
+}
+
+// renameFields renames an object's keys found in names, leaving the rest
+// and every value as they are
+func renameFields(obj map[string]any, names map[string]string) map[string]any {
+	renamed := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if name, ok := names[k]; ok {
+			k = name
+		}
+		renamed[k] = v
+	}
+	return renamed
+}
+
+// toSnakeCase converts a camelCase name to snake_case, keeping acronyms
+// together: userID becomes user_id. snake_case names are unchanged.
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toCamelCase converts a snake_case name to lowerCamelCase, as protobuf
+// does for JSON field names
+func toCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package connect
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	configconnect "github.com/jumppad-labs/polymorph/internal/config/connect"
+)
+
+func TestFieldCasing(t *testing.T) {
+	tests := []struct {
+		snake, camel string
+	}{
+		{"user_id", "userId"},
+		{"next_page_token", "nextPageToken"},
+		{"id", "id"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.camel, toCamelCase(tt.snake))
+		require.Equal(t, tt.snake, toSnakeCase(tt.camel))
+		require.Equal(t, tt.snake, toSnakeCase(tt.snake))
+	}
+
+	// Acronyms stay together
+	require.Equal(t, "user_id", toSnakeCase("userID"))
+	require.Equal(t, "http_status", toSnakeCase("HTTPStatus"))
+}
+
+func TestFieldCasing_RenamesSchemaFields(t *testing.T) {
+	casing := newFieldCasing(&config.ResourceConfig{
+		Name: "order",
+		Fields: []*config.FieldConfig{
+			{Name: "id"},
+			{Name: "_id"},
+			{Name: "HTTPCode"},
+			{Name: "user_id"},
+			{Name: "shipping_info"},
+		},
+	}, "orders")
+
+	stored := map[string]any{
+		"id":            "o-1",
+		"_id":           "legacy",
+		"HTTPCode":      200,
+		"user_id":       "u-1",
+		"shipping_info": map[string]any{"post_code": "N1"},
+	}
+	camel := map[string]any{
+		"id":           "o-1",
+		"_id":          "legacy",
+		"HTTPCode":     200,
+		"userId":       "u-1",
+		"shippingInfo": map[string]any{"post_code": "N1"},
+	}
+
+	// Only top-level fields are renamed, and _id keeps its name rather than
+	// colliding with id
+	require.Equal(t, camel, casing.item(stored))
+	require.Equal(t, map[string]any{"order": stored}, casing.request(map[string]any{"order": camel}))
+
+	// List envelopes and filters are converted too
+	require.Equal(t,
+		map[string]any{"orders": []any{camel}, "nextPageToken": ""},
+		casing.list(map[string]any{"orders": []any{stored}, "next_page_token": ""}),
+	)
+	require.Equal(t,
+		map[string]any{"page_size": 10, "filter": map[string]any{"user_id": "u-1"}},
+		casing.request(map[string]any{"pageSize": 10, "filter": map[string]any{"userId": "u-1"}}),
+	)
+}
+
+func TestConnectService_CamelCase(t *testing.T) {
+	svc, err := NewConnectService(&configconnect.Service{
+		Name:       "test-api",
+		Listen:     "127.0.0.1:0",
+		Package:    "api.v1",
+		JSONCasing: "camel",
+		Resources: []*config.ResourceConfig{{
+			Name: "order",
+			Rows: 1,
+			Fields: []*config.FieldConfig{
+				{Name: "id", Type: "uuid"},
+				{Name: "user_id", Type: "uuid"},
+				{Name: "total_cents", Type: "int"},
+			},
+		}},
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+	baseURL := "http://" + svc.ResolvedAddress() + "/api.v1.OrderService"
+
+	created := makeRequest(t, baseURL+"/CreateOrder", map[string]any{
+		"order": map[string]any{"id": "o-1", "userId": "u-1", "totalCents": 1250},
+	})
+	require.Equal(t, map[string]any{"id": "o-1", "userId": "u-1", "totalCents": 1250.0}, created)
+
+	// Stored under the snake_case field names
+	item, err := svc.resourceStore.Get("order", "o-1")
+	require.NoError(t, err)
+	require.Equal(t, "u-1", item["user_id"])
+	require.EqualValues(t, 1250, item["total_cents"])
+
+	// Filters and paging fields are converted too
+	list := makeRequest(t, baseURL+"/ListOrders", map[string]any{
+		"pageSize": 10,
+		"filter":   map[string]any{"userId": "u-1"},
+	})
+	require.Len(t, list["orders"], 1)
+	require.Equal(t, "u-1", list["orders"].([]any)[0].(map[string]any)["userId"])
+	require.Contains(t, list, "nextPageToken")
+
+	// Errors keep Connect's envelope
+	resp, err := http.Post(baseURL+"/GetOrder", "application/json", strings.NewReader(`{"id":"missing"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestConnectService_SnakeCaseDefault(t *testing.T) {
+	baseURL := startConnectService(t, &config.ResourceConfig{
+		Name: "order",
+		Rows: 1,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+			{Name: "user_id", Type: "uuid"},
+		},
+	})
+
+	created := makeRequest(t, baseURL+"/CreateOrder", map[string]any{
+		"order": map[string]any{"id": "o-1", "user_id": "u-1"},
+	})
+	require.Equal(t, map[string]any{"id": "o-1", "user_id": "u-1"}, created)
+}
@@ -0,0 +1,268 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+// Content types understood by the connect service. Messages have no protobuf
+// schema, so only JSON payloads can be decoded.
+const (
+	contentTypeJSON         = "application/json"
+	contentTypeGRPCWebJSON  = "application/grpc-web+json"
+	contentTypeStreamPrefix = "application/connect+"
+)
+
+// gRPC-Web and Connect streaming frame flags and header size
+const (
+	frameFlagCompressed = 0x01
+	frameFlagEndStream  = 0x02
+	frameFlagTrailer    = 0x80
+	frameHeaderSize     = 5
+)
+
+// maxMessageSize is the largest gRPC-Web message accepted, the same 4MiB
+// limit gRPC servers apply by default
+const maxMessageSize = 4 << 20
+
+// codecHandler negotiates the wire format of each request. Connect JSON
+// requests pass straight through; gRPC-Web JSON requests are unframed,
+// handled as Connect JSON and framed on the way out; protobuf codecs are
+// answered with an unimplemented error rather than mis-parsed as JSON, as
+// are Connect streaming requests.
+func codecHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+
+		switch {
+		case mediaType == contentTypeGRPCWebJSON:
+			serveGRPCWeb(next, w, r)
+		case strings.HasPrefix(mediaType, "application/grpc"):
+			writeGRPCStatus(w, mediaType, nil, connect.CodeUnimplemented, unsupportedCodecMessage(mediaType))
+		case strings.HasPrefix(mediaType, contentTypeStreamPrefix):
+			writeConnectEndStream(w, mediaType, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("streaming RPCs are not supported: all methods are unary")))
+		case strings.HasPrefix(mediaType, "application/proto"):
+			writeConnectError(w, connect.NewError(connect.CodeUnimplemented, fmt.Errorf("%s", unsupportedCodecMessage(mediaType))))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// unsupportedCodecMessage explains why a request's codec was rejected
+func unsupportedCodecMessage(mediaType string) string {
+	return fmt.Sprintf("content type %q is not supported: messages have no protobuf schema, use %s or %s",
+		mediaType, contentTypeJSON, contentTypeGRPCWebJSON)
+}
+
+// serveGRPCWeb handles a unary gRPC-Web request with a JSON payload
+func serveGRPCWeb(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	payload, err := readFrame(r.Body)
+	if err != nil {
+		code := connect.CodeInvalidArgument
+		if errors.Is(err, errMessageTooLarge) {
+			code = connect.CodeResourceExhausted
+		}
+		writeGRPCStatus(w, contentTypeGRPCWebJSON, nil, code, err.Error())
+		return
+	}
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	req := r.Clone(r.Context())
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	req.ContentLength = int64(len(payload))
+	req.Header.Set("Content-Type", contentTypeJSON)
+
+	rec := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(rec, req)
+
+	if rec.status != http.StatusOK {
+		var errResp struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		code := connect.CodeUnknown
+		if json.Unmarshal(rec.body.Bytes(), &errResp) == nil {
+			code.UnmarshalText([]byte(errResp.Code))
+		}
+		writeGRPCStatus(w, contentTypeGRPCWebJSON, nil, code, errResp.Message)
+		return
+	}
+
+	writeGRPCStatus(w, contentTypeGRPCWebJSON, rec.body.Bytes(), 0, "")
+}
+
+// errMessageTooLarge is returned by readFrame for a frame over maxMessageSize
+var errMessageTooLarge = errors.New("message too large")
+
+// readFrame reads a single uncompressed gRPC-Web message frame. The length
+// in the header is checked against maxMessageSize before the payload is
+// allocated.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("invalid frame: %w", err)
+	}
+	if header[0]&frameFlagCompressed != 0 {
+		return nil, fmt.Errorf("compressed messages are not supported")
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", errMessageTooLarge, size, maxMessageSize)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("invalid frame: %w", err)
+	}
+	return payload, nil
+}
+
+// writeFrame writes a gRPC-Web frame with the given flags
+func writeFrame(w io.Writer, flags byte, payload []byte) {
+	var header [frameHeaderSize]byte
+	header[0] = flags
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	w.Write(header[:])
+	w.Write(payload)
+}
+
+// writeGRPCStatus writes a gRPC-Web response: the message frame when
+// message is non-nil, followed by a trailer frame carrying the status. The
+// status is also sent as headers so trailers-only clients see it.
+func writeGRPCStatus(w http.ResponseWriter, contentType string, message []byte, code connect.Code, msg string) {
+	w.Header().Set("Content-Type", contentType)
+	if message == nil {
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+		w.Header().Set("Grpc-Message", percentEncode(msg))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if message != nil {
+		writeFrame(w, 0, message)
+	}
+	trailer := fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\n", code, percentEncode(msg))
+	writeFrame(w, frameFlagTrailer, []byte(trailer))
+}
+
+// percentEncode encodes a grpc-message value as required by the gRPC spec
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// writeConnectError writes a unary Connect-RPC error response: the JSON
+// error envelope with the HTTP status the protocol assigns to the code.
+// Error metadata is sent as headers.
+func writeConnectError(w http.ResponseWriter, err *connect.Error) {
+	for k, v := range err.Meta() {
+		w.Header()[k] = v
+	}
+	// Unary errors are never compressed
+	w.Header().Del("Content-Encoding")
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(connectHTTPStatus(err.Code()))
+	w.Write(connectErrorJSON(err))
+}
+
+// writeConnectEndStream answers a Connect streaming request with an error.
+// Streaming errors always use HTTP 200 and travel in the end-of-stream
+// message, alongside the error's metadata as trailers.
+func writeConnectEndStream(w http.ResponseWriter, contentType string, err *connect.Error) {
+	end := map[string]any{"error": json.RawMessage(connectErrorJSON(err))}
+	if len(err.Meta()) > 0 {
+		end["metadata"] = err.Meta()
+	}
+	data, _ := json.Marshal(end)
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	writeFrame(w, frameFlagEndStream, data)
+}
+
+// connectErrorJSON encodes an error in Connect's JSON error shape. The code
+// is the protocol's string form, such as "not_found", and detail values are
+// unpadded base64 protobuf messages tagged with their type.
+func connectErrorJSON(err *connect.Error) []byte {
+	errResp := map[string]any{
+		"code": err.Code().String(),
+	}
+	if msg := err.Message(); msg != "" {
+		errResp["message"] = msg
+	}
+	if details := err.Details(); len(details) > 0 {
+		encoded := make([]map[string]string, 0, len(details))
+		for _, d := range details {
+			encoded = append(encoded, map[string]string{
+				"type":  d.Type(),
+				"value": base64.RawStdEncoding.EncodeToString(d.Bytes()),
+			})
+		}
+		errResp["details"] = encoded
+	}
+
+	data, _ := json.Marshal(errResp)
+	return data
+}
+
+// connectHTTPStatus maps a Connect code to the HTTP status used for unary
+// errors, as defined by the Connect protocol
+func connectHTTPStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return 499
+	case connect.CodeInvalidArgument, connect.CodeFailedPrecondition, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bufferedResponse captures a handler's response so it can be re-encoded
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
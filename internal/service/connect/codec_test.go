@@ -0,0 +1,218 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// postGRPCWeb sends a framed gRPC-Web JSON request and returns the response
+// with its body split into frames
+func postGRPCWeb(t *testing.T, url string, msg any) (*http.Response, []byte, []byte) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writeFrame(&body, 0, mustMarshal(msg))
+
+	resp, err := http.Post(url, contentTypeGRPCWebJSON, &body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, contentTypeGRPCWebJSON, resp.Header.Get("Content-Type"))
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var message, trailer []byte
+	for r := bytes.NewReader(data); r.Len() > 0; {
+		flags, err := r.ReadByte()
+		require.NoError(t, err)
+		r.UnreadByte()
+		payload, err := readFrame(r)
+		require.NoError(t, err)
+		if flags&frameFlagTrailer != 0 {
+			trailer = payload
+		} else {
+			message = payload
+		}
+	}
+	return resp, message, trailer
+}
+
+// startUserService starts a service exposing three generated users
+func startUserService(t *testing.T) string {
+	t.Helper()
+	return startConnectService(t, &config.ResourceConfig{
+		Name: "user",
+		Rows: 3,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+			{Name: "name", Type: "name"},
+		},
+	})
+}
+
+func TestConnectService_GRPCWeb(t *testing.T) {
+	baseURL := startUserService(t)
+
+	_, message, trailer := postGRPCWeb(t, baseURL+"/ListUsers", map[string]any{})
+	require.Equal(t, "grpc-status: 0\r\ngrpc-message: \r\n", string(trailer))
+
+	var list map[string][]map[string]any
+	require.NoError(t, json.Unmarshal(message, &list))
+	require.Len(t, list["users"], 3)
+
+	id := list["users"][0]["id"]
+	_, message, _ = postGRPCWeb(t, baseURL+"/GetUser", map[string]any{"id": id})
+	var user map[string]any
+	require.NoError(t, json.Unmarshal(message, &user))
+	require.Equal(t, id, user["id"])
+}
+
+func TestConnectService_GRPCWebError(t *testing.T) {
+	baseURL := startUserService(t)
+
+	resp, message, trailer := postGRPCWeb(t, baseURL+"/GetUser", map[string]any{"id": "missing"})
+	require.Nil(t, message)
+	require.Contains(t, string(trailer), "grpc-status: 5\r\n")
+	require.Equal(t, "5", resp.Header.Get("Grpc-Status"))
+}
+
+func TestConnectService_GRPCWebTooLarge(t *testing.T) {
+	baseURL := startUserService(t)
+
+	// The header claims a 4GiB message; it is rejected without being read
+	header := []byte{0, 0xFF, 0xFF, 0xFF, 0xFF}
+	resp, err := http.Post(baseURL+"/ListUsers", contentTypeGRPCWebJSON, bytes.NewReader(header))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "8", resp.Header.Get("Grpc-Status"))
+	require.Contains(t, resp.Header.Get("Grpc-Message"), "message too large")
+}
+
+func TestConnectService_UnsupportedCodec(t *testing.T) {
+	baseURL := startUserService(t)
+
+	// Binary protobuf can't be decoded without a schema
+	resp, err := http.Post(baseURL+"/ListUsers", "application/proto", bytes.NewReader([]byte{0x0a, 0x00}))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+
+	var errResp map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	require.Equal(t, "unimplemented", errResp["code"])
+	require.Contains(t, errResp["message"], contentTypeGRPCWebJSON)
+
+	resp, err = http.Post(baseURL+"/ListUsers", "application/grpc-web+proto", bytes.NewReader(nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "12", resp.Header.Get("Grpc-Status"))
+}
+
+func TestWriteConnectError(t *testing.T) {
+	detail, err := connect.NewErrorDetail(wrapperspb.String("user-42"))
+	require.NoError(t, err)
+
+	connectErr := connect.NewError(connect.CodeNotFound, fmt.Errorf("user not found"))
+	connectErr.AddDetail(detail)
+	connectErr.Meta().Set("X-Lookup", "users")
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Encoding", "gzip")
+	writeConnectError(w, connectErr)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, contentTypeJSON, w.Header().Get("Content-Type"))
+	require.Empty(t, w.Header().Get("Content-Encoding"))
+	require.Equal(t, "users", w.Header().Get("X-Lookup"))
+	require.JSONEq(t, `{
+		"code": "not_found",
+		"message": "user not found",
+		"details": [{"type": "google.protobuf.StringValue", "value": "Cgd1c2VyLTQy"}]
+	}`, w.Body.String())
+}
+
+func TestConnectHTTPStatus(t *testing.T) {
+	tests := map[connect.Code]int{
+		connect.CodeCanceled:           499,
+		connect.CodeUnknown:            http.StatusInternalServerError,
+		connect.CodeInvalidArgument:    http.StatusBadRequest,
+		connect.CodeDeadlineExceeded:   http.StatusGatewayTimeout,
+		connect.CodeNotFound:           http.StatusNotFound,
+		connect.CodeAlreadyExists:      http.StatusConflict,
+		connect.CodePermissionDenied:   http.StatusForbidden,
+		connect.CodeResourceExhausted:  http.StatusTooManyRequests,
+		connect.CodeFailedPrecondition: http.StatusBadRequest,
+		connect.CodeAborted:            http.StatusConflict,
+		connect.CodeOutOfRange:         http.StatusBadRequest,
+		connect.CodeUnimplemented:      http.StatusNotImplemented,
+		connect.CodeInternal:           http.StatusInternalServerError,
+		connect.CodeUnavailable:        http.StatusServiceUnavailable,
+		connect.CodeDataLoss:           http.StatusInternalServerError,
+		connect.CodeUnauthenticated:    http.StatusUnauthorized,
+	}
+	for code, want := range tests {
+		require.Equal(t, want, connectHTTPStatus(code), code.String())
+	}
+}
+
+func TestConnectService_ErrorEnvelope(t *testing.T) {
+	baseURL := startUserService(t)
+
+	resp, err := http.Post(baseURL+"/GetUser", contentTypeJSON, bytes.NewReader(mustMarshal(map[string]any{})))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, contentTypeJSON, resp.Header.Get("Content-Type"))
+
+	var errResp map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	require.Equal(t, map[string]any{"code": "invalid_argument", "message": "id is required"}, errResp)
+}
+
+func TestConnectService_StreamingRejected(t *testing.T) {
+	baseURL := startUserService(t)
+
+	var body bytes.Buffer
+	writeFrame(&body, 0, []byte("{}"))
+	resp, err := http.Post(baseURL+"/ListUsers", "application/connect+json", &body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Streaming errors arrive as an end-of-stream message on a 200
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/connect+json", resp.Header.Get("Content-Type"))
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, byte(frameFlagEndStream), data[0])
+
+	payload, err := readFrame(bytes.NewReader(data))
+	require.NoError(t, err)
+	var end struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &end))
+	require.Equal(t, "unimplemented", end.Error.Code)
+	require.Contains(t, end.Error.Message, "streaming")
+}
+
+func TestPercentEncode(t *testing.T) {
+	require.Equal(t, "not found: 100%25 caf%C3%A9", percentEncode("not found: 100% café"))
+}
@@ -1,6 +1,7 @@
 package connect
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -20,15 +21,17 @@ type CustomMethodHandler struct {
 	packageName string
 	serviceName string
 	serviceVars map[string]cty.Value
+	vars        map[string]cty.Value
 }
 
 // NewCustomMethodHandler creates a new custom method handler
-func NewCustomMethodHandler(method *configconnect.Handler, packageName, serviceName string, serviceVars map[string]cty.Value) (*CustomMethodHandler, error) {
+func NewCustomMethodHandler(method *configconnect.Handler, packageName, serviceName string, serviceVars, vars map[string]cty.Value) (*CustomMethodHandler, error) {
 	return &CustomMethodHandler{
 		method:      method,
 		packageName: packageName,
 		serviceName: serviceName,
 		serviceVars: serviceVars,
+		vars:        vars,
 	}, nil
 }
 
@@ -50,14 +53,17 @@ func (h *CustomMethodHandler) handleMethod(w http.ResponseWriter, r *http.Reques
 	}
 	defer r.Body.Close()
 
-	var req map[string]any
-	if err := json.Unmarshal(body, &req); err != nil {
-		h.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
-		return
+	// Connect clients may send an empty body for an empty message
+	req := map[string]any{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			h.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
+			return
+		}
 	}
 
 	// Build evaluation context from request
-	evalCtx := buildEvalContext(req, h.serviceVars)
+	evalCtx := buildEvalContext(req, h.serviceVars, h.vars)
 
 	// Execute steps if present
 	if len(h.method.Steps) > 0 {
@@ -69,23 +75,42 @@ func (h *CustomMethodHandler) handleMethod(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Evaluate response body expression if present
-	var response any
+	var response any = map[string]any{}
 	if h.method.Response != nil && h.method.Response.BodyExpr != nil {
 		value, diags := h.method.Response.BodyExpr.Value(evalCtx)
 		if diags.HasErrors() {
 			h.writeError(w, connect.NewError(connect.CodeInternal, fmt.Errorf("response evaluation failed: %s", diags.Error())))
 			return
 		}
+		if !value.IsNull() {
+			if !value.Type().Equals(cty.String) {
+				h.writeError(w, connect.NewError(connect.CodeInternal, fmt.Errorf("response body must be a string, got %s", value.Type().FriendlyName())))
+				return
+			}
+
+			// Parse the response body as JSON
+			bodyStr := value.AsString()
+			if err := json.Unmarshal([]byte(bodyStr), &response); err != nil {
+				// If it's not JSON, return as string
+				response = map[string]any{"result": bodyStr}
+			}
+		}
+	}
 
-		// Parse the response body as JSON
-		bodyStr := value.AsString()
-		if err := json.Unmarshal([]byte(bodyStr), &response); err != nil {
-			// If it's not JSON, return as string
-			response = map[string]any{"result": bodyStr}
+	// Apply response headers, evaluated like the body
+	if h.method.Response != nil && h.method.Response.HeadersExpr != nil {
+		headers, diags := h.method.Response.HeadersExpr.Value(evalCtx)
+		if diags.HasErrors() {
+			h.writeError(w, connect.NewError(connect.CodeInternal, fmt.Errorf("response headers evaluation failed: %s", diags.Error())))
+			return
+		}
+		if !headers.IsNull() && headers.CanIterateElements() {
+			for k, v := range headers.AsValueMap() {
+				if v.Type().Equals(cty.String) {
+					w.Header().Set(k, v.AsString())
+				}
+			}
 		}
-	} else {
-		// Empty response
-		response = map[string]any{}
 	}
 
 	// Write response
@@ -94,50 +119,23 @@ func (h *CustomMethodHandler) handleMethod(w http.ResponseWriter, r *http.Reques
 
 // writeResponse writes a successful Connect-RPC response
 func (h *CustomMethodHandler) writeResponse(w http.ResponseWriter, resp any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
 	data, err := json.Marshal(resp)
 	if err != nil {
 		h.writeError(w, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to marshal response: %w", err)))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
 
 // writeError writes a Connect-RPC error response
 func (h *CustomMethodHandler) writeError(w http.ResponseWriter, err *connect.Error) {
-	w.Header().Set("Content-Type", "application/json")
-
-	// Map Connect code to HTTP status code
-	httpStatus := http.StatusInternalServerError
-	switch err.Code() {
-	case connect.CodeInvalidArgument:
-		httpStatus = http.StatusBadRequest
-	case connect.CodeNotFound:
-		httpStatus = http.StatusNotFound
-	case connect.CodeAlreadyExists:
-		httpStatus = http.StatusConflict
-	case connect.CodePermissionDenied:
-		httpStatus = http.StatusForbidden
-	case connect.CodeUnauthenticated:
-		httpStatus = http.StatusUnauthorized
-	}
-
-	w.WriteHeader(httpStatus)
-
-	// Write error in Connect format
-	errResp := map[string]any{
-		"code":    err.Code().String(),
-		"message": err.Message(),
-	}
-
-	data, _ := json.Marshal(errResp)
-	w.Write(data)
+	writeConnectError(w, err)
 }
 
 // buildEvalContext builds an HCL evaluation context from the request
-func buildEvalContext(req map[string]any, serviceVars map[string]cty.Value) *hcl.EvalContext {
-	return config.BuildEvalContextFromMap(req, serviceVars)
+func buildEvalContext(req map[string]any, serviceVars, vars map[string]cty.Value) *hcl.EvalContext {
+	return config.BuildEvalContextFromMap(req, serviceVars, vars)
 }
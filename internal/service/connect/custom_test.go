@@ -2,7 +2,10 @@ package connect
 
 import (
 	"context"
+	"io"
 	"log/slog"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -137,7 +140,7 @@ func TestCustomMethodHandler(t *testing.T) {
 		Name: "TestMethod",
 	}
 
-	handler, err := NewCustomMethodHandler(method, "api.v1", "UserService", nil)
+	handler, err := NewCustomMethodHandler(method, "api.v1", "UserService", nil, nil)
 	require.NoError(t, err)
 	require.NotNil(t, handler)
 	require.Equal(t, "TestMethod", handler.method.Name)
@@ -185,3 +188,83 @@ func TestConnectServiceWithCustomMethods(t *testing.T) {
 	err = svc.Stop(ctx)
 	require.NoError(t, err)
 }
+
+func TestCustomMethodInvoke(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "connect" "user-api" {
+  listen  = "127.0.0.1:0"
+  package = "api.v1"
+
+  resource "user" {
+    rows = 3
+    field "id"   { type = "uuid" }
+    field "name" { type = "name" }
+  }
+
+  resource "order" {
+    rows = 2
+    field "id" { type = "uuid" }
+  }
+
+  handle "SearchUsers" {
+    response {
+      headers = { "X-Query" = request.query }
+      body = jsonencode({
+        query = request.query
+        users = [{ name = "Ada" }]
+      })
+    }
+  }
+
+  handle "GetUser" {
+    response {
+      body = jsonencode({ id = request.id, name = "Overridden" })
+    }
+  }
+
+  handle "Ping" {
+    response {
+      body = "pong"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewConnectService(cfg.Services[0].(*configconnect.Service), slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+	baseURL := "http://" + svc.ResolvedAddress() + "/api.v1.UserService"
+
+	// Custom methods sit alongside CRUD and evaluate their response
+	resp, err := http.Post(baseURL+"/SearchUsers", "application/json", strings.NewReader(`{"query":"ada"}`))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "ada", resp.Header.Get("X-Query"))
+	require.JSONEq(t, `{"query":"ada","users":[{"name":"Ada"}]}`, string(body))
+
+	// A handle block named after a CRUD method overrides it
+	result := makeRequest(t, baseURL+"/GetUser", map[string]any{"id": "42"})
+	require.Equal(t, map[string]any{"id": "42", "name": "Overridden"}, result)
+
+	// The CRUD methods that aren't overridden still work
+	result = makeRequest(t, baseURL+"/ListUsers", map[string]any{})
+	require.Len(t, result["users"], 3)
+
+	// Every resource gets its own service path
+	result = makeRequest(t, "http://"+svc.ResolvedAddress()+"/api.v1.OrderService/ListOrders", map[string]any{})
+	require.Len(t, result["orders"], 2)
+
+	// Plain text bodies are wrapped, and an empty request is an empty message
+	resp, err = http.Post(baseURL+"/Ping", "application/json", nil)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"result":"pong"}`, string(body))
+}
@@ -0,0 +1,103 @@
+package connect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// listRequest is the body of a List<Resources> RPC. All fields are optional;
+// without a page_size every item is returned.
+type listRequest struct {
+	PageSize  int            `json:"page_size"`
+	PageToken string         `json:"page_token"`
+	Filter    map[string]any `json:"filter"`
+}
+
+// pageTokenPrefix namespaces page tokens so arbitrary base64 isn't accepted
+const pageTokenPrefix = "offset:"
+
+// decodeListRequest parses a List request body, treating an empty body as an
+// empty request
+func decodeListRequest(r io.Reader) (*listRequest, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &listRequest{}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return req, nil
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, err
+	}
+	if req.PageSize < 0 {
+		return nil, fmt.Errorf("page_size must not be negative")
+	}
+	return req, nil
+}
+
+// filterItems keeps the items whose fields equal every filter value. Values
+// are compared by their string form, so a filter of 30 matches an int field.
+func filterItems(items []map[string]any, filter map[string]any) []map[string]any {
+	if len(filter) == 0 {
+		return items
+	}
+
+	var matched []map[string]any
+items:
+	for _, item := range items {
+		for field, want := range filter {
+			if fmt.Sprint(item[field]) != fmt.Sprint(want) {
+				continue items
+			}
+		}
+		matched = append(matched, item)
+	}
+	return matched
+}
+
+// paginate returns the page of items selected by the request and the token
+// for the next page, which is empty on the last page
+func paginate(items []map[string]any, req *listRequest) ([]map[string]any, string, error) {
+	offset, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+
+	if req.PageSize == 0 || req.PageSize >= len(items) {
+		return items, "", nil
+	}
+	return items[:req.PageSize], encodePageToken(offset + req.PageSize), nil
+}
+
+// encodePageToken encodes an offset as an opaque page token
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenPrefix + strconv.Itoa(offset)))
+}
+
+// decodePageToken decodes a page token into an offset. An empty token is
+// the first page.
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), pageTokenPrefix) {
+		return 0, fmt.Errorf("invalid page_token")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), pageTokenPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid page_token")
+	}
+	return offset, nil
+}
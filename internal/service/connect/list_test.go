@@ -0,0 +1,143 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+func TestConnectService_ListPagination(t *testing.T) {
+	baseURL := startConnectService(t, &config.ResourceConfig{
+		Name: "user",
+		Rows: 7,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+		},
+	})
+
+	all := makeRequest(t, baseURL+"/ListUsers", map[string]any{})["users"].([]any)
+	require.Len(t, all, 7)
+	require.NotContains(t, makeRequest(t, baseURL+"/ListUsers", map[string]any{}), "next_page_token")
+
+	// Walk the pages, collecting ids in order
+	var seen []any
+	var pages int
+	token := ""
+	for {
+		resp := makeRequest(t, baseURL+"/ListUsers", map[string]any{"page_size": 3, "page_token": token})
+		for _, item := range resp["users"].([]any) {
+			seen = append(seen, item.(map[string]any)["id"])
+		}
+		pages++
+
+		token = resp["next_page_token"].(string)
+		if token == "" {
+			break
+		}
+	}
+
+	require.Equal(t, 3, pages)
+	require.Len(t, seen, 7)
+	for i, item := range all {
+		require.Equal(t, item.(map[string]any)["id"], seen[i], "each item is listed exactly once, in order")
+	}
+}
+
+func TestConnectService_ListInvalidPageToken(t *testing.T) {
+	baseURL := startConnectService(t, &config.ResourceConfig{
+		Name: "user",
+		Rows: 2,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+		},
+	})
+
+	for _, body := range []map[string]any{
+		{"page_size": 1, "page_token": "not-a-token"},
+		{"page_size": -1},
+	} {
+		resp, err := http.Post(baseURL+"/ListUsers", "application/json", bytes.NewReader(mustMarshal(body)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	offset, err := decodePageToken(encodePageToken(42))
+	require.NoError(t, err)
+	require.Equal(t, 42, offset)
+
+	_, err = decodePageToken(encodePageToken(-1))
+	require.Error(t, err)
+}
+
+func TestConnectService_ListFilter(t *testing.T) {
+	baseURL := startConnectService(t, &config.ResourceConfig{
+		Name: "order",
+		Rows: 30,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+			{Name: "status", Type: "enum", Values: []string{"pending", "shipped"}},
+		},
+	})
+
+	all := makeRequest(t, baseURL+"/ListOrders", map[string]any{})["orders"].([]any)
+
+	var want int
+	for _, item := range all {
+		if item.(map[string]any)["status"] == "shipped" {
+			want++
+		}
+	}
+
+	resp := makeRequest(t, baseURL+"/ListOrders", map[string]any{"filter": map[string]any{"status": "shipped"}})
+	orders := resp["orders"].([]any)
+	require.Len(t, orders, want)
+	for _, item := range orders {
+		require.Equal(t, "shipped", item.(map[string]any)["status"])
+	}
+
+	// Filtering applies before paging
+	resp = makeRequest(t, baseURL+"/ListOrders", map[string]any{
+		"filter":    map[string]any{"status": "shipped"},
+		"page_size": want,
+	})
+	require.Len(t, resp["orders"], want)
+	require.Empty(t, resp["next_page_token"])
+}
+
+func TestConnectService_ListUnknownFilterField(t *testing.T) {
+	baseURL := startConnectService(t, &config.ResourceConfig{
+		Name: "order",
+		Rows: 2,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+		},
+	})
+
+	body := mustMarshal(map[string]any{"filter": map[string]any{"colour": "red"}})
+	resp, err := http.Post(baseURL+"/ListOrders", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var errResp map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	require.Equal(t, "invalid_argument", errResp["code"])
+	require.Contains(t, errResp["message"], `unknown filter field "colour"`)
+}
+
+func TestFilterItems(t *testing.T) {
+	items := []map[string]any{
+		{"id": "a", "age": 30},
+		{"id": "b", "age": 40},
+	}
+	require.Equal(t, items[:1], filterItems(items, map[string]any{"age": float64(30)}))
+	require.Equal(t, items, filterItems(items, nil))
+}
@@ -1,7 +1,9 @@
 package connect
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,10 +26,12 @@ type ResourceHandler struct {
 	pluralName    string
 	generator     *fake.Generator
 	pluralizer    *pluralize.Client
+	refs          *fake.RefRegistry
 }
 
-// NewResourceHandler creates a new resource handler for Connect-RPC
-func NewResourceHandler(res *config.ResourceConfig, store *resource.Store, packageName string) (*ResourceHandler, error) {
+// NewResourceHandler creates a new resource handler for Connect-RPC. Generated
+// ids are registered in refs, and ref fields naming another resource draw from it.
+func NewResourceHandler(res *config.ResourceConfig, store *resource.Store, packageName string, refs *fake.RefRegistry) (*ResourceHandler, error) {
 	pluralizer := pluralize.NewClient()
 	pluralName := pluralizer.Plural(res.Name)
 
@@ -43,6 +47,7 @@ func NewResourceHandler(res *config.ResourceConfig, store *resource.Store, packa
 		pluralName:   pluralName,
 		generator:    fake.NewGenerator(),
 		pluralizer:   pluralizer,
+		refs:         refs,
 	}
 
 	return rh, nil
@@ -55,15 +60,17 @@ func (rh *ResourceHandler) Initialize() error {
 	for _, field := range rh.resource.Fields {
 		f := resource.Field{
 			Name:       field.Name,
-			Type:       mapFieldType(field.Type),
-			PrimaryKey: field.Name == "id",
+			Type:       mapFieldType(field),
+			PrimaryKey: field.Name == rh.primaryKey(),
 		}
 		fields = append(fields, f)
 	}
 
 	schema := resource.Schema{
-		Name:   rh.tableName,
-		Fields: fields,
+		Name:       rh.tableName,
+		Fields:     fields,
+		SoftDelete: rh.resource.SoftDelete,
+		Versioned:  rh.resource.Versioned,
 	}
 
 	// Create table
@@ -73,7 +80,9 @@ func (rh *ResourceHandler) Initialize() error {
 
 	// Generate initial data
 	rows := 10
-	if rh.resource.Rows > 0 {
+	if rh.resource.RowsMin != nil && rh.resource.RowsMax != nil {
+		rows = rh.generator.RowCount(*rh.resource.RowsMin, *rh.resource.RowsMax)
+	} else if rh.resource.Rows > 0 {
 		rows = rh.resource.Rows
 	}
 
@@ -81,23 +90,11 @@ func (rh *ResourceHandler) Initialize() error {
 		item := make(map[string]any)
 
 		for _, field := range rh.resource.Fields {
-			// Build config map from individual fields
-			config := make(map[string]any)
-			if field.Min != nil {
-				config["min"] = *field.Min
-			}
-			if field.Max != nil {
-				config["max"] = *field.Max
-			}
-			if len(field.Values) > 0 {
-				config["values"] = field.Values
-			}
-
-			fieldCfg := fake.FieldConfig{
-				Name:   field.Name,
-				Type:   fake.FakeType(field.Type),
-				Config: config,
+			var refIDs []string
+			if field.Type == "ref" && field.Resource != "" && rh.refs != nil {
+				refIDs = rh.refs.IDs(field.Resource)
 			}
+			fieldCfg := field.FakeField(refIDs)
 
 			value, err := rh.generator.Generate(fieldCfg)
 			if err != nil {
@@ -110,11 +107,24 @@ func (rh *ResourceHandler) Initialize() error {
 		if err := rh.store.Insert(rh.tableName, item); err != nil {
 			return fmt.Errorf("failed to insert item: %w", err)
 		}
+
+		if id, ok := item[rh.primaryKey()]; ok && rh.refs != nil {
+			rh.refs.Register(rh.resource.Name, fmt.Sprintf("%v", id))
+		}
 	}
 
 	return nil
 }
 
+// primaryKey returns the name of the resource's primary key field, its
+// first, as for http resources
+func (rh *ResourceHandler) primaryKey() string {
+	if len(rh.resource.Fields) == 0 {
+		return ""
+	}
+	return rh.resource.Fields[0].Name
+}
+
 // RegisterHandlers registers the Connect-RPC handlers and returns the path and handler
 func (rh *ResourceHandler) RegisterHandlers() (string, http.Handler) {
 	// Create the service path: /api.v1.UserService/
@@ -138,6 +148,16 @@ func (rh *ResourceHandler) RegisterHandlers() (string, http.Handler) {
 	return servicePath, mux
 }
 
+// hasField reports whether the resource declares a field
+func (rh *ResourceHandler) hasField(name string) bool {
+	for _, field := range rh.resource.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // handleGet handles Get<Resource> RPC
 func (rh *ResourceHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	// Parse Connect-RPC request
@@ -148,8 +168,8 @@ func (rh *ResourceHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	var req map[string]any
-	if err := json.Unmarshal(body, &req); err != nil {
+	req, err := resource.DecodeObject(bytes.NewReader(body))
+	if err != nil {
 		rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
 		return
 	}
@@ -174,6 +194,14 @@ func (rh *ResourceHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 
 // handleList handles List<Resources> RPC
 func (rh *ResourceHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	// Parse optional paging and filter fields
+	req, err := decodeListRequest(r.Body)
+	if err != nil {
+		rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
+		return
+	}
+	defer r.Body.Close()
+
 	// List all items
 	items, err := rh.store.List(rh.tableName)
 	if err != nil {
@@ -181,9 +209,27 @@ func (rh *ResourceHandler) handleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Filters must name declared fields
+	for field := range req.Filter {
+		if !rh.hasField(field) {
+			rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unknown filter field %q", field)))
+			return
+		}
+	}
+	items = filterItems(items, req.Filter)
+
+	page, nextToken, err := paginate(items, req)
+	if err != nil {
+		rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, err))
+		return
+	}
+
 	// Create response with items field
 	resp := map[string]any{
-		rh.pluralName: items,
+		rh.pluralName: page,
+	}
+	if req.PageSize > 0 {
+		resp["next_page_token"] = nextToken
 	}
 
 	// Write response
@@ -200,8 +246,8 @@ func (rh *ResourceHandler) handleCreate(w http.ResponseWriter, r *http.Request)
 	}
 	defer r.Body.Close()
 
-	var req map[string]any
-	if err := json.Unmarshal(body, &req); err != nil {
+	req, err := resource.DecodeObject(bytes.NewReader(body))
+	if err != nil {
 		rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
 		return
 	}
@@ -222,7 +268,11 @@ func (rh *ResourceHandler) handleCreate(w http.ResponseWriter, r *http.Request)
 
 	// Insert into store
 	if err := rh.store.Insert(rh.tableName, item); err != nil {
-		rh.writeError(w, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to insert: %w", err)))
+		code := connect.CodeInternal
+		if errors.As(err, new(*resource.FieldError)) {
+			code = connect.CodeInvalidArgument
+		}
+		rh.writeError(w, connect.NewError(code, fmt.Errorf("failed to insert: %w", err)))
 		return
 	}
 
@@ -240,8 +290,8 @@ func (rh *ResourceHandler) handleUpdate(w http.ResponseWriter, r *http.Request)
 	}
 	defer r.Body.Close()
 
-	var req map[string]any
-	if err := json.Unmarshal(body, &req); err != nil {
+	req, err := resource.DecodeObject(bytes.NewReader(body))
+	if err != nil {
 		rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
 		return
 	}
@@ -269,7 +319,11 @@ func (rh *ResourceHandler) handleUpdate(w http.ResponseWriter, r *http.Request)
 
 	// Update in store
 	if err := rh.store.Update(rh.tableName, fmt.Sprintf("%v", id), item); err != nil {
-		rh.writeError(w, connect.NewError(connect.CodeNotFound, err))
+		code := connect.CodeNotFound
+		if errors.As(err, new(*resource.FieldError)) {
+			code = connect.CodeInvalidArgument
+		}
+		rh.writeError(w, connect.NewError(code, err))
 		return
 	}
 
@@ -287,8 +341,8 @@ func (rh *ResourceHandler) handleDelete(w http.ResponseWriter, r *http.Request)
 	}
 	defer r.Body.Close()
 
-	var req map[string]any
-	if err := json.Unmarshal(body, &req); err != nil {
+	req, err := resource.DecodeObject(bytes.NewReader(body))
+	if err != nil {
 		rh.writeError(w, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid request: %w", err)))
 		return
 	}
@@ -326,52 +380,34 @@ func (rh *ResourceHandler) writeResponse(w http.ResponseWriter, resp any) {
 
 // writeError writes a Connect-RPC error response
 func (rh *ResourceHandler) writeError(w http.ResponseWriter, err *connect.Error) {
-	w.Header().Set("Content-Type", "application/json")
-
-	// Map Connect code to HTTP status code
-	httpStatus := http.StatusInternalServerError
-	switch err.Code() {
-	case connect.CodeInvalidArgument:
-		httpStatus = http.StatusBadRequest
-	case connect.CodeNotFound:
-		httpStatus = http.StatusNotFound
-	case connect.CodeAlreadyExists:
-		httpStatus = http.StatusConflict
-	case connect.CodePermissionDenied:
-		httpStatus = http.StatusForbidden
-	case connect.CodeUnauthenticated:
-		httpStatus = http.StatusUnauthorized
-	}
-
-	w.WriteHeader(httpStatus)
-
-	// Write error in Connect format
-	errResp := map[string]any{
-		"code":    err.Code().String(),
-		"message": err.Message(),
-	}
-
-	data, _ := json.Marshal(errResp)
-	w.Write(data)
+	writeConnectError(w, err)
 }
 
 // mapFieldType maps fake data types to resource field types
-func mapFieldType(fakeType string) resource.FieldType {
-	switch fakeType {
+func mapFieldType(field *config.FieldConfig) resource.FieldType {
+	switch field.Type {
 	case "uuid":
 		return resource.FieldTypeString
 	case "name", "email":
 		return resource.FieldTypeString
-	case "int":
+	case "int", "sequence":
 		return resource.FieldTypeInt
 	case "decimal":
 		return resource.FieldTypeFloat
+	case "money":
+		// Formatted amounts such as "$12.34" are strings
+		if field.Formatted {
+			return resource.FieldTypeString
+		}
+		return resource.FieldTypeFloat
 	case "bool":
 		return resource.FieldTypeBool
 	case "date", "datetime":
 		return resource.FieldTypeString
 	case "enum", "ref":
 		return resource.FieldTypeString
+	case "json":
+		return resource.FieldTypeAny
 	default:
 		return resource.FieldTypeString
 	}
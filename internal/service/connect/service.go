@@ -10,6 +10,7 @@ import (
 
 	"github.com/jumppad-labs/polymorph/internal/config"
 	configconnect "github.com/jumppad-labs/polymorph/internal/config/connect"
+	"github.com/jumppad-labs/polymorph/internal/fake"
 	"github.com/jumppad-labs/polymorph/internal/resource"
 	"github.com/jumppad-labs/polymorph/internal/service"
 	"golang.org/x/net/http2"
@@ -26,6 +27,7 @@ type ConnectService struct {
 	customHandlers   []*CustomMethodHandler
 	server           *http.Server
 	listener         net.Listener
+	resolvedAddress  string
 	mux              *http.ServeMux
 }
 
@@ -41,10 +43,17 @@ func NewConnectService(cfg *configconnect.Service, logger *slog.Logger) (*Connec
 
 	if len(cfg.Resources) > 0 {
 		resourceStore = resource.NewStore()
+		refs := fake.NewRefRegistry()
+
+		// Referenced resources must be generated before the resources that use them
+		resources, err := config.SortResources(cfg.Resources)
+		if err != nil {
+			return nil, err
+		}
 
 		// Create resource handlers
-		for _, res := range cfg.Resources {
-			rh, err := NewResourceHandler(res, resourceStore, cfg.Package)
+		for _, res := range resources {
+			rh, err := NewResourceHandler(res, resourceStore, cfg.Package, refs)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create resource handler for %q: %w", res.Name, err)
 			}
@@ -77,7 +86,7 @@ func NewConnectService(cfg *configconnect.Service, logger *slog.Logger) (*Connec
 	// Create custom method handlers from handle blocks
 	var customHandlers []*CustomMethodHandler
 	for _, handler := range cfg.Handlers {
-		mh, err := NewCustomMethodHandler(handler, cfg.Package, serviceName, cfg.Vars)
+		mh, err := NewCustomMethodHandler(handler, cfg.Package, serviceName, cfg.Vars, cfg.Variables)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create custom method handler for %q: %w", handler.Name, err)
 		}
@@ -85,14 +94,20 @@ func NewConnectService(cfg *configconnect.Service, logger *slog.Logger) (*Connec
 	}
 	svc.customHandlers = customHandlers
 
-	// Register all resource handlers as Connect-RPC endpoints
+	// Register all resource handlers as Connect-RPC endpoints, each under
+	// its own service path
 	for _, rh := range resourceHandlers {
-		_, handler := rh.RegisterHandlers()
+		path, handler := rh.RegisterHandlers()
+		if cfg.JSONCasing == casingCamel {
+			handler = camelCaseHandler(handler, newFieldCasing(rh.resource, rh.pluralName))
+		}
 		// Wrap handler with h2c for HTTP/2 without TLS
-		svc.mux.Handle("/", h2c.NewHandler(handler, &http2.Server{}))
+		svc.mux.Handle(path, h2c.NewHandler(handler, &http2.Server{}))
 	}
 
-	// Register custom method handlers
+	// Register custom method handlers. Their exact paths take precedence
+	// over the resource's service path, so a handle block named after a
+	// CRUD method overrides it.
 	for _, mh := range customHandlers {
 		path, handler := mh.RegisterHandler()
 		svc.mux.HandleFunc(path, handler)
@@ -117,6 +132,12 @@ func (s *ConnectService) Address() string {
 	return s.config.Listen
 }
 
+// ResolvedAddress returns the address the service bound to, which differs
+// from Address when listening on port 0. It is empty before Start.
+func (s *ConnectService) ResolvedAddress() string {
+	return s.resolvedAddress
+}
+
 // Upstreams returns the list of upstream service dependencies
 func (s *ConnectService) Upstreams() []string {
 	return s.config.Upstreams
@@ -125,22 +146,23 @@ func (s *ConnectService) Upstreams() []string {
 // Start starts the Connect-RPC server
 func (s *ConnectService) Start(ctx context.Context) error {
 	// Create listener
-	listener, err := net.Listen("tcp", s.config.Listen)
+	listener, err := service.Listen(s.config.Listen)
 	if err != nil {
-		return fmt.Errorf("failed to create listener: %w", err)
+		return err
 	}
 
 	// Wrap with TLS if configured
-	listener, err = service.WrapListenerTLS(listener, s.config.TLS)
+	wrapped, err := service.WrapListenerTLS(listener, s.config.TLS)
 	if err != nil {
 		listener.Close()
 		return fmt.Errorf("failed to configure TLS: %w", err)
 	}
-	s.listener = listener
+	s.listener = wrapped
+	s.resolvedAddress = service.ListenerAddress(wrapped)
 
-	// Create HTTP server with h2c handler
+	// Create HTTP server with h2c handler, negotiating the codec per request
 	s.server = &http.Server{
-		Handler: s.mux,
+		Handler: codecHandler(s.mux),
 	}
 
 	// Start server in background
@@ -150,7 +172,7 @@ func (s *ConnectService) Start(ctx context.Context) error {
 	}
 	go func() {
 		s.logger.Info("service listening", "proto", proto, "addr", s.config.Listen)
-		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		if err := s.server.Serve(wrapped); err != nil && err != http.ErrServerClosed {
 			s.logger.Error("server error", "error", err)
 		}
 	}()
@@ -166,9 +188,13 @@ func (s *ConnectService) Stop(ctx context.Context) error {
 
 	s.logger.Info("stopping service")
 
-	// Use a timeout context for shutdown
-	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	// Bound shutdown unless the caller already set a grace period
+	shutdownCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+	}
 
 	if err := s.server.Shutdown(shutdownCtx); err != nil {
 		return fmt.Errorf("failed to shutdown server: %w", err)
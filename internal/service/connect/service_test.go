@@ -12,6 +12,8 @@ import (
 
 	"github.com/jumppad-labs/polymorph/internal/config"
 	configconnect "github.com/jumppad-labs/polymorph/internal/config/connect"
+	"github.com/jumppad-labs/polymorph/internal/fake"
+	"github.com/jumppad-labs/polymorph/internal/resource"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,6 +41,28 @@ func TestNewConnectService(t *testing.T) {
 	require.Equal(t, "0.0.0.0:9090", svc.Address())
 }
 
+func TestResourceHandler_RegistersPrimaryKey(t *testing.T) {
+	refs := fake.NewRefRegistry()
+	rh, err := NewResourceHandler(&config.ResourceConfig{
+		Name: "team",
+		Rows: 3,
+		Fields: []*config.FieldConfig{
+			{Name: "code", Type: "uuid"},
+			{Name: "name", Type: "name"},
+		},
+	}, resource.NewStore(), "api.v1", refs)
+	require.NoError(t, err)
+	require.NoError(t, rh.Initialize())
+
+	// Refs point at the first field, the primary key, even without an id
+	ids := refs.IDs("team")
+	require.Len(t, ids, 3)
+	for _, id := range ids {
+		_, err := rh.store.Get(rh.tableName, id)
+		require.NoError(t, err)
+	}
+}
+
 func TestNewConnectServiceNoPack(t *testing.T) {
 	cfg := &configconnect.Service{
 		Name:   "test-api",
@@ -158,6 +182,24 @@ func TestConnectServiceCRUD(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
 
+// startConnectService starts a service on a free port exposing a single
+// resource and returns the base URL of its methods
+func startConnectService(t *testing.T, res *config.ResourceConfig) string {
+	t.Helper()
+
+	svc, err := NewConnectService(&configconnect.Service{
+		Name:      "test-api",
+		Listen:    "127.0.0.1:0",
+		Package:   "api.v1",
+		Resources: []*config.ResourceConfig{res},
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+
+	return "http://" + svc.ResolvedAddress() + "/api.v1." + capitalizeFirst(res.Name) + "Service"
+}
+
 func makeRequest(t *testing.T, url string, body map[string]any) map[string]any {
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(mustMarshal(body)))
 	require.NoError(t, err)
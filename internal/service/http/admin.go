@@ -0,0 +1,205 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/service"
+)
+
+// adminPrefix is the path prefix for admin endpoints
+const adminPrefix = "/admin/"
+
+// seedRequest is the body of a resource seed request
+type seedRequest struct {
+	Rows *int   `json:"rows"`
+	Seed *int64 `json:"seed"`
+}
+
+// chaosRequest is the body of a chaos update. Omitted fields leave the
+// current setting alone.
+type chaosRequest struct {
+	ErrorRate *float64 `json:"error_rate"`
+	Status    int      `json:"status"`
+	P50       string   `json:"p50"`
+	P90       string   `json:"p90"`
+	P99       string   `json:"p99"`
+	Reset     bool     `json:"reset"`
+}
+
+// handleAdmin serves the admin endpoints enabled by an admin block
+func (s *HTTPService) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	// POST /admin/drain
+	if r.URL.Path == adminPrefix+"drain" {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleDrain(w)
+		return
+	}
+
+	// POST /admin/chaos
+	if r.URL.Path == adminPrefix+"chaos" {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleChaos(w, r)
+		return
+	}
+
+	// POST /admin/resources/:name/seed
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, adminPrefix), "/")
+	if len(parts) == 3 && parts[0] == "resources" && parts[2] == "seed" {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleSeed(w, r, parts[1])
+		return
+	}
+
+	http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+}
+
+// handleDrain fails the health check so load balancers stop routing new
+// requests here, while requests already in flight complete
+func (s *HTTPService) handleDrain(w http.ResponseWriter) {
+	s.readiness.Drain()
+	s.logger.Info("draining service")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"status":"draining"}`))
+}
+
+// handleSeed truncates a resource and regenerates its data
+func (s *HTTPService) handleSeed(w http.ResponseWriter, r *http.Request, name string) {
+	var rh *ResourceHandler
+	for _, h := range s.resourceHandlers {
+		if h.resource.Name == name {
+			rh = h
+			break
+		}
+	}
+	if rh == nil {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown resource: %s"}`, name), http.StatusNotFound)
+		return
+	}
+
+	var req seedRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"invalid JSON: %v"}`, err), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Rows != nil && *req.Rows < 0 {
+		http.Error(w, `{"error":"rows must be non-negative"}`, http.StatusBadRequest)
+		return
+	}
+
+	count, err := rh.Reseed(req.Rows, req.Seed)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"failed to seed resource: %v"}`, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"resource": name,
+		"rows":     count,
+	})
+}
+
+// handleChaos swaps the service-level latency and error injectors, so chaos
+// can be tuned without editing config and reloading. Handler-level timing
+// and error blocks still take precedence. reset restores the configured
+// injectors.
+func (s *HTTPService) handleChaos(w http.ResponseWriter, r *http.Request) {
+	var req chaosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"invalid JSON: %v"}`, err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Reset {
+		s.latencyInjector.Store(s.baseLatency)
+		s.errorInjector.Store(s.baseErrors)
+		s.logger.Info("chaos reset to configured values")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"reset"}`))
+		return
+	}
+
+	// Validate everything before swapping anything. Percentiles that are
+	// sent replace the current ones and the rest are kept.
+	var latency *service.LatencyInjector
+	if req.P50 != "" || req.P90 != "" || req.P99 != "" {
+		timing := s.latencyInjector.Load().Config()
+		for _, p := range []struct {
+			name  string
+			value string
+			dst   *time.Duration
+		}{
+			{"p50", req.P50, &timing.P50},
+			{"p90", req.P90, &timing.P90},
+			{"p99", req.P99, &timing.P99},
+		} {
+			if p.value == "" {
+				continue
+			}
+			d, err := service.ParseDuration(p.value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf(`{"error":"invalid %s: %v"}`, p.name, err), http.StatusBadRequest)
+				return
+			}
+			*p.dst = d
+		}
+		latency = service.NewLatencyInjector(timing)
+	}
+	if req.ErrorRate != nil && (*req.ErrorRate < 0 || *req.ErrorRate > 1) {
+		http.Error(w, `{"error":"error_rate must be between 0 and 1"}`, http.StatusBadRequest)
+		return
+	}
+	// The status is only used by the error rule error_rate creates
+	if req.ErrorRate == nil && req.Status != 0 {
+		http.Error(w, `{"error":"status requires error_rate"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Status == 0 {
+		req.Status = http.StatusInternalServerError
+	}
+	if req.Status < 400 || req.Status > 599 {
+		http.Error(w, `{"error":"status must be an error status (400-599)"}`, http.StatusBadRequest)
+		return
+	}
+
+	if latency != nil {
+		s.latencyInjector.Store(latency)
+	}
+	if req.ErrorRate != nil {
+		var errs *service.ErrorInjector
+		if *req.ErrorRate > 0 {
+			errs = service.NewErrorInjector([]*service.ErrorConfig{{
+				Name:   "admin",
+				Rate:   *req.ErrorRate,
+				Status: req.Status,
+				Body:   `{"error":"injected error"}`,
+			}})
+		}
+		s.errorInjector.Store(errs)
+	}
+	s.logger.Info("chaos updated", "error_rate", req.ErrorRate, "status", req.Status, "p50", req.P50, "p90", req.P90, "p99", req.P99)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"updated"}`))
+}
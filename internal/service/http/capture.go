@@ -0,0 +1,174 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// debugRequestsPath serves the captured exchanges when body capture is on
+const debugRequestsPath = "/debug/requests"
+
+// Capture defaults, used when the debug block leaves them unset
+const (
+	defaultCaptureMax      = 50
+	defaultCaptureBodySize = 64 * 1024
+)
+
+// CapturedExchange is a full request/response pair recorded for debugging.
+// Bodies beyond the size cap are truncated.
+type CapturedExchange struct {
+	Sequence          uint64      `json:"sequence"`
+	Timestamp         time.Time   `json:"timestamp"`
+	Method            string      `json:"method"`
+	URL               string      `json:"url"`
+	RequestHeaders    http.Header `json:"request_headers"`
+	RequestBody       string      `json:"request_body"`
+	RequestTruncated  bool        `json:"request_truncated,omitempty"`
+	Status            int         `json:"status"`
+	ResponseHeaders   http.Header `json:"response_headers"`
+	ResponseBody      string      `json:"response_body"`
+	ResponseTruncated bool        `json:"response_truncated,omitempty"`
+	Duration          int64       `json:"duration_ms"` // milliseconds
+}
+
+// bodyCapture keeps the last max exchanges in a ring buffer
+type bodyCapture struct {
+	mu       sync.Mutex
+	entries  []CapturedExchange
+	max      int
+	bodySize int
+	sequence uint64
+}
+
+// newBodyCapture creates a capture buffer from a debug block, or returns nil
+// when body capture is off
+func newBodyCapture(cfg *config.DebugConfig) (*bodyCapture, error) {
+	if cfg == nil || !cfg.CaptureBodies {
+		return nil, nil
+	}
+
+	c := &bodyCapture{max: cfg.Max, bodySize: defaultCaptureBodySize}
+	if c.max <= 0 {
+		c.max = defaultCaptureMax
+	}
+	if cfg.MaxBodySize != "" {
+		size, err := config.ParseMemorySize(cfg.MaxBodySize)
+		if err != nil {
+			return nil, err
+		}
+		c.bodySize = int(size)
+	}
+	return c, nil
+}
+
+// begin starts capturing an exchange, returning the writer and request the
+// service should use and a function that records the exchange when done
+func (c *bodyCapture) begin(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	start := time.Now()
+
+	// Read at most the cap plus one byte, then replay it ahead of the rest
+	// of the body so handlers still see everything
+	prefix, _ := io.ReadAll(io.LimitReader(r.Body, int64(c.bodySize)+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+
+	reqBody, reqTruncated := prefix, false
+	if len(prefix) > c.bodySize {
+		reqBody, reqTruncated = prefix[:c.bodySize], true
+	}
+
+	cw := &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: c.bodySize}
+	return cw, r, func() {
+		c.add(CapturedExchange{
+			Timestamp:         start,
+			Method:            r.Method,
+			URL:               r.URL.RequestURI(),
+			RequestHeaders:    r.Header.Clone(),
+			RequestBody:       string(reqBody),
+			RequestTruncated:  reqTruncated,
+			Status:            cw.status,
+			ResponseHeaders:   w.Header().Clone(),
+			ResponseBody:      cw.body.String(),
+			ResponseTruncated: cw.truncated,
+			Duration:          time.Since(start).Milliseconds(),
+		})
+	}
+}
+
+// add appends an exchange, evicting the oldest beyond max
+func (c *bodyCapture) add(ex CapturedExchange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sequence++
+	ex.Sequence = c.sequence
+	c.entries = append(c.entries, ex)
+	if len(c.entries) > c.max {
+		c.entries = append(c.entries[:0], c.entries[len(c.entries)-c.max:]...)
+	}
+}
+
+// Exchanges returns the captured exchanges, oldest first
+func (c *bodyCapture) Exchanges() []CapturedExchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]CapturedExchange, len(c.entries))
+	copy(out, c.entries)
+	return out
+}
+
+// ServeHTTP serves GET /debug/requests
+func (c *bodyCapture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.Exchanges())
+}
+
+// captureWriter records the status and the first limit bytes of the body
+type captureWriter struct {
+	http.ResponseWriter
+	status    int
+	written   bool
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	if !cw.written {
+		cw.status = status
+		cw.written = true
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.written = true
+	room := cw.limit - cw.body.Len()
+	if len(b) > room {
+		cw.truncated = true
+	}
+	if room > 0 {
+		cw.body.Write(b[:min(room, len(b))])
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// detectContentType picks a Content-Type for a response body that doesn't
+// set one: JSON if the body parses as JSON, XML if it starts with an XML
+// declaration, HTML if it starts with any other tag, and plain text otherwise
+func detectContentType(body string) string {
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case json.Valid([]byte(trimmed)):
+		return "application/json"
+	case strings.HasPrefix(trimmed, "<?xml"):
+		return "application/xml"
+	case strings.HasPrefix(trimmed, "<"):
+		return "text/html; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// decodeBinaryBody decodes a response's base64_body, or returns nil when the
+// response doesn't set one
+func decodeBinaryBody(resp *config.ResponseConfig) ([]byte, error) {
+	if resp == nil || resp.Base64Body == "" {
+		return nil, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(resp.Base64Body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid response base64_body: %w", err)
+	}
+	return raw, nil
+}
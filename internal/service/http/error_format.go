@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// errorRenderer renders an error Polymorph generates itself as a response
+// body in the service's error_format
+type errorRenderer func(r *http.Request, status int, message string) (string, error)
+
+// errorFormatKey is the request context key holding the errorRenderer
+type errorFormatKey struct{}
+
+// withErrorFormat attaches the service's error_format to a request, so
+// handlers that don't hold the service, such as resources, can use it
+func (s *HTTPService) withErrorFormat(r *http.Request) *http.Request {
+	if s.config.ErrorFormat == nil || bodyExprEmpty(s.config.ErrorFormat) {
+		return r
+	}
+	render := func(r *http.Request, status int, message string) (string, error) {
+		body, err := s.renderError(r, status, message)
+		if err != nil {
+			s.logger.Error("failed to render error_format", "error", err)
+		}
+		return body, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), errorFormatKey{}, errorRenderer(render)))
+}
+
+// renderError evaluates error_format with error.message and error.status
+// set, alongside the usual request variables
+func (s *HTTPService) renderError(r *http.Request, status int, message string) (string, error) {
+	evalCtx := config.BuildEvalContext(r, nil, s.config.Vars, s.config.Variables)
+	evalCtx.Variables["error"] = cty.ObjectVal(map[string]cty.Value{
+		"message": cty.StringVal(message),
+		"status":  cty.NumberIntVal(int64(status)),
+	})
+
+	value, diags := s.config.ErrorFormat.Value(evalCtx)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("failed to evaluate error_format: %s", diags.Error())
+	}
+	if value.IsNull() || !value.Type().Equals(cty.String) {
+		return "", fmt.Errorf("error_format must be a string; wrap it in jsonencode()")
+	}
+	return value.AsString(), nil
+}
+
+// writeError writes an error Polymorph generates itself, such as a 404 or
+// a failed step. It uses the service's error_format when one is set, and
+// {"error":"<message>"} otherwise or if the format can't be rendered.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if render, ok := r.Context().Value(errorFormatKey{}).(errorRenderer); ok {
+		if body, err := render(r, status, message); err == nil {
+			w.Header().Set("Content-Type", detectContentType(body))
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/step"
+)
+
+// runBeforeHook runs the service's before hook ahead of a handler. It
+// returns false when the hook wrote a response and the handler must not run.
+func (s *HTTPService) runBeforeHook(w http.ResponseWriter, r *http.Request, evalCtx *hcl.EvalContext, handlerName string) bool {
+	hook := s.config.Before
+	if hook == nil {
+		return true
+	}
+
+	if err := step.NewExecutor(hook.Steps).Execute(r.Context(), evalCtx); err != nil {
+		s.logger.Error("before hook failed", "handler", handlerName, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "before hook failed: "+err.Error())
+		return false
+	}
+
+	resp := hook.Response
+	if resp == nil {
+		return true
+	}
+
+	if resp.WhenExpr != nil {
+		val, diags := resp.WhenExpr.Value(evalCtx)
+		if diags.HasErrors() {
+			s.logger.Error("failed to evaluate before hook when", "handler", handlerName, "error", diags.Error())
+			writeError(w, r, http.StatusInternalServerError, "before hook evaluation failed")
+			return false
+		}
+		if !val.IsNull() {
+			if !val.IsKnown() || !val.Type().Equals(cty.Bool) {
+				s.logger.Error("before hook when must be a bool", "handler", handlerName, "type", val.Type().FriendlyName())
+				writeError(w, r, http.StatusInternalServerError, "before hook evaluation failed")
+				return false
+			}
+			if val.False() {
+				return true
+			}
+		}
+	}
+
+	if err := s.beforeResponse.write(w, evalCtx, resp.Status); err != nil {
+		s.logger.Error("failed to evaluate before hook response", "handler", handlerName, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "before hook evaluation failed")
+	}
+	return false
+}
+
+// runAfterHook runs the service's after hook once a handler's response has
+// been sent. The response can no longer change, so failures are only logged.
+func (s *HTTPService) runAfterHook(w http.ResponseWriter, r *http.Request, evalCtx *hcl.EvalContext, handlerName string) {
+	hook := s.config.After
+	if hook == nil {
+		return
+	}
+
+	// Send the response before running the hook's steps
+	http.NewResponseController(w).Flush()
+
+	if err := step.NewExecutor(hook.Steps).Execute(r.Context(), evalCtx); err != nil {
+		s.logger.Error("after hook failed", "handler", handlerName, "error", err)
+	}
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// defaultIdempotencyTTL is how long an Idempotency-Key is remembered when a
+// resource sets no idempotency_ttl
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeys bounds how many responses a resource remembers; past
+// it the oldest are forgotten before they expire
+const maxIdempotencyKeys = 10000
+
+// idempotencyKeys remembers the response to each create request sent with
+// an Idempotency-Key, so a retry gets the original response instead of
+// creating a second item
+type idempotencyKeys struct {
+	ttl time.Duration
+	max int
+	now func() time.Time
+
+	mu      sync.Mutex // guards entries, order and locks
+	entries map[string]*idempotentResponse
+	// order holds the stored keys oldest first. Every key lives for the
+	// same TTL, so it is also the order they expire in.
+	order []storedKey
+	locks map[string]*keyLock
+}
+
+// idempotentResponse is a stored create response and a fingerprint of the
+// request body that produced it
+type idempotentResponse struct {
+	cachedResponse
+	fingerprint [sha256.Size]byte
+}
+
+// storedKey is a key in the expiry order and when its response was stored
+type storedKey struct {
+	key    string
+	stored time.Time
+}
+
+// keyLock serializes the requests for one key, held for a whole create so
+// concurrent retries wait for the first to finish. users counts the
+// requests holding or waiting for it.
+type keyLock struct {
+	sync.Mutex
+	users int
+}
+
+// newIdempotencyKeys creates the key store for a resource, remembering
+// responses for its idempotency_ttl
+func newIdempotencyKeys(res *config.ResourceConfig) (*idempotencyKeys, error) {
+	ttl, err := res.IdempotencyKeyTTL()
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &idempotencyKeys{
+		ttl:     ttl,
+		max:     maxIdempotencyKeys,
+		now:     time.Now,
+		entries: make(map[string]*idempotentResponse),
+		locks:   make(map[string]*keyLock),
+	}, nil
+}
+
+// serve runs create for a request carrying an Idempotency-Key. The first
+// request with a key is created as usual and a successful response is
+// remembered for the TTL. Retries with the same body replay it with an
+// Idempotent-Replayed header; reusing the key for a different body is
+// rejected with 422.
+func (k *idempotencyKeys) serve(w http.ResponseWriter, r *http.Request, key string, create http.HandlerFunc) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	fingerprint := sha256.Sum256(body)
+
+	unlock := k.lock(key)
+	defer unlock()
+
+	if entry, ok := k.get(key); ok {
+		if entry.fingerprint != fingerprint {
+			writeError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body")
+			return
+		}
+		for name, values := range entry.header {
+			w.Header()[name] = append([]string(nil), values...)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+		return
+	}
+
+	// Headers set before the create, such as X-Request-Id, belong to this
+	// request and aren't replayed
+	before := w.Header().Clone()
+	rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+	create(rec, r)
+	if rec.status >= 200 && rec.status < 300 {
+		k.put(key, &idempotentResponse{
+			cachedResponse: cachedResponse{
+				status: rec.status,
+				header: changedHeaders(before, w.Header()),
+				body:   rec.body.Bytes(),
+			},
+			fingerprint: fingerprint,
+		})
+	}
+}
+
+// lock takes the lock for key, returning the function that releases it.
+// Locks are dropped once no request holds or waits for them.
+func (k *idempotencyKeys) lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.users++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.users--; l.users == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// get returns the unexpired response stored under key
+func (k *idempotencyKeys) get(key string) (*idempotentResponse, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.expire(k.now())
+	entry, ok := k.entries[key]
+	return entry, ok
+}
+
+// put stores a response under key, forgetting the oldest responses when
+// the store is full
+func (k *idempotencyKeys) put(key string, entry *idempotentResponse) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	entry.stored = now
+	k.entries[key] = entry
+	k.order = append(k.order, storedKey{key: key, stored: now})
+	k.expire(now)
+	for len(k.entries) > k.max {
+		k.pop()
+	}
+}
+
+// expire forgets the responses stored at least a TTL before now. Keys
+// expire in the order they were stored, so only the front of order is
+// checked.
+func (k *idempotencyKeys) expire(now time.Time) {
+	for len(k.order) > 0 && now.Sub(k.order[0].stored) >= k.ttl {
+		k.pop()
+	}
+}
+
+// pop forgets the oldest stored response. A key stored again after it
+// expired has a later entry in order, which is left alone.
+func (k *idempotencyKeys) pop() {
+	oldest := k.order[0]
+	k.order[0] = storedKey{}
+	k.order = k.order[1:]
+	if entry, ok := k.entries[oldest.key]; ok && entry.stored.Equal(oldest.stored) {
+		delete(k.entries, oldest.key)
+	}
+}
+
+// changedHeaders returns the headers in after that are missing from before
+// or have different values
+func changedHeaders(before, after http.Header) http.Header {
+	changed := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			changed[name] = append([]string(nil), values...)
+		}
+	}
+	return changed
+}
+
+// recordingWriter passes a response through while keeping a copy of its
+// status and body
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
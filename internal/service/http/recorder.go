@@ -0,0 +1,192 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// fixture is a recorded upstream response stored as JSON
+type fixture struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body"`
+	// BodyEncoding is "base64" when the body is not valid UTF-8
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// fixturePathKey carries a request's fixture path to the proxy's response hook
+type fixturePathKey struct{}
+
+// unsafeFixtureChars matches characters replaced in fixture file names
+var unsafeFixtureChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// recorder proxies requests to a target, saving each response as a fixture
+// and replaying saved fixtures instead of calling the target
+type recorder struct {
+	dir    string
+	mode   string
+	proxy  *httputil.ReverseProxy
+	logger *slog.Logger
+}
+
+// newRecorder creates a recorder from a record block
+func newRecorder(cfg *config.RecordConfig, target *url.URL, logger *slog.Logger) *recorder {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = "auto"
+	}
+
+	rec := &recorder{
+		dir:    cfg.Dir,
+		mode:   mode,
+		proxy:  newTargetProxy(target),
+		logger: logger,
+	}
+	rec.proxy.ModifyResponse = rec.save
+	return rec
+}
+
+// ServeHTTP replays a recorded fixture or proxies and records the response
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	path := rec.fixturePath(r, body)
+
+	if rec.mode != "record" {
+		fx, err := loadFixture(path)
+		if err == nil {
+			fx.write(w, r)
+			return
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			rec.logger.Error("failed to load fixture", "path", path, "error", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to load fixture")
+			return
+		}
+		if rec.mode == "replay" {
+			writeError(w, r, http.StatusNotFound, "no recorded fixture")
+			return
+		}
+	}
+
+	rec.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), fixturePathKey{}, path)))
+}
+
+// fixturePath names a request's fixture by method, path and a hash of the
+// method, URL and body
+func (rec *recorder) fixturePath(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	hash := hex.EncodeToString(h.Sum(nil))[:16]
+
+	name := strings.Trim(unsafeFixtureChars.ReplaceAllString(r.URL.Path, "-"), "-")
+	if name == "" {
+		name = "root"
+	}
+	return filepath.Join(rec.dir, fmt.Sprintf("%s-%s-%s.json", r.Method, name, hash))
+}
+
+// save records the upstream response as a fixture and restores its body
+func (rec *recorder) save(resp *http.Response) error {
+	path, ok := resp.Request.Context().Value(fixturePathKey{}).(string)
+	if !ok {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fx := fixture{
+		Method:  resp.Request.Method,
+		URL:     resp.Request.URL.String(),
+		Status:  resp.StatusCode,
+		Headers: resp.Header.Clone(),
+		Body:    string(body),
+	}
+	fx.Headers.Del("Content-Length")
+	fx.Headers.Del("Date")
+	if !utf8.Valid(body) {
+		fx.Body = base64.StdEncoding.EncodeToString(body)
+		fx.BodyEncoding = "base64"
+	}
+
+	if err := fx.store(path); err != nil {
+		// Recording is best effort; the client still gets the response
+		rec.logger.Error("failed to save fixture", "path", path, "error", err)
+	}
+	return nil
+}
+
+// loadFixture reads a fixture file
+func loadFixture(path string) (*fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fx fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return &fx, nil
+}
+
+// store writes the fixture to path, creating its directory
+func (fx *fixture) store(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// write replays the fixture as the response
+func (fx *fixture) write(w http.ResponseWriter, r *http.Request) {
+	body := []byte(fx.Body)
+	if fx.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(fx.Body)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, "invalid fixture body")
+			return
+		}
+		body = decoded
+	}
+
+	for k, v := range fx.Headers {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(fx.Status)
+	w.Write(body)
+}
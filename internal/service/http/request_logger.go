@@ -1,9 +1,13 @@
 package http
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/service"
 )
 
 // RequestLog represents a single HTTP request log entry
@@ -12,6 +16,7 @@ type RequestLog struct {
 	Timestamp time.Time `json:"timestamp"`
 	Method    string    `json:"method"`
 	Path      string    `json:"path"`
+	ClientIP  string    `json:"client_ip"`
 	Status    int       `json:"status"`
 	Duration  int64     `json:"duration_ms"` // milliseconds
 	Level     string    `json:"level"`       // "info" or "debug"
@@ -25,6 +30,8 @@ type RequestLogger struct {
 	sequence uint64
 	writePos int
 	full     bool
+	// subscribers are called with each request as it is logged
+	subscribers []func(RequestLog)
 }
 
 // NewRequestLogger creates a new request logger with the given capacity
@@ -39,27 +46,41 @@ func NewRequestLogger(capacity int) *RequestLogger {
 }
 
 // Log records a new request
-func (rl *RequestLogger) Log(method, path string, status int, duration time.Duration, level string) {
+func (rl *RequestLogger) Log(method, path, clientIP string, status int, duration time.Duration, level string) {
 	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
 	rl.sequence++
 
-	rl.logs[rl.writePos] = RequestLog{
+	entry := RequestLog{
 		Sequence:  rl.sequence,
 		Timestamp: time.Now(),
 		Method:    method,
 		Path:      path,
+		ClientIP:  clientIP,
 		Status:    status,
 		Duration:  duration.Milliseconds(),
 		Level:     level,
 	}
+	rl.logs[rl.writePos] = entry
 
 	rl.writePos++
 	if rl.writePos >= rl.capacity {
 		rl.writePos = 0
 		rl.full = true
 	}
+	subscribers := rl.subscribers
+	rl.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(entry)
+	}
+}
+
+// Subscribe registers fn to be called with every request logged from now on.
+// fn runs on the request's goroutine, so it should be quick.
+func (rl *RequestLogger) Subscribe(fn func(RequestLog)) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.subscribers = append(rl.subscribers, fn)
 }
 
 // GetLogs returns logs after the given sequence number (0 = all logs)
@@ -111,11 +132,35 @@ func (rl *RequestLogger) GetLatestSequence() uint64 {
 	return rl.sequence
 }
 
+// RequestLogWriter prints request logs from any number of services to a
+// single writer, one line per request
+type RequestLogWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRequestLogWriter creates a RequestLogWriter that prints to w
+func NewRequestLogWriter(w io.Writer) *RequestLogWriter {
+	return &RequestLogWriter{w: w}
+}
+
+// Subscriber returns a RequestLogger subscriber that prints the named
+// service's requests
+func (lw *RequestLogWriter) Subscriber(service string) func(RequestLog) {
+	return func(log RequestLog) {
+		lw.mu.Lock()
+		defer lw.mu.Unlock()
+		fmt.Fprintf(lw.w, "%s %s %s %s %d %dms\n",
+			log.Timestamp.Format("15:04:05.000"), service, log.Method, log.Path, log.Status, log.Duration)
+	}
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
 	status int
 	written bool
+	size   int // body bytes written
 }
 
 func (rw *responseWriter) WriteHeader(status int) {
@@ -131,7 +176,14 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 		rw.status = http.StatusOK
 		rw.written = true
 	}
-	return rw.ResponseWriter.Write(b)
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
 
 // LoggingMiddleware wraps an http.Handler to log requests
@@ -147,6 +199,6 @@ func (rl *RequestLogger) LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Log the request
 		duration := time.Since(start)
-		rl.Log(r.Method, r.URL.Path, wrapped.status, duration, "info")
+		rl.Log(r.Method, r.URL.Path, service.ClientIP(r, nil), wrapped.status, duration, "info")
 	})
 }
@@ -2,10 +2,16 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gertd/go-pluralize"
 	"github.com/jumppad-labs/polymorph/internal/config"
@@ -17,12 +23,16 @@ import (
 type ResourceHandler struct {
 	resource   *config.ResourceConfig
 	store      *resource.Store
+	refs       *fake.RefRegistry
 	pluralName string
 	idPattern  *regexp.Regexp
+	ids        resource.IDGenerator // Assigns ids on create (optional)
+	idempotent *idempotencyKeys     // Responses to creates by Idempotency-Key
 }
 
-// NewResourceHandler creates a new resource handler
-func NewResourceHandler(res *config.ResourceConfig, store *resource.Store) (*ResourceHandler, error) {
+// NewResourceHandler creates a new resource handler. Generated primary keys
+// are registered in refs, and ref fields naming another resource draw from it.
+func NewResourceHandler(res *config.ResourceConfig, store *resource.Store, refs *fake.RefRegistry) (*ResourceHandler, error) {
 	// Derive plural name
 	pluralizer := pluralize.NewClient()
 	pluralName := pluralizer.Plural(res.Name)
@@ -34,11 +44,26 @@ func NewResourceHandler(res *config.ResourceConfig, store *resource.Store) (*Res
 		return nil, fmt.Errorf("failed to compile ID pattern: %w", err)
 	}
 
+	var ids resource.IDGenerator
+	if res.IDStrategy != "" {
+		if ids, err = resource.NewIDGenerator(res.IDStrategy); err != nil {
+			return nil, err
+		}
+	}
+
+	idempotent, err := newIdempotencyKeys(res)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ResourceHandler{
 		resource:   res,
 		store:      store,
+		refs:       refs,
 		pluralName: pluralName,
 		idPattern:  idPattern,
+		ids:        ids,
+		idempotent: idempotent,
 	}, nil
 }
 
@@ -46,14 +71,17 @@ func NewResourceHandler(res *config.ResourceConfig, store *resource.Store) (*Res
 func (rh *ResourceHandler) Initialize() error {
 	// Create table schema
 	schema := resource.Schema{
-		Name:   rh.resource.Name,
-		Fields: make([]resource.Field, 0, len(rh.resource.Fields)),
+		Name:       rh.resource.Name,
+		Fields:     make([]resource.Field, 0, len(rh.resource.Fields)),
+		SoftDelete: rh.resource.SoftDelete,
+		Versioned:  rh.resource.Versioned,
+		Timestamps: rh.resource.Timestamps,
 	}
 
 	for _, field := range rh.resource.Fields {
 		resourceField := resource.Field{
 			Name:  field.Name,
-			Type:  rh.mapFieldType(field.Type),
+			Type:  rh.mapFieldType(field),
 			Index: false, // Could be enhanced to support indexing
 		}
 
@@ -72,8 +100,8 @@ func (rh *ResourceHandler) Initialize() error {
 	}
 
 	// Generate initial data
-	if rh.resource.Rows > 0 {
-		if err := rh.generateData(); err != nil {
+	if rh.resource.Rows > 0 || rh.resource.RowsMax != nil {
+		if _, err := rh.generateData(nil, rh.resource.Seed); err != nil {
 			return fmt.Errorf("failed to generate data: %w", err)
 		}
 	}
@@ -81,82 +109,103 @@ func (rh *ResourceHandler) Initialize() error {
 	return nil
 }
 
+// Reseed replaces the resource's data with freshly generated rows. A nil
+// rows or seed falls back to the resource configuration. Returns the number
+// of rows generated.
+func (rh *ResourceHandler) Reseed(rows *int, seed *int64) (int, error) {
+	if err := rh.store.Truncate(rh.resource.Name); err != nil {
+		return 0, err
+	}
+	if rh.refs != nil {
+		rh.refs.Reset(rh.resource.Name)
+	}
+	if seed == nil {
+		seed = rh.resource.Seed
+	}
+	return rh.generateData(rows, seed)
+}
+
 // mapFieldType converts config field type to resource field type
-func (rh *ResourceHandler) mapFieldType(typ string) resource.FieldType {
-	switch typ {
+func (rh *ResourceHandler) mapFieldType(field *config.FieldConfig) resource.FieldType {
+	switch field.Type {
 	case "uuid", "name", "email", "date", "datetime", "enum", "ref":
 		return resource.FieldTypeString
-	case "int":
+	case "int", "sequence":
 		return resource.FieldTypeInt
 	case "decimal":
 		return resource.FieldTypeFloat
+	case "money":
+		// Formatted amounts such as "$12.34" are strings
+		if field.Formatted {
+			return resource.FieldTypeString
+		}
+		return resource.FieldTypeFloat
 	case "bool":
 		return resource.FieldTypeBool
+	case "json":
+		return resource.FieldTypeAny
 	default:
 		return resource.FieldTypeString
 	}
 }
 
-// generateData generates fake data for the resource
-func (rh *ResourceHandler) generateData() error {
+// generateData generates fake data for the resource, returning the number
+// of rows inserted. A non-nil rows overrides the configured row count.
+func (rh *ResourceHandler) generateData(rows *int, seed *int64) (int, error) {
 	var gen *fake.Generator
-	if rh.resource.Seed != nil {
-		gen = fake.NewSeededGenerator(*rh.resource.Seed)
+	if seed != nil {
+		gen = fake.NewSeededGenerator(*seed)
 	} else {
 		gen = fake.NewGenerator()
 	}
 
+	// A row range overrides the fixed row count
+	count := rh.resource.Rows
+	if rows != nil {
+		count = *rows
+	} else if rh.resource.RowsMin != nil && rh.resource.RowsMax != nil {
+		count = gen.RowCount(*rh.resource.RowsMin, *rh.resource.RowsMax)
+	}
+
 	// Convert config fields to fake field configs
 	fakeFields := make([]fake.FieldConfig, 0, len(rh.resource.Fields))
 	for _, field := range rh.resource.Fields {
-		fakeField := fake.FieldConfig{
-			Name:   field.Name,
-			Type:   fake.FakeType(field.Type),
-			Config: field.Config,
-		}
-
-		// Handle min/max for numeric types
-		if field.Min != nil || field.Max != nil {
-			if fakeField.Config == nil {
-				fakeField.Config = make(map[string]any)
-			}
-			if field.Min != nil {
-				fakeField.Config["min"] = *field.Min
+		// Draw ref ids from the resource the field points at
+		var refIDs []string
+		if field.Type == "ref" && field.Resource != "" {
+			if rh.refs == nil {
+				return 0, fmt.Errorf("field %q references resource %q but no ref registry is configured", field.Name, field.Resource)
 			}
-			if field.Max != nil {
-				fakeField.Config["max"] = *field.Max
-			}
-		}
-
-		// Handle values for enum types
-		if len(field.Values) > 0 {
-			if fakeField.Config == nil {
-				fakeField.Config = make(map[string]any)
-			}
-			values := make([]any, len(field.Values))
-			for i, v := range field.Values {
-				values[i] = v
-			}
-			fakeField.Config["values"] = values
+			refIDs = rh.refs.IDs(field.Resource)
 		}
-
-		fakeFields = append(fakeFields, fakeField)
+		fakeFields = append(fakeFields, field.FakeField(refIDs))
 	}
 
 	// Generate rows
-	rows, err := gen.GenerateRows(fakeFields, rh.resource.Rows)
+	generated, err := gen.GenerateRows(fakeFields, count)
 	if err != nil {
-		return fmt.Errorf("failed to generate rows: %w", err)
+		return 0, fmt.Errorf("failed to generate rows: %w", err)
 	}
 
 	// Insert into store
-	for _, row := range rows {
+	for _, row := range generated {
 		if err := rh.store.Insert(rh.resource.Name, row); err != nil {
-			return fmt.Errorf("failed to insert row: %w", err)
+			return 0, fmt.Errorf("failed to insert row: %w", err)
 		}
+		rh.seedID(row)
 	}
 
-	return nil
+	// Register primary keys so later resources can reference them
+	if rh.refs != nil && len(rh.resource.Fields) > 0 {
+		pk := rh.resource.Fields[0].Name
+		ids := make([]string, 0, len(generated))
+		for _, row := range generated {
+			ids = append(ids, fmt.Sprintf("%v", row[pk]))
+		}
+		rh.refs.Register(rh.resource.Name, ids...)
+	}
+
+	return len(generated), nil
 }
 
 // Match checks if the request matches this resource's routes
@@ -170,8 +219,8 @@ func (rh *ResourceHandler) Match(method, path string) bool {
 	case "POST":
 		// POST /resources
 		return path == listPath
-	case "PUT":
-		// PUT /resources/:id
+	case "PUT", "PATCH":
+		// PUT /resources/:id, PATCH /resources/:id
 		return rh.idPattern.MatchString(path)
 	case "DELETE":
 		// DELETE /resources/:id
@@ -203,20 +252,48 @@ func (rh *ResourceHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		rh.handleCreate(w, r)
 	case "PUT":
 		rh.handleUpdate(w, r)
+	case "PATCH":
+		rh.handlePatch(w, r)
 	case "DELETE":
 		rh.handleDelete(w, r)
 	default:
-		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
 // handleList handles GET /resources
 func (rh *ResourceHandler) handleList(w http.ResponseWriter, r *http.Request) {
-	items, err := rh.store.List(rh.resource.Name)
+	fields, err := rh.requestFields(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	relations, err := rh.requestRelations(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list := rh.store.List
+	if includeDeleted(r) {
+		list = rh.store.ListWithDeleted
+	}
+
+	items, err := list(rh.resource.Name)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(`{"error":"failed to list items: %v"}`, err), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to list items: %v", err))
+		return
+	}
+	if rh.resource.Stream {
+		rh.streamList(w, items, fields, relations)
 		return
 	}
+	for i, item := range items {
+		if items[i], err = rh.expand(item, project(item, fields), relations); err != nil {
+			writeError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
 
 	// TODO: Add pagination support
 	response := map[string]any{
@@ -229,88 +306,473 @@ func (rh *ResourceHandler) handleList(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// streamFlushEvery is how many items a streamed list writes between flushes
+const streamFlushEvery = 100
+
+// streamList writes a list response an item at a time, flushing as it goes,
+// so a large list is never encoded in memory as a whole and the first bytes
+// go out straight away. The response has no Content-Length and is sent with
+// chunked transfer encoding. The status is written before the items are
+// expanded, so an error part way through aborts the response instead,
+// leaving the client with a truncated body it can't mistake for a full list.
+func (rh *ResourceHandler) streamList(w http.ResponseWriter, items []map[string]any, fields []string, relations []*config.RelationConfig) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	io.WriteString(w, `{"data":[`)
+	for i, item := range items {
+		expanded, err := rh.expand(item, project(item, fields), relations)
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		if err := enc.Encode(expanded); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+		if (i+1)%streamFlushEvery == 0 {
+			rc.Flush()
+		}
+	}
+	fmt.Fprintf(w, `],"total":%d}`+"\n", len(items))
+}
+
+// setETag sets the ETag of a versioned resource's item to its version
+func (rh *ResourceHandler) setETag(w http.ResponseWriter, item map[string]any) {
+	if version, ok := item[resource.VersionField]; rh.resource.Versioned && ok {
+		w.Header().Set("ETag", fmt.Sprintf(`"%v"`, version))
+	}
+}
+
+// versionMatch is the precondition of an update to a versioned resource:
+// any current version, or one of versions
+type versionMatch struct {
+	any      bool
+	versions []int64
+}
+
+// requestVersion returns the versions an update was made against, taken from
+// the If-Match header or, failing that, the body's version field. ok is
+// false when the request names no version.
+func requestVersion(r *http.Request, item map[string]any) (match versionMatch, ok bool, err error) {
+	if values := r.Header.Values("If-Match"); len(values) > 0 {
+		return parseIfMatch(strings.Join(values, ","))
+	}
+	if _, ok := item[resource.VersionField]; !ok {
+		return versionMatch{}, false, nil
+	}
+
+	raw := fmt.Sprint(item[resource.VersionField])
+	version, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return versionMatch{}, true, fmt.Errorf("invalid version: %s", raw)
+	}
+	return versionMatch{versions: []int64{version}}, true, nil
+}
+
+// parseIfMatch parses an If-Match header as RFC 9110 defines it: "*", or a
+// comma-separated list of entity tags. If-Match compares strongly, so weak
+// tags never match, and neither do tags that aren't a version.
+func parseIfMatch(header string) (match versionMatch, ok bool, err error) {
+	if strings.TrimSpace(header) == "*" {
+		return versionMatch{any: true}, true, nil
+	}
+
+	rest := header
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return match, true, nil
+		}
+		weak := strings.HasPrefix(rest, "W/")
+		rest = strings.TrimPrefix(rest, "W/")
+		if !strings.HasPrefix(rest, `"`) {
+			return versionMatch{}, true, fmt.Errorf("invalid If-Match: %s", header)
+		}
+		end := strings.IndexByte(rest[1:], '"') + 1
+		if end == 0 {
+			return versionMatch{}, true, fmt.Errorf("invalid If-Match: %s", header)
+		}
+		tag := rest[1:end]
+		rest = strings.TrimLeft(rest[end+1:], " \t")
+		if rest != "" && rest[0] != ',' {
+			return versionMatch{}, true, fmt.Errorf("invalid If-Match: %s", header)
+		}
+
+		if version, err := strconv.ParseInt(tag, 10, 64); err == nil && !weak {
+			match.versions = append(match.versions, version)
+		}
+	}
+}
+
+// includeDeleted reports whether a read asked for soft-deleted items
+func includeDeleted(r *http.Request) bool {
+	return r.URL.Query().Get("include_deleted") == "true"
+}
+
+// requestFields returns the fields named by a read's fields query parameter,
+// or nil when the whole item was asked for. Unknown fields are dropped, or
+// rejected when the resource sets strict_fields.
+func (rh *ResourceHandler) requestFields(r *http.Request) ([]string, error) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, nil
+	}
+
+	known := make(map[string]bool, len(rh.resource.Fields)+2)
+	for _, field := range rh.resource.Fields {
+		known[field.Name] = true
+	}
+	known[resource.DeletedAtField] = rh.resource.SoftDelete
+	known[resource.VersionField] = rh.resource.Versioned
+	if rh.resource.Timestamps {
+		known[resource.CreatedAtField] = true
+		known[resource.UpdatedAtField] = true
+	}
+
+	var fields []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			if rh.resource.StrictFields {
+				return nil, fmt.Errorf("unknown field: %s", name)
+			}
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// project returns a copy of item holding only the given fields. A nil fields
+// returns the item unchanged.
+func project(item map[string]any, fields []string) map[string]any {
+	if fields == nil {
+		return item
+	}
+
+	out := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if value, ok := item[field]; ok {
+			out[field] = value
+		}
+	}
+	return out
+}
+
+// requestRelations returns the relations named by a read's expand query
+// parameter. Unknown relation names are an error.
+func (rh *ResourceHandler) requestRelations(r *http.Request) ([]*config.RelationConfig, error) {
+	param := r.URL.Query().Get("expand")
+	if param == "" {
+		return nil, nil
+	}
+
+	var relations []*config.RelationConfig
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		i := slices.IndexFunc(rh.resource.Relations, func(rel *config.RelationConfig) bool { return rel.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown relation: %s", name)
+		}
+		relations = append(relations, rh.resource.Relations[i])
+	}
+	return relations, nil
+}
+
+// expand returns a copy of item with the rows of each relation embedded
+// under the relation's name. The item's primary key is looked up in the
+// related resource's field.
+func (rh *ResourceHandler) expand(item, projected map[string]any, relations []*config.RelationConfig) (map[string]any, error) {
+	if len(relations) == 0 {
+		return projected, nil
+	}
+
+	out := maps.Clone(projected)
+	id := item[rh.resource.Fields[0].Name]
+	for _, rel := range relations {
+		related, err := rh.store.Where(rel.Resource, rel.Field, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand %s: %w", rel.Name, err)
+		}
+		if related == nil {
+			related = []map[string]any{}
+		}
+		out[rel.Name] = related
+	}
+	return out, nil
+}
+
 // handleGet handles GET /resources/:id
 func (rh *ResourceHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	id, ok := rh.extractID(r.URL.Path)
 	if !ok {
-		http.Error(w, `{"error":"invalid ID"}`, http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "invalid ID")
+		return
+	}
+
+	fields, err := rh.requestFields(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	relations, err := rh.requestRelations(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	item, err := rh.store.Get(rh.resource.Name, id)
+	get := rh.store.Get
+	if includeDeleted(r) {
+		get = rh.store.GetWithDeleted
+	}
+
+	item, err := get(rh.resource.Name, id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "not found")
 		} else {
-			http.Error(w, fmt.Sprintf(`{"error":"failed to get item: %v"}`, err), http.StatusInternalServerError)
+			writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to get item: %v", err))
 		}
 		return
 	}
 
+	response, err := rh.expand(item, project(item, fields), relations)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rh.setETag(w, item)
+	if notModified(w, r, item) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(item)
+	json.NewEncoder(w).Encode(response)
 }
 
-// handleCreate handles POST /resources
+// notModified sets Last-Modified from an item's updated_at and reports
+// whether the request's If-Modified-Since shows the client's copy is still
+// current. Items without an updated_at are always sent, and as in RFC 9110
+// If-Modified-Since is ignored when the request has If-None-Match.
+func notModified(w http.ResponseWriter, r *http.Request, item map[string]any) bool {
+	value, _ := item[resource.UpdatedAtField].(string)
+	modified, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	modified = modified.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+
+	if r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// handleCreate handles POST /resources. Requests with an Idempotency-Key
+// header are created at most once per key.
 func (rh *ResourceHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
-	var item map[string]any
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		http.Error(w, fmt.Sprintf(`{"error":"invalid JSON: %v"}`, err), http.StatusBadRequest)
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		rh.idempotent.serve(w, r, key, rh.create)
+		return
+	}
+	rh.create(w, r)
+}
+
+// create inserts the item in a create request's body
+func (rh *ResourceHandler) create(w http.ResponseWriter, r *http.Request) {
+	item, err := resource.DecodeObject(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
+	rh.assignID(item)
 
 	if err := rh.store.Insert(rh.resource.Name, item); err != nil {
-		http.Error(w, fmt.Sprintf(`{"error":"failed to create item: %v"}`, err), http.StatusInternalServerError)
+		if errors.As(err, new(*resource.FieldError)) {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+		} else {
+			writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to create item: %v", err))
+		}
 		return
 	}
 
+	rh.setETag(w, item)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(item)
 }
 
+// assignID sets a generated primary key on an item created without one,
+// when the resource has an id_strategy. A sequence counts on from the
+// largest id in use; any id that is still taken is skipped.
+func (rh *ResourceHandler) assignID(item map[string]any) {
+	pk := rh.resource.Fields[0].Name
+	if rh.ids == nil {
+		return
+	}
+	if item[pk] != nil {
+		rh.seedID(item)
+		return
+	}
+	for {
+		id := rh.ids.NextID()
+		if _, err := rh.store.GetWithDeleted(rh.resource.Name, id); err != nil {
+			item[pk] = id
+			return
+		}
+	}
+}
+
+// seedID moves the id generator past an item's primary key, so generated
+// ids don't walk through the ones already in use
+func (rh *ResourceHandler) seedID(item map[string]any) {
+	seeder, ok := rh.ids.(resource.IDSeeder)
+	if !ok || len(rh.resource.Fields) == 0 {
+		return
+	}
+	if id := item[rh.resource.Fields[0].Name]; id != nil {
+		seeder.SeedFrom(fmt.Sprint(id))
+	}
+}
+
 // handleUpdate handles PUT /resources/:id
 func (rh *ResourceHandler) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	id, ok := rh.extractID(r.URL.Path)
 	if !ok {
-		http.Error(w, `{"error":"invalid ID"}`, http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "invalid ID")
+		return
+	}
+
+	item, err := resource.DecodeObject(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
 		return
 	}
 
-	var item map[string]any
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		http.Error(w, fmt.Sprintf(`{"error":"invalid JSON: %v"}`, err), http.StatusBadRequest)
+	rh.update(w, r, id, item, item)
+}
+
+// handlePatch handles PATCH /resources/:id, applying the body to the stored
+// item as a JSON Merge Patch (RFC 7386): fields in the patch replace those in
+// the item, nested objects merge, and null removes a field
+func (rh *ResourceHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := rh.extractID(r.URL.Path)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "invalid ID")
 		return
 	}
 
-	if err := rh.store.Update(rh.resource.Name, id, item); err != nil {
+	patch, err := resource.DecodeObject(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	existing, err := rh.store.Get(rh.resource.Name, id)
+	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "not found")
 		} else {
-			http.Error(w, fmt.Sprintf(`{"error":"failed to update item: %v"}`, err), http.StatusInternalServerError)
+			writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to get item: %v", err))
 		}
 		return
 	}
 
+	rh.update(w, r, id, mergePatch(existing, patch), patch)
+}
+
+// update replaces a stored item and writes the result. For versioned
+// resources the version is read from the request body, as sent by the client.
+func (rh *ResourceHandler) update(w http.ResponseWriter, r *http.Request, id string, item, body map[string]any) {
+	update := rh.store.Update
+	if rh.resource.Versioned {
+		// Versioned resources only accept updates against the current version
+		match, ok, err := requestVersion(r, body)
+		if !ok {
+			writeError(w, r, http.StatusPreconditionRequired, "If-Match header or version field is required")
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !match.any {
+			update = func(table, id string, item map[string]any) error {
+				return rh.store.UpdateIfVersion(table, id, item, match.versions...)
+			}
+		}
+	}
+
+	if err := update(rh.resource.Name, id, item); err != nil {
+		if errors.As(err, new(*resource.FieldError)) {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+		} else if errors.Is(err, resource.ErrVersionConflict) {
+			writeError(w, r, http.StatusConflict, "version conflict")
+		} else if strings.Contains(err.Error(), "not found") {
+			writeError(w, r, http.StatusNotFound, "not found")
+		} else {
+			writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to update item: %v", err))
+		}
+		return
+	}
+
+	rh.setETag(w, item)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(item)
 }
 
+// mergePatch returns target with a JSON Merge Patch applied. Stored items are
+// shared, so target is copied rather than modified.
+func mergePatch(target, patch map[string]any) map[string]any {
+	out := maps.Clone(target)
+	if out == nil {
+		out = make(map[string]any, len(patch))
+	}
+	for key, value := range patch {
+		switch v := value.(type) {
+		case nil:
+			delete(out, key)
+		case map[string]any:
+			nested, _ := out[key].(map[string]any)
+			out[key] = mergePatch(nested, v)
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
 // handleDelete handles DELETE /resources/:id
 func (rh *ResourceHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	id, ok := rh.extractID(r.URL.Path)
 	if !ok {
-		http.Error(w, `{"error":"invalid ID"}`, http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "invalid ID")
 		return
 	}
 
 	if err := rh.store.Delete(rh.resource.Name, id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "not found")
 		} else {
-			http.Error(w, fmt.Sprintf(`{"error":"failed to delete item: %v"}`, err), http.StatusInternalServerError)
+			writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to delete item: %v", err))
 		}
 		return
 	}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// preparedResponse is a response block with its template parsed and its
+// base64_body decoded ahead of any request. Handlers, the not_found block
+// and before hooks all evaluate their responses through it.
+type preparedResponse struct {
+	config *config.ResponseConfig
+	tmpl   *template.Template
+	binary []byte // decoded base64_body, nil when unset
+}
+
+// prepareResponse parses a response's template and decodes its base64_body
+func prepareResponse(name string, resp *config.ResponseConfig) (*preparedResponse, error) {
+	tmpl, err := newResponseTemplate(name, resp)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := decodeBinaryBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &preparedResponse{config: resp, tmpl: tmpl, binary: raw}, nil
+}
+
+// evaluate renders the response's status, headers and body, with status
+// defaulting to defaultStatus. Content-Type comes from content_type, then
+// the evaluated headers, and is otherwise detected from the body unless the
+// headers already sent on the writer set one.
+func (p *preparedResponse) evaluate(evalCtx *hcl.EvalContext, defaultStatus int, sent http.Header) (int, http.Header, []byte, error) {
+	resp := p.config
+
+	// Use the binary body, render the response template, or evaluate the
+	// body expression if present
+	var body []byte
+	switch {
+	case p.binary != nil:
+		body = p.binary
+	case p.tmpl != nil:
+		rendered, err := renderTemplate(p.tmpl, evalCtx)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("template: %w", err)
+		}
+		body = []byte(rendered)
+	case resp.BodyExpr != nil:
+		value, diags := resp.BodyExpr.Value(evalCtx)
+		if diags.HasErrors() {
+			return 0, nil, nil, fmt.Errorf("body: %s", diags.Error())
+		}
+		// An omitted body is null
+		if !value.IsNull() {
+			if !value.IsKnown() || !value.Type().Equals(cty.String) {
+				return 0, nil, nil, fmt.Errorf("body must be a string, got %s", value.Type().FriendlyName())
+			}
+			body = []byte(value.AsString())
+		}
+	}
+
+	status := defaultStatus
+	if resp.Status != nil {
+		status = *resp.Status
+	}
+
+	header := make(http.Header)
+	if resp.HeadersExpr != nil {
+		value, diags := resp.HeadersExpr.Value(evalCtx)
+		if diags.HasErrors() {
+			return 0, nil, nil, fmt.Errorf("headers: %s", diags.Error())
+		}
+		if !value.IsNull() {
+			if !value.CanIterateElements() {
+				return 0, nil, nil, fmt.Errorf("headers must be an object, got %s", value.Type().FriendlyName())
+			}
+			for key, v := range value.AsValueMap() {
+				if v.IsNull() || !v.IsKnown() || !v.Type().Equals(cty.String) {
+					return 0, nil, nil, fmt.Errorf("header %q must be a string, got %s", key, v.Type().FriendlyName())
+				}
+				header.Set(key, v.AsString())
+			}
+		}
+	}
+
+	if resp.ContentType != "" {
+		header.Set("Content-Type", resp.ContentType)
+	} else if header.Get("Content-Type") == "" && sent.Get("Content-Type") == "" && len(body) > 0 {
+		if p.binary != nil {
+			header.Set("Content-Type", http.DetectContentType(body))
+		} else {
+			header.Set("Content-Type", detectContentType(string(body)))
+		}
+	}
+
+	return status, header, body, nil
+}
+
+// write evaluates the response and writes it to w
+func (p *preparedResponse) write(w http.ResponseWriter, evalCtx *hcl.EvalContext, defaultStatus int) error {
+	status, header, body, err := p.evaluate(evalCtx, defaultStatus, w.Header())
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(status)
+	if len(body) > 0 {
+		w.Write(body)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/jumppad-labs/polymorph/internal/service"
+)
+
+// defaultCacheKey is used when a cache block sets no key
+const defaultCacheKey = "path+query"
+
+// responseCache memoizes a handler's evaluated responses for a TTL
+type responseCache struct {
+	ttl     time.Duration
+	key     []string
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+// cachedResponse is a stored response and the time it was evaluated
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+	stored time.Time
+}
+
+// newResponseCache creates a response cache from a handler's cache block
+func newResponseCache(cfg *config.CacheConfig) (*responseCache, error) {
+	ttl, err := service.ParseDuration(cfg.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cache.ttl: %w", err)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("cache.ttl must be positive")
+	}
+
+	key := cfg.Key
+	if key == "" {
+		key = defaultCacheKey
+	}
+	parts := strings.Split(key, "+")
+	for _, part := range parts {
+		if part != "path" && part != "query" {
+			return nil, fmt.Errorf("invalid cache.key part %q (must be path or query)", part)
+		}
+	}
+
+	return &responseCache{
+		ttl:     ttl,
+		key:     parts,
+		entries: make(map[string]*cachedResponse),
+	}, nil
+}
+
+// keyFor builds the cache key for a request from the configured parts
+func (c *responseCache) keyFor(r *http.Request) string {
+	values := make([]string, len(c.key))
+	for i, part := range c.key {
+		switch part {
+		case "path":
+			values[i] = r.URL.Path
+		case "query":
+			// Encode sorts by key so parameter order doesn't matter
+			values[i] = r.URL.Query().Encode()
+		}
+	}
+	return strings.Join(values, "?")
+}
+
+// get returns the unexpired response stored under key
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.stored) >= c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// put stores a copy of a response under key, dropping any expired entries
+func (c *responseCache) put(key string, status int, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.Sub(entry.stored) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = &cachedResponse{
+		status: status,
+		header: header.Clone(),
+		body:   body,
+		stored: now,
+	}
+}
+
+// write replays a cached response with an Age header
+func (e *cachedResponse) write(w http.ResponseWriter) {
+	for k, v := range e.header.Clone() {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(e.stored).Seconds())))
+	w.WriteHeader(e.status)
+	w.Write(e.body)
+}
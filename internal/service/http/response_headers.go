@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/jumppad-labs/polymorph/internal/step"
+)
+
+// parseResponseHeaders evaluates a service's response_headers once, at
+// startup. An unset attribute gives no headers.
+func parseResponseHeaders(expr hcl.Expression, evalCtx *hcl.EvalContext) (http.Header, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	value, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to evaluate response_headers: %s", diags.Error())
+	}
+	if value.IsNull() {
+		return nil, nil
+	}
+	if ty := value.Type(); !ty.IsObjectType() && !ty.IsMapType() {
+		return nil, fmt.Errorf("response_headers must be a map of strings, got %s", ty.FriendlyName())
+	}
+
+	header := make(http.Header)
+	for name, v := range value.AsValueMap() {
+		if v.IsNull() || !v.Type().Equals(cty.String) {
+			return nil, fmt.Errorf("response_headers %q must be a string", name)
+		}
+		header.Set(name, v.AsString())
+	}
+	return header, nil
+}
+
+// applyResponseHeaders sets the service's response_headers and the
+// request's X-Request-Id on a response, before anything else writes to it
+// so handlers can still override them. A request without an id is given
+// one, set on the request too so request.id and upstream calls see it.
+func (s *HTTPService) applyResponseHeaders(w http.ResponseWriter, r *http.Request) {
+	for name, values := range s.responseHeaders {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+
+	id := r.Header.Get(step.RequestIDHeader)
+	if id == "" {
+		id = uuid.NewString()
+		r.Header.Set(step.RequestIDHeader, id)
+	}
+	w.Header().Set(step.RequestIDHeader, id)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
@@ -49,20 +50,38 @@ func (r *Router) Match(req *http.Request) (*Route, bool) {
 	return nil, false
 }
 
+// AllowedMethods returns the sorted methods of routes whose path matches
+// the request's. A request that matches no route but has allowed methods
+// used the wrong method.
+func (r *Router) AllowedMethods(req *http.Request) []string {
+	var methods []string
+	for _, route := range r.routes {
+		if route.Method != "" && matchPath(route, req.URL.Path) && !slices.Contains(methods, route.Method) {
+			methods = append(methods, route.Method)
+		}
+	}
+	slices.Sort(methods)
+	return methods
+}
+
 // matchRoute checks if a route matches a request
 func (r *Router) matchRoute(route *Route, req *http.Request) bool {
 	if route.Method != "" && route.Method != req.Method {
 		return false
 	}
+	return matchPath(route, req.URL.Path)
+}
 
+// matchPath checks if a route's path matches a request path
+func matchPath(route *Route, path string) bool {
 	// Fast path: no params, exact match
 	if !strings.Contains(route.Path, ":") {
-		return route.Path == req.URL.Path
+		return route.Path == path
 	}
 
 	// Segment-by-segment matching with :param support
 	routeParts := strings.Split(route.Path, "/")
-	reqParts := strings.Split(req.URL.Path, "/")
+	reqParts := strings.Split(path, "/")
 	if len(routeParts) != len(reqParts) {
 		return false
 	}
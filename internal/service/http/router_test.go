@@ -271,3 +271,20 @@ func TestRouter_Match_EmptyRouter(t *testing.T) {
 	require.False(t, ok)
 	require.Nil(t, route)
 }
+
+func TestRouter_AllowedMethods(t *testing.T) {
+	router := NewRouter()
+	for _, h := range []*confighttp.Handler{
+		{Name: "get", Route: "GET /users/:id"},
+		{Name: "delete", Route: "DELETE /users/:id"},
+		{Name: "put", Route: "PUT /users/:id"},
+		{Name: "get-again", Route: "GET /users/:id"},
+		{Name: "list", Route: "GET /users"},
+	} {
+		require.NoError(t, router.AddHandler(h))
+	}
+
+	require.Equal(t, []string{"DELETE", "GET", "PUT"}, router.AllowedMethods(httptest.NewRequest("POST", "/users/42", nil)))
+	require.Equal(t, []string{"GET"}, router.AllowedMethods(httptest.NewRequest("POST", "/users", nil)))
+	require.Empty(t, router.AllowedMethods(httptest.NewRequest("POST", "/orders", nil)))
+}
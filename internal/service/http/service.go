@@ -6,12 +6,21 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/jumppad-labs/polymorph/internal/config"
 	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/fake"
 	"github.com/jumppad-labs/polymorph/internal/meta"
 	"github.com/jumppad-labs/polymorph/internal/metrics"
 	"github.com/jumppad-labs/polymorph/internal/resource"
@@ -20,6 +29,7 @@ import (
 	"github.com/jumppad-labs/polymorph/internal/step"
 	"github.com/jumppad-labs/polymorph/internal/tracing"
 	"github.com/jumppad-labs/polymorph/pkg/api/meta/v1/metaapiconnect"
+	"github.com/zclconf/go-cty/cty"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/http2"
@@ -35,20 +45,40 @@ type HTTPService struct {
 	resourceHandlers []*ResourceHandler
 	resourceStore    *resource.Store
 	server           *http.Server
-	listener         net.Listener
-	latencyInjector  *service.LatencyInjector
-	errorInjector    *service.ErrorInjector
+	listeners        []net.Listener
+	resolvedAddress  string // Address of the first listener
+	readiness        *service.Readiness
+	// Service-level injectors, swappable at runtime through /admin/chaos
+	latencyInjector  atomic.Pointer[service.LatencyInjector]
+	errorInjector    atomic.Pointer[service.ErrorInjector]
+	baseLatency      *service.LatencyInjector // Configured injectors, restored on reset
+	baseErrors       *service.ErrorInjector
 	mux              *http.ServeMux
 	allConfigs       []config.Service                // All services for meta API
 	requestLogger    *RequestLogger                  // Request log ring buffer
+	capture          *bodyCapture                    // Full request/response capture (optional)
 	staticHandler    http.Handler                    // Static file server (optional)
 	staticPrefix     string                          // URL prefix for static files
 	loadGenerator    *service.LoadGenerator          // CPU/memory load generator (optional)
 	rateLimiter      *service.RateLimiter            // Service-level rate limiter (optional)
 	handlerLimiters  map[string]*service.RateLimiter // Handler-level rate limiters
+	handlerCaches    map[string]*responseCache       // Handler-level response caches
+	handlerResponses map[string]*preparedResponse    // Handler responses, templates parsed and bodies decoded
+	notFound         *preparedResponse               // not_found response (optional)
+	beforeResponse   *preparedResponse               // Before hook response (optional)
+	transfer         *transferTiming                 // Service-level ttfb and body_time (optional)
+	handlerTransfers map[string]*transferTiming      // Handler-level ttfb and body_time
+	responseHeaders  http.Header                     // Headers added to every response (optional)
+	throttle         int64                           // Service-level bytes per second (optional)
+	handlerThrottles map[string]int64                // Handler-level bytes per second
 	metricsEnabled   bool                            // Whether to serve metrics endpoint
 	metricsPath      string                          // Prometheus scrape path
 	specHandler      *SpecHandler                    // OpenAPI spec handler (optional)
+	specOverrides    map[*specRoute]*specOverride    // Per-operation spec injection
+	corsMaxAge       time.Duration                   // CORS preflight cache duration
+	corsOrigins      []*regexp.Regexp                // CORS origin patterns ("~" entries)
+	trustedProxies   []netip.Prefix                  // Peers whose forwarding headers are believed
+	fallback         http.Handler                    // Proxy or recorder for unmatched requests (optional)
 }
 
 // NewHTTPService creates a new HTTP service
@@ -68,10 +98,17 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 
 	if len(cfg.Resources) > 0 {
 		resourceStore = resource.NewStore()
+		refs := fake.NewRefRegistry()
+
+		// Referenced resources must be generated before the resources that use them
+		resources, err := config.SortResources(cfg.Resources)
+		if err != nil {
+			return nil, err
+		}
 
 		// Create resource handlers
-		for _, res := range cfg.Resources {
-			rh, err := NewResourceHandler(res, resourceStore)
+		for _, res := range resources {
+			rh, err := NewResourceHandler(res, resourceStore, refs)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create resource handler for %q: %w", res.Name, err)
 			}
@@ -87,26 +124,12 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 
 	// Initialize timing injector if configured
 	var latencyInjector *service.LatencyInjector
-	if cfg.Timing != nil {
-		p50, err := service.ParseDuration(cfg.Timing.P50)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse timing.p50: %w", err)
-		}
-		p90, err := service.ParseDuration(cfg.Timing.P90)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse timing.p90: %w", err)
-		}
-		p99, err := service.ParseDuration(cfg.Timing.P99)
+	if cfg.Timing != nil && cfg.Timing.HasPercentiles() {
+		timing, err := parseTimingConfig(cfg.Timing)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse timing.p99: %w", err)
+			return nil, err
 		}
-
-		latencyInjector = service.NewLatencyInjector(service.TimingConfig{
-			P50:      p50,
-			P90:      p90,
-			P99:      p99,
-			Variance: cfg.Timing.Variance,
-		})
+		latencyInjector = service.NewLatencyInjector(timing)
 	}
 
 	// Initialize error injector if configured
@@ -117,10 +140,8 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 			// Evaluate error response body if present
 			var bodyStr string
 			if errCfg.Response != nil && errCfg.Response.BodyExpr != nil {
-				// Create a minimal eval context with just functions (no request)
-				evalCtx := &hcl.EvalContext{
-					Functions: config.Functions(),
-				}
+				// Create an eval context without request variables
+				evalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 				value, diags := errCfg.Response.BodyExpr.Value(evalCtx)
 				if diags.HasErrors() {
 					return nil, fmt.Errorf("failed to evaluate error %q body: %s", errCfg.Name, diags.Error())
@@ -130,10 +151,8 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 
 			headers := make(map[string]string)
 			if errCfg.Response != nil && errCfg.Response.HeadersExpr != nil {
-				// Create a minimal eval context with just functions (no request)
-				headersEvalCtx := &hcl.EvalContext{
-					Functions: config.Functions(),
-				}
+				// Create an eval context without request variables
+				headersEvalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 				// Evaluate headers expression
 				headersVal, diags := errCfg.Response.HeadersExpr.Value(headersEvalCtx)
 				if diags.HasErrors() {
@@ -165,16 +184,29 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 		router:           router,
 		resourceStore:    resourceStore,
 		resourceHandlers: resourceHandlers,
-		latencyInjector:  latencyInjector,
-		errorInjector:    errorInjector,
+		baseLatency:      latencyInjector,
+		baseErrors:       errorInjector,
 		requestLogger:    NewRequestLogger(1000), // Store last 1000 requests
 		metricsEnabled:   metrics.IsEnabled(),
 		metricsPath:      metrics.Path(),
 	}
 
+	// A standalone service is ready immediately; a registry replaces this
+	// with readiness that tracks upstreams
+	svc.readiness = &service.Readiness{}
+	svc.latencyInjector.Store(latencyInjector)
+	svc.errorInjector.Store(errorInjector)
+	svc.readiness.MarkReady()
+
+	capture, err := newBodyCapture(cfg.Debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse debug.max_body_size: %w", err)
+	}
+	svc.capture = capture
+
 	// Set up static file server if configured
 	if cfg.Static != nil {
-		fs := http.FileServer(http.Dir(cfg.Static.Root))
+		fs := newStaticFileHandler(cfg.Static)
 		prefix := cfg.Static.Route
 		if prefix == "" {
 			prefix = "/"
@@ -194,6 +226,56 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 			return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
 		}
 		svc.specHandler = sh
+
+		svc.specOverrides, err = newSpecOverrides(sh, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Compile CORS origin patterns
+	if cfg.CORS != nil {
+		for _, o := range cfg.CORS.AllowedOrigins {
+			if !strings.HasPrefix(o, "~") {
+				continue
+			}
+			re, err := regexp.Compile(strings.TrimPrefix(o, "~"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid cors origin pattern %q: %w", o, err)
+			}
+			svc.corsOrigins = append(svc.corsOrigins, re)
+		}
+	}
+
+	// Parse CORS preflight max age if configured
+	if cfg.CORS != nil && cfg.CORS.MaxAge != "" {
+		maxAge, err := service.ParseDuration(cfg.CORS.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cors.max_age: %w", err)
+		}
+		svc.corsMaxAge = maxAge
+	}
+
+	trustedProxies, err := config.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	svc.trustedProxies = trustedProxies
+
+	// Set up fallback proxy or recorder if configured
+	if cfg.Fallback != nil {
+		target, err := evalTargetURL(cfg.Fallback.TargetExpr, config.NewEvalContext(cfg.Vars, cfg.Variables))
+		if err != nil {
+			return nil, fmt.Errorf("fallback: %w", err)
+		}
+		svc.fallback = newTargetProxy(target)
+	}
+	if cfg.Record != nil {
+		target, err := evalTargetURL(cfg.Record.TargetExpr, config.NewEvalContext(cfg.Vars, cfg.Variables))
+		if err != nil {
+			return nil, fmt.Errorf("record: %w", err)
+		}
+		svc.fallback = newRecorder(cfg.Record, target, logger)
 	}
 
 	// Set up load generator if configured
@@ -201,7 +283,7 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 		var memBytes int64
 		if cfg.Load.Memory != "" {
 			var err error
-			memBytes, err = service.ParseMemorySize(cfg.Load.Memory)
+			memBytes, err = config.ParseMemorySize(cfg.Load.Memory)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse load.memory: %w", err)
 			}
@@ -222,10 +304,11 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 		rlCfg := service.RateLimitConfig{
 			RPS:    cfg.RateLimit.RPS,
 			Status: cfg.RateLimit.Status,
+			PerIP:  cfg.RateLimit.PerIP,
 		}
 		if cfg.RateLimit.Response != nil {
 			if cfg.RateLimit.Response.BodyExpr != nil {
-				evalCtx := &hcl.EvalContext{Functions: config.Functions()}
+				evalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 				value, diags := cfg.RateLimit.Response.BodyExpr.Value(evalCtx)
 				if diags.HasErrors() {
 					return nil, fmt.Errorf("failed to evaluate rate_limit response body: %s", diags.Error())
@@ -233,7 +316,7 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 				rlCfg.Body = value.AsString()
 			}
 			if cfg.RateLimit.Response.HeadersExpr != nil {
-				evalCtx := &hcl.EvalContext{Functions: config.Functions()}
+				evalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 				headersVal, diags := cfg.RateLimit.Response.HeadersExpr.Value(evalCtx)
 				if diags.HasErrors() {
 					return nil, fmt.Errorf("failed to evaluate rate_limit response headers: %s", diags.Error())
@@ -258,10 +341,11 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 			hlCfg := service.RateLimitConfig{
 				RPS:    handler.RateLimit.RPS,
 				Status: handler.RateLimit.Status,
+				PerIP:  handler.RateLimit.PerIP,
 			}
 			if handler.RateLimit.Response != nil {
 				if handler.RateLimit.Response.BodyExpr != nil {
-					evalCtx := &hcl.EvalContext{Functions: config.Functions()}
+					evalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 					value, diags := handler.RateLimit.Response.BodyExpr.Value(evalCtx)
 					if diags.HasErrors() {
 						return nil, fmt.Errorf("failed to evaluate handler %q rate_limit response body: %s", handler.Name, diags.Error())
@@ -269,7 +353,7 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 					hlCfg.Body = value.AsString()
 				}
 				if handler.RateLimit.Response.HeadersExpr != nil {
-					evalCtx := &hcl.EvalContext{Functions: config.Functions()}
+					evalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 					headersVal, diags := handler.RateLimit.Response.HeadersExpr.Value(evalCtx)
 					if diags.HasErrors() {
 						return nil, fmt.Errorf("failed to evaluate handler %q rate_limit response headers: %s", handler.Name, diags.Error())
@@ -286,9 +370,121 @@ func NewHTTPService(cfg *confighttp.Service, logger *slog.Logger) (*HTTPService,
 		}
 	}
 
+	// Prepare handler, not_found and before hook responses, parsing
+	// templates and decoding binary bodies
+	svc.handlerResponses = make(map[string]*preparedResponse)
+	for _, handler := range cfg.Handlers {
+		if handler.Response == nil {
+			continue
+		}
+		resp, err := prepareResponse(handler.Name, handler.Response)
+		if err != nil {
+			return nil, fmt.Errorf("handler %q: %w", handler.Name, err)
+		}
+		svc.handlerResponses[handler.Name] = resp
+	}
+	if cfg.NotFound != nil {
+		svc.notFound, err = prepareResponse("not_found", cfg.NotFound)
+		if err != nil {
+			return nil, fmt.Errorf("not_found: %w", err)
+		}
+	}
+	if cfg.Before != nil && cfg.Before.Response != nil {
+		svc.beforeResponse, err = prepareResponse("before", cfg.Before.Response.AsResponse())
+		if err != nil {
+			return nil, fmt.Errorf("before hook: %w", err)
+		}
+	}
+
+	// Parse service and handler-level transfer timing
+	svc.transfer, err = newTransferTiming(cfg.Timing)
+	if err != nil {
+		return nil, err
+	}
+	for _, handler := range cfg.Handlers {
+		transfer, err := newTransferTiming(handler.Timing)
+		if err != nil {
+			return nil, fmt.Errorf("handler %q: %w", handler.Name, err)
+		}
+		if transfer != nil {
+			if svc.handlerTransfers == nil {
+				svc.handlerTransfers = make(map[string]*transferTiming)
+			}
+			svc.handlerTransfers[handler.Name] = transfer
+		}
+	}
+
+	// Evaluate headers added to every response
+	svc.responseHeaders, err = parseResponseHeaders(cfg.ResponseHeaders, config.NewEvalContext(cfg.Vars, cfg.Variables))
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse service and handler-level bandwidth throttles
+	if cfg.Throttle != "" {
+		if svc.throttle, err = config.ParseThrottle(cfg.Throttle); err != nil {
+			return nil, err
+		}
+	}
+	for _, handler := range cfg.Handlers {
+		if handler.Throttle == "" {
+			continue
+		}
+		rate, err := config.ParseThrottle(handler.Throttle)
+		if err != nil {
+			return nil, fmt.Errorf("handler %q: %w", handler.Name, err)
+		}
+		if svc.handlerThrottles == nil {
+			svc.handlerThrottles = make(map[string]int64)
+		}
+		svc.handlerThrottles[handler.Name] = rate
+	}
+
+	// Set up handler-level response caches
+	for _, handler := range cfg.Handlers {
+		if handler.Cache != nil {
+			cache, err := newResponseCache(handler.Cache)
+			if err != nil {
+				return nil, fmt.Errorf("handler %q: %w", handler.Name, err)
+			}
+			if svc.handlerCaches == nil {
+				svc.handlerCaches = make(map[string]*responseCache)
+			}
+			svc.handlerCaches[handler.Name] = cache
+		}
+	}
+
 	return svc, nil
 }
 
+// evalTargetURL evaluates a target expression to an upstream URL
+func evalTargetURL(expr hcl.Expression, evalCtx *hcl.EvalContext) (*url.URL, error) {
+	targetVal, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to evaluate target: %s", diags.Error())
+	}
+	if targetVal.IsNull() || targetVal.Type() != cty.String {
+		return nil, fmt.Errorf("target must be a string")
+	}
+	target, err := url.Parse(targetVal.AsString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid target URL: %w", err)
+	}
+	return target, nil
+}
+
+// newTargetProxy creates a reverse proxy to target that sends the target's
+// Host header rather than the client's, for virtual-hosted upstreams
+func newTargetProxy(target *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+	}
+	return proxy
+}
+
 // Name returns the service name
 func (s *HTTPService) Name() string {
 	return s.name
@@ -304,6 +500,17 @@ func (s *HTTPService) Address() string {
 	return s.config.Listen
 }
 
+// ResolvedAddress returns the address the service bound to, which differs
+// from Address when listening on port 0. It is empty before Start.
+func (s *HTTPService) ResolvedAddress() string {
+	return s.resolvedAddress
+}
+
+// SetReadiness sets the readiness reported on the health endpoint
+func (s *HTTPService) SetReadiness(r *service.Readiness) {
+	s.readiness = r
+}
+
 // Upstreams returns the list of upstream service dependencies
 func (s *HTTPService) Upstreams() []string {
 	return s.config.Upstreams
@@ -334,36 +541,33 @@ func (s *HTTPService) GetRequestLogger() interface{} {
 
 // Start starts the HTTP server
 func (s *HTTPService) Start(ctx context.Context) error {
-	// Create listener
-	listener, err := net.Listen("tcp", s.config.Listen)
+	// Create a listener for each address, wrapped with TLS if configured
+	addrs := append([]string{s.config.Listen}, s.config.ExtraListen...)
+	listeners, err := service.ListenAll(addrs, s.config.TLS, s.config.Socket)
 	if err != nil {
-		return fmt.Errorf("failed to create listener: %w", err)
+		return err
 	}
-
-	// Wrap with TLS if configured
-	listener, err = service.WrapListenerTLS(listener, s.config.TLS)
-	if err != nil {
-		listener.Close()
-		return fmt.Errorf("failed to configure TLS: %w", err)
-	}
-	s.listener = listener
+	s.listeners = listeners
+	s.resolvedAddress = service.ListenerAddress(listeners[0])
 
 	// Create HTTP server
 	s.server = &http.Server{
 		Handler: s,
 	}
 
-	// Start server in background
+	// Serve every listener in the background; Shutdown closes them all
 	proto := "HTTP"
 	if s.config.TLS != nil {
 		proto = "HTTPS"
 	}
-	go func() {
-		s.logger.Info("service listening", "proto", proto, "addr", s.config.Listen)
-		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			s.logger.Error("server error", "error", err)
-		}
-	}()
+	for i, listener := range listeners {
+		go func() {
+			s.logger.Info("service listening", "proto", proto, "addr", addrs[i])
+			if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("server error", "error", err)
+			}
+		}()
+	}
 
 	return nil
 }
@@ -376,9 +580,13 @@ func (s *HTTPService) Stop(ctx context.Context) error {
 
 	s.logger.Info("stopping service")
 
-	// Use a timeout context for shutdown
-	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	// Bound shutdown unless the caller already set a grace period
+	shutdownCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+	}
 
 	if err := s.server.Shutdown(shutdownCtx); err != nil {
 		return fmt.Errorf("failed to shutdown server: %w", err)
@@ -387,18 +595,96 @@ func (s *HTTPService) Stop(ctx context.Context) error {
 	return nil
 }
 
+// servesHealth reports whether r should get the readiness response: the
+// health path is answered only when no route, resource or fallback takes it
+func (s *HTTPService) servesHealth(r *http.Request) bool {
+	if r.URL.Path != service.HealthPath || s.fallback != nil {
+		return false
+	}
+	if _, ok := s.router.Match(r); ok || len(s.router.AllowedMethods(r)) > 0 {
+		return false
+	}
+	for _, rh := range s.resourceHandlers {
+		if rh.Match(r.Method, r.URL.Path) {
+			return false
+		}
+	}
+	if s.mux != nil {
+		if _, pattern := s.mux.Handler(r); pattern != "" {
+			return false
+		}
+	}
+	if s.specHandler != nil {
+		if _, ok := s.specHandler.Match(r.Method, r.URL.Path); ok {
+			return false
+		}
+	}
+	return true
+}
+
 // ServeHTTP handles incoming HTTP requests
 func (s *HTTPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.applyResponseHeaders(w, r)
+
 	// Serve Prometheus metrics endpoint
 	if s.metricsEnabled && r.URL.Path == s.metricsPath {
 		metrics.Handler().ServeHTTP(w, r)
 		return
 	}
 
+	// Serve readiness for health checks the config doesn't handle itself
+	if s.servesHealth(r) {
+		s.readiness.ServeHTTP(w, r)
+		return
+	}
+
+	// Deferred so the gauge drops even if a handler panics
+	defer metrics.TrackInFlight(s.name)()
+
+	clientIP := s.clientIP(r)
+
 	start := time.Now()
 
-	// Wrap response writer to capture status code
-	wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+	// Recover panics so one bad request can't take the service down
+	guard := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+	w = guard
+	r = s.withErrorFormat(r)
+
+	// Each route below sets label. The request is logged and its metrics
+	// recorded under it once the response, or a recovered panic's 500, has
+	// been written.
+	var label string
+	defer func() {
+		if label != "" {
+			s.finish(r, guard, clientIP, label, start)
+		}
+	}()
+	defer s.recoverPanic(guard, r)
+
+	// Serve admin endpoints if enabled
+	if s.config.Admin != nil && strings.HasPrefix(r.URL.Path, adminPrefix) {
+		s.handleAdmin(w, r)
+		return
+	}
+
+	// Capture full exchanges for debugging if enabled
+	if s.capture != nil {
+		if r.URL.Path == debugRequestsPath {
+			s.capture.ServeHTTP(w, r)
+			return
+		}
+		var done func()
+		w, r, done = s.capture.begin(w, r)
+		defer done()
+	}
+
+	// Throttle response bodies if configured; a matched handler's own
+	// throttle replaces the service rate below
+	var throttle *throttledWriter
+	if s.throttle > 0 || s.handlerThrottles != nil {
+		throttle = &throttledWriter{ResponseWriter: w, ctx: r.Context(), rate: s.throttle}
+		w = throttle
+	}
 
 	// Apply CORS headers
 	if s.config.CORS != nil {
@@ -413,36 +699,61 @@ func (s *HTTPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				break
 			}
 		}
+		if !allowed && origin != "" {
+			for _, re := range s.corsOrigins {
+				if re.MatchString(origin) {
+					allowed = true
+					break
+				}
+			}
+		}
+
+		// A preflight requesting a method outside the allow list gets no
+		// allow headers, so the browser fails it
+		if allowed && r.Method == "OPTIONS" {
+			if requested := r.Header.Get("Access-Control-Request-Method"); requested != "" {
+				allowed = corsMethodAllowed(cors.AllowedMethods, requested)
+			}
+		}
 
 		if allowed {
 			if len(cors.AllowedOrigins) == 1 && cors.AllowedOrigins[0] == "*" {
-				wrapped.Header().Set("Access-Control-Allow-Origin", "*")
+				w.Header().Set("Access-Control-Allow-Origin", "*")
 			} else {
-				wrapped.Header().Set("Access-Control-Allow-Origin", origin)
-				wrapped.Header().Set("Vary", "Origin")
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Vary", "Origin")
 			}
 
-			methods := "GET, POST, PUT, DELETE, OPTIONS"
+			methods := strings.Join(defaultCORSMethods, ", ")
 			if len(cors.AllowedMethods) > 0 {
 				methods = strings.Join(cors.AllowedMethods, ", ")
 			}
-			wrapped.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Methods", methods)
 
 			headers := "Content-Type, Authorization"
 			if len(cors.AllowedHeaders) > 0 {
 				headers = strings.Join(cors.AllowedHeaders, ", ")
 			}
-			wrapped.Header().Set("Access-Control-Allow-Headers", headers)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
 
 			if cors.AllowCredentials != nil && *cors.AllowCredentials {
-				wrapped.Header().Set("Access-Control-Allow-Credentials", "true")
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			// Max-Age only applies to preflights, Expose-Headers only to actual responses
+			if r.Method == "OPTIONS" {
+				if s.corsMaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(s.corsMaxAge.Seconds())))
+				}
+			} else if len(cors.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
 			}
 		}
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
-			wrapped.WriteHeader(http.StatusNoContent)
-			s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, time.Since(start), getLogLevel(r.URL.Path, wrapped.status))
+			label = "preflight"
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 	}
@@ -451,9 +762,8 @@ func (s *HTTPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if s.mux != nil {
 		_, pattern := s.mux.Handler(r)
 		if pattern != "" {
-			s.mux.ServeHTTP(wrapped, r)
-			// Log the request with appropriate level based on status
-			s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, time.Since(start), getLogLevel(r.URL.Path, wrapped.status))
+			label = "mux"
+			s.mux.ServeHTTP(w, r)
 			return
 		}
 	}
@@ -461,74 +771,204 @@ func (s *HTTPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// First, check if any resource handler matches
 	for _, rh := range s.resourceHandlers {
 		if rh.Match(r.Method, r.URL.Path) {
-			rh.Handle(wrapped, r)
-			// Log the request
-			s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, time.Since(start), getLogLevel(r.URL.Path, wrapped.status))
+			label = rh.resource.Name
+			rh.Handle(w, r)
 			return
 		}
 	}
 
 	// Try to match a regular route
 	route, ok := s.router.Match(r)
+	handlerW, handlerReq := w, r
 	if !ok {
 		// Try spec handler (OpenAPI-derived routes)
 		if s.specHandler != nil {
 			if specRoute, matched := s.specHandler.Match(r.Method, r.URL.Path); matched {
-				s.handleSpecRoute(wrapped, r, specRoute)
-				duration := time.Since(start)
-				s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, duration, getLogLevel(r.URL.Path, wrapped.status))
-				metrics.RecordRequest(s.name, "spec", wrapped.status, duration)
+				label = "spec"
+				s.handleSpecRoute(w, r, specRoute)
 				return
 			}
 		}
 
+		// HEAD is answered by the GET route without its body
+		if r.Method == http.MethodHead {
+			getReq := r.Clone(r.Context())
+			getReq.Method = http.MethodGet
+			if route, ok = s.router.Match(getReq); ok {
+				handlerW, handlerReq = &headResponseWriter{w}, getReq
+			}
+		}
+	}
+	if !ok {
 		// Try static file server if configured
 		if s.staticHandler != nil && strings.HasPrefix(r.URL.Path, s.staticPrefix) {
-			s.staticHandler.ServeHTTP(wrapped, r)
-			duration := time.Since(start)
-			s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, duration, getLogLevel(r.URL.Path, wrapped.status))
-			metrics.RecordRequest(s.name, "static", wrapped.status, duration)
+			label = "static"
+			s.staticHandler.ServeHTTP(w, r)
+			return
+		}
+
+		// Proxy or replay unmatched requests if fallback or record is configured
+		if s.fallback != nil {
+			label = "fallback"
+			s.fallback.ServeHTTP(w, r)
+			return
+		}
+
+		// OPTIONS lists the methods of a known path the static server and
+		// fallback haven't taken
+		allowed := s.router.AllowedMethods(r)
+		if r.Method == http.MethodOptions && len(allowed) > 0 {
+			label = "options"
+			w.Header().Set("Allow", allowHeader(allowed))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// A known path requested with the wrong method
+		if len(allowed) > 0 {
+			label = "method_not_allowed"
+			w.Header().Set("Allow", allowHeader(allowed))
+			writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
 
 		// No matching route - return 404
-		wrapped.WriteHeader(http.StatusNotFound)
-		wrapped.Header().Set("Content-Type", "application/json")
-		wrapped.Write([]byte(`{"error":"not found"}`))
-		// Log the 404
-		duration := time.Since(start)
-		s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, duration, getLogLevel(r.URL.Path, wrapped.status))
-		metrics.RecordRequest(s.name, "not_found", wrapped.status, duration)
+		label = "not_found"
+		s.writeNotFound(w, r)
 		return
 	}
 
-	// Handle the request with the matched route
-	s.handleRequest(wrapped, r, route)
+	// Handle the request with the matched route, whose own throttle
+	// replaces the service rate
+	if rate, ok := s.handlerThrottles[route.Handler.Name]; ok {
+		throttle.rate = rate
+	}
+	label = route.Handler.Name
+	s.handleRequest(handlerW, handlerReq, route)
+}
 
-	// Log and record metrics
+// finish logs a served request and records its metrics under label
+func (s *HTTPService) finish(r *http.Request, w *responseWriter, clientIP, label string, start time.Time) {
 	duration := time.Since(start)
-	s.requestLogger.Log(r.Method, r.URL.Path, wrapped.status, duration, getLogLevel(r.URL.Path, wrapped.status))
-	metrics.RecordRequest(s.name, route.Handler.Name, wrapped.status, duration)
+	s.requestLogger.Log(r.Method, r.URL.Path, clientIP, w.status, duration, getLogLevel(r.URL.Path, w.status))
+	metrics.RecordRequest(s.name, label, w.status, duration)
+	metrics.RecordResponseSize(s.name, label, w.size)
 }
 
-// handleSpecRoute applies service-level injection and writes a spec-derived response.
+// clientIP returns the request's client address, honouring forwarding
+// headers from trusted proxies
+func (s *HTTPService) clientIP(r *http.Request) string {
+	return service.ClientIP(r, s.trustedProxies)
+}
+
+// recoverPanic turns a panic in request handling into a 500, logging it
+// with its stack. Aborted handlers are re-raised so net/http can drop the
+// connection as intended.
+func (s *HTTPService) recoverPanic(w *responseWriter, r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	s.logger.Error("panic handling request", "method", r.Method, "path", r.URL.Path, "client_ip", s.clientIP(r), "panic", v, "stack", string(debug.Stack()))
+	metrics.RecordPanic(s.name)
+
+	// Too late to change the status once the response has started
+	if !w.written {
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// allowHeader formats a path's methods for an Allow header, adding the
+// HEAD and OPTIONS methods that are answered automatically
+func allowHeader(methods []string) string {
+	if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+	slices.Sort(methods)
+	return strings.Join(methods, ", ")
+}
+
+// headResponseWriter discards the body of a GET response served for HEAD
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeNotFound writes the response for an unmatched route, using the
+// service's not_found block when configured
+func (s *HTTPService) writeNotFound(w http.ResponseWriter, r *http.Request) {
+	if s.notFound == nil {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	evalCtx := config.BuildEvalContext(r, nil, s.config.Vars, s.config.Variables)
+	if err := s.notFound.write(w, evalCtx, http.StatusNotFound); err != nil {
+		s.logger.Error("failed to evaluate not_found response", "error", err)
+		writeError(w, r, http.StatusNotFound, "not found")
+	}
+}
+
+// defaultCORSMethods are advertised when no allowed_methods are configured
+var defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// corsMethodAllowed reports whether a preflight's requested method is in the
+// configured allow list. A "*" entry allows any method.
+func corsMethodAllowed(allowedMethods []string, method string) bool {
+	if len(allowedMethods) == 0 {
+		allowedMethods = defaultCORSMethods
+	}
+	for _, m := range allowedMethods {
+		if m == "*" || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSpecRoute applies service-level injection, or the operation's spec
+// override, and writes a spec-derived response.
 func (s *HTTPService) handleSpecRoute(w http.ResponseWriter, r *http.Request, route *specRoute) {
-	// Apply service-level latency injection
-	if s.latencyInjector != nil {
-		s.latencyInjector.Inject(r.Context())
+	// Apply latency and error injection (override-level replaces service-level)
+	latency, errs := s.latencyInjector.Load(), s.errorInjector.Load()
+	if o, ok := s.specOverrides[route]; ok {
+		if o.latency != nil {
+			latency = o.latency
+		}
+		if o.errors != nil {
+			errs = o.errors
+		}
+	}
+
+	if latency != nil {
+		latency.Inject(r.Context())
 	}
 
-	// Apply service-level error injection
-	if s.errorInjector != nil {
-		if errCfg := s.errorInjector.ShouldInject(); errCfg != nil {
-			s.errorInjector.WriteError(w, errCfg)
+	if errs != nil {
+		if errCfg := errs.ShouldInject(); errCfg != nil {
+			errs.WriteError(w, errCfg)
 			return
 		}
 	}
 
 	// Apply service-level rate limiting
 	if s.rateLimiter != nil {
-		if !s.rateLimiter.Allow() {
+		if !s.rateLimiter.Allow(s.clientIP(r)) {
 			s.rateLimiter.WriteError(w)
 			return
 		}
@@ -546,15 +986,12 @@ func (s *HTTPService) handleSpecRoute(w http.ResponseWriter, r *http.Request, ro
 }
 
 // convertErrorConfigs converts config.ErrorConfig to service.ErrorConfig
-func convertErrorConfigs(errorCfgs []*config.ErrorConfig) ([]*service.ErrorConfig, error) {
+func convertErrorConfigs(errorCfgs []*config.ErrorConfig, evalCtx *hcl.EvalContext) ([]*service.ErrorConfig, error) {
 	result := make([]*service.ErrorConfig, 0, len(errorCfgs))
 	for _, errCfg := range errorCfgs {
 		// Evaluate error response body if present
 		var bodyStr string
 		if errCfg.Response != nil && errCfg.Response.BodyExpr != nil {
-			evalCtx := &hcl.EvalContext{
-				Functions: config.Functions(),
-			}
 			value, diags := errCfg.Response.BodyExpr.Value(evalCtx)
 			if diags.HasErrors() {
 				return nil, fmt.Errorf("failed to evaluate error %q body: %s", errCfg.Name, diags.Error())
@@ -564,10 +1001,7 @@ func convertErrorConfigs(errorCfgs []*config.ErrorConfig) ([]*service.ErrorConfi
 
 		headers := make(map[string]string)
 		if errCfg.Response != nil && errCfg.Response.HeadersExpr != nil {
-			headersEvalCtx := &hcl.EvalContext{
-				Functions: config.Functions(),
-			}
-			headersVal, diags := errCfg.Response.HeadersExpr.Value(headersEvalCtx)
+			headersVal, diags := errCfg.Response.HeadersExpr.Value(evalCtx)
 			if diags.HasErrors() {
 				return nil, fmt.Errorf("failed to evaluate error %q headers: %s", errCfg.Name, diags.Error())
 			}
@@ -599,10 +1033,11 @@ func (s *HTTPService) handleRequest(w http.ResponseWriter, r *http.Request, rout
 		trace.WithAttributes(
 			attribute.String("service", s.name),
 			attribute.String("handler", handler.Name),
+			attribute.String("request_id", r.Header.Get(step.RequestIDHeader)),
 		),
 	)
 	defer span.End()
-	r = r.WithContext(ctx)
+	r = r.WithContext(step.WithRequestID(ctx, r.Header.Get(step.RequestIDHeader)))
 
 	if handler.Response == nil {
 		// No response configured - return empty 200
@@ -610,40 +1045,26 @@ func (s *HTTPService) handleRequest(w http.ResponseWriter, r *http.Request, rout
 		return
 	}
 
-	// Apply latency injection (handler-level overrides service-level)
-	if handler.Timing != nil {
-		// Handler has its own timing config - parse and create injector for it
-		p50, err := service.ParseDuration(handler.Timing.P50)
+	// Apply latency injection (handler-level percentiles override
+	// service-level; a handler block with only ttfb and body_time keeps the
+	// service's latency)
+	if handler.Timing != nil && handler.Timing.HasPercentiles() {
+		// Handler has its own percentiles - parse and create injector for them
+		timing, err := parseTimingConfig(handler.Timing)
 		if err != nil {
-			s.logger.Error("failed to parse handler timing.p50", "handler", handler.Name, "error", err)
+			s.logger.Error("failed to parse handler timing", "handler", handler.Name, "error", err)
 		} else {
-			p90, err := service.ParseDuration(handler.Timing.P90)
-			if err != nil {
-				s.logger.Error("failed to parse handler timing.p90", "handler", handler.Name, "error", err)
-			} else {
-				p99, err := service.ParseDuration(handler.Timing.P99)
-				if err != nil {
-					s.logger.Error("failed to parse handler timing.p99", "handler", handler.Name, "error", err)
-				} else {
-					handlerLatency := service.NewLatencyInjector(service.TimingConfig{
-						P50:      p50,
-						P90:      p90,
-						P99:      p99,
-						Variance: handler.Timing.Variance,
-					})
-					handlerLatency.Inject(r.Context())
-				}
-			}
+			service.NewLatencyInjector(timing).Inject(r.Context())
 		}
-	} else if s.latencyInjector != nil {
+	} else if latency := s.latencyInjector.Load(); latency != nil {
 		// Use service-level timing
-		s.latencyInjector.Inject(r.Context())
+		latency.Inject(r.Context())
 	}
 
 	// Apply error injection (handler-level overrides service-level)
 	if len(handler.Errors) > 0 {
 		// Handler has its own error configs - convert and create injector for them
-		errorConfigs, err := convertErrorConfigs(handler.Errors)
+		errorConfigs, err := convertErrorConfigs(handler.Errors, config.NewEvalContext(s.config.Vars, s.config.Variables))
 		if err != nil {
 			s.logger.Error("failed to convert handler error configs", "handler", handler.Name, "error", err)
 		} else {
@@ -653,23 +1074,23 @@ func (s *HTTPService) handleRequest(w http.ResponseWriter, r *http.Request, rout
 				return
 			}
 		}
-	} else if s.errorInjector != nil {
+	} else if errs := s.errorInjector.Load(); errs != nil {
 		// Use service-level errors
-		if errCfg := s.errorInjector.ShouldInject(); errCfg != nil {
+		if errCfg := errs.ShouldInject(); errCfg != nil {
 			metrics.RecordError(s.name, handler.Name, "injected")
-			s.errorInjector.WriteError(w, errCfg)
+			errs.WriteError(w, errCfg)
 			return
 		}
 	}
 
 	// Apply rate limiting (handler-level overrides service-level)
 	if rl, ok := s.handlerLimiters[handler.Name]; ok {
-		if !rl.Allow() {
+		if !rl.Allow(s.clientIP(r)) {
 			rl.WriteError(w)
 			return
 		}
 	} else if s.rateLimiter != nil {
-		if !s.rateLimiter.Allow() {
+		if !s.rateLimiter.Allow(s.clientIP(r)) {
 			s.rateLimiter.WriteError(w)
 			return
 		}
@@ -684,7 +1105,24 @@ func (s *HTTPService) handleRequest(w http.ResponseWriter, r *http.Request, rout
 
 	// Build evaluation context from request
 	pathParams := ExtractParams(route, r)
-	evalCtx := config.BuildEvalContext(r, pathParams, s.config.Vars)
+	evalCtx := config.BuildEvalContext(r, pathParams, s.config.Vars, s.config.Variables)
+
+	// Run service-level hooks around the handler, even for cached responses
+	if !s.runBeforeHook(w, r, evalCtx, handler.Name) {
+		return
+	}
+	defer s.runAfterHook(w, r, evalCtx, handler.Name)
+
+	// Serve a cached response, skipping steps, while it is within its TTL
+	var cache *responseCache
+	var cacheKey string
+	if c, ok := s.handlerCaches[handler.Name]; ok && r.Method == http.MethodGet {
+		cache, cacheKey = c, c.keyFor(r)
+		if entry, ok := cache.get(cacheKey); ok {
+			entry.write(w)
+			return
+		}
+	}
 
 	// Execute steps if present
 	if len(handler.Steps) > 0 {
@@ -693,64 +1131,39 @@ func (s *HTTPService) handleRequest(w http.ResponseWriter, r *http.Request, rout
 			s.logger.Error("step execution failed", "handler", handler.Name, "error", err)
 			metrics.RecordError(s.name, handler.Name, "step_failed")
 			span.RecordError(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintf(w, `{"error":"step execution failed: %s"}`, err.Error())
+			writeError(w, r, http.StatusInternalServerError, "step execution failed: "+err.Error())
 			return
 		}
 	}
 
-	resp := handler.Response
-
-	// Evaluate response body expression if present
-	var bodyStr string
-	if resp.BodyExpr != nil {
-		value, diags := resp.BodyExpr.Value(evalCtx)
-		if diags.HasErrors() {
-			s.logger.Error("failed to evaluate response body", "handler", handler.Name, "error", diags.Error())
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Header().Set("Content-Type", "application/json")
-			w.Write([]byte(fmt.Sprintf(`{"error":"response evaluation failed: %s"}`, diags.Error())))
-			return
-		}
-
-		// Convert to string
-		bodyStr = value.AsString()
+	status, header, body, err := s.handlerResponses[handler.Name].evaluate(evalCtx, http.StatusOK, w.Header())
+	if err != nil {
+		s.logger.Error("failed to evaluate response", "handler", handler.Name, "error", err)
+		writeError(w, r, http.StatusInternalServerError, "response evaluation failed: "+err.Error())
+		return
 	}
 
-	// Set status code
-	status := http.StatusOK
-	if resp.Status != nil {
-		status = *resp.Status
+	if cache != nil {
+		cache.put(cacheKey, status, header, body)
 	}
 
-	// Evaluate and set headers
-	if resp.HeadersExpr != nil {
-		headersVal, diags := resp.HeadersExpr.Value(evalCtx)
-		if diags.HasErrors() {
-			s.logger.Error("failed to evaluate response headers", "handler", handler.Name, "error", diags.Error())
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Header().Set("Content-Type", "application/json")
-			w.Write([]byte(fmt.Sprintf(`{"error":"header evaluation failed: %s"}`, diags.Error())))
-			return
-		}
-		// Convert to map and set headers (check for null first)
-		if !headersVal.IsNull() {
-			for key, val := range headersVal.AsValueMap() {
-				w.Header().Set(key, val.AsString())
-			}
-		}
+	// Write response, slowed by ttfb and body_time (handler-level overrides
+	// service-level; a handler block with only percentiles keeps the
+	// service's transfer timing)
+	for key, values := range header {
+		w.Header()[key] = values
 	}
-
-	// Set content-type if not already set and body contains JSON
-	if w.Header().Get("Content-Type") == "" && bodyStr != "" {
-		w.Header().Set("Content-Type", "application/json")
+	transfer := s.transfer
+	if t, ok := s.handlerTransfers[handler.Name]; ok {
+		transfer = t
+	}
+	if transfer != nil {
+		transfer.write(r.Context(), w, status, body)
+		return
 	}
-
-	// Write response
 	w.WriteHeader(status)
-	if bodyStr != "" {
-		w.Write([]byte(bodyStr))
+	if len(body) > 0 {
+		w.Write(body)
 	}
 }
 
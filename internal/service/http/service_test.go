@@ -1,20 +1,39 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/jumppad-labs/polymorph/internal/config"
 	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/jumppad-labs/polymorph/internal/metrics"
+	"github.com/jumppad-labs/polymorph/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestNewHTTPService(t *testing.T) {
@@ -68,7 +87,7 @@ func TestHTTPService_StartStop(t *testing.T) {
 	err = svc.Start(ctx)
 	require.NoError(t, err)
 	require.NotNil(t, svc.server)
-	require.NotNil(t, svc.listener)
+	require.Len(t, svc.listeners, 1)
 
 	// Give server time to start
 	time.Sleep(10 * time.Millisecond)
@@ -78,6 +97,480 @@ func TestHTTPService_StartStop(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestHTTPService_ResolvedAddress(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "test",
+		Listen: "127.0.0.1:0",
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+	require.Empty(t, svc.ResolvedAddress())
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	host, port, err := net.SplitHostPort(svc.ResolvedAddress())
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", host)
+	require.NotEqual(t, "0", port)
+	require.Equal(t, svc.listeners[0].Addr().String(), svc.ResolvedAddress())
+	require.Equal(t, "127.0.0.1:0", svc.Address())
+}
+
+func TestHTTPService_MultipleListen(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:        "test",
+		Listen:      "127.0.0.1:0",
+		ExtraListen: []string{"127.0.0.1:0"},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	require.Len(t, svc.listeners, 2)
+	require.Equal(t, svc.listeners[0].Addr().String(), svc.ResolvedAddress())
+
+	// Both addresses serve the same handler
+	for _, ln := range svc.listeners {
+		resp, err := http.Get("http://" + ln.Addr().String() + service.HealthPath)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// Stopping closes every listener
+	require.NoError(t, svc.Stop(ctx))
+	for _, ln := range svc.listeners {
+		_, err := net.Dial("tcp", ln.Addr().String())
+		require.Error(t, err)
+	}
+}
+
+func TestHTTPService_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "api.sock")
+	cfg := &confighttp.Service{
+		Name:   "test",
+		Listen: "unix://" + sock,
+		Handlers: []*confighttp.Handler{
+			{
+				Name:  "hello",
+				Route: "GET /hello",
+				Response: &config.ResponseConfig{
+					BodyExpr: hcl.StaticExpr(cty.StringVal("hello"), hcl.Range{}),
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	require.Equal(t, "unix://"+sock, svc.ResolvedAddress())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	resp, err := client.Get("http://unix/hello")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "hello", string(body))
+
+	// Stopping removes the socket file
+	require.NoError(t, svc.Stop(ctx))
+	_, err = os.Stat(sock)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestHTTPService_SocketOptions(t *testing.T) {
+	noDelay := false
+	cfg := &confighttp.Service{
+		Name:   "test",
+		Listen: "127.0.0.1:0",
+		Socket: &config.SocketConfig{KeepAlive: "30s", NoDelay: &noDelay},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	// Requests are served as usual on connections with the options set
+	resp, err := http.Get("http://" + svc.ResolvedAddress() + service.HealthPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// An invalid option fails the start
+	cfg.Socket.KeepAlive = "often"
+	svc, err = NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+	require.ErrorContains(t, svc.Start(ctx), "invalid keep_alive")
+}
+
+func TestHTTPService_TransferTiming(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	cfg := &confighttp.Service{
+		Name:   "test",
+		Listen: "127.0.0.1:0",
+		Handlers: []*confighttp.Handler{
+			{
+				Name:  "slow",
+				Route: "GET /slow",
+				Timing: &config.TimingConfig{
+					TTFB:     "100ms",
+					BodyTime: "200ms",
+				},
+				Response: &config.ResponseConfig{
+					BodyExpr: hcl.StaticExpr(cty.StringVal(body), hcl.Range{}),
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	start := time.Now()
+	resp, err := http.Get("http://" + svc.ResolvedAddress() + "/slow")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	headers := time.Since(start)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.GreaterOrEqual(t, headers, 100*time.Millisecond)
+	require.Less(t, headers, 250*time.Millisecond)
+
+	got, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, string(got))
+	require.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+
+	// An invalid duration fails construction
+	cfg.Handlers[0].Timing.BodyTime = "slowly"
+	_, err = NewHTTPService(cfg, slog.Default())
+	require.ErrorContains(t, err, "timing.body_time")
+}
+
+func TestHTTPService_HandlerTimingFallback(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  timing {
+    p50  = "100ms"
+    p90  = "100ms"
+    p99  = "100ms"
+    ttfb = "100ms"
+  }
+
+  handle "transfer" {
+    route = "GET /transfer"
+    timing {
+      body_time = "1ms"
+    }
+    response {
+      body = "ok"
+    }
+  }
+
+  handle "latency" {
+    route = "GET /latency"
+    timing {
+      p50 = "50ms"
+      p90 = "50ms"
+      p99 = "50ms"
+    }
+    response {
+      body = "ok"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	elapsed := func(path string) time.Duration {
+		start := time.Now()
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, "ok", rec.Body.String())
+		return time.Since(start)
+	}
+
+	// Only ttfb and body_time: the service's latency still applies, but not
+	// its ttfb
+	d := elapsed("/transfer")
+	require.GreaterOrEqual(t, d, 100*time.Millisecond)
+	require.Less(t, d, 190*time.Millisecond)
+
+	// Only percentiles: the handler's latency, then the service's ttfb
+	d = elapsed("/latency")
+	require.GreaterOrEqual(t, d, 150*time.Millisecond)
+	require.Less(t, d, 240*time.Millisecond)
+}
+
+func TestHTTPService_Throttle(t *testing.T) {
+	body := strings.Repeat("x", 20<<10)
+	cfg := &confighttp.Service{
+		Name:     "test",
+		Listen:   "127.0.0.1:0",
+		Throttle: "1mb/s",
+		Handlers: []*confighttp.Handler{
+			{
+				Name:     "download",
+				Route:    "GET /download",
+				Throttle: "50kb/s",
+				Response: &config.ResponseConfig{
+					BodyExpr: hcl.StaticExpr(cty.StringVal(body), hcl.Range{}),
+				},
+			},
+			{
+				Name:  "fast",
+				Route: "GET /fast",
+				Response: &config.ResponseConfig{
+					BodyExpr: hcl.StaticExpr(cty.StringVal(body), hcl.Range{}),
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	download := func(path string) time.Duration {
+		start := time.Now()
+		resp, err := http.Get("http://" + svc.ResolvedAddress() + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, body, string(got))
+		return time.Since(start)
+	}
+
+	// 20kb at the handler's 50kb/s takes about 400ms
+	elapsed := download("/download")
+	require.GreaterOrEqual(t, elapsed, 350*time.Millisecond)
+	require.Less(t, elapsed, time.Second)
+
+	// Other handlers use the service's 1mb/s, about 20ms
+	require.Less(t, download("/fast"), 200*time.Millisecond)
+
+	// A client that goes away stops the transfer
+	reqCtx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, "http://"+svc.ResolvedAddress()+"/download", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	cancel()
+	_, err = io.ReadAll(resp.Body)
+	require.Error(t, err)
+	resp.Body.Close()
+}
+
+func TestHTTPService_ThrottleStatic(t *testing.T) {
+	body := strings.Repeat("x", 20<<10)
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "big.txt"), []byte(body), 0o644))
+
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:     "test",
+		Listen:   "127.0.0.1:0",
+		Throttle: "50kb/s",
+		Static:   &config.StaticConfig{Root: root},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	// The service's throttle applies to routes without handlers too: 20kb
+	// at 50kb/s takes about 400ms
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/big.txt", nil))
+	require.Equal(t, body, rec.Body.String())
+	require.GreaterOrEqual(t, time.Since(start), 350*time.Millisecond)
+}
+
+func TestHTTPService_PortInUse(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+	addr := taken.Addr().String()
+
+	cfg := &confighttp.Service{
+		Name:        "test",
+		Listen:      "127.0.0.1:0",
+		ExtraListen: []string{addr},
+	}
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	err = svc.Start(context.Background())
+	require.ErrorIs(t, err, service.ErrAddressInUse)
+	require.ErrorContains(t, err, "can't listen on "+addr)
+
+	// The registry names the service alongside the address
+	registry := service.NewRegistry(nil)
+	registry.Register(svc)
+	err = registry.Start(context.Background())
+	require.ErrorContains(t, err, `failed to start service "test": can't listen on `+addr+`: address already in use`)
+}
+
+func TestHTTPService_TLSConfigError(t *testing.T) {
+	// Find a free port to listen on
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := free.Addr().String()
+	free.Close()
+
+	cfg := &confighttp.Service{
+		Name:   "test",
+		Listen: addr,
+		TLS: &config.TLSConfig{
+			Cert: filepath.Join(t.TempDir(), "missing.crt"),
+			Key:  filepath.Join(t.TempDir(), "missing.key"),
+		},
+	}
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	err = svc.Start(context.Background())
+	require.ErrorContains(t, err, "failed to configure TLS")
+	require.ErrorContains(t, err, "missing.crt")
+
+	// The listener opened before TLS failed was closed, freeing the port
+	ln, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	ln.Close()
+}
+
+func TestRegistry_ShutdownDrainDelay(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "hello" {
+    route = "GET /hello"
+    response {
+      body = "hello"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	registry := service.NewRegistry(nil)
+	registry.Register(svc)
+	require.NoError(t, registry.Start(context.Background()))
+	baseURL := "http://" + svc.ResolvedAddress()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(baseURL + service.HealthPath)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- registry.Shutdown(500*time.Millisecond, time.Second) }()
+
+	// During the drain delay health fails but requests are still served
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(baseURL + service.HealthPath)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+	resp, err := http.Get(baseURL + "/hello")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "hello", string(body))
+
+	// Once it has passed the services stop
+	require.NoError(t, <-done)
+	_, err = http.Get(baseURL + "/hello")
+	require.Error(t, err)
+}
+
+func TestHTTPService_Health(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "test",
+		Listen: "127.0.0.1:0",
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	readiness := &service.Readiness{}
+	svc.SetReadiness(readiness)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", service.HealthPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	readiness.MarkReady()
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", service.HealthPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"ready"}`, rec.Body.String())
+}
+
+func TestHTTPService_HealthRoute(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "health" {
+    route = "GET /healthz"
+    response {
+      body = jsonencode({ status = "custom" })
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	svc.SetReadiness(&service.Readiness{})
+
+	// A route on the health path takes it over from readiness
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", service.HealthPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"custom"}`, rec.Body.String())
+}
+
 func TestHTTPService_ServeHTTP(t *testing.T) {
 	// Helper to create expression from string
 	makeExpr := func(s string) hcl.Expression {
@@ -122,7 +615,7 @@ func TestHTTPService_ServeHTTP(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// Get the actual listen address
-	addr := svc.listener.Addr().String()
+	addr := svc.listeners[0].Addr().String()
 	baseURL := "http://" + addr
 
 	// Test hello endpoint
@@ -167,13 +660,43 @@ func TestHTTPService_ServeHTTP(t *testing.T) {
 		require.JSONEq(t, `{"error":"not found"}`, string(body))
 	})
 
+	t.Run("HEAD /hello returns GET headers without a body", func(t *testing.T) {
+		resp, err := http.Head(baseURL + "/hello")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, "test-value", resp.Header.Get("X-Custom-Header"))
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Empty(t, body)
+	})
+
+	t.Run("OPTIONS /hello lists allowed methods", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodOptions, baseURL+"/hello", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "GET, HEAD, OPTIONS", resp.Header.Get("Allow"))
+	})
+
 	// Test wrong method
-	t.Run("POST /hello returns 404", func(t *testing.T) {
+	t.Run("POST /hello returns 405", func(t *testing.T) {
 		resp, err := http.Post(baseURL+"/hello", "application/json", nil)
 		require.NoError(t, err)
 		defer resp.Body.Close()
 
-		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		require.Equal(t, "GET, HEAD, OPTIONS", resp.Header.Get("Allow"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"error":"method not allowed"}`, string(body))
 	})
 }
 
@@ -200,7 +723,7 @@ func TestHTTPService_EmptyResponse(t *testing.T) {
 
 	time.Sleep(10 * time.Millisecond)
 
-	addr := svc.listener.Addr().String()
+	addr := svc.listeners[0].Addr().String()
 	resp, err := http.Get("http://" + addr + "/empty")
 	require.NoError(t, err)
 	defer resp.Body.Close()
@@ -237,7 +760,7 @@ func TestHTTPService_StaticFiles(t *testing.T) {
 
 	time.Sleep(10 * time.Millisecond)
 
-	baseURL := "http://" + svc.listener.Addr().String()
+	baseURL := "http://" + svc.listeners[0].Addr().String()
 
 	t.Run("serves file at root", func(t *testing.T) {
 		resp, err := http.Get(baseURL + "/index.html")
@@ -261,6 +784,28 @@ func TestHTTPService_StaticFiles(t *testing.T) {
 		require.Equal(t, "body{}", string(body))
 	})
 
+	t.Run("honours If-Modified-Since", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/css/style.css")
+		require.NoError(t, err)
+		resp.Body.Close()
+		lastModified := resp.Header.Get("Last-Modified")
+		require.NotEmpty(t, lastModified)
+
+		req, err := http.NewRequest("GET", baseURL+"/css/style.css", nil)
+		require.NoError(t, err)
+		req.Header.Set("If-Modified-Since", lastModified)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+		req.Header.Set("If-Modified-Since", "Mon, 02 Jan 2006 15:04:05 GMT")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
 	t.Run("404 for missing file", func(t *testing.T) {
 		resp, err := http.Get(baseURL + "/nope.txt")
 		require.NoError(t, err)
@@ -293,7 +838,7 @@ func TestHTTPService_StaticFilesWithPrefix(t *testing.T) {
 
 	time.Sleep(10 * time.Millisecond)
 
-	baseURL := "http://" + svc.listener.Addr().String()
+	baseURL := "http://" + svc.listeners[0].Addr().String()
 
 	t.Run("serves file under prefix", func(t *testing.T) {
 		resp, err := http.Get(baseURL + "/assets/app.js")
@@ -314,3 +859,2968 @@ func TestHTTPService_StaticFilesWithPrefix(t *testing.T) {
 		require.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
 }
+
+func TestHTTPService_StaticOptions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<div id=app></div>"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "assets"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "assets", "app.js"), []byte("render()"), 0644))
+
+	listing := false
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "spa",
+		Listen: "127.0.0.1:0",
+		Static: &config.StaticConfig{
+			Root:             dir,
+			SPA:              true,
+			CacheControl:     "max-age=3600",
+			DirectoryListing: &listing,
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	t.Run("serves files with cache control", func(t *testing.T) {
+		rec := get("/assets/app.js")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "render()", rec.Body.String())
+		require.Equal(t, "max-age=3600", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("falls back to index for client routes", func(t *testing.T) {
+		rec := get("/users/42/settings")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "<div id=app></div>", rec.Body.String())
+		require.Equal(t, "max-age=3600", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("directory listing disabled", func(t *testing.T) {
+		rec := get("/assets/")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.NotContains(t, rec.Body.String(), "app.js")
+	})
+}
+
+func TestHTTPService_StaticCustomIndex(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "home.html"), []byte("docs home"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "files"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "files", "a.txt"), []byte("a"), 0644))
+
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "docs",
+		Listen: "127.0.0.1:0",
+		Static: &config.StaticConfig{Root: dir, Index: "home.html"},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/docs/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "docs home", rec.Body.String())
+
+	// Directories without an index are listed by default, and SPA fallback is off
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/files/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "a.txt")
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// A directory without its trailing slash is redirected to it
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/docs?v=2", nil))
+	require.Equal(t, http.StatusMovedPermanently, rec.Code)
+	require.Equal(t, "docs/?v=2", rec.Header().Get("Location"))
+}
+
+func TestHTTPService_StaticDirectoryRedirectWithPrefix(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "css"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "css", "index.html"), []byte("styles"), 0644))
+
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "assets",
+		Listen: "127.0.0.1:0",
+		Static: &config.StaticConfig{Route: "/assets", Root: dir},
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	defer svc.Stop(context.Background())
+
+	// The client follows the redirect back under the prefix
+	resp, err := http.Get("http://" + svc.ResolvedAddress() + "/assets/css")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "/assets/css/", resp.Request.URL.Path)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "styles", string(body))
+}
+
+func TestHTTPService_CorrelatedRefs(t *testing.T) {
+	seed := int64(42)
+	cfg := &confighttp.Service{
+		Name:   "refs-test",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			// Declared before the resource it references
+			{
+				Name: "order",
+				Rows: 50,
+				Seed: &seed,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "user_id", Type: "ref", Resource: "user"},
+				},
+			},
+			{
+				Name: "user",
+				Rows: 5,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	users, err := svc.resourceStore.List("user")
+	require.NoError(t, err)
+	require.Len(t, users, 5)
+
+	userIDs := make(map[string]bool, len(users))
+	for _, user := range users {
+		userIDs[user["id"].(string)] = true
+	}
+
+	orders, err := svc.resourceStore.List("order")
+	require.NoError(t, err)
+	require.Len(t, orders, 50)
+
+	for _, order := range orders {
+		userID, ok := order["user_id"].(string)
+		require.True(t, ok)
+		require.True(t, userIDs[userID], "order references unknown user %q", userID)
+	}
+}
+
+func TestHTTPService_DeterministicRefs(t *testing.T) {
+	userSeed, orderSeed := int64(1), int64(2)
+	newService := func() *HTTPService {
+		svc, err := NewHTTPService(&confighttp.Service{
+			Name:   "refs-test",
+			Listen: "127.0.0.1:0",
+			Resources: []*config.ResourceConfig{
+				{
+					Name: "user",
+					Rows: 10,
+					Seed: &userSeed,
+					Fields: []*config.FieldConfig{
+						{Name: "id", Type: "uuid"},
+					},
+				},
+				{
+					Name: "order",
+					Rows: 30,
+					Seed: &orderSeed,
+					Fields: []*config.FieldConfig{
+						{Name: "id", Type: "sequence"},
+						{Name: "user_id", Type: "ref", Resource: "user", Deterministic: true},
+					},
+				},
+			},
+		}, slog.Default())
+		require.NoError(t, err)
+		return svc
+	}
+
+	owners := func(svc *HTTPService) map[any]any {
+		orders, err := svc.resourceStore.List("order")
+		require.NoError(t, err)
+		byOrder := make(map[any]any, len(orders))
+		for _, order := range orders {
+			byOrder[order["id"]] = order["user_id"]
+		}
+		return byOrder
+	}
+
+	// Independent generations relate the same orders to the same users
+	first := newService()
+	want := owners(first)
+	require.Len(t, want, 30)
+	require.Equal(t, want, owners(newService()))
+
+	// Regenerating both with the same seeds keeps every order's user
+	for _, rh := range first.resourceHandlers {
+		_, err := rh.Reseed(nil, nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, want, owners(first))
+}
+
+func TestHTTPService_StreamList(t *testing.T) {
+	seed := int64(7)
+	cfg := &confighttp.Service{
+		Name:   "stream-test",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:   "event",
+				Rows:   2000,
+				Seed:   &seed,
+				Stream: true,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	defer svc.Stop(context.Background())
+
+	resp, err := http.Get("http://" + svc.ResolvedAddress() + "/events?fields=id")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+	require.Equal(t, int64(-1), resp.ContentLength)
+
+	var list struct {
+		Data  []map[string]any `json:"data"`
+		Total int              `json:"total"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 2000, list.Total)
+	require.Len(t, list.Data, 2000)
+
+	// Matches the buffered list, item for item
+	items, err := svc.resourceStore.List("event")
+	require.NoError(t, err)
+	for i, item := range items {
+		require.Equal(t, map[string]any{"id": item["id"]}, list.Data[i])
+	}
+}
+
+func TestHTTPService_StreamListAbortsOnError(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "stream-test",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:   "event",
+				Rows:   500,
+				Stream: true,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "score", Type: "decimal"},
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+	// NaN can't be encoded as JSON, so the stream fails after the status
+	require.NoError(t, svc.resourceStore.Insert("event", map[string]any{"id": "bad", "score": math.NaN()}))
+	require.NoError(t, svc.Start(context.Background()))
+	defer svc.Stop(context.Background())
+
+	resp, err := http.Get("http://" + svc.ResolvedAddress() + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = io.ReadAll(resp.Body)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestHTTPService_UnknownRefResource(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "refs-test",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "order",
+				Rows: 1,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "user_id", Type: "ref", Resource: "user"},
+				},
+			},
+		},
+	}
+
+	_, err := NewHTTPService(cfg, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown resource")
+}
+
+func TestHTTPService_ResourceRowRange(t *testing.T) {
+	seed := int64(99)
+	rowsMin, rowsMax := 10, 20
+	newCfg := func() *confighttp.Service {
+		return &confighttp.Service{
+			Name:   "rows-test",
+			Listen: "127.0.0.1:0",
+			Resources: []*config.ResourceConfig{
+				{
+					Name:    "user",
+					Rows:    100,
+					RowsMin: &rowsMin,
+					RowsMax: &rowsMax,
+					Seed:    &seed,
+					Fields: []*config.FieldConfig{
+						{Name: "id", Type: "uuid"},
+						{Name: "name", Type: "name"},
+					},
+				},
+			},
+		}
+	}
+
+	counts := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		svc, err := NewHTTPService(newCfg(), slog.Default())
+		require.NoError(t, err)
+
+		users, err := svc.resourceStore.List("user")
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(users), rowsMin)
+		require.LessOrEqual(t, len(users), rowsMax)
+		counts = append(counts, len(users))
+	}
+
+	// Same seed yields the same row count
+	require.Equal(t, counts[0], counts[1])
+}
+
+func TestHTTPService_CORSMaxAgeAndExposedHeaders(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "cors-test",
+		Listen: "127.0.0.1:0",
+		CORS: &config.CORSConfig{
+			AllowedOrigins: []string{"https://example.com"},
+			ExposedHeaders: []string{"X-Request-Id", "X-Total-Count"},
+			MaxAge:         "10m",
+		},
+		Handlers: []*confighttp.Handler{
+			{
+				Name:  "hello",
+				Route: "GET /hello",
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	baseURL := "http://" + svc.listeners[0].Addr().String()
+
+	t.Run("preflight carries max age", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodOptions, baseURL+"/hello", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "600", resp.Header.Get("Access-Control-Max-Age"))
+		require.Empty(t, resp.Header.Get("Access-Control-Expose-Headers"))
+	})
+
+	t.Run("actual response carries exposed headers", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, baseURL+"/hello", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", "https://example.com")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "X-Request-Id, X-Total-Count", resp.Header.Get("Access-Control-Expose-Headers"))
+		require.Empty(t, resp.Header.Get("Access-Control-Max-Age"))
+	})
+}
+
+func TestNewHTTPService_InvalidCORSMaxAge(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "cors-test",
+		Listen: "127.0.0.1:0",
+		CORS: &config.CORSConfig{
+			AllowedOrigins: []string{"*"},
+			MaxAge:         "soon",
+		},
+	}
+
+	_, err := NewHTTPService(cfg, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cors.max_age")
+}
+
+func TestHTTPService_CORSPreflightMethods(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedMethods []string
+		requested      string
+		allowed        bool
+	}{
+		{name: "allowed method", allowedMethods: []string{"GET", "POST"}, requested: "POST", allowed: true},
+		{name: "disallowed method", allowedMethods: []string{"GET", "POST"}, requested: "DELETE", allowed: false},
+		{name: "wildcard allows any method", allowedMethods: []string{"*"}, requested: "PATCH", allowed: true},
+		{name: "defaults allow PATCH", requested: "PATCH", allowed: true},
+		{name: "defaults reject unlisted method", requested: "TRACE", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &confighttp.Service{
+				Name:   "cors-test",
+				Listen: "127.0.0.1:0",
+				CORS: &config.CORSConfig{
+					AllowedOrigins: []string{"https://example.com"},
+					AllowedMethods: tt.allowedMethods,
+				},
+			}
+
+			svc, err := NewHTTPService(cfg, slog.Default())
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			require.NoError(t, svc.Start(ctx))
+			defer svc.Stop(ctx)
+
+			req, err := http.NewRequest(http.MethodOptions, "http://"+svc.listeners[0].Addr().String()+"/anything", nil)
+			require.NoError(t, err)
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("Access-Control-Request-Method", tt.requested)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusNoContent, resp.StatusCode)
+			if tt.allowed {
+				require.Equal(t, "https://example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+				require.NotEmpty(t, resp.Header.Get("Access-Control-Allow-Methods"))
+				if tt.allowedMethods == nil {
+					require.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), tt.requested)
+				}
+			} else {
+				require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+				require.Empty(t, resp.Header.Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
+
+func TestHTTPService_CORSOriginPatterns(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "cors-test",
+		Listen: "127.0.0.1:0",
+		CORS: &config.CORSConfig{
+			AllowedOrigins: []string{"https://example.com", `~^https://pr-\d+\.example\.com$`},
+		},
+		Handlers: []*confighttp.Handler{
+			{
+				Name:  "hello",
+				Route: "GET /hello",
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	baseURL := "http://" + svc.listeners[0].Addr().String()
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://example.com", allowed: true},
+		{origin: "https://pr-123.example.com", allowed: true},
+		{origin: "https://pr-abc.example.com", allowed: false},
+		{origin: "https://pr-123.example.com.evil.io", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, baseURL+"/hello", nil)
+			require.NoError(t, err)
+			req.Header.Set("Origin", tt.origin)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			if tt.allowed {
+				require.Equal(t, tt.origin, resp.Header.Get("Access-Control-Allow-Origin"))
+			} else {
+				require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestNewHTTPService_InvalidCORSOriginPattern(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "cors-test",
+		Listen: "127.0.0.1:0",
+		CORS: &config.CORSConfig{
+			AllowedOrigins: []string{"~https://(unclosed"},
+		},
+	}
+
+	_, err := NewHTTPService(cfg, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid cors origin pattern")
+}
+
+func TestHTTPService_StepResultsInResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/users/42", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "users")
+		w.Write([]byte(`{"id":"42","name":"Ada","profile":{"plan":"pro"}}`))
+	}))
+	defer upstream.Close()
+
+	cfg, err := parser.Parse([]byte(fmt.Sprintf(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  handle "dashboard" {
+    route = "GET /dashboard/:id"
+
+    step "user" {
+      http {
+        url = "%s/users/${request.params.id}"
+      }
+    }
+
+    response {
+      body = jsonencode({
+        name   = step.user.body.name
+        plan   = step.user.body.profile.plan
+        status = step.user.status
+        source = step.user.headers["X-Upstream"]
+      })
+    }
+  }
+}
+`, upstream.URL)), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard/42", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"name":"Ada","plan":"pro","status":200,"source":"users"}`, rec.Body.String())
+}
+
+func TestHTTPService_StepFailure(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  handle "dashboard" {
+    route = "GET /dashboard"
+
+    step "user" {
+      http {
+        url = "http://127.0.0.1:1/unreachable"
+      }
+    }
+
+    response {
+      body = jsonencode(step.user.body)
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Contains(t, body["error"], `step "user" failed`)
+}
+
+func TestHTTPService_StepRequestID(t *testing.T) {
+	var mu sync.Mutex
+	var upstreamIDs []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		upstreamIDs = append(upstreamIDs, r.Header.Get("X-Request-Id"))
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	cfg, err := parser.Parse([]byte(fmt.Sprintf(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  handle "dashboard" {
+    route = "GET /dashboard"
+
+    step "user" {
+      http {
+        url = "%[1]s/user"
+      }
+    }
+
+    step "orders" {
+      http {
+        url = "%[1]s/orders"
+      }
+    }
+
+    response {
+      body = "ok"
+    }
+  }
+}
+`, upstream.URL)), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	// An incoming id reaches every step's upstream
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []string{"req-123", "req-123"}, upstreamIDs)
+
+	// A generated id is the one echoed back to the client
+	upstreamIDs = nil
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	id := rec.Header().Get("X-Request-Id")
+	require.NotEmpty(t, id)
+	require.Equal(t, []string{id, id}, upstreamIDs)
+}
+
+func TestHTTPService_ErrorFormat(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  error_format = jsonencode({
+    errors = [{ message = error.message, code = error.status, path = request.path }]
+  })
+
+  resource "user" {
+    field "id" { type = "uuid" }
+  }
+
+  handle "dashboard" {
+    route = "GET /dashboard"
+
+    step "user" {
+      http {
+        url = "http://127.0.0.1:1/unreachable"
+      }
+    }
+
+    response {
+      body = jsonencode(step.user.body)
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	type envelope struct {
+		Errors []struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+			Path    string `json:"path"`
+		} `json:"errors"`
+	}
+	get := func(path string) (int, envelope) {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var body envelope
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+		require.Len(t, body.Errors, 1)
+		return rec.Code, body
+	}
+
+	// A failed step
+	status, body := get("/dashboard")
+	require.Equal(t, http.StatusInternalServerError, status)
+	require.Contains(t, body.Errors[0].Message, `step "user" failed`)
+	require.Equal(t, 500, body.Errors[0].Code)
+
+	// An unmatched route
+	status, body = get("/missing")
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "not found", body.Errors[0].Message)
+	require.Equal(t, 404, body.Errors[0].Code)
+	require.Equal(t, "/missing", body.Errors[0].Path)
+
+	// A missing resource item
+	status, body = get("/users/nope")
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "/users/nope", body.Errors[0].Path)
+}
+
+func TestHTTPService_ResponseHeaders(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  response_headers = {
+    "Server"                 = "polymorph"
+    "X-Content-Type-Options" = "nosniff"
+  }
+
+  resource "user" {
+    rows = 1
+    field "id" { type = "uuid" }
+  }
+
+  handle "hello" {
+    route = "GET /hello"
+    response {
+      body = jsonencode({ request_id = request.id })
+    }
+  }
+
+  handle "custom" {
+    route = "GET /custom"
+    response {
+      headers = { "Server" = "custom" }
+      body    = "ok"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		maps.Copy(req.Header, header)
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Handler, resource and 404 responses all carry the headers and an id
+	for _, path := range []string{"/hello", "/users", "/missing"} {
+		rec := get(path, nil)
+		require.Equal(t, "polymorph", rec.Header().Get("Server"), path)
+		require.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"), path)
+		_, err := uuid.Parse(rec.Header().Get("X-Request-Id"))
+		require.NoError(t, err, path)
+	}
+
+	// A generated id is the one handlers see as request.id
+	rec := get("/hello", nil)
+	require.JSONEq(t, fmt.Sprintf(`{"request_id":%q}`, rec.Header().Get("X-Request-Id")), rec.Body.String())
+
+	// An incoming id is kept
+	rec = get("/hello", http.Header{"X-Request-Id": {"req-123"}})
+	require.Equal(t, "req-123", rec.Header().Get("X-Request-Id"))
+	require.JSONEq(t, `{"request_id":"req-123"}`, rec.Body.String())
+
+	// Handler headers take precedence
+	require.Equal(t, "custom", get("/custom", nil).Header().Get("Server"))
+}
+
+func TestNewHTTPService_InvalidResponseHeaders(t *testing.T) {
+	for src, want := range map[string]string{
+		`["x"]`:             "response_headers must be a map of strings, got tuple",
+		`{ "X-Count" = 5 }`: `response_headers "X-Count" must be a string`,
+	} {
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.Pos{Line: 1, Column: 1})
+		require.False(t, diags.HasErrors())
+
+		_, err := NewHTTPService(&confighttp.Service{
+			Name:            "api",
+			Listen:          "127.0.0.1:0",
+			ResponseHeaders: expr,
+		}, slog.Default())
+		require.ErrorContains(t, err, want, src)
+	}
+}
+
+func TestHTTPService_ResponseCache(t *testing.T) {
+	var upstreamCalls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"plan":"pro"}`))
+	}))
+	defer upstream.Close()
+
+	cfg, err := parser.Parse([]byte(fmt.Sprintf(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "report" {
+    route = "GET /report"
+
+    cache {
+      ttl = "200ms"
+      key = "path+query"
+    }
+
+    step "account" {
+      http {
+        url = "%s/account"
+      }
+    }
+
+    response {
+      body = jsonencode({ id = uuid(), plan = step.account.body.plan })
+    }
+  }
+}
+`, upstream.URL)), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	first := get("/report?a=1&b=2")
+	require.Empty(t, first.Header().Get("Age"))
+
+	// Within the TTL the cached body is returned without running steps,
+	// regardless of query parameter order
+	second := get("/report?b=2&a=1")
+	require.Equal(t, first.Body.String(), second.Body.String())
+	require.Equal(t, "0", second.Header().Get("Age"))
+	require.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	require.Equal(t, int32(1), upstreamCalls.Load())
+
+	// A different query is a different cache entry
+	other := get("/report?a=3")
+	require.NotEqual(t, first.Body.String(), other.Body.String())
+
+	// After the TTL the response is recomputed
+	time.Sleep(250 * time.Millisecond)
+	third := get("/report?a=1&b=2")
+	require.NotEqual(t, first.Body.String(), third.Body.String())
+	require.Empty(t, third.Header().Get("Age"))
+	require.Equal(t, int32(3), upstreamCalls.Load())
+}
+
+func TestResponseCache_CopiesHeaders(t *testing.T) {
+	cache, err := newResponseCache(&config.CacheConfig{TTL: "1m"})
+	require.NoError(t, err)
+
+	header := http.Header{"X-Plan": {"pro"}}
+	cache.put("/report", http.StatusOK, header, []byte("{}"))
+
+	// Changing the header after it is stored doesn't change the entry
+	header["X-Plan"][0] = "free"
+	header.Set("X-Extra", "1")
+
+	entry, ok := cache.get("/report")
+	require.True(t, ok)
+	rec := httptest.NewRecorder()
+	entry.write(rec)
+	require.Equal(t, "pro", rec.Header().Get("X-Plan"))
+	require.Empty(t, rec.Header().Get("X-Extra"))
+
+	// Nor does changing a replayed response
+	rec.Header()["X-Plan"][0] = "free"
+	rec = httptest.NewRecorder()
+	entry.write(rec)
+	require.Equal(t, "pro", rec.Header().Get("X-Plan"))
+}
+
+func TestNewHTTPService_InvalidCache(t *testing.T) {
+	tests := map[string]struct {
+		cache *config.CacheConfig
+		err   string
+	}{
+		"bad ttl":  {cache: &config.CacheConfig{TTL: "soon"}, err: "failed to parse cache.ttl"},
+		"zero ttl": {cache: &config.CacheConfig{TTL: "0s"}, err: "cache.ttl must be positive"},
+		"bad key":  {cache: &config.CacheConfig{TTL: "1s", Key: "path+cookie"}, err: `invalid cache.key part "cookie"`},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &confighttp.Service{
+				Name:   "test",
+				Listen: "127.0.0.1:0",
+				Handlers: []*confighttp.Handler{
+					{Name: "report", Route: "GET /report", Cache: tt.cache},
+				},
+			}
+			_, err := NewHTTPService(cfg, slog.Default())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestHTTPService_Fallback(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", "real")
+		w.Header().Set("X-Host", r.Host)
+		w.Write([]byte("real " + r.URL.Path))
+	}))
+	defer backend.Close()
+
+	// The real service's address is the stub backend, so service.real.url reaches it
+	cfg, err := parser.Parse([]byte(fmt.Sprintf(`
+service "http" "real" {
+  listen = "%s"
+}
+
+service "http" "mock" {
+  listen = "127.0.0.1:0"
+
+  fallback {
+    target = service.real.url
+  }
+
+  handle "users" {
+    route = "GET /users"
+    response {
+      body = jsonencode({ source = "mock" })
+    }
+  }
+}
+`, backend.Listener.Addr().String())), "test.hcl")
+	require.NoError(t, err)
+
+	mockCfg := cfg.Services[1].(*confighttp.Service)
+	require.Equal(t, []string{"real"}, mockCfg.GetInferredUpstreams())
+
+	svc, err := NewHTTPService(mockCfg, slog.Default())
+	require.NoError(t, err)
+
+	// Defined routes return the mock
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"source":"mock"}`, rec.Body.String())
+
+	// Undefined routes are proxied to the backend
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/orders/1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "real", rec.Header().Get("X-Backend"))
+	require.Equal(t, "real /orders/1", rec.Body.String())
+	// with the backend's Host rather than the client's
+	require.Equal(t, backend.Listener.Addr().String(), rec.Header().Get("X-Host"))
+
+	// Other methods on a mocked path reach the backend rather than a 405
+	for _, method := range []string{"POST", "OPTIONS"} {
+		rec = httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest(method, "/users", nil))
+		require.Equal(t, http.StatusOK, rec.Code, method)
+		require.Equal(t, "real /users", rec.Body.String(), method)
+		require.Empty(t, rec.Header().Get("Allow"), method)
+	}
+
+	// So is the health path, rather than answering for the backend
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", service.HealthPath, nil))
+	require.Equal(t, "real /healthz", rec.Body.String())
+}
+
+func TestHTTPService_RecordReplay(t *testing.T) {
+	var upstreamCalls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "real")
+		w.Header().Set("X-Host", r.Host)
+		fmt.Fprintf(w, `{"method":%q,"path":%q,"body":%q}`, r.Method, r.URL.Path, body)
+	}))
+
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	newService := func(mode string) *HTTPService {
+		svc, err := NewHTTPService(&confighttp.Service{
+			Name:   "api",
+			Listen: "127.0.0.1:0",
+			Record: &config.RecordConfig{
+				TargetExpr: hcl.StaticExpr(cty.StringVal(upstream.URL), hcl.Range{}),
+				Dir:        dir,
+				Mode:       mode,
+			},
+		}, slog.Default())
+		require.NoError(t, err)
+		return svc
+	}
+
+	do := func(svc *HTTPService, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	// First run: requests are proxied and recorded
+	svc := newService("")
+	first := do(svc, "GET", "/users/1", "")
+	require.Equal(t, http.StatusOK, first.Code)
+	require.JSONEq(t, `{"method":"GET","path":"/users/1","body":""}`, first.Body.String())
+	require.Equal(t, strings.TrimPrefix(upstream.URL, "http://"), first.Header().Get("X-Host"))
+	do(svc, "POST", "/users", `{"name":"a"}`)
+	do(svc, "POST", "/users", `{"name":"b"}`)
+	require.Equal(t, int32(3), upstreamCalls.Load())
+
+	// Requests differing only in body get their own fixtures
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// Second run, offline: fixtures are replayed without the network
+	upstream.Close()
+	svc = newService("")
+
+	replayed := do(svc, "GET", "/users/1", "")
+	require.Equal(t, http.StatusOK, replayed.Code)
+	require.Equal(t, first.Body.String(), replayed.Body.String())
+	require.Equal(t, "real", replayed.Header().Get("X-Upstream"))
+
+	replayed = do(svc, "POST", "/users", `{"name":"b"}`)
+	require.JSONEq(t, `{"method":"POST","path":"/users","body":"{\"name\":\"b\"}"}`, replayed.Body.String())
+
+	// Replay mode never proxies a request it hasn't recorded
+	missing := do(newService("replay"), "GET", "/users/2", "")
+	require.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestHTTPService_ResourceIntegers(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "user",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "visits", Type: "int"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	// 2^53 + 1 can't be represented as a float64
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("POST", "/users", strings.NewReader(`{"id":"u1","visits":9007199254740993,"score":42,"ratio":0.5}`)))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/users/u1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"visits":9007199254740993`)
+	require.Contains(t, rec.Body.String(), `"score":42,`)
+	require.Contains(t, rec.Body.String(), `"ratio":0.5`)
+
+	// String-encoded numbers are stored as the field's type
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("PUT", "/users/u1", strings.NewReader(`{"visits":"12"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"id":"u1","visits":12}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("POST", "/users", strings.NewReader(`{"id":"u2","visits":"lots"}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{"error":"invalid value for field visits: expected int, got string"}`, rec.Body.String())
+}
+
+func TestHTTPService_SoftDelete(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:       "user",
+				SoftDelete: true,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	require.Equal(t, http.StatusCreated, do("POST", "/users", `{"id":"u1","name":"Ada"}`).Code)
+	require.Equal(t, http.StatusCreated, do("POST", "/users", `{"id":"u2","name":"Grace"}`).Code)
+	require.Equal(t, http.StatusNoContent, do("DELETE", "/users/u1", "").Code)
+	require.Equal(t, http.StatusNotFound, do("DELETE", "/users/u1", "").Code)
+
+	var list struct {
+		Data  []map[string]any `json:"data"`
+		Total int              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(do("GET", "/users", "").Body.Bytes(), &list))
+	require.Equal(t, 1, list.Total)
+	require.Equal(t, "u2", list.Data[0]["id"])
+	require.Equal(t, http.StatusNotFound, do("GET", "/users/u1", "").Code)
+
+	require.NoError(t, json.Unmarshal(do("GET", "/users?include_deleted=true", "").Body.Bytes(), &list))
+	require.Equal(t, 2, list.Total)
+
+	rec := do("GET", "/users/u1?include_deleted=true", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var user map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	require.Equal(t, "Ada", user["name"])
+	require.NotEmpty(t, user["deleted_at"])
+}
+
+func TestHTTPService_ResourceIfModifiedSince(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "user",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+					{Name: "updated_at", Type: "datetime"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("POST", "/users", `{"id":"u1","name":"Ada","updated_at":"2026-03-01T12:00:00Z"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = do("GET", "/users/u1", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", rec.Header().Get("Last-Modified"))
+
+	// Unchanged since the client's copy
+	rec = do("GET", "/users/u1", "", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"})
+	require.Equal(t, http.StatusNotModified, rec.Code)
+	require.Empty(t, rec.Body.String())
+
+	// Changed since the client's copy
+	rec = do("GET", "/users/u1", "", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 11:00:00 GMT"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "Ada")
+
+	rec = do("PUT", "/users/u1", `{"name":"Ada Lovelace","updated_at":"2026-03-02T09:30:00Z"}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = do("GET", "/users/u1", "", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "Ada Lovelace")
+	require.Equal(t, "Mon, 02 Mar 2026 09:30:00 GMT", rec.Header().Get("Last-Modified"))
+}
+
+func TestHTTPService_ResourceIDStrategy(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:       "user",
+				IDStrategy: "uuid",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+			{
+				Name:       "order",
+				IDStrategy: "sequence",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "int"},
+					{Name: "total", Type: "decimal"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	create := func(path, body string) map[string]any {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var item map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &item))
+		return item
+	}
+
+	// A generated id is returned and the item can be read back with it
+	user := create("/users", `{"name":"Ada"}`)
+	id, ok := user["id"].(string)
+	require.True(t, ok)
+	_, err = uuid.Parse(id)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/users/"+id, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// A client-supplied id is kept
+	require.Equal(t, "u1", create("/users", `{"id":"u1","name":"Grace"}`)["id"])
+
+	// Sequence ids skip those already taken
+	require.Equal(t, float64(1), create("/orders", `{"total":10}`)["id"])
+	require.Equal(t, float64(2), create("/orders", `{"id":2,"total":20}`)["id"])
+	require.Equal(t, float64(3), create("/orders", `{"total":30}`)["id"])
+
+	// and count on from a larger client-supplied id
+	require.Equal(t, float64(10), create("/orders", `{"id":10,"total":40}`)["id"])
+	require.Equal(t, float64(11), create("/orders", `{"total":50}`)["id"])
+}
+
+func TestHTTPService_ResourceIDStrategyAfterGeneratedRows(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:       "order",
+				Rows:       1500,
+				IDStrategy: "sequence",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "sequence"},
+					{Name: "total", Type: "decimal"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	// The sequence starts after the largest generated id
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", strings.NewReader(`{"total":10}`)))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var item map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &item))
+	require.Equal(t, float64(1501), item["id"])
+}
+
+func TestHTTPService_ResourceJSONField(t *testing.T) {
+	depth, breadth := 1, 2
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "event",
+				Rows: 3,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "payload", Type: "json", Depth: &depth, Breadth: &breadth},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 3)
+
+	// Payloads are native objects of scalars, not JSON strings
+	for _, item := range list.Data {
+		payload, ok := item["payload"].(map[string]any)
+		require.True(t, ok, item["payload"])
+		require.Len(t, payload, breadth)
+		for _, v := range payload {
+			switch v.(type) {
+			case map[string]any, []any:
+				t.Fatalf("depth 1 payload nests %v", v)
+			}
+		}
+	}
+}
+
+func TestHTTPService_ResourceMoneyField(t *testing.T) {
+	min, max := 1.0, 100.0
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "product",
+				Rows: 5,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "price", Type: "money", Min: &min, Max: &max},
+					{Name: "label", Type: "money", Currency: "EUR", Formatted: true},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/products", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	for _, item := range list.Data {
+		price := item["price"].(float64)
+		require.GreaterOrEqual(t, price, min)
+		require.LessOrEqual(t, price, max)
+		require.Regexp(t, `^€\d+\.\d{2}$`, item["label"])
+	}
+
+	// A formatted field stores strings, so writes keep them as written
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("POST", "/products", strings.NewReader(`{"id":"p1","price":"9.99","label":"€9.99"}`)))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.JSONEq(t, `{"id":"p1","price":9.99,"label":"€9.99"}`, rec.Body.String())
+}
+
+func TestHTTPService_ResourceSequenceField(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "account",
+				Rows: 3,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "sequence"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/accounts?sort=id", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	ids := make([]any, 0, len(list.Data))
+	for _, item := range list.Data {
+		ids = append(ids, item["id"])
+	}
+	require.Equal(t, []any{1.0, 2.0, 3.0}, ids)
+
+	// Integer keys are found by their path segment
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/accounts/2", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestHTTPService_ResourceRegexField(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  resource "car" {
+    rows = 5
+    field "id"    { type = "uuid" }
+    field "plate" {
+      type    = "regex"
+      pattern = "[A-Z]{3}-\\d{4}"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/cars", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 5)
+	for _, item := range list.Data {
+		require.Regexp(t, `^[A-Z]{3}-\d{4}$`, item["plate"])
+	}
+}
+
+func TestHTTPService_ResourceTimestamps(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:       "user",
+				Rows:       3,
+				Timestamps: true,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	do := func(method, path, body string) map[string]any {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		require.Less(t, rec.Code, 300, rec.Body.String())
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+		return out
+	}
+
+	// Generated rows are stamped too
+	list := do("GET", "/users", "")
+	for _, item := range list["data"].([]any) {
+		require.NotEmpty(t, item.(map[string]any)["created_at"])
+	}
+
+	created := do("POST", "/users", `{"id":"u1","name":"Ada"}`)
+	require.NotEmpty(t, created["created_at"])
+	require.Equal(t, created["created_at"], created["updated_at"])
+
+	time.Sleep(2 * time.Millisecond)
+	do("PUT", "/users/u1", `{"name":"Ada Lovelace"}`)
+	got := do("GET", "/users/u1", "")
+	require.Equal(t, created["created_at"], got["created_at"])
+	require.NotEqual(t, created["updated_at"], got["updated_at"])
+
+	// The fields can be selected like declared ones
+	got = do("GET", "/users/u1?fields=id,updated_at", "")
+	require.Len(t, got, 2)
+}
+
+func TestHTTPService_ResourceIdempotencyKey(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:           "payment",
+				IDStrategy:     "sequence",
+				IdempotencyTTL: "50ms",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "int"},
+					{Name: "amount", Type: "int"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	var requests int
+	create := func(key, body string) *httptest.ResponseRecorder {
+		requests++
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+		req.Header.Set("X-Request-Id", fmt.Sprintf("req-%d", requests))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, req)
+		return rec
+	}
+	count := func() int {
+		items, err := svc.resourceStore.List("payment")
+		require.NoError(t, err)
+		return len(items)
+	}
+
+	first := create("k1", `{"amount":100}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.JSONEq(t, `{"id":1,"amount":100}`, first.Body.String())
+
+	// A retry replays the first response without creating another row
+	retry := create("k1", `{"amount":100}`)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Equal(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	require.Equal(t, 1, count())
+
+	// with its own request id rather than the first request's
+	require.Equal(t, "req-1", first.Header().Get("X-Request-Id"))
+	require.Equal(t, "req-2", retry.Header().Get("X-Request-Id"))
+
+	// The same key with a different body is rejected
+	rec := create("k1", `{"amount":200}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Equal(t, 1, count())
+
+	// Other keys, and requests without one, create as usual
+	require.JSONEq(t, `{"id":2,"amount":100}`, create("k2", `{"amount":100}`).Body.String())
+	require.JSONEq(t, `{"id":3,"amount":100}`, create("", `{"amount":100}`).Body.String())
+
+	// Once the key expires, it creates again
+	time.Sleep(60 * time.Millisecond)
+	rec = create("k1", `{"amount":100}`)
+	require.JSONEq(t, `{"id":4,"amount":100}`, rec.Body.String())
+	require.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, 4, count())
+}
+
+func TestIdempotencyKeys_BoundsAndExpires(t *testing.T) {
+	keys, err := newIdempotencyKeys(&config.ResourceConfig{Name: "payment", IdempotencyTTL: "1m"})
+	require.NoError(t, err)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	keys.max = 2
+
+	var created int
+	create := func(w http.ResponseWriter, r *http.Request) {
+		created++
+		w.WriteHeader(http.StatusCreated)
+	}
+	serve := func(key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		keys.serve(rec, httptest.NewRequest("POST", "/payments", strings.NewReader(`{}`)), key, create)
+		return rec
+	}
+
+	serve("a")
+	now = now.Add(time.Second)
+	serve("b")
+	now = now.Add(time.Second)
+	serve("c")
+	require.Equal(t, 3, created)
+
+	// The oldest key is forgotten once the store is full
+	require.Len(t, keys.entries, 2)
+	require.NotContains(t, keys.entries, "a")
+	require.Equal(t, "true", serve("c").Header().Get("Idempotent-Replayed"))
+
+	// and the rest once their TTL passes
+	now = now.Add(time.Minute)
+	require.Empty(t, serve("c").Header().Get("Idempotent-Replayed"))
+	require.Len(t, keys.entries, 1)
+	require.Len(t, keys.order, 1)
+	require.Empty(t, keys.locks)
+}
+
+func TestIdempotencyKeys_LocksPerKey(t *testing.T) {
+	keys, err := newIdempotencyKeys(&config.ResourceConfig{Name: "payment"})
+	require.NoError(t, err)
+
+	// A create holding one key doesn't hold up another key
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		keys.serve(httptest.NewRecorder(), httptest.NewRequest("POST", "/payments", strings.NewReader(`{}`)), "slow", func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		})
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	keys.serve(rec, httptest.NewRequest("POST", "/payments", strings.NewReader(`{}`)), "fast", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// while a retry of the same key waits for it and replays its response
+	retried := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		keys.serve(rec, httptest.NewRequest("POST", "/payments", strings.NewReader(`{}`)), "slow", func(w http.ResponseWriter, r *http.Request) {
+			t.Error("retry created again")
+		})
+		retried <- rec
+	}()
+	select {
+	case <-retried:
+		t.Fatal("retry didn't wait for the first create")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	require.Equal(t, "true", (<-retried).Header().Get("Idempotent-Replayed"))
+}
+
+func TestHTTPService_VersionedResource(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name:      "user",
+				Versioned: true,
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("POST", "/users", `{"id":"u1","name":"Ada"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	rec = do("GET", "/users/u1", "", nil)
+	require.Equal(t, `"1"`, rec.Header().Get("ETag"))
+	require.JSONEq(t, `{"id":"u1","name":"Ada","version":1}`, rec.Body.String())
+
+	// The current version succeeds and bumps the version
+	rec = do("PUT", "/users/u1", `{"name":"Ada Lovelace"}`, map[string]string{"If-Match": `"1"`})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	require.JSONEq(t, `{"id":"u1","name":"Ada Lovelace","version":2}`, rec.Body.String())
+
+	// A stale version conflicts, whether sent as a header or in the body
+	rec = do("PUT", "/users/u1", `{"name":"Ada"}`, map[string]string{"If-Match": `"1"`})
+	require.Equal(t, http.StatusConflict, rec.Code)
+	rec = do("PUT", "/users/u1", `{"name":"Ada","version":1}`, nil)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do("PUT", "/users/u1", `{"name":"Ada","version":2}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Updates must name a version
+	rec = do("PUT", "/users/u1", `{"name":"Ada"}`, nil)
+	require.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	// If-Match takes a list of tags, compared strongly, or * for any version
+	for _, tt := range []struct {
+		ifMatch string
+		status  int
+	}{
+		{`"1", "3"`, http.StatusOK},
+		{`W/"4"`, http.StatusConflict},
+		{`"x", W/"4", "4"`, http.StatusOK},
+		{`*`, http.StatusOK},
+		{`"6`, http.StatusBadRequest},
+		{`6`, http.StatusBadRequest},
+	} {
+		rec = do("PUT", "/users/u1", `{"name":"Ada"}`, map[string]string{"If-Match": tt.ifMatch})
+		require.Equal(t, tt.status, rec.Code, tt.ifMatch)
+	}
+	rec = do("GET", "/users/u1", "", nil)
+	require.Equal(t, `"6"`, rec.Header().Get("ETag"))
+}
+
+func TestHTTPService_ResourcePatch(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "user",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+					{Name: "email", Type: "email"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	require.Equal(t, http.StatusCreated, do("POST", "/users", `{"id":"u1","name":"Ada","email":"ada@example.com","address":{"city":"London","zip":"N1"}}`).Code)
+
+	// Fields missing from the patch are left alone
+	rec := do("PATCH", "/users/u1", `{"name":"Ada Lovelace","address":{"city":"Paris"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var user map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	require.Equal(t, "Ada Lovelace", user["name"])
+	require.Equal(t, "ada@example.com", user["email"])
+	require.Equal(t, map[string]any{"city": "Paris", "zip": "N1"}, user["address"])
+
+	// null removes a field
+	require.Equal(t, http.StatusOK, do("PATCH", "/users/u1", `{"email":null}`).Code)
+	user = nil
+	require.NoError(t, json.Unmarshal(do("GET", "/users/u1", "").Body.Bytes(), &user))
+	require.NotContains(t, user, "email")
+	require.Equal(t, "Ada Lovelace", user["name"])
+
+	require.Equal(t, http.StatusNotFound, do("PATCH", "/users/missing", `{"name":"x"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do("PATCH", "/users/u1", `[1]`).Code)
+}
+
+func TestHTTPService_FieldProjection(t *testing.T) {
+	res := &config.ResourceConfig{
+		Name: "user",
+		Rows: 3,
+		Fields: []*config.FieldConfig{
+			{Name: "id", Type: "uuid"},
+			{Name: "name", Type: "name"},
+			{Name: "email", Type: "email"},
+		},
+	}
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:      "api",
+		Listen:    "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{res},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	var list struct {
+		Data  []map[string]any `json:"data"`
+		Total int              `json:"total"`
+	}
+	rec := get("/users?fields=id,name,nickname")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Equal(t, 3, list.Total)
+	for _, user := range list.Data {
+		require.Len(t, user, 2)
+		require.Contains(t, user, "id")
+		require.Contains(t, user, "name")
+	}
+
+	id := list.Data[0]["id"].(string)
+	var user map[string]any
+	rec = get("/users/" + id + "?fields=email")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	require.Len(t, user, 1)
+	require.NotEmpty(t, user["email"])
+
+	// The store keeps every field
+	user = nil
+	require.NoError(t, json.Unmarshal(get("/users/"+id).Body.Bytes(), &user))
+	require.Len(t, user, 3)
+
+	res.StrictFields = true
+	rec = get("/users?fields=id,nickname")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "unknown field: nickname")
+	require.Equal(t, http.StatusBadRequest, get("/users/"+id+"?fields=nickname").Code)
+}
+
+func TestHTTPService_ExpandRelation(t *testing.T) {
+	svc, err := NewHTTPService(&confighttp.Service{
+		Name:   "api",
+		Listen: "127.0.0.1:0",
+		Resources: []*config.ResourceConfig{
+			{
+				Name: "user",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+				Relations: []*config.RelationConfig{
+					{Name: "orders", Resource: "order", Field: "user_id"},
+				},
+			},
+			{
+				Name: "order",
+				Fields: []*config.FieldConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "user_id", Type: "ref", Resource: "user"},
+				},
+			},
+		},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	require.Equal(t, http.StatusCreated, do("POST", "/users", `{"id":"u1","name":"Ada"}`).Code)
+	require.Equal(t, http.StatusCreated, do("POST", "/users", `{"id":"u2","name":"Grace"}`).Code)
+	require.Equal(t, http.StatusCreated, do("POST", "/orders", `{"id":"o1","user_id":"u1"}`).Code)
+	require.Equal(t, http.StatusCreated, do("POST", "/orders", `{"id":"o2","user_id":"u1"}`).Code)
+	require.Equal(t, http.StatusCreated, do("POST", "/orders", `{"id":"o3","user_id":"u2"}`).Code)
+
+	rec := do("GET", "/users/u1?expand=orders", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var user struct {
+		Name   string           `json:"name"`
+		Orders []map[string]any `json:"orders"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	require.Equal(t, "Ada", user.Name)
+	require.Len(t, user.Orders, 2)
+	for _, order := range user.Orders {
+		require.Equal(t, "u1", order["user_id"])
+	}
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(do("GET", "/users?expand=orders&fields=id", "").Body.Bytes(), &list))
+	require.Len(t, list.Data, 2)
+	for _, u := range list.Data {
+		require.Len(t, u, 2)
+		require.NotNil(t, u["orders"])
+	}
+
+	// Expansion is not stored
+	var plain map[string]any
+	require.NoError(t, json.Unmarshal(do("GET", "/users/u1", "").Body.Bytes(), &plain))
+	require.NotContains(t, plain, "orders")
+
+	require.Equal(t, http.StatusBadRequest, do("GET", "/users/u1?expand=invoices", "").Code)
+}
+
+func TestHTTPService_ResponseTemplate(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "greet" {
+    route = "GET /users/:id"
+
+    response {
+      engine   = "go-template"
+      template = <<-EOT
+        {"id":"{{ .request.params.id }}","greeting":"hello {{ or .request.query.name "stranger" }}"}
+      EOT
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42?name=ada", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"id":"42","greeting":"hello ada"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/users/7", nil))
+	require.JSONEq(t, `{"id":"7","greeting":"hello stranger"}`, rec.Body.String())
+}
+
+func TestNewHTTPService_InvalidResponseTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		resp   *config.ResponseConfig
+		errMsg string
+	}{
+		{
+			name:   "unknown engine",
+			resp:   &config.ResponseConfig{Engine: "jinja", Template: "x"},
+			errMsg: `invalid response engine "jinja"`,
+		},
+		{
+			name:   "missing template",
+			resp:   &config.ResponseConfig{Engine: "go-template"},
+			errMsg: "requires a template",
+		},
+		{
+			name:   "template without engine",
+			resp:   &config.ResponseConfig{Template: "x"},
+			errMsg: `requires engine = "go-template"`,
+		},
+		{
+			name:   "parse error",
+			resp:   &config.ResponseConfig{Engine: "go-template", Template: "{{ .request"},
+			errMsg: "invalid response template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHTTPService(&confighttp.Service{
+				Name:   "api",
+				Listen: "127.0.0.1:0",
+				Handlers: []*confighttp.Handler{
+					{Name: "greet", Route: "GET /greet", Response: tt.resp},
+				},
+			}, slog.Default())
+			require.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestHTTPService_ResponseSizeMetric(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "sized" {
+  listen = "127.0.0.1:0"
+
+  handle "hello" {
+    route = "GET /hello"
+    response {
+      body = "{\"message\":\"hello\"}"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	observer, err := metrics.ResponseSize.GetMetricWithLabelValues("sized", "hello")
+	require.NoError(t, err)
+	m := &dto.Metric{}
+	require.NoError(t, observer.(prometheus.Metric).Write(m))
+	require.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+	require.Equal(t, float64(len(`{"message":"hello"}`)), m.GetHistogram().GetSampleSum())
+}
+
+func TestHTTPService_RecordsEveryRoute(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "labelled" {
+  listen = "127.0.0.1:0"
+
+  resource "user" {
+    rows = 1
+    field "id" { type = "uuid" }
+  }
+
+  handle "broken" {
+    route = "GET /broken"
+    response {
+      body = "never"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	delete(svc.handlerResponses, "broken")
+
+	requests := func(label, status string) float64 {
+		m := &dto.Metric{}
+		require.NoError(t, metrics.RequestsTotal.WithLabelValues("labelled", label, status).Write(m))
+		return m.GetCounter().GetValue()
+	}
+
+	// Resource routes are recorded under the resource's name
+	svc.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	require.Equal(t, float64(1), requests("user", "200"))
+
+	// A recovered panic is recorded with the 500 that was written
+	svc.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/broken", nil))
+	require.Equal(t, float64(1), requests("broken", "500"))
+}
+
+func TestHTTPService_DetectContentType(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "typed" {
+  listen = "127.0.0.1:0"
+
+  handle "json" {
+    route = "GET /json"
+    response {
+      body = jsonencode({ message = "hello" })
+    }
+  }
+
+  handle "html" {
+    route = "GET /html"
+    response {
+      body = "<h1>hello</h1>"
+    }
+  }
+
+  handle "xml" {
+    route = "GET /xml"
+    response {
+      body = "<?xml version=\"1.0\"?><message>hello</message>"
+    }
+  }
+
+  handle "text" {
+    route = "GET /text"
+    response {
+      body = "hello"
+    }
+  }
+
+  handle "explicit" {
+    route = "GET /explicit"
+    response {
+      headers = { "Content-Type" = "text/csv" }
+      body    = "<not html>"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	for path, want := range map[string]string{
+		"/json":     "application/json",
+		"/html":     "text/html; charset=utf-8",
+		"/xml":      "application/xml",
+		"/text":     "text/plain; charset=utf-8",
+		"/explicit": "text/csv",
+	} {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		require.Equal(t, want, rec.Header().Get("Content-Type"), path)
+	}
+}
+
+func TestHTTPService_ResponseContentType(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "typed" {
+  listen = "127.0.0.1:0"
+
+  handle "json" {
+    route = "GET /json"
+    response {
+      content_type = "application/json; charset=utf-8"
+      body         = jsonencode({ message = "hello" })
+    }
+  }
+
+  handle "override" {
+    route = "GET /override"
+    response {
+      content_type = "application/vnd.api+json"
+      headers      = { "Content-Type" = "text/plain" }
+      body         = "{}"
+    }
+  }
+
+  not_found {
+    content_type = "application/problem+json"
+    body         = jsonencode({ title = "Not Found" })
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	for path, want := range map[string]string{
+		"/json":     "application/json; charset=utf-8",
+		"/override": "application/vnd.api+json",
+		"/missing":  "application/problem+json",
+	} {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, want, rec.Header().Get("Content-Type"), path)
+	}
+}
+
+func TestHTTPService_Base64Body(t *testing.T) {
+	// A 1x1 transparent PNG
+	const pixel = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+	cfg, err := parser.Parse([]byte(`
+service "http" "images" {
+  listen = "127.0.0.1:0"
+
+  handle "pixel" {
+    route = "GET /pixel.png"
+    response {
+      base64_body = "`+pixel+`"
+    }
+  }
+
+  handle "proto" {
+    route = "GET /message"
+    response {
+      content_type = "application/x-protobuf"
+      base64_body  = "CgVoZWxsbxD/AQ=="
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/pixel.png", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	want, err := base64.StdEncoding.DecodeString(pixel)
+	require.NoError(t, err)
+	require.Equal(t, want, rec.Body.Bytes())
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/message", nil))
+	require.Equal(t, "application/x-protobuf", rec.Header().Get("Content-Type"))
+	require.Equal(t, []byte{0x0a, 0x05, 'h', 'e', 'l', 'l', 'o', 0x10, 0xff, 0x01}, rec.Body.Bytes())
+
+	// Invalid base64 is rejected
+	_, err = NewHTTPService(&confighttp.Service{
+		Name:     "bad",
+		Listen:   "127.0.0.1:0",
+		Handlers: []*confighttp.Handler{{Name: "pixel", Route: "GET /", Response: &config.ResponseConfig{Base64Body: "not base64!"}}},
+	}, slog.Default())
+	require.ErrorContains(t, err, `handler "pixel": invalid response base64_body`)
+}
+
+func TestHTTPService_InFlightMetric(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "busy" {
+  listen = "127.0.0.1:0"
+
+  handle "slow" {
+    route = "GET /slow"
+
+    timing {
+      p50 = "300ms"
+      p90 = "300ms"
+      p99 = "300ms"
+    }
+
+    response {
+      body = "{}"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	inFlight := func() float64 {
+		m := &dto.Metric{}
+		require.NoError(t, metrics.InFlightRequests.WithLabelValues("busy").Write(m))
+		return m.GetGauge().GetValue()
+	}
+
+	const concurrent = 3
+	var wg sync.WaitGroup
+	for range concurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+		}()
+	}
+
+	require.Eventually(t, func() bool { return inFlight() == concurrent }, time.Second, 5*time.Millisecond)
+	wg.Wait()
+	require.Equal(t, 0.0, inFlight())
+}
+
+func TestHTTPService_RecoversPanic(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "fragile" {
+  listen = "127.0.0.1:0"
+
+  handle "broken" {
+    route = "GET /broken"
+
+    response {
+      body = "never"
+    }
+  }
+
+  handle "ok" {
+    route = "GET /ok"
+
+    response {
+      body = "ok"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	// Every handler's response is prepared at startup; without one, rendering
+	// the response panics
+	delete(svc.handlerResponses, "broken")
+
+	panics := func() float64 {
+		m := &dto.Metric{}
+		require.NoError(t, metrics.PanicsTotal.WithLabelValues("fragile").Write(m))
+		return m.GetCounter().GetValue()
+	}
+	before := panics()
+
+	w := httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		svc.ServeHTTP(w, httptest.NewRequest("GET", "/broken", nil))
+	})
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+	require.Equal(t, before+1, panics())
+
+	// The service keeps serving
+	w = httptest.NewRecorder()
+	svc.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "ok", w.Body.String())
+}
+
+func TestHTTPService_TrustedProxies(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "edge" {
+  listen          = "127.0.0.1:0"
+  trusted_proxies = ["10.0.0.0/8"]
+
+  rate_limit {
+    rps    = 1
+    per_ip = true
+  }
+
+  handle "hello" {
+    route = "GET /hello"
+
+    response {
+      body = "hi"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	get := func(remote, forwardedFor string) int {
+		r := httptest.NewRequest("GET", "/hello", nil)
+		r.RemoteAddr = remote
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		svc.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Clients behind the trusted load balancer get their own buckets
+	require.Equal(t, http.StatusOK, get("10.0.0.2:5000", "198.51.100.1"))
+	require.Equal(t, http.StatusTooManyRequests, get("10.0.0.2:5000", "198.51.100.1"))
+	require.Equal(t, http.StatusOK, get("10.0.0.2:5000", "198.51.100.2"))
+
+	// An untrusted peer can't escape its bucket by spoofing the header
+	require.Equal(t, http.StatusOK, get("203.0.113.9:5000", "198.51.100.3"))
+	require.Equal(t, http.StatusTooManyRequests, get("203.0.113.9:5000", "198.51.100.4"))
+
+	logs := svc.GetRequestLogger().(*RequestLogger).GetLogs(0, 10)
+	require.Len(t, logs, 5)
+	require.Equal(t, "198.51.100.1", logs[0].ClientIP)
+	require.Equal(t, "198.51.100.2", logs[2].ClientIP)
+	require.Equal(t, "203.0.113.9", logs[4].ClientIP)
+}
+
+func TestHTTPService_AdminChaos(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "chaos" {
+  listen = "127.0.0.1:0"
+
+  admin {}
+
+  handle "hello" {
+    route = "GET /hello"
+
+    response {
+      body = "hi"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	chaos := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("POST", "/admin/chaos", strings.NewReader(body)))
+		return rec
+	}
+	statuses := func() []int {
+		var codes []int
+		for range 20 {
+			rec := httptest.NewRecorder()
+			svc.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+			codes = append(codes, rec.Code)
+		}
+		return codes
+	}
+	all := func(code int) []int { return slices.Repeat([]int{code}, 20) }
+
+	require.Equal(t, all(http.StatusOK), statuses())
+
+	rec := chaos(`{"error_rate": 1.0, "status": 503}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"updated"}`, rec.Body.String())
+	require.Equal(t, all(http.StatusServiceUnavailable), statuses())
+
+	rec = chaos(`{"error_rate": 0}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, all(http.StatusOK), statuses())
+
+	// Latency applies to later requests
+	rec = chaos(`{"p50": "50ms", "p90": "50ms", "p99": "50ms"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	start := time.Now()
+	svc.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/hello", nil))
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// Percentiles that are sent are merged into the current ones
+	rec = chaos(`{"p50": "10ms"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	timing := svc.latencyInjector.Load().Config()
+	require.Equal(t, 10*time.Millisecond, timing.P50)
+	require.Equal(t, 50*time.Millisecond, timing.P90)
+	require.Equal(t, 50*time.Millisecond, timing.P99)
+
+	// Reset restores the configured values, which here is no chaos at all
+	rec = chaos(`{"reset": true}`)
+	require.JSONEq(t, `{"status":"reset"}`, rec.Body.String())
+	require.Nil(t, svc.latencyInjector.Load())
+	require.Nil(t, svc.errorInjector.Load())
+
+	// Invalid settings change nothing
+	for _, body := range []string{
+		`{"error_rate": 1.5}`,
+		`{"error_rate": 1, "status": 200}`,
+		`{"p50": "fast", "p90": "1s", "p99": "1s"}`,
+		`{"status": 503}`,
+		`not json`,
+	} {
+		require.Equal(t, http.StatusBadRequest, chaos(body).Code, body)
+	}
+	require.Equal(t, all(http.StatusOK), statuses())
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/chaos", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHTTPService_AdminSeed(t *testing.T) {
+	newCfg := func(admin *config.AdminConfig) *confighttp.Service {
+		return &confighttp.Service{
+			Name:   "seed-test",
+			Listen: "127.0.0.1:0",
+			Admin:  admin,
+			Resources: []*config.ResourceConfig{
+				{
+					Name: "user",
+					Rows: 10,
+					Fields: []*config.FieldConfig{
+						{Name: "id", Type: "uuid"},
+						{Name: "name", Type: "name"},
+					},
+				},
+			},
+		}
+	}
+
+	svc, err := NewHTTPService(newCfg(&config.AdminConfig{}), slog.Default())
+	require.NoError(t, err)
+
+	seed := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		return rec
+	}
+	list := func() []map[string]any {
+		users, err := svc.resourceStore.List("user")
+		require.NoError(t, err)
+		return users
+	}
+
+	// The same seed regenerates identical data
+	rec := seed("/admin/resources/user/seed", `{"rows":5,"seed":7}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"resource":"user","rows":5}`, rec.Body.String())
+	first := list()
+	require.Len(t, first, 5)
+
+	rec = seed("/admin/resources/user/seed", `{"rows":5,"seed":7}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, first, list())
+
+	// A different row count replaces the data rather than appending
+	rec = seed("/admin/resources/user/seed", `{"rows":3,"seed":7}`)
+	require.JSONEq(t, `{"resource":"user","rows":3}`, rec.Body.String())
+	require.Len(t, list(), 3)
+
+	// An empty body falls back to the configured row count
+	rec = seed("/admin/resources/user/seed", "")
+	require.JSONEq(t, `{"resource":"user","rows":10}`, rec.Body.String())
+	require.Len(t, list(), 10)
+
+	rec = seed("/admin/resources/order/seed", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = seed("/admin/resources/user/seed", `{"rows":-1}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Without an admin block the endpoint is not exposed
+	svc, err = NewHTTPService(newCfg(nil), slog.Default())
+	require.NoError(t, err)
+	rec = seed("/admin/resources/user/seed", `{"rows":1}`)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Len(t, list(), 10)
+}
+
+func TestHTTPService_AdminDrain(t *testing.T) {
+	// The upstream holds the handler open until released
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+	}))
+	defer upstream.Close()
+
+	cfg, err := parser.Parse([]byte(fmt.Sprintf(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  admin {}
+
+  handle "slow" {
+    route = "GET /slow"
+
+    step "wait" {
+      http {
+        url = "%s/wait"
+      }
+    }
+
+    response {
+      body = jsonencode({ done = true })
+    }
+  }
+}
+`, upstream.URL)), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	baseURL := "http://" + svc.ResolvedAddress()
+
+	type result struct {
+		status int
+		err    error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		resp.Body.Close()
+		inFlight <- result{status: resp.StatusCode}
+	}()
+	<-arrived
+
+	resp, err := http.Get(baseURL + "/admin/drain")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Post(baseURL+"/admin/drain", "application/json", nil)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.JSONEq(t, `{"status":"draining"}`, string(body))
+
+	// Health checks fail while the in-flight request is still running
+	resp, err = http.Get(baseURL + service.HealthPath)
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.JSONEq(t, `{"status":"draining"}`, string(body))
+
+	// Stopping waits for the in-flight request within the grace period. Drop
+	// the client's idle connections so only the in-flight one holds it up.
+	http.DefaultClient.CloseIdleConnections()
+	stopped := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopped <- svc.Stop(ctx)
+	}()
+
+	close(release)
+	res := <-inFlight
+	require.NoError(t, res.err)
+	require.Equal(t, http.StatusOK, res.status)
+	require.NoError(t, <-stopped)
+}
+
+func TestHTTPService_RequestLogSubscriber(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "create" {
+    route = "POST /users"
+    response {
+      status = 201
+      body   = jsonencode({ id = 1 })
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	logWriter := NewRequestLogWriter(&out)
+	svc.GetRequestLogger().(*RequestLogger).Subscribe(logWriter.Subscriber("api"))
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("POST", "/users", nil))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Regexp(t, `^\d{2}:\d{2}:\d{2}\.\d{3} api POST /users 201 \d+ms$`, lines[0])
+	require.Regexp(t, ` api GET /missing 404 \d+ms$`, lines[1])
+}
+
+func TestHTTPService_Hooks(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/auth":
+			if r.URL.Query().Get("token") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"user":"ada"}`))
+		}
+	}))
+	defer upstream.Close()
+
+	cfg, err := parser.Parse([]byte(fmt.Sprintf(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  before {
+    step "auth" {
+      http {
+        url = "%[1]s/auth?token=${request.query.token}"
+      }
+    }
+
+    response {
+      when   = step.auth.status != 200
+      status = 401
+      body   = jsonencode({ error = "unauthorized" })
+    }
+  }
+
+  after {
+    step "audit" {
+      http {
+        url = "%[1]s/audit?user=${step.auth.body.user}"
+      }
+    }
+  }
+
+  handle "profile" {
+    route = "GET /profile"
+
+    step "load" {
+      http {
+        url = "%[1]s/profile"
+      }
+    }
+
+    response {
+      body = jsonencode({ user = step.auth.body.user })
+    }
+  }
+}
+`, upstream.URL)), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	// The before hook aborts the handler
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/profile?token=wrong", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.JSONEq(t, `{"error":"unauthorized"}`, rec.Body.String())
+	mu.Lock()
+	require.Equal(t, []string{"/auth"}, calls)
+	calls = nil
+	mu.Unlock()
+
+	// Otherwise it runs first, and the after hook runs once the response is written
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/profile?token=secret", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"user":"ada"}`, rec.Body.String())
+	mu.Lock()
+	require.Equal(t, []string{"/auth", "/profile", "/audit"}, calls)
+	mu.Unlock()
+}
+
+func TestHTTPService_HookResponseOptions(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  before {
+    response {
+      when         = request.path != "/profile"
+      status       = 503
+      content_type = "text/plain; charset=utf-8"
+      engine       = "go-template"
+      template     = "{{ .request.path }} is down"
+    }
+  }
+
+  handle "profile" {
+    route = "GET /profile"
+    response {
+      body = "ok"
+    }
+  }
+
+  handle "orders" {
+    route = "GET /orders"
+    response {
+      body = "ok"
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	// The hook response honors content_type and template like a handler's
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/orders", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Equal(t, "/orders is down", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/profile", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHTTPService_HookResponseBinaryAndBadHeaders(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "gateway" {
+  listen = "127.0.0.1:0"
+
+  before {
+    response {
+      status      = 200
+      base64_body = "iVBORw0KGgo="
+    }
+  }
+
+  handle "img" {
+    route = "GET /img"
+    response {
+      body = "unreachable"
+    }
+  }
+
+  not_found {
+    headers = { "X-Path" = { path = request.path } }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/img", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	require.Equal(t, []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}, rec.Body.Bytes())
+
+	// A non-string header value is an error rather than a panic
+	rec = httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	})
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Empty(t, rec.Header().Get("X-Path"))
+}
+
+func TestHTTPService_CaptureBodies(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  debug {
+    capture_bodies = true
+    max            = 2
+    max_body_size  = "8B"
+  }
+
+  handle "echo" {
+    route = "POST /echo"
+    response {
+      status = 201
+      body   = jsonencode({ ok = true })
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	for _, body := range []string{"first", "second", "a much longer body"} {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("POST", "/echo?n=1", strings.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.Equal(t, `{"ok":true}`, rec.Body.String(), "capture must not alter the response")
+	}
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/requests", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var captured []CapturedExchange
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &captured))
+
+	// Only the last two exchanges are kept, with bodies capped at 8 bytes
+	require.Len(t, captured, 2)
+	require.Equal(t, uint64(2), captured[0].Sequence)
+	require.Equal(t, "second", captured[0].RequestBody)
+	require.False(t, captured[0].RequestTruncated)
+
+	last := captured[1]
+	require.Equal(t, "POST", last.Method)
+	require.Equal(t, "/echo?n=1", last.URL)
+	require.Equal(t, "a much l", last.RequestBody)
+	require.True(t, last.RequestTruncated)
+	require.Equal(t, http.StatusCreated, last.Status)
+	require.Equal(t, `{"ok":tr`, last.ResponseBody)
+	require.True(t, last.ResponseTruncated)
+	require.Equal(t, "application/json", last.ResponseHeaders.Get("Content-Type"))
+}
+
+func TestHTTPService_CaptureBodiesPreservesRequest(t *testing.T) {
+	capture, err := newBodyCapture(&config.DebugConfig{CaptureBodies: true, MaxBodySize: "4B"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	_, req, done := capture.begin(httptest.NewRecorder(), req)
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(body))
+	done()
+
+	require.Equal(t, "0123", capture.Exchanges()[0].RequestBody)
+}
+
+func TestHTTPService_NotFoundBlock(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  not_found {
+    status  = 410
+    headers = { "X-Error-Code" = "ROUTE_NOT_FOUND" }
+    body    = jsonencode({ error = { code = "not_found", path = request.path } })
+  }
+
+  handle "hello" {
+    route = "GET /hello"
+    response {
+      body = jsonencode({ message = "hello" })
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	require.Equal(t, http.StatusGone, rec.Code)
+	require.Equal(t, "ROUTE_NOT_FOUND", rec.Header().Get("X-Error-Code"))
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"error":{"code":"not_found","path":"/missing"}}`, rec.Body.String())
+
+	// Matched routes are unaffected
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHTTPService_ExplicitHeadAndOptions(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "get" {
+    route = "GET /items"
+    response {
+      body = jsonencode({ items = [] })
+    }
+  }
+
+  handle "head" {
+    route = "HEAD /items"
+    response {
+      status  = 204
+      headers = { "X-Total" = "0" }
+    }
+  }
+
+  handle "options" {
+    route = "OPTIONS /items"
+    response {
+      status  = 200
+      headers = { "Allow" = "GET" }
+    }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+
+	// Explicit handlers take precedence over the automatic responses
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("HEAD", "/items", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, "0", rec.Header().Get("X-Total"))
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/items", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "GET", rec.Header().Get("Allow"))
+}
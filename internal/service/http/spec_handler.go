@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/jumppad-labs/polymorph/internal/config"
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/renderer"
 )
 
@@ -25,11 +28,25 @@ type SpecHandler struct {
 }
 
 type specRoute struct {
-	method   string   // "GET", "POST", etc.
-	path     string   // "/pets/:petId" (converted from {petId})
-	segments []string // pre-split path segments for matching
-	response []byte   // pre-generated JSON response
-	status   int      // HTTP status code
+	method       string                // "GET", "POST", etc.
+	path         string                // "/pets/:petId" (converted from {petId})
+	segments     []string              // pre-split path segments for matching
+	specResponse                       // default (lowest 2xx) response
+	responses    map[int]*specResponse // other declared responses, by status
+}
+
+// specResponse is a pre-generated response for one declared status code.
+type specResponse struct {
+	status      int           // HTTP status code
+	response    []byte        // pre-generated response for the default content type
+	contentType string        // default (first declared) content type
+	variants    []specVariant // responses for every declared content type
+}
+
+// specVariant is a pre-generated response body for one content type.
+type specVariant struct {
+	contentType string
+	body        []byte
 }
 
 // NewSpecHandler loads an OpenAPI 3.x spec and builds routes with pre-generated mock responses.
@@ -39,7 +56,14 @@ func NewSpecHandler(cfg *config.SpecConfig, logger *slog.Logger) (*SpecHandler,
 		return nil, fmt.Errorf("failed to read spec file %q: %w", cfg.Path, err)
 	}
 
-	doc, err := libopenapi.NewDocument(specBytes)
+	// Resolve external file references relative to the spec's directory
+	docConfig := datamodel.NewDocumentConfiguration()
+	docConfig.BasePath = filepath.Dir(cfg.Path)
+	docConfig.SpecFilePath = filepath.Base(cfg.Path)
+	docConfig.AllowFileReferences = true
+	docConfig.Logger = logger
+
+	doc, err := libopenapi.NewDocumentWithConfiguration(specBytes, docConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
 	}
@@ -98,61 +122,24 @@ func NewSpecHandler(cfg *config.SpecConfig, logger *slog.Logger) (*SpecHandler,
 				continue
 			}
 
-			var responseBytes []byte
-
-			if resp.Content != nil {
-				jsonMedia := resp.Content.GetOrZero("application/json")
-				if jsonMedia != nil && jsonMedia.Schema != nil {
-					schema := jsonMedia.Schema.Schema()
-
-					// Check if schema is an array type
-					isArray := false
-					if schema != nil {
-						for _, t := range schema.Type {
-							if t == "array" {
-								isArray = true
-								break
-							}
-						}
-					}
-
-					if isArray && schema.Items != nil && schema.Items.A != nil {
-						// Array schema: generate N items from the items schema
-						itemSchema := schema.Items.A.Schema()
-						if itemSchema != nil {
-							items := make([]json.RawMessage, 0, rows)
-							for i := 0; i < rows; i++ {
-								mockBytes, genErr := mg.GenerateMock(itemSchema, "")
-								if genErr != nil {
-									logger.Warn("failed to generate array item mock",
-										"path", path, "method", method, "error", genErr)
-									break
-								}
-								items = append(items, json.RawMessage(mockBytes))
-							}
-							if len(items) > 0 {
-								responseBytes, _ = json.MarshalIndent(items, "", "  ")
-							}
-						}
-					} else if schema != nil {
-						// Non-array schema: generate mock from the media type
-						mockBytes, genErr := mg.GenerateMock(jsonMedia, "")
-						if genErr != nil {
-							logger.Warn("failed to generate mock response",
-								"path", path, "method", method, "error", genErr)
-						} else {
-							responseBytes = mockBytes
-						}
-					}
-				}
+			opLogger := logger.With("path", path, "method", method)
+			route := &specRoute{
+				method:       strings.ToUpper(method),
+				path:         convertedPath,
+				segments:     strings.Split(convertedPath, "/"),
+				specResponse: *buildSpecResponse(mg, resp, statusCode, rows, opLogger),
+				responses:    make(map[int]*specResponse),
 			}
 
-			route := &specRoute{
-				method:   strings.ToUpper(method),
-				path:     convertedPath,
-				segments: strings.Split(convertedPath, "/"),
-				response: responseBytes,
-				status:   statusCode,
+			// Pre-generate every other declared status so it can be forced per request
+			for _, code := range codes {
+				c, parseErr := strconv.Atoi(code)
+				if parseErr != nil || c == statusCode {
+					continue
+				}
+				if alt := op.Responses.Codes.GetOrZero(code); alt != nil {
+					route.responses[c] = buildSpecResponse(mg, alt, c, rows, opLogger)
+				}
 			}
 			routes = append(routes, route)
 
@@ -167,6 +154,126 @@ func NewSpecHandler(cfg *config.SpecConfig, logger *slog.Logger) (*SpecHandler,
 	return &SpecHandler{routes: routes, logger: logger}, nil
 }
 
+// buildSpecResponse pre-generates a body for every supported content type
+// declared on a response.
+func buildSpecResponse(mg *renderer.MockGenerator, resp *v3.Response, status, rows int, logger *slog.Logger) *specResponse {
+	sr := &specResponse{status: status}
+	if resp.Content == nil {
+		return sr
+	}
+
+	for contentType, media := range resp.Content.FromOldest() {
+		if !isJSONMediaType(contentType) && !isXMLMediaType(contentType) {
+			continue
+		}
+
+		body, genErr := generateMediaResponse(mg, media, rows)
+		if genErr != nil {
+			logger.Warn("failed to generate mock response",
+				"status", status, "contentType", contentType, "error", genErr)
+		}
+		if body != nil && isXMLMediaType(contentType) {
+			body, genErr = jsonToXML(body, xmlRootName(media))
+			if genErr != nil {
+				logger.Warn("failed to render XML response",
+					"status", status, "contentType", contentType, "error", genErr)
+				continue
+			}
+		}
+		if body == nil {
+			continue
+		}
+		sr.variants = append(sr.variants, specVariant{contentType: contentType, body: body})
+	}
+
+	// The first declared content type is the default
+	if len(sr.variants) > 0 {
+		sr.response = sr.variants[0].body
+		sr.contentType = sr.variants[0].contentType
+	}
+	return sr
+}
+
+// generateMediaResponse builds a JSON mock body for a media type. Array
+// schemas without examples are expanded to rows items; on a partial failure
+// the items generated so far are returned along with the error.
+func generateMediaResponse(mg *renderer.MockGenerator, media *v3.MediaType, rows int) ([]byte, error) {
+	if media == nil || media.Schema == nil {
+		return nil, nil
+	}
+	schema := media.Schema.Schema()
+	if schema == nil {
+		return nil, nil
+	}
+
+	// Declared examples are returned verbatim
+	if mediaHasExample(media) {
+		return mg.GenerateMock(media, "")
+	}
+
+	isArray := false
+	for _, t := range schema.Type {
+		if t == "array" {
+			isArray = true
+			break
+		}
+	}
+
+	if !isArray || schema.Items == nil || schema.Items.A == nil {
+		return mg.GenerateMock(media, "")
+	}
+
+	// Array schema: generate N items from the items schema
+	itemSchema := schema.Items.A.Schema()
+	if itemSchema == nil {
+		return nil, nil
+	}
+	items := make([]json.RawMessage, 0, rows)
+	var genErr error
+	for i := 0; i < rows; i++ {
+		mockBytes, err := mg.GenerateMock(itemSchema, "")
+		if err != nil {
+			genErr = fmt.Errorf("failed to generate array item mock: %w", err)
+			break
+		}
+		items = append(items, json.RawMessage(mockBytes))
+	}
+	if len(items) == 0 {
+		return nil, genErr
+	}
+	body, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return body, genErr
+}
+
+// isJSONMediaType reports whether a content type is JSON or a +json suffix type.
+func isJSONMediaType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+// isXMLMediaType reports whether a content type is XML or a +xml suffix type.
+func isXMLMediaType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml")
+}
+
+// mediaHasExample reports whether a media type or its top-level schema
+// declares an example. Property-level examples are picked up by the mock
+// generator when rendering the schema.
+func mediaHasExample(media *v3.MediaType) bool {
+	if media.Example != nil || (media.Examples != nil && media.Examples.Len() > 0) {
+		return true
+	}
+	if media.Schema == nil {
+		return false
+	}
+	schema := media.Schema.Schema()
+	return schema != nil && (schema.Example != nil || len(schema.Examples) > 0)
+}
+
 // Match finds a matching spec route for the given HTTP method and path.
 func (sh *SpecHandler) Match(method, path string) (*specRoute, bool) {
 	for _, route := range sh.routes {
@@ -205,13 +312,99 @@ func (sh *SpecHandler) Match(method, path string) (*specRoute, bool) {
 	return nil, false
 }
 
-// Handle writes the pre-generated response for a matched spec route.
+// Handle writes the pre-generated response for a matched spec route,
+// choosing among the declared content types using the Accept header. A
+// declared status can be forced with the __status query parameter or the
+// X-Mock-Status header; unknown statuses fall back to the default response.
 func (sh *SpecHandler) Handle(w http.ResponseWriter, r *http.Request, route *specRoute) {
-	if route.response != nil {
-		w.Header().Set("Content-Type", "application/json")
+	resp := &route.specResponse
+	if forced := requestedMockStatus(r); forced != 0 {
+		if alt, ok := route.responses[forced]; ok {
+			resp = alt
+		}
+	}
+
+	body, contentType := resp.negotiate(r.Header.Get("Accept"))
+	if body != nil {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(resp.status)
+	if body != nil {
+		w.Write(body)
+	}
+}
+
+// negotiate picks the variant that best matches the Accept header. The
+// default content type is used when Accept is absent, "*/*", or matches
+// nothing declared.
+func (sr *specResponse) negotiate(accept string) ([]byte, string) {
+	for _, mr := range parseAccept(accept) {
+		if mr.q <= 0 {
+			continue
+		}
+		for _, v := range sr.variants {
+			if mediaRangeMatches(mr.mediaType, v.contentType) {
+				return v.body, v.contentType
+			}
+		}
+	}
+	return sr.response, sr.contentType
+}
+
+// requestedMockStatus returns the status forced by the request, or 0.
+func requestedMockStatus(r *http.Request) int {
+	value := r.URL.Query().Get("__status")
+	if value == "" {
+		value = r.Header.Get("X-Mock-Status")
+	}
+	if value == "" {
+		return 0
+	}
+	status, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return status
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept splits an Accept header into media ranges in header order.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && k == "q" {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	// Stable sort keeps header order for equal quality
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// mediaRangeMatches reports whether an Accept media range covers a content type.
+func mediaRangeMatches(mediaRange, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
 	}
-	w.WriteHeader(route.status)
-	if route.response != nil {
-		w.Write(route.response)
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(contentType, prefix+"/")
 	}
+	return false
 }
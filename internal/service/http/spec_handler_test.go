@@ -1,11 +1,14 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -66,6 +69,42 @@ func TestNewSpecHandler_InvalidSpec(t *testing.T) {
 	require.Nil(t, sh)
 }
 
+func TestNewSpecHandler_ExternalRefs(t *testing.T) {
+	cfg := &config.SpecConfig{
+		Path: "testdata/multifile/openapi.yaml",
+	}
+
+	sh, err := NewSpecHandler(cfg, slog.Default())
+	require.NoError(t, err)
+	require.Len(t, sh.routes, 2)
+
+	route, ok := sh.Match("GET", "/pets/1")
+	require.True(t, ok)
+
+	// Pet comes from schemas/pet.yaml, owner from schemas/owner.yaml
+	var pet map[string]any
+	require.NoError(t, json.Unmarshal(route.response, &pet))
+	require.Contains(t, pet, "id")
+	require.Contains(t, pet, "name")
+	require.Equal(t, map[string]any{"email": "owner@example.com"}, pet["owner"])
+
+	route, ok = sh.Match("GET", "/pets")
+	require.True(t, ok)
+	var pets []map[string]any
+	require.NoError(t, json.Unmarshal(route.response, &pets))
+	require.Len(t, pets, 10)
+}
+
+func TestNewSpecHandler_CircularExternalRefs(t *testing.T) {
+	cfg := &config.SpecConfig{
+		Path: "testdata/circular/openapi.yaml",
+	}
+
+	_, err := NewSpecHandler(cfg, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "circular reference")
+}
+
 func TestNewSpecHandler_JSONSpec(t *testing.T) {
 	dir := t.TempDir()
 	specPath := filepath.Join(dir, "spec.json")
@@ -215,6 +254,207 @@ func TestSpecHandler_ArrayResponse(t *testing.T) {
 	}
 }
 
+func TestSpecHandler_Examples(t *testing.T) {
+	cfg := &config.SpecConfig{
+		Path: "testdata/examples.yaml",
+	}
+
+	sh, err := NewSpecHandler(cfg, slog.Default())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantBody string
+	}{
+		{
+			name:     "media type example on array response",
+			path:     "/pets",
+			wantBody: `[{"id":1,"name":"Rex"},{"id":2,"name":"Tom"}]`,
+		},
+		{
+			name:     "named media type examples",
+			path:     "/pets/1",
+			wantBody: `{"id":1,"name":"Rex"}`,
+		},
+		{
+			name:     "schema property examples",
+			path:     "/owners/1",
+			wantBody: `{"id":42,"email":"owner@example.com"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, ok := sh.Match("GET", tt.path)
+			require.True(t, ok)
+			require.JSONEq(t, tt.wantBody, string(route.response))
+		})
+	}
+}
+
+func TestSpecHandler_ContentNegotiation(t *testing.T) {
+	cfg := &config.SpecConfig{
+		Path: "testdata/negotiation.yaml",
+	}
+
+	sh, err := NewSpecHandler(cfg, slog.Default())
+	require.NoError(t, err)
+
+	route, ok := sh.Match("GET", "/pets/7")
+	require.True(t, ok)
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "no accept uses first declared",
+			wantContentType: "application/json",
+			wantBody:        `{"id":7,"name":"Rex & Co"}`,
+		},
+		{
+			name:            "wildcard uses first declared",
+			accept:          "*/*",
+			wantContentType: "application/json",
+			wantBody:        `{"id":7,"name":"Rex & Co"}`,
+		},
+		{
+			name:            "json",
+			accept:          "application/json",
+			wantContentType: "application/json",
+			wantBody:        `{"id":7,"name":"Rex & Co"}`,
+		},
+		{
+			name:            "xml",
+			accept:          "application/xml",
+			wantContentType: "application/xml",
+			wantBody:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<pet><id>7</id><name>Rex &amp; Co</name></pet>`,
+		},
+		{
+			name:            "quality values",
+			accept:          "application/json;q=0.5, application/xml",
+			wantContentType: "application/xml",
+		},
+		{
+			name:            "unsupported falls back to first declared",
+			accept:          "text/csv",
+			wantContentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/pets/7", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			sh.Handle(rec, req, route)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, tt.wantContentType, rec.Header().Get("Content-Type"))
+			if tt.wantBody == "" {
+				return
+			}
+			if tt.wantContentType == "application/json" {
+				require.JSONEq(t, tt.wantBody, rec.Body.String())
+			} else {
+				require.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJSONToXML_ElementNames(t *testing.T) {
+	out, err := jsonToXML([]byte(`{"first name":"Ada","1st":true,"a<b>":1,"_id":"x","":2}`), "user")
+	require.NoError(t, err)
+	require.Equal(t, xml.Header+`<user><_>2</_><_1st>true</_1st><_id>x</_id><a_b_>1</a_b_><first_name>Ada</first_name></user>`, string(out))
+
+	// The output is well-formed
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestSpecHandler_ForcedStatus(t *testing.T) {
+	cfg := &config.SpecConfig{
+		Path: "testdata/examples.yaml",
+	}
+
+	sh, err := NewSpecHandler(cfg, slog.Default())
+	require.NoError(t, err)
+
+	route, ok := sh.Match("GET", "/pets/1")
+	require.True(t, ok)
+
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "default success",
+			target:     "/pets/1",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"name":"Rex"}`,
+		},
+		{
+			name:       "query param",
+			target:     "/pets/1?__status=404",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"pet not found"}`,
+		},
+		{
+			name:       "header",
+			target:     "/pets/1",
+			header:     "404",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"pet not found"}`,
+		},
+		{
+			name:       "declared status without content",
+			target:     "/pets/1?__status=500",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "undeclared status falls back to default",
+			target:     "/pets/1?__status=418",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"name":"Rex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Mock-Status", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			sh.Handle(rec, req, route)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody == "" {
+				require.Empty(t, rec.Body.String())
+				return
+			}
+			require.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
 func TestSpecHandler_NoSchema(t *testing.T) {
 	cfg := &config.SpecConfig{
 		Path: "testdata/petstore.yaml",
@@ -297,7 +537,7 @@ func TestSpecHandler_Integration(t *testing.T) {
 
 	time.Sleep(10 * time.Millisecond)
 
-	baseURL := "http://" + svc.listener.Addr().String()
+	baseURL := "http://" + svc.listeners[0].Addr().String()
 
 	t.Run("GET /pets returns array", func(t *testing.T) {
 		resp, err := http.Get(baseURL + "/pets")
@@ -353,3 +593,48 @@ func TestSpecHandler_Integration(t *testing.T) {
 		require.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
 }
+
+func TestSpecHandler_Overrides(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "spec-test",
+		Listen: "127.0.0.1:0",
+		Spec: &config.SpecConfig{
+			Path: "testdata/petstore.yaml",
+			Overrides: []*config.SpecOverrideConfig{
+				{
+					Route:  "GET /pets/:id",
+					Errors: []*config.ErrorConfig{{Name: "unavailable", Rate: 1, Status: http.StatusServiceUnavailable}},
+				},
+			},
+		},
+	}
+
+	svc, err := NewHTTPService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		svc.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code
+	}
+
+	// The overridden operation always fails; the rest of the spec is untouched
+	for range 10 {
+		require.Equal(t, http.StatusServiceUnavailable, get("/pets/some-uuid"))
+		require.Equal(t, http.StatusOK, get("/pets"))
+	}
+}
+
+func TestSpecHandler_OverrideUnknownRoute(t *testing.T) {
+	cfg := &confighttp.Service{
+		Name:   "spec-test",
+		Listen: "127.0.0.1:0",
+		Spec: &config.SpecConfig{
+			Path:      "testdata/petstore.yaml",
+			Overrides: []*config.SpecOverrideConfig{{Route: "GET /owners/:id"}},
+		},
+	}
+
+	_, err := NewHTTPService(cfg, slog.Default())
+	require.ErrorContains(t, err, `spec override "GET /owners/:id" matches no operation in the spec`)
+}
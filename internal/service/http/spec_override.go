@@ -0,0 +1,71 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/service"
+)
+
+// specOverride holds the injectors that replace the service's for one spec
+// operation. A nil injector falls back to the service-level one.
+type specOverride struct {
+	latency *service.LatencyInjector
+	errors  *service.ErrorInjector
+}
+
+// newSpecOverrides builds the overrides for a spec, keyed by the route each
+// one matches. Overrides must name an operation in the spec.
+func newSpecOverrides(sh *SpecHandler, cfg *confighttp.Service) (map[*specRoute]*specOverride, error) {
+	if len(cfg.Spec.Overrides) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[*specRoute]*specOverride, len(cfg.Spec.Overrides))
+	for _, o := range cfg.Spec.Overrides {
+		r, err := parseRoute(o.Route)
+		if err != nil || r.Method == "" {
+			return nil, fmt.Errorf("spec override %q: route must be \"METHOD /path\"", o.Route)
+		}
+		route, ok := sh.Match(r.Method, r.Path)
+		if !ok {
+			return nil, fmt.Errorf("spec override %q matches no operation in the spec", o.Route)
+		}
+
+		override := &specOverride{}
+		if o.Timing != nil && o.Timing.HasPercentiles() {
+			timing, err := parseTimingConfig(o.Timing)
+			if err != nil {
+				return nil, fmt.Errorf("spec override %q: %w", o.Route, err)
+			}
+			override.latency = service.NewLatencyInjector(timing)
+		}
+		if len(o.Errors) > 0 {
+			errorConfigs, err := convertErrorConfigs(o.Errors, config.NewEvalContext(cfg.Vars, cfg.Variables))
+			if err != nil {
+				return nil, fmt.Errorf("spec override %q: %w", o.Route, err)
+			}
+			override.errors = service.NewErrorInjector(errorConfigs)
+		}
+		overrides[route] = override
+	}
+	return overrides, nil
+}
+
+// parseTimingConfig converts a timing block's percentile strings
+func parseTimingConfig(cfg *config.TimingConfig) (service.TimingConfig, error) {
+	p50, err := service.ParseDuration(cfg.P50)
+	if err != nil {
+		return service.TimingConfig{}, fmt.Errorf("failed to parse timing.p50: %w", err)
+	}
+	p90, err := service.ParseDuration(cfg.P90)
+	if err != nil {
+		return service.TimingConfig{}, fmt.Errorf("failed to parse timing.p90: %w", err)
+	}
+	p99, err := service.ParseDuration(cfg.P99)
+	if err != nil {
+		return service.TimingConfig{}, fmt.Errorf("failed to parse timing.p99: %w", err)
+	}
+	return service.TimingConfig{P50: p50, P90: p90, P99: p99, Variance: cfg.Variance}, nil
+}
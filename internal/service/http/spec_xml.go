@@ -0,0 +1,91 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"unicode"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// xmlRootName returns the root element name for an XML response, taken from
+// the schema's xml.name when declared.
+func xmlRootName(media *v3.MediaType) string {
+	if media != nil && media.Schema != nil {
+		if schema := media.Schema.Schema(); schema != nil && schema.XML != nil && schema.XML.Name != "" {
+			return schema.XML.Name
+		}
+	}
+	return "response"
+}
+
+// jsonToXML converts a generated JSON body to XML. Object keys become child
+// elements in sorted order and array entries become <item> elements.
+func jsonToXML(body []byte, root string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := writeXMLElement(&buf, root, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlName makes a data key usable as an element name: characters XML names
+// can't contain become underscores, and a name that can't start one is
+// prefixed with an underscore
+func xmlName(key string) string {
+	name := []rune(key)
+	for i, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || (!unicode.IsLetter(name[0]) && name[0] != '_') {
+		return "_" + string(name)
+	}
+	return string(name)
+}
+
+func writeXMLElement(buf *bytes.Buffer, name string, v any) error {
+	name = xmlName(name)
+	buf.WriteString("<" + name + ">")
+
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := writeXMLElement(buf, k, val[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if err := writeXMLElement(buf, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := xml.EscapeText(buf, []byte(fmt.Sprint(val))); err != nil {
+			return fmt.Errorf("failed to escape XML text: %w", err)
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+	return nil
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// defaultStaticIndex is the file served for directory requests
+const defaultStaticIndex = "index.html"
+
+// staticFileHandler serves files from a directory with configurable index,
+// single-page app fallback, caching and directory listing
+type staticFileHandler struct {
+	root         http.Dir
+	index        string
+	spa          bool
+	listing      bool
+	cacheControl string
+	fileServer   http.Handler
+}
+
+// newStaticFileHandler creates a static file handler from config
+func newStaticFileHandler(cfg *config.StaticConfig) *staticFileHandler {
+	h := &staticFileHandler{
+		root:         http.Dir(cfg.Root),
+		index:        cfg.Index,
+		spa:          cfg.SPA,
+		listing:      true,
+		cacheControl: cfg.CacheControl,
+		fileServer:   http.FileServer(http.Dir(cfg.Root)),
+	}
+	if h.index == "" {
+		h.index = defaultStaticIndex
+	}
+	if cfg.DirectoryListing != nil {
+		h.listing = *cfg.DirectoryListing
+	}
+	return h
+}
+
+func (h *staticFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+
+	info, err := h.stat(name)
+	if errors.Is(err, fs.ErrNotExist) && h.spa {
+		// Unmatched paths belong to the client-side router
+		name = "/" + h.index
+		info, err = h.stat(name)
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if info.IsDir() {
+		index := path.Join(name, h.index)
+		indexInfo, err := h.stat(index)
+		hasIndex := err == nil && !indexInfo.IsDir()
+		if !hasIndex && !h.listing {
+			http.NotFound(w, r)
+			return
+		}
+		// As http.FileServer does, directories are only served with a
+		// trailing slash so relative links in them resolve
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			redirectToDir(w, r)
+			return
+		}
+		if hasIndex {
+			h.serveFile(w, r, index)
+			return
+		}
+		h.fileServer.ServeHTTP(w, r)
+		return
+	}
+
+	h.serveFile(w, r, name)
+}
+
+// redirectToDir permanently redirects a directory request to its path with
+// a trailing slash. The location is relative, so it holds when a route
+// prefix has been stripped from the request's URL.
+func redirectToDir(w http.ResponseWriter, r *http.Request) {
+	requestPath := r.URL.EscapedPath()
+	if r.RequestURI != "" {
+		// The path as sent, before any prefix was stripped
+		requestPath, _, _ = strings.Cut(r.RequestURI, "?")
+	}
+	location := path.Base(requestPath) + "/"
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+// stat returns the file info for a slash-separated path under the root
+func (h *staticFileHandler) stat(name string) (fs.FileInfo, error) {
+	f, err := h.root.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
+// serveFile writes a file, handling Range and conditional requests
+func (h *staticFileHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	f, err := h.root.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.cacheControl)
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
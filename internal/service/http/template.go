@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// newResponseTemplate parses a handler's response template, or returns nil
+// when the response uses an HCL body
+func newResponseTemplate(name string, resp *config.ResponseConfig) (*template.Template, error) {
+	if err := resp.ValidateTemplate(); err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Engine != config.EngineGoTemplate {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(resp.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// bodyExprEmpty reports whether an optional body attribute was left unset.
+// gohcl fills omitted expression fields with a null literal.
+func bodyExprEmpty(expr hcl.Expression) bool {
+	val, diags := expr.Value(nil)
+	return !diags.HasErrors() && val.IsNull()
+}
+
+// renderTemplate executes a response template against the same variables
+// HCL expressions see, so {{ .request.params.id }} mirrors request.params.id
+func renderTemplate(tmpl *template.Template, evalCtx *hcl.EvalContext) (string, error) {
+	raw, err := ctyjson.SimpleJSONValue{Value: cty.ObjectVal(evalCtx.Variables)}.MarshalJSON()
+	if err != nil {
+		return "", fmt.Errorf("failed to convert template data: %w", err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", fmt.Errorf("failed to convert template data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// throttleTick is roughly how often a throttled body is flushed
+const throttleTick = 100 * time.Millisecond
+
+// throttledWriter limits how fast a response body is written, simulating a
+// slow network link. Writes are split into chunks that are flushed and
+// followed by a pause, so the client sees the body arrive at rate. A rate
+// of zero writes at full speed.
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx  context.Context
+	rate int64 // bytes per second
+}
+
+func (tw *throttledWriter) Write(b []byte) (int, error) {
+	if tw.rate <= 0 {
+		return tw.ResponseWriter.Write(b)
+	}
+
+	rc := http.NewResponseController(tw.ResponseWriter)
+	chunk := max(1, int(tw.rate*int64(throttleTick)/int64(time.Second)))
+	written := 0
+	for written < len(b) {
+		n := min(chunk, len(b)-written)
+		m, err := tw.ResponseWriter.Write(b[written : written+n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		rc.Flush()
+		// Stop if the client goes away rather than sleeping out the body
+		if !sleepContext(tw.ctx, time.Duration(n)*time.Second/time.Duration(tw.rate)) {
+			return written, tw.ctx.Err()
+		}
+	}
+	return written, nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (tw *throttledWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
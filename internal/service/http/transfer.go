@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/jumppad-labs/polymorph/internal/service"
+)
+
+// transferChunkInterval is roughly how often a body spread over body_time
+// is flushed to the client
+const transferChunkInterval = 50 * time.Millisecond
+
+// transferTiming models a slow response separately from request latency:
+// the headers are held back for ttfb, then the body trickles out in flushed
+// chunks over bodyTime
+type transferTiming struct {
+	ttfb     time.Duration
+	bodyTime time.Duration
+}
+
+// newTransferTiming parses a timing block's ttfb and body_time. It returns
+// nil when neither is set.
+func newTransferTiming(cfg *config.TimingConfig) (*transferTiming, error) {
+	if cfg == nil || (cfg.TTFB == "" && cfg.BodyTime == "") {
+		return nil, nil
+	}
+	t := &transferTiming{}
+	if cfg.TTFB != "" {
+		d, err := service.ParseDuration(cfg.TTFB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timing.ttfb: %w", err)
+		}
+		t.ttfb = d
+	}
+	if cfg.BodyTime != "" {
+		d, err := service.ParseDuration(cfg.BodyTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timing.body_time: %w", err)
+		}
+		t.bodyTime = d
+	}
+	return t, nil
+}
+
+// write sends status and body with the configured timing. It gives up
+// quietly if the client goes away.
+func (t *transferTiming) write(ctx context.Context, w http.ResponseWriter, status int, body []byte) {
+	rc := http.NewResponseController(w)
+	if !sleepContext(ctx, t.ttfb) {
+		return
+	}
+	w.WriteHeader(status)
+	rc.Flush()
+
+	if len(body) == 0 {
+		return
+	}
+	if t.bodyTime <= 0 {
+		w.Write(body)
+		return
+	}
+
+	chunks := max(1, min(int(t.bodyTime/transferChunkInterval), len(body)))
+	size := (len(body) + chunks - 1) / chunks
+	interval := t.bodyTime / time.Duration((len(body)+size-1)/size)
+	for start := 0; start < len(body); start += size {
+		if !sleepContext(ctx, interval) {
+			return
+		}
+		if _, err := w.Write(body[start:min(start+size, len(body))]); err != nil {
+			return
+		}
+		rc.Flush()
+	}
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
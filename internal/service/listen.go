@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// unixScheme prefixes listen addresses that are Unix domain socket paths,
+// as in unix:///tmp/polymorph.sock
+const unixScheme = "unix://"
+
+// splitNetwork returns the network and address to listen on or dial for a
+// listen address
+func splitNetwork(addr string) (network, address string) {
+	if path, ok := strings.CutPrefix(addr, unixScheme); ok {
+		return "unix", path
+	}
+	return "tcp", addr
+}
+
+// ErrAddressInUse is returned when another process already listens on an
+// address
+var ErrAddressInUse = errors.New("address already in use")
+
+// Listen opens a listener on a TCP host:port or, for unix:// addresses, a
+// Unix domain socket. A stale socket file left by a previous run is
+// replaced; the socket file is removed again when the listener is closed.
+func Listen(addr string) (net.Listener, error) {
+	network, address := splitNetwork(addr)
+	if network == "unix" {
+		if err := removeStaleSocket(address); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen(network, address)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return nil, fmt.Errorf("can't listen on %s: %w", addr, ErrAddressInUse)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't listen on %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// CheckListen binds and releases each of a service's listen addresses, so a
+// port that is already taken can be reported before anything starts
+func CheckListen(name string, addrs []string) error {
+	for _, addr := range addrs {
+		ln, err := Listen(addr)
+		if err != nil {
+			return fmt.Errorf("service %q: %w", name, err)
+		}
+		ln.Close()
+	}
+	return nil
+}
+
+// removeStaleSocket deletes a socket file nothing is listening on. A socket
+// that still accepts connections is left for net.Listen to report as in use.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// ListenerAddress returns the address a listener is bound to in listen
+// syntax, so Unix sockets keep their unix:// scheme
+func ListenerAddress(ln net.Listener) string {
+	if ln.Addr().Network() == "unix" {
+		return unixScheme + ln.Addr().String()
+	}
+	return ln.Addr().String()
+}
+
+// ListenAll opens a listener on each address, with socket options and TLS
+// if configured. If any address can't be bound, the listeners already
+// opened are closed so a failed start leaves nothing behind.
+func ListenAll(addrs []string, tlsCfg *config.TLSConfig, socketCfg *config.SocketConfig) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	closeAll := func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}
+
+	for _, addr := range addrs {
+		ln, err := Listen(addr)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+
+		// Socket options go on the raw TCP connection, beneath TLS
+		withOptions, err := WrapListenerSocket(ln, socketCfg)
+		if err != nil {
+			ln.Close()
+			closeAll()
+			return nil, err
+		}
+		wrapped, err := WrapListenerTLS(withOptions, tlsCfg)
+		if err != nil {
+			ln.Close()
+			closeAll()
+			return nil, fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		listeners = append(listeners, wrapped)
+	}
+	return listeners, nil
+}
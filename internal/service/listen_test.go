@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAll(t *testing.T) {
+	listeners, err := ListenAll([]string{"127.0.0.1:0", "127.0.0.1:0"}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+	for _, ln := range listeners {
+		ln.Close()
+	}
+
+	// A failed address closes the listeners already opened
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	_, err = ListenAll([]string{"127.0.0.1:0", taken.Addr().String()}, nil, nil)
+	require.ErrorIs(t, err, ErrAddressInUse)
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "test.sock")
+
+	ln, err := Listen("unix://" + sock)
+	require.NoError(t, err)
+	require.Equal(t, "unix://"+sock, ListenerAddress(ln))
+
+	// A socket that is still listening is reported as in use
+	_, err = Listen("unix://" + sock)
+	require.Error(t, err)
+
+	// A stale socket file left behind is replaced
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	_, err = os.Stat(sock)
+	require.NoError(t, err)
+
+	ln, err = Listen("unix://" + sock)
+	require.NoError(t, err)
+	require.NoError(t, WaitForAddresses(context.Background(), []string{ListenerAddress(ln)}, 10*time.Millisecond))
+
+	// Closing the listener removes the socket file
+	ln.Close()
+	_, err = os.Stat(sock)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestCheckListen(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+	addr := taken.Addr().String()
+
+	err = CheckListen("api", []string{"127.0.0.1:0", addr})
+	require.ErrorIs(t, err, ErrAddressInUse)
+	require.EqualError(t, err, `service "api": can't listen on `+addr+`: address already in use`)
+
+	require.NoError(t, CheckListen("api", []string{"127.0.0.1:0"}))
+}
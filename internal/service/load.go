@@ -2,10 +2,7 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"runtime"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -81,41 +78,3 @@ func (l *LoadGenerator) Generate(ctx context.Context) {
 
 	wg.Wait()
 }
-
-// ParseMemorySize parses a human-readable memory size string (e.g., "256MB", "1GB", "512KB").
-func ParseMemorySize(s string) (int64, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, nil
-	}
-
-	s = strings.ToUpper(s)
-
-	multipliers := []struct {
-		suffix string
-		mult   int64
-	}{
-		{"GB", 1 << 30},
-		{"MB", 1 << 20},
-		{"KB", 1 << 10},
-		{"B", 1},
-	}
-
-	for _, m := range multipliers {
-		if strings.HasSuffix(s, m.suffix) {
-			numStr := strings.TrimSuffix(s, m.suffix)
-			num, err := strconv.ParseFloat(strings.TrimSpace(numStr), 64)
-			if err != nil {
-				return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
-			}
-			return int64(num * float64(m.mult)), nil
-		}
-	}
-
-	// Plain number = bytes
-	num, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
-	}
-	return num, nil
-}
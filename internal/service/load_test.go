@@ -9,38 +9,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestParseMemorySize(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-		wantErr  bool
-	}{
-		{"1KB", 1024, false},
-		{"1MB", 1 << 20, false},
-		{"1GB", 1 << 30, false},
-		{"512KB", 512 * 1024, false},
-		{"256MB", 256 * (1 << 20), false},
-		{"100B", 100, false},
-		{"1024", 1024, false},
-		{"", 0, false},
-		{"1kb", 1024, false},
-		{"bad", 0, true},
-		{"MB", 0, true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got, err := ParseMemorySize(tt.input)
-			if tt.wantErr {
-				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				require.Equal(t, tt.expected, got)
-			}
-		})
-	}
-}
-
 func TestLoadGenerator_CPULoad(t *testing.T) {
 	gen := NewLoadGenerator(LoadConfig{
 		CPUCores:   1,
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrderByUpstreams orders services so that every upstream starts before the
+// services that depend on it. Declaration order is preserved where there is
+// no dependency, and upstreams that are not in the list are ignored.
+func OrderByUpstreams(services []Service) ([]Service, error) {
+	byName := make(map[string]Service, len(services))
+	for _, svc := range services {
+		byName[svc.Name()] = svc
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(services))
+	ordered := make([]Service, 0, len(services))
+
+	var visit func(svc Service, chain []string) error
+	visit = func(svc Service, chain []string) error {
+		chain = append(chain, svc.Name())
+		switch state[svc.Name()] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("service dependency cycle detected: %s", strings.Join(chain, " -> "))
+		}
+
+		state[svc.Name()] = visiting
+		for _, name := range svc.Upstreams() {
+			upstream, ok := byName[name]
+			if !ok {
+				continue
+			}
+			if err := visit(upstream, chain); err != nil {
+				return err
+			}
+		}
+		state[svc.Name()] = visited
+
+		ordered = append(ordered, svc)
+		return nil
+	}
+
+	for _, svc := range services {
+		if err := visit(svc, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
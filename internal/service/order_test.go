@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderByUpstreams_StartsBackendFirst(t *testing.T) {
+	var startOrder []string
+	record := func(name string) { startOrder = append(startOrder, name) }
+
+	// Declared with the gateway ahead of the backend it proxies to
+	gateway := &mockService{name: "gateway", typ: "proxy", upstreams: []string{"backend"}, onStart: record}
+	backend := &mockService{name: "backend", typ: "http", onStart: record}
+	other := &mockService{name: "other", typ: "tcp", onStart: record}
+
+	ordered, err := OrderByUpstreams([]Service{gateway, other, backend})
+	require.NoError(t, err)
+
+	registry := NewRegistry(nil)
+	for _, svc := range ordered {
+		registry.Register(svc)
+	}
+	require.NoError(t, registry.Start(context.Background()))
+
+	require.Equal(t, []string{"backend", "gateway", "other"}, startOrder)
+}
+
+func TestOrderByUpstreams_DependsOn(t *testing.T) {
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen     = "127.0.0.1:8080"
+  depends_on = ["cache"]
+}
+
+service "tcp" "cache" {
+  listen = "127.0.0.1:6379"
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	var startOrder []string
+	record := func(name string) { startOrder = append(startOrder, name) }
+
+	var services []Service
+	for _, svcCfg := range cfg.Services {
+		services = append(services, &mockService{
+			name:      svcCfg.ServiceName(),
+			typ:       svcCfg.ServiceType(),
+			upstreams: svcCfg.GetInferredUpstreams(),
+			onStart:   record,
+		})
+	}
+
+	ordered, err := OrderByUpstreams(services)
+	require.NoError(t, err)
+
+	registry := NewRegistry(nil)
+	for _, svc := range ordered {
+		registry.Register(svc)
+	}
+	require.NoError(t, registry.Start(context.Background()))
+
+	require.Equal(t, []string{"cache", "api"}, startOrder)
+}
+
+func TestOrderByUpstreams_PreservesOrderWithoutDependencies(t *testing.T) {
+	a := &mockService{name: "a"}
+	b := &mockService{name: "b"}
+	c := &mockService{name: "c", upstreams: []string{"external"}}
+
+	ordered, err := OrderByUpstreams([]Service{a, b, c})
+	require.NoError(t, err)
+	require.Equal(t, []Service{a, b, c}, ordered)
+}
+
+func TestOrderByUpstreams_Cycle(t *testing.T) {
+	a := &mockService{name: "a", upstreams: []string{"b"}}
+	b := &mockService{name: "b", upstreams: []string{"a"}}
+
+	_, err := OrderByUpstreams([]Service{a, b})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "service dependency cycle detected: a -> b -> a")
+}
@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// catalogSchema is the schema every declared table lives in
+const catalogSchema = "public"
+
+// catalogTable is a system catalog view built from the declared tables
+type catalogTable struct {
+	columns []ColumnDef
+	rows    func(m *QueryMatcher) [][]string
+}
+
+// catalogTables maps supported information_schema and pg_catalog views to
+// their columns and row builders
+var catalogTables = map[string]catalogTable{
+	"information_schema.tables": {
+		columns: textColumns("table_schema", "table_name", "table_type"),
+		rows: func(m *QueryMatcher) [][]string {
+			var rows [][]string
+			for _, name := range m.catalogTableNames() {
+				rows = append(rows, []string{catalogSchema, name, "BASE TABLE"})
+			}
+			return rows
+		},
+	},
+	"information_schema.columns": {
+		columns: []ColumnDef{
+			{Name: "table_schema", TypeOID: oidText},
+			{Name: "table_name", TypeOID: oidText},
+			{Name: "column_name", TypeOID: oidText},
+			{Name: "ordinal_position", TypeOID: oidInt4},
+			{Name: "column_default", TypeOID: oidText},
+			{Name: "is_nullable", TypeOID: oidText},
+			{Name: "data_type", TypeOID: oidText},
+			{Name: "udt_name", TypeOID: oidText},
+		},
+		rows: func(m *QueryMatcher) [][]string {
+			var rows [][]string
+			for _, name := range m.catalogTableNames() {
+				for i, col := range m.tables[name] {
+					nullable := "YES"
+					if col.Name == "id" {
+						nullable = "NO"
+					}
+					dataType, udtName := pgTypeNames(col.TypeOID)
+					rows = append(rows, []string{
+						catalogSchema, name, col.Name, strconv.Itoa(i + 1), sqlNull, nullable, dataType, udtName,
+					})
+				}
+			}
+			return rows
+		},
+	},
+	"pg_tables": {
+		columns: textColumns("schemaname", "tablename", "tableowner"),
+		rows: func(m *QueryMatcher) [][]string {
+			var rows [][]string
+			for _, name := range m.catalogTableNames() {
+				rows = append(rows, []string{catalogSchema, name, "postgres"})
+			}
+			return rows
+		},
+	},
+	"pg_namespace": {
+		columns: textColumns("nspname"),
+		rows: func(m *QueryMatcher) [][]string {
+			return [][]string{{"pg_catalog"}, {"information_schema"}, {catalogSchema}}
+		},
+	},
+	"pg_class": {
+		columns: textColumns("relname", "relkind", "nspname"),
+		rows: func(m *QueryMatcher) [][]string {
+			var rows [][]string
+			for _, name := range m.catalogTableNames() {
+				rows = append(rows, []string{name, "r", catalogSchema})
+			}
+			return rows
+		},
+	},
+}
+
+// textColumns builds text column definitions
+func textColumns(names ...string) []ColumnDef {
+	cols := make([]ColumnDef, len(names))
+	for i, name := range names {
+		cols[i] = ColumnDef{Name: name, TypeOID: oidText}
+	}
+	return cols
+}
+
+// catalogTableNames returns the SQL-facing (plural) name of each declared
+// table in declaration order
+func (m *QueryMatcher) catalogTableNames() []string {
+	names := make([]string, len(m.declared))
+	for i, name := range m.declared {
+		names[i] = m.pluralizer.Plural(name)
+	}
+	return names
+}
+
+// handleCatalog answers a SELECT against a system catalog view. Unknown
+// views return no rows. Supports a column list and a WHERE clause of
+// equality conditions joined by AND.
+func (m *QueryMatcher) handleCatalog(tableName, normalized string) (*QueryResult, error) {
+	view, ok := catalogTables[strings.TrimPrefix(tableName, "pg_catalog.")]
+	if !ok {
+		return &QueryResult{Tag: "SELECT 0"}, nil
+	}
+
+	rows := filterCatalogRows(view.columns, view.rows(m), extractWhereConditions(normalized))
+	columns, rows, err := projectCatalogColumns(view.columns, rows, extractSelectList(normalized))
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := extractLimit(normalized); limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+
+	return &QueryResult{
+		Columns: columns,
+		Rows:    rows,
+		Tag:     fmt.Sprintf("SELECT %d", len(rows)),
+	}, nil
+}
+
+// filterCatalogRows keeps rows matching every condition on a known column.
+// Conditions on other columns are ignored.
+func filterCatalogRows(columns []ColumnDef, rows [][]string, conditions map[string]string) [][]string {
+	var filtered [][]string
+rows:
+	for _, row := range rows {
+		for i, col := range columns {
+			if value, ok := conditions[col.Name]; ok && row[i] != value {
+				continue rows
+			}
+		}
+		filtered = append(filtered, row)
+	}
+	return filtered
+}
+
+// projectCatalogColumns narrows rows to the selected columns
+func projectCatalogColumns(columns []ColumnDef, rows [][]string, selected []string) ([]ColumnDef, [][]string, error) {
+	if len(selected) == 0 || (len(selected) == 1 && selected[0] == "*") {
+		return columns, rows, nil
+	}
+
+	indexes := make([]int, len(selected))
+	projected := make([]ColumnDef, len(selected))
+	for i, name := range selected {
+		idx := -1
+		for j, col := range columns {
+			if col.Name == name {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, nil, fmt.Errorf("column %q does not exist", name)
+		}
+		indexes[i] = idx
+		projected[i] = columns[idx]
+	}
+
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		out[i] = make([]string, len(indexes))
+		for j, idx := range indexes {
+			out[i][j] = row[idx]
+		}
+	}
+	return projected, out, nil
+}
+
+// extractSelectList returns the column names between SELECT and FROM,
+// stripping table qualifiers
+func extractSelectList(normalized string) []string {
+	rest := strings.TrimPrefix(normalized, "select ")
+	idx := strings.Index(rest, " from ")
+	if idx < 0 {
+		return nil
+	}
+
+	var names []string
+	for _, part := range strings.Split(rest[:idx], ",") {
+		name := strings.TrimSpace(part)
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// extractWhereConditions parses "a = 'x' and b = 'y'" into a map, stopping
+// at ORDER BY or LIMIT
+func extractWhereConditions(normalized string) map[string]string {
+	idx := strings.Index(normalized, " where ")
+	if idx < 0 {
+		return nil
+	}
+	clause := normalized[idx+len(" where "):]
+	for _, end := range []string{" order by ", " limit "} {
+		if i := strings.Index(clause, end); i >= 0 {
+			clause = clause[:i]
+		}
+	}
+
+	conditions := make(map[string]string)
+	for _, cond := range strings.Split(clause, " and ") {
+		parts := strings.SplitN(cond, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		field := strings.TrimSpace(parts[0])
+		if dot := strings.LastIndex(field, "."); dot >= 0 {
+			field = field[dot+1:]
+		}
+		conditions[field] = unquoteValue(parts[1])
+	}
+	return conditions
+}
+
+// pgTypeNames returns the information_schema data_type and udt_name for a
+// type OID
+func pgTypeNames(oid int32) (string, string) {
+	switch oid {
+	case oidUUID:
+		return "uuid", "uuid"
+	case oidInt4:
+		return "integer", "int4"
+	case oidBool:
+		return "boolean", "bool"
+	case oidFloat8:
+		return "double precision", "float8"
+	case oidTimestamp:
+		return "timestamp without time zone", "timestamp"
+	case oidJSON:
+		return "json", "json"
+	default:
+		return "text", "text"
+	}
+}
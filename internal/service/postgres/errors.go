@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// Defaults for injected query errors: the error a client sees when the
+// server is shut down mid-session
+const (
+	defaultErrorSQLState = "57P01"
+	defaultErrorMessage  = "terminating connection due to administrator command"
+)
+
+// queryErrorInjector probabilistically fails queries with a configured
+// SQLSTATE instead of executing them
+type queryErrorInjector struct {
+	rate     float64
+	sqlstate string
+	message  string
+	fatal    bool
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newQueryErrorInjector creates an injector from an errors block
+func newQueryErrorInjector(cfg *config.QueryErrorConfig) *queryErrorInjector {
+	inj := &queryErrorInjector{
+		rate:     cfg.Rate,
+		sqlstate: cfg.SQLState,
+		message:  cfg.Message,
+		fatal:    cfg.Fatal,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if inj.sqlstate == "" {
+		inj.sqlstate = defaultErrorSQLState
+	}
+	if inj.message == "" {
+		inj.message = defaultErrorMessage
+	}
+	return inj
+}
+
+// ShouldInject reports whether the next query should fail
+func (e *queryErrorInjector) ShouldInject() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rng.Float64() < e.rate
+}
+
+// severity returns the ErrorResponse severity for the injected error
+func (e *queryErrorInjector) severity() string {
+	if e.fatal {
+		return "FATAL"
+	}
+	return "ERROR"
+}
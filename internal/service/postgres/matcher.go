@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"fmt"
+	"maps"
+	"strconv"
 	"strings"
 
 	"github.com/gertd/go-pluralize"
@@ -15,7 +17,12 @@ type TableColumn struct {
 	TypeOID int32
 }
 
-// QueryResult holds the result of executing a query.
+// sqlNull is the row value sent as SQL NULL. Text values can't contain a
+// NUL byte, so it can't be mistaken for one.
+const sqlNull = "\x00"
+
+// QueryResult holds the result of executing a query. A row value of sqlNull
+// is NULL.
 type QueryResult struct {
 	Columns []ColumnDef
 	Rows    [][]string
@@ -32,6 +39,7 @@ type customPattern struct {
 type QueryMatcher struct {
 	store     *resource.Store
 	tables    map[string][]TableColumn // table name -> columns
+	declared  []string                 // registered table names in order
 	patterns  []customPattern
 	pluralizer *pluralize.Client
 }
@@ -49,6 +57,7 @@ func NewQueryMatcher(store *resource.Store) *QueryMatcher {
 // Both singular and plural forms are registered for lookup.
 func (m *QueryMatcher) RegisterTable(name string, columns []TableColumn) {
 	m.tables[name] = columns
+	m.declared = append(m.declared, name)
 	plural := m.pluralizer.Plural(name)
 	if plural != name {
 		m.tables[plural] = columns
@@ -66,6 +75,13 @@ func (m *QueryMatcher) AddPattern(pattern, fromTable, where string) {
 
 // Execute matches and executes a SQL query, returning the result.
 func (m *QueryMatcher) Execute(query string) (*QueryResult, error) {
+	return m.ExecuteTx(query, nil)
+}
+
+// ExecuteTx executes a SQL query on a connection with transaction state.
+// BEGIN, COMMIT and ROLLBACK update tx, and changes made inside a
+// transaction block are recorded so ROLLBACK can revert them.
+func (m *QueryMatcher) ExecuteTx(query string, tx *transaction) (*QueryResult, error) {
 	normalized := normalizeSQL(query)
 	// Preserve original casing for value extraction (same whitespace normalization, no lowercasing)
 	preserved := normalizeWhitespace(query)
@@ -82,10 +98,10 @@ func (m *QueryMatcher) Execute(query string) (*QueryResult, error) {
 		}
 	}
 
-	return m.executeAuto(normalized, preserved)
+	return m.executeAuto(normalized, preserved, tx)
 }
 
-func (m *QueryMatcher) executeAuto(normalized, preserved string) (*QueryResult, error) {
+func (m *QueryMatcher) executeAuto(normalized, preserved string, tx *transaction) (*QueryResult, error) {
 	words := strings.Fields(normalized)
 	if len(words) == 0 {
 		return &QueryResult{Tag: "EMPTY"}, nil
@@ -95,20 +111,37 @@ func (m *QueryMatcher) executeAuto(normalized, preserved string) (*QueryResult,
 	case "select":
 		return m.handleSelect(normalized)
 	case "insert":
-		return m.handleInsert(normalized, preserved)
+		return m.handleInsert(normalized, preserved, tx)
 	case "update":
-		return m.handleUpdate(normalized, preserved)
+		return m.handleUpdate(normalized, preserved, tx)
 	case "delete":
-		return m.handleDelete(normalized)
+		return m.handleDelete(normalized, tx)
 	case "set":
 		return &QueryResult{Tag: "SET"}, nil
 	case "show":
 		return m.handleShow(normalized)
-	case "begin":
+	case "begin", "start":
+		if tx != nil {
+			tx.Begin()
+		}
 		return &QueryResult{Tag: "BEGIN"}, nil
-	case "commit":
+	case "commit", "end":
+		if tx != nil {
+			committed, err := tx.Commit()
+			if err != nil {
+				return nil, err
+			}
+			if !committed {
+				return &QueryResult{Tag: "ROLLBACK"}, nil
+			}
+		}
 		return &QueryResult{Tag: "COMMIT"}, nil
-	case "rollback":
+	case "rollback", "abort":
+		if tx != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, err
+			}
+		}
 		return &QueryResult{Tag: "ROLLBACK"}, nil
 	case "discard":
 		return &QueryResult{Tag: "DISCARD ALL"}, nil
@@ -206,9 +239,9 @@ func (m *QueryMatcher) handleSelect(normalized string) (*QueryResult, error) {
 		return &QueryResult{Tag: "SELECT 0"}, nil
 	}
 
-	// Return empty results for system catalog queries
+	// Answer system catalog queries from the declared tables
 	if strings.HasPrefix(tableName, "pg_") || strings.HasPrefix(tableName, "information_schema") {
-		return &QueryResult{Tag: "SELECT 0"}, nil
+		return m.handleCatalog(tableName, normalized)
 	}
 
 	storeTable, cols, err := m.resolveTable(tableName)
@@ -249,7 +282,7 @@ func (m *QueryMatcher) handleSelect(normalized string) (*QueryResult, error) {
 	return m.buildSelectResult(cols, items), nil
 }
 
-func (m *QueryMatcher) handleInsert(normalized, preserved string) (*QueryResult, error) {
+func (m *QueryMatcher) handleInsert(normalized, preserved string, tx *transaction) (*QueryResult, error) {
 	tableName := extractTableName(normalized, "into")
 	if tableName == "" {
 		return nil, fmt.Errorf("cannot determine table name from INSERT")
@@ -273,14 +306,24 @@ func (m *QueryMatcher) handleInsert(normalized, preserved string) (*QueryResult,
 		row[col] = values[i]
 	}
 
+	// An insert over an existing id replaces it, so undo restores the old row
+	id, _ := row["id"].(string)
+	prev, _ := m.store.Get(storeTable, id)
+
 	if err := m.store.Insert(storeTable, row); err != nil {
 		return nil, err
 	}
+	tx.Record(func() error {
+		if prev != nil {
+			return m.store.Insert(storeTable, prev)
+		}
+		return m.store.Delete(storeTable, id)
+	})
 
 	return &QueryResult{Tag: "INSERT 0 1"}, nil
 }
 
-func (m *QueryMatcher) handleUpdate(normalized, preserved string) (*QueryResult, error) {
+func (m *QueryMatcher) handleUpdate(normalized, preserved string, tx *transaction) (*QueryResult, error) {
 	tableName := extractTableName(normalized, "update")
 	if tableName == "" {
 		return nil, fmt.Errorf("cannot determine table name from UPDATE")
@@ -320,6 +363,7 @@ func (m *QueryMatcher) handleUpdate(normalized, preserved string) (*QueryResult,
 
 	count := 0
 	for _, item := range items {
+		prev := maps.Clone(item)
 		for k, v := range setAssigns {
 			item[k] = v
 		}
@@ -327,13 +371,14 @@ func (m *QueryMatcher) handleUpdate(normalized, preserved string) (*QueryResult,
 		if err := m.store.Update(storeTable, id, item); err != nil {
 			return nil, err
 		}
+		tx.Record(func() error { return m.store.Insert(storeTable, prev) })
 		count++
 	}
 
 	return &QueryResult{Tag: fmt.Sprintf("UPDATE %d", count)}, nil
 }
 
-func (m *QueryMatcher) handleDelete(normalized string) (*QueryResult, error) {
+func (m *QueryMatcher) handleDelete(normalized string, tx *transaction) (*QueryResult, error) {
 	tableName := extractTableName(normalized, "from")
 	if tableName == "" {
 		return nil, fmt.Errorf("cannot determine table name from DELETE")
@@ -351,9 +396,11 @@ func (m *QueryMatcher) handleDelete(normalized string) (*QueryResult, error) {
 
 	var count int
 	if field == "id" {
+		prev, _ := m.store.Get(storeTable, value)
 		if err := m.store.Delete(storeTable, value); err != nil {
 			return nil, err
 		}
+		tx.Record(func() error { return m.store.Insert(storeTable, prev) })
 		count = 1
 	} else {
 		items, err := m.store.Where(storeTable, field, value)
@@ -365,6 +412,7 @@ func (m *QueryMatcher) handleDelete(normalized string) (*QueryResult, error) {
 			if err := m.store.Delete(storeTable, id); err != nil {
 				return nil, err
 			}
+			tx.Record(func() error { return m.store.Insert(storeTable, item) })
 			count++
 		}
 	}
@@ -418,7 +466,7 @@ func (m *QueryMatcher) buildSelectResult(cols []TableColumn, items []map[string]
 	for i, item := range items {
 		row := make([]string, len(cols))
 		for j, c := range cols {
-			row[j] = fmt.Sprintf("%v", item[c.Name])
+			row[j] = formatValue(item[c.Name])
 		}
 		rows[i] = row
 	}
@@ -430,6 +478,16 @@ func (m *QueryMatcher) buildSelectResult(cols []TableColumn, items []map[string]
 	}
 }
 
+// formatValue renders a stored value as text. Floats are written in full
+// rather than with an exponent, so whole numbers decoded from JSON as
+// float64 read back as integers.
+func formatValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
 // normalizeSQL normalizes a SQL query for matching (lowercased).
 func normalizeSQL(sql string) string {
 	return strings.ToLower(normalizeWhitespace(sql))
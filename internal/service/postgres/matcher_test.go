@@ -249,3 +249,91 @@ func TestQueryMatcher_UnsupportedQuery(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "unsupported")
 }
+
+func TestQueryMatcher_InformationSchemaTables(t *testing.T) {
+	m := setupTestMatcher(t)
+
+	result, err := m.Execute("SELECT table_name FROM information_schema.tables WHERE table_schema = 'public'")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 1", result.Tag)
+	require.Equal(t, [][]string{{"users"}}, result.Rows)
+}
+
+func TestQueryMatcher_InformationSchemaColumns(t *testing.T) {
+	m := setupTestMatcher(t)
+
+	result, err := m.Execute(`SELECT c.column_name, c.data_type, c.is_nullable
+		FROM information_schema.columns c
+		WHERE c.table_schema = 'public' AND c.table_name = 'users'
+		ORDER BY c.ordinal_position`)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 3", result.Tag)
+	require.Equal(t, []ColumnDef{
+		{Name: "column_name", TypeOID: oidText},
+		{Name: "data_type", TypeOID: oidText},
+		{Name: "is_nullable", TypeOID: oidText},
+	}, result.Columns)
+	require.Equal(t, [][]string{
+		{"id", "uuid", "NO"},
+		{"name", "text", "YES"},
+		{"email", "text", "YES"},
+	}, result.Rows)
+
+	// No column has a default
+	result, err = m.Execute("SELECT column_default FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'id'")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{sqlNull}}, result.Rows)
+
+	result, err = m.Execute("SELECT * FROM information_schema.columns WHERE table_name = 'orders'")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 0", result.Tag)
+
+	_, err = m.Execute("SELECT nonexistent FROM information_schema.columns")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not exist")
+}
+
+func TestQueryMatcher_PgCatalog(t *testing.T) {
+	m := setupTestMatcher(t)
+
+	result, err := m.Execute("SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname = 'public'")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"users"}}, result.Rows)
+
+	result, err = m.Execute("SELECT relname FROM pg_class WHERE relkind = 'r'")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"users"}}, result.Rows)
+
+	// Unsupported catalog views return no rows
+	result, err = m.Execute("SELECT * FROM pg_type")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT 0", result.Tag)
+}
+
+func TestQueryMatcher_SelectNumbers(t *testing.T) {
+	store := resource.NewStore()
+	require.NoError(t, store.CreateTable("account", resource.Schema{
+		Name: "account",
+		Fields: []resource.Field{
+			{Name: "id", Type: resource.FieldTypeAny, PrimaryKey: true, Index: true},
+			{Name: "balance", Type: resource.FieldTypeAny},
+			{Name: "rate", Type: resource.FieldTypeAny},
+		},
+	}))
+
+	// Items created over REST before integers were preserved held float64
+	require.NoError(t, store.Insert("account", map[string]any{
+		"id": int64(1), "balance": float64(1234567), "rate": 0.25,
+	}))
+
+	m := NewQueryMatcher(store)
+	m.RegisterTable("account", []TableColumn{
+		{Name: "id", Type: "int", TypeOID: oidInt4},
+		{Name: "balance", Type: "int", TypeOID: oidInt4},
+		{Name: "rate", Type: "float", TypeOID: oidFloat8},
+	})
+
+	result, err := m.Execute("SELECT * FROM accounts")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1", "1234567", "0.25"}}, result.Rows)
+}
@@ -39,7 +39,9 @@ const (
 
 // Transaction status
 const (
-	txIdle byte = 'I'
+	txIdle          byte = 'I'
+	txInTransaction byte = 'T'
+	txFailed        byte = 'E'
 )
 
 // Error field codes
@@ -57,6 +59,7 @@ const (
 	oidFloat8    int32 = 701
 	oidUUID      int32 = 2950
 	oidTimestamp int32 = 1114
+	oidJSON      int32 = 114
 )
 
 // StartupMessage represents the initial client message.
@@ -236,8 +239,14 @@ func writeDataRow(w io.Writer, values []string) error {
 	data = append(data, buf...)
 
 	for _, val := range values {
-		valBytes := []byte(val)
 		lenBuf := make([]byte, 4)
+		if val == sqlNull {
+			// NULL is a length of -1 with no value bytes
+			binary.BigEndian.PutUint32(lenBuf, 0xFFFFFFFF)
+			data = append(data, lenBuf...)
+			continue
+		}
+		valBytes := []byte(val)
 		binary.BigEndian.PutUint32(lenBuf, uint32(len(valBytes)))
 		data = append(data, lenBuf...)
 		data = append(data, valBytes...)
@@ -271,14 +280,16 @@ func typeOIDForFakeType(fakeType string) int32 {
 	switch fakeType {
 	case "uuid":
 		return oidUUID
-	case "int":
+	case "int", "sequence":
 		return oidInt4
 	case "bool":
 		return oidBool
-	case "decimal", "float":
+	case "decimal", "float", "money":
 		return oidFloat8
 	case "date", "datetime":
 		return oidTimestamp
+	case "json":
+		return oidJSON
 	default:
 		return oidText
 	}
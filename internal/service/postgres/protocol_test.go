@@ -75,7 +75,7 @@ func TestWriteRowDescription(t *testing.T) {
 func TestWriteDataRow(t *testing.T) {
 	var buf bytes.Buffer
 
-	err := writeDataRow(&buf, []string{"abc-123", "Alice"})
+	err := writeDataRow(&buf, []string{"abc-123", "Alice", sqlNull})
 	require.NoError(t, err)
 
 	msgType, body, err := readMessage(&buf)
@@ -84,7 +84,10 @@ func TestWriteDataRow(t *testing.T) {
 
 	// Parse number of columns
 	numCols := binary.BigEndian.Uint16(body[:2])
-	require.Equal(t, uint16(2), numCols)
+	require.Equal(t, uint16(3), numCols)
+
+	// NULL is a length of -1 with no value
+	require.Equal(t, []byte{0xFF, 0xFF, 0xFF, 0xFF}, body[len(body)-4:])
 }
 
 func TestWriteCommandComplete(t *testing.T) {
@@ -4,33 +4,40 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"math/rand"
 	"net"
 	"sync"
+	"sync/atomic"
 
 	"github.com/jumppad-labs/polymorph/internal/config"
 	configpg "github.com/jumppad-labs/polymorph/internal/config/postgres"
 	"github.com/jumppad-labs/polymorph/internal/fake"
+	"github.com/jumppad-labs/polymorph/internal/metrics"
 	"github.com/jumppad-labs/polymorph/internal/resource"
 	"github.com/jumppad-labs/polymorph/internal/service"
 )
 
 // PostgresService implements a fake PostgreSQL database service.
 type PostgresService struct {
-	name      string
-	config    *configpg.Service
-	logger    *slog.Logger
-	auth      *Authenticator
-	matcher   *QueryMatcher
-	store     *resource.Store
-	listener  net.Listener
-	tlsConfig *tls.Config
-	wg        sync.WaitGroup
-	ctx       context.Context
-	cancel    context.CancelFunc
+	name            string
+	config          *configpg.Service
+	logger          *slog.Logger
+	auth            *Authenticator
+	matcher         *QueryMatcher
+	store           *resource.Store
+	queryErrors     *queryErrorInjector
+	latencyInjector *service.LatencyInjector
+	listener        net.Listener
+	resolvedAddress string
+	tlsConfig       *tls.Config
+	connections     atomic.Int32
+	wg              sync.WaitGroup
+	ctx             context.Context
+	cancel          context.CancelFunc
 }
 
 // NewPostgresService creates a new PostgreSQL service from config.
@@ -68,7 +75,7 @@ func NewPostgresService(cfg *configpg.Service, logger *slog.Logger) (*PostgresSe
 		}
 
 		// Generate fake rows
-		if tbl.Rows > 0 {
+		if tbl.Rows > 0 || tbl.RowsMax != nil {
 			var gen *fake.Generator
 			if tbl.Seed != nil {
 				gen = fake.NewSeededGenerator(*tbl.Seed)
@@ -76,37 +83,25 @@ func NewPostgresService(cfg *configpg.Service, logger *slog.Logger) (*PostgresSe
 				gen = fake.NewGenerator()
 			}
 
+			// A row range overrides the fixed row count
+			count := tbl.Rows
+			if tbl.RowsMin != nil && tbl.RowsMax != nil {
+				count = gen.RowCount(*tbl.RowsMin, *tbl.RowsMax)
+			}
+
 			fakeFields := make([]fake.FieldConfig, len(tbl.Columns))
 			for i, col := range tbl.Columns {
-				fc := fake.FieldConfig{
-					Name: col.Name,
-					Type: fake.FakeType(col.Type),
-				}
-				cfg := make(map[string]any)
-				if col.Min != nil {
-					cfg["min"] = *col.Min
-				}
-				if col.Max != nil {
-					cfg["max"] = *col.Max
-				}
-				if len(col.Values) > 0 {
-					anyValues := make([]any, len(col.Values))
-					for j, v := range col.Values {
-						anyValues[j] = v
-					}
-					cfg["values"] = anyValues
-				}
-				if len(cfg) > 0 {
-					fc.Config = cfg
-				}
-				fakeFields[i] = fc
+				fakeFields[i] = col.FakeField()
 			}
 
-			rows, err := gen.GenerateRows(fakeFields, tbl.Rows)
+			rows, err := gen.GenerateRows(fakeFields, count)
 			if err != nil {
 				return nil, fmt.Errorf("generate data for table %q: %w", tbl.Name, err)
 			}
 			for _, row := range rows {
+				if err := encodeJSONColumns(tbl.Columns, row); err != nil {
+					return nil, fmt.Errorf("generate data for table %q: %w", tbl.Name, err)
+				}
 				if err := store.Insert(tbl.Name, row); err != nil {
 					return nil, fmt.Errorf("insert row into %q: %w", tbl.Name, err)
 				}
@@ -121,6 +116,10 @@ func NewPostgresService(cfg *configpg.Service, logger *slog.Logger) (*PostgresSe
 				Type:    col.Type,
 				TypeOID: typeOIDForFakeType(col.Type),
 			}
+			// Formatted amounts such as "$12.34" are text
+			if col.FakeField().FormattedMoney() {
+				colDefs[i].TypeOID = oidText
+			}
 		}
 		matcher.RegisterTable(tbl.Name, colDefs)
 	}
@@ -130,14 +129,58 @@ func NewPostgresService(cfg *configpg.Service, logger *slog.Logger) (*PostgresSe
 		matcher.AddPattern(q.Pattern, q.FromTable, q.Where)
 	}
 
-	return &PostgresService{
+	svc := &PostgresService{
 		name:    cfg.Name,
 		config:  cfg,
 		logger:  logger,
 		auth:    auth,
 		matcher: matcher,
 		store:   store,
-	}, nil
+	}
+	if cfg.QueryErrors != nil {
+		svc.queryErrors = newQueryErrorInjector(cfg.QueryErrors)
+	}
+
+	// Initialize timing injector if configured
+	if cfg.Timing != nil {
+		p50, err := service.ParseDuration(cfg.Timing.P50)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timing.p50: %w", err)
+		}
+		p90, err := service.ParseDuration(cfg.Timing.P90)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timing.p90: %w", err)
+		}
+		p99, err := service.ParseDuration(cfg.Timing.P99)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timing.p99: %w", err)
+		}
+
+		svc.latencyInjector = service.NewLatencyInjector(service.TimingConfig{
+			P50:      p50,
+			P90:      p90,
+			P99:      p99,
+			Variance: cfg.Timing.Variance,
+		})
+	}
+
+	return svc, nil
+}
+
+// encodeJSONColumns replaces a row's generated json values with their JSON
+// text, which is how postgres sends json columns
+func encodeJSONColumns(columns []*config.ColumnConfig, row map[string]any) error {
+	for _, col := range columns {
+		if fake.FakeType(col.Type) != fake.TypeJSON {
+			continue
+		}
+		data, err := json.Marshal(row[col.Name])
+		if err != nil {
+			return fmt.Errorf("encode column %q: %w", col.Name, err)
+		}
+		row[col.Name] = string(data)
+	}
+	return nil
 }
 
 func (s *PostgresService) Name() string        { return s.name }
@@ -145,13 +188,22 @@ func (s *PostgresService) Type() string        { return "postgres" }
 func (s *PostgresService) Address() string     { return s.config.Listen }
 func (s *PostgresService) Upstreams() []string { return s.config.Upstreams }
 
+// ResolvedAddress returns the address the service bound to, or an empty
+// string before Start.
+func (s *PostgresService) ResolvedAddress() string { return s.resolvedAddress }
+
 // Start begins listening for PostgreSQL client connections.
 func (s *PostgresService) Start(ctx context.Context) error {
 	s.ctx, s.cancel = context.WithCancel(ctx)
 
-	listener, err := net.Listen("tcp", s.config.Listen)
+	ln, err := service.Listen(s.config.Listen)
 	if err != nil {
-		return fmt.Errorf("listen: %w", err)
+		return err
+	}
+	listener, err := service.WrapListenerSocket(ln, s.config.Socket)
+	if err != nil {
+		ln.Close()
+		return err
 	}
 
 	// Build TLS config if present (used for PostgreSQL SSL negotiation)
@@ -164,6 +216,7 @@ func (s *PostgresService) Start(ctx context.Context) error {
 		s.tlsConfig = tlsCfg
 	}
 	s.listener = listener
+	s.resolvedAddress = service.ListenerAddress(listener)
 
 	s.wg.Add(1)
 	go func() {
@@ -211,15 +264,21 @@ func (s *PostgresService) acceptLoop() {
 			}
 		}
 
+		// Over-limit connections still complete the startup handshake so the
+		// client sees a proper error rather than a reset
+		active := s.connections.Add(1)
+		overLimit := s.config.MaxConnections > 0 && int(active) > s.config.MaxConnections
+
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
-			s.handleConnection(conn)
+			defer s.connections.Add(-1)
+			s.handleConnection(conn, overLimit)
 		}()
 	}
 }
 
-func (s *PostgresService) handleConnection(conn net.Conn) {
+func (s *PostgresService) handleConnection(conn net.Conn, overLimit bool) {
 	defer conn.Close()
 
 	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
@@ -258,6 +317,13 @@ func (s *PostgresService) handleConnection(conn net.Conn) {
 		}
 	}
 
+	if overLimit {
+		s.logger.Warn("rejecting connection: too many connections", "remote", conn.RemoteAddr(), "max", s.config.MaxConnections)
+		writeErrorResponse(rw, "FATAL", "53300", "sorry, too many clients already")
+		rw.Flush()
+		return
+	}
+
 	// Authenticate
 	if _, err := s.auth.Authenticate(rw, startup); err != nil {
 		s.logger.Error("auth failed", "error", err)
@@ -280,6 +346,16 @@ func (s *PostgresService) handleConnection(conn net.Conn) {
 	writeReadyForQuery(rw, txIdle)
 	rw.Flush()
 
+	// A connection that ends inside a transaction block rolls it back
+	tx := newTransaction()
+	defer func() {
+		if tx.status != txIdle {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error("rollback error", "error", err)
+			}
+		}
+	}()
+
 	// Query loop
 	for {
 		select {
@@ -301,23 +377,51 @@ func (s *PostgresService) handleConnection(conn net.Conn) {
 		case msgTerminate:
 			return
 		case msgQuery:
+			// Fail the query instead of executing it if an error is injected
+			if s.queryErrors != nil && s.queryErrors.ShouldInject() {
+				writeErrorResponse(rw, s.queryErrors.severity(), s.queryErrors.sqlstate, s.queryErrors.message)
+				if s.queryErrors.fatal {
+					rw.Flush()
+					return
+				}
+				tx.Fail()
+				writeReadyForQuery(rw, tx.status)
+				rw.Flush()
+				continue
+			}
+
 			query := string(body[:len(body)-1]) // strip null terminator
-			s.handleQuery(rw, query)
+			s.handleQuery(rw, query, tx)
 			rw.Flush()
 		default:
 			writeErrorResponse(rw, "ERROR", "0A000",
 				fmt.Sprintf("unsupported message type: %c", msgType))
-			writeReadyForQuery(rw, txIdle)
+			writeReadyForQuery(rw, tx.status)
 			rw.Flush()
 		}
 	}
 }
 
-func (s *PostgresService) handleQuery(w io.Writer, query string) {
-	result, err := s.matcher.Execute(query)
+func (s *PostgresService) handleQuery(w io.Writer, query string, tx *transaction) {
+	defer metrics.TrackInFlight(s.name)()
+
+	// Simulate a loaded database; shutdown cuts the delay short
+	if s.latencyInjector != nil {
+		s.latencyInjector.Inject(s.ctx)
+	}
+
+	// A failed transaction block only accepts its end
+	if tx.status == txFailed && !endsTransaction(query) {
+		writeErrorResponse(w, "ERROR", "25P02", "current transaction is aborted, commands ignored until end of transaction block")
+		writeReadyForQuery(w, tx.status)
+		return
+	}
+
+	result, err := s.matcher.ExecuteTx(query, tx)
 	if err != nil {
+		tx.Fail()
 		writeErrorResponse(w, "ERROR", "42601", err.Error())
-		writeReadyForQuery(w, txIdle)
+		writeReadyForQuery(w, tx.status)
 		return
 	}
 
@@ -329,7 +433,7 @@ func (s *PostgresService) handleQuery(w io.Writer, query string) {
 	}
 
 	writeCommandComplete(w, result.Tag)
-	writeReadyForQuery(w, txIdle)
+	writeReadyForQuery(w, tx.status)
 }
 
 func init() {
@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
+	"slices"
 	"testing"
 	"time"
 
@@ -59,6 +62,43 @@ func TestNewPostgresService_WithTables(t *testing.T) {
 	require.Len(t, items, 10)
 }
 
+func TestNewPostgresService_RowRange(t *testing.T) {
+	seed := int64(7)
+	rowsMin, rowsMax := 5, 15
+	newCfg := func() *configpg.Service {
+		return &configpg.Service{
+			Name:   "testdb",
+			Listen: "127.0.0.1:0",
+			Tables: []*config.TableConfig{
+				{
+					Name:    "user",
+					RowsMin: &rowsMin,
+					RowsMax: &rowsMax,
+					Seed:    &seed,
+					Columns: []*config.ColumnConfig{
+						{Name: "id", Type: "uuid"},
+						{Name: "name", Type: "name"},
+					},
+				},
+			},
+		}
+	}
+
+	svc, err := NewPostgresService(newCfg(), slog.Default())
+	require.NoError(t, err)
+	items, err := svc.store.List("user")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(items), rowsMin)
+	require.LessOrEqual(t, len(items), rowsMax)
+
+	// Same seed yields the same row count
+	svc2, err := NewPostgresService(newCfg(), slog.Default())
+	require.NoError(t, err)
+	items2, err := svc2.store.List("user")
+	require.NoError(t, err)
+	require.Len(t, items2, len(items))
+}
+
 func startTestService(t *testing.T, cfg *configpg.Service) (*PostgresService, string) {
 	t.Helper()
 
@@ -189,6 +229,33 @@ func parseErrorMessage(body []byte) string {
 	return "unknown error"
 }
 
+// sendQueryError sends a query that is expected to fail, returning the
+// error's SQLSTATE and message
+func sendQueryError(t *testing.T, rw *bufio.ReadWriter, sql string) (string, string) {
+	t.Helper()
+
+	writeMessage(rw, msgQuery, append([]byte(sql), 0))
+	rw.Flush()
+
+	msgType, body, err := readMessage(rw)
+	require.NoError(t, err)
+	require.Equal(t, msgErrorResponse, msgType)
+
+	var code string
+	for i := 0; i < len(body)-1; {
+		field := body[i]
+		end := i + 1
+		for end < len(body) && body[end] != 0 {
+			end++
+		}
+		if field == errFieldCode {
+			code = string(body[i+1 : end])
+		}
+		i = end + 1
+	}
+	return code, parseErrorMessage(body)
+}
+
 func TestPostgresService_Connect_TrustAuth(t *testing.T) {
 	cfg := &configpg.Service{
 		Name:   "testdb",
@@ -200,6 +267,20 @@ func TestPostgresService_Connect_TrustAuth(t *testing.T) {
 	require.NotNil(t, rw)
 }
 
+func TestPostgresService_ResolvedAddress(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+	}
+
+	svc, addr := startTestService(t, cfg)
+	require.Equal(t, addr, svc.ResolvedAddress())
+
+	_, port, err := net.SplitHostPort(svc.ResolvedAddress())
+	require.NoError(t, err)
+	require.NotEqual(t, "0", port)
+}
+
 func TestPostgresService_Connect_MD5Auth(t *testing.T) {
 	cfg := &configpg.Service{
 		Name:   "testdb",
@@ -215,6 +296,105 @@ func TestPostgresService_Connect_MD5Auth(t *testing.T) {
 	require.NotNil(t, rw)
 }
 
+func TestNewPostgresService_JSONColumn(t *testing.T) {
+	seed := int64(42)
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "event",
+				Rows: 5,
+				Seed: &seed,
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "payload", Type: "json"},
+				},
+			},
+		},
+	}
+
+	svc, err := NewPostgresService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	// json columns are stored as JSON text
+	items, err := svc.store.List("event")
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+	for _, item := range items {
+		payload, ok := item["payload"].(string)
+		require.True(t, ok)
+		require.True(t, json.Valid([]byte(payload)), payload)
+	}
+	require.Equal(t, oidJSON, typeOIDForFakeType("json"))
+}
+
+func TestNewPostgresService_FormattedMoneyColumn(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "invoice",
+				Rows: 3,
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "total", Type: "money", Formatted: true},
+					{Name: "tax", Type: "decimal", Formatted: true},
+				},
+			},
+		},
+	}
+
+	svc, err := NewPostgresService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	// Only money columns are formatted, and sent as text
+	oids := make(map[string]int32)
+	for _, col := range svc.matcher.tables["invoice"] {
+		oids[col.Name] = col.TypeOID
+	}
+	require.Equal(t, int32(oidText), oids["total"])
+	require.Equal(t, typeOIDForFakeType("decimal"), oids["tax"])
+
+	items, err := svc.store.List("invoice")
+	require.NoError(t, err)
+	for _, item := range items {
+		require.IsType(t, "", item["total"])
+		require.IsType(t, float64(0), item["tax"])
+	}
+}
+
+func TestNewPostgresService_SequenceColumn(t *testing.T) {
+	start, step := 100, 5
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "account",
+				Rows: 4,
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "sequence", Start: &start, Step: &step},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}
+
+	svc, err := NewPostgresService(cfg, slog.Default())
+	require.NoError(t, err)
+
+	items, err := svc.store.List("account")
+	require.NoError(t, err)
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item["id"].(int))
+	}
+	slices.Sort(ids)
+	require.Equal(t, []int{100, 105, 110, 115}, ids)
+}
+
 func TestPostgresService_Query_Select(t *testing.T) {
 	seed := int64(42)
 	cfg := &configpg.Service{
@@ -302,3 +482,297 @@ func TestPostgresService_Query_MultipleQueries(t *testing.T) {
 	rows2, _ := sendQuery(t, rw, "SELECT * FROM users")
 	require.Len(t, rows2, 3)
 }
+
+func TestPostgresService_QueryErrors(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		QueryErrors: &config.QueryErrorConfig{
+			Rate:     1.0,
+			SQLState: "53300",
+			Message:  "too many connections",
+		},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	// Every query fails, and the session stays usable between them
+	for i := 0; i < 5; i++ {
+		code, msg := sendQueryError(t, rw, "SELECT 1")
+		require.Equal(t, "53300", code)
+		require.Equal(t, "too many connections", msg)
+
+		msgType, _, err := readMessage(rw)
+		require.NoError(t, err)
+		require.Equal(t, msgReadyForQuery, msgType)
+	}
+}
+
+func TestPostgresService_QueryErrors_Fatal(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		QueryErrors: &config.QueryErrorConfig{
+			Rate:  1.0,
+			Fatal: true,
+		},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	code, msg := sendQueryError(t, rw, "SELECT 1")
+	require.Equal(t, defaultErrorSQLState, code)
+	require.Equal(t, defaultErrorMessage, msg)
+
+	// The server closes the connection after a fatal error
+	_, _, err := readMessage(rw)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestPostgresService_QueryErrors_ZeroRate(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:        "testdb",
+		Listen:      "127.0.0.1:0",
+		QueryErrors: &config.QueryErrorConfig{Rate: 0},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	_, tag := sendQuery(t, rw, "SELECT 1")
+	require.NotEmpty(t, tag)
+}
+
+func TestPostgresService_Timing(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Timing: &config.TimingConfig{
+			P50: "10ms",
+			P90: "20ms",
+			P99: "40ms",
+		},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	const queries = 40
+	durations := make([]time.Duration, 0, queries)
+	for i := 0; i < queries; i++ {
+		start := time.Now()
+		sendQuery(t, rw, "SELECT 1")
+		durations = append(durations, time.Since(start))
+	}
+	slices.Sort(durations)
+
+	// No query is faster than p50, the median stays below p90 and the
+	// slowest near p99 (with slack for scheduling)
+	require.GreaterOrEqual(t, durations[0], 10*time.Millisecond)
+	require.Less(t, durations[queries/2], 30*time.Millisecond)
+	require.Less(t, durations[queries-1], 90*time.Millisecond)
+}
+
+func TestNewPostgresService_InvalidTiming(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Timing: &config.TimingConfig{P50: "fast", P90: "20ms", P99: "40ms"},
+	}
+
+	_, err := NewPostgresService(cfg, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timing.p50")
+}
+
+func TestPostgresService_Query_InformationSchema(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "order",
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "total", Type: "decimal"},
+					{Name: "created_at", Type: "datetime"},
+				},
+			},
+		},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	rows, tag := sendQuery(t, rw, "SELECT column_name, ordinal_position, udt_name FROM information_schema.columns WHERE table_name = 'orders'")
+	require.Equal(t, "SELECT 3", tag)
+	require.Equal(t, [][]string{
+		{"id", "1", "uuid"},
+		{"total", "2", "float8"},
+		{"created_at", "3", "timestamp"},
+	}, rows)
+}
+
+// sendQueryStatus sends a query, ignoring its results and errors, and
+// returns the transaction status from ReadyForQuery
+func sendQueryStatus(t *testing.T, rw *bufio.ReadWriter, sql string) byte {
+	t.Helper()
+
+	writeMessage(rw, msgQuery, append([]byte(sql), 0))
+	rw.Flush()
+
+	for {
+		msgType, body, err := readMessage(rw)
+		require.NoError(t, err)
+		if msgType == msgReadyForQuery {
+			return body[0]
+		}
+	}
+}
+
+func TestPostgresService_Transactions(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "user",
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "uuid"},
+					{Name: "name", Type: "name"},
+				},
+			},
+		},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	// A rolled-back insert doesn't persist
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "BEGIN"))
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "INSERT INTO users (id, name) VALUES ('u1', 'Alice')"))
+	rows, _ := sendQuery(t, rw, "SELECT * FROM users")
+	require.Len(t, rows, 1, "a transaction sees its own changes")
+	require.Equal(t, txIdle, sendQueryStatus(t, rw, "ROLLBACK"))
+	rows, _ = sendQuery(t, rw, "SELECT * FROM users")
+	require.Empty(t, rows)
+
+	// A committed insert does
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "BEGIN"))
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "INSERT INTO users (id, name) VALUES ('u2', 'Bob')"))
+	require.Equal(t, txIdle, sendQueryStatus(t, rw, "COMMIT"))
+	rows, _ = sendQuery(t, rw, "SELECT * FROM users")
+	require.Equal(t, [][]string{{"u2", "Bob"}}, rows)
+
+	// Updates and deletes are reverted too
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "BEGIN"))
+	sendQuery(t, rw, "UPDATE users SET name = 'Robert' WHERE id = 'u2'")
+	sendQuery(t, rw, "DELETE FROM users WHERE id = 'u2'")
+	require.Equal(t, txIdle, sendQueryStatus(t, rw, "ROLLBACK"))
+	rows, _ = sendQuery(t, rw, "SELECT * FROM users")
+	require.Equal(t, [][]string{{"u2", "Bob"}}, rows)
+}
+
+func TestPostgresService_FailedTransaction(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "user",
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "uuid"},
+				},
+			},
+		},
+	}
+
+	_, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "BEGIN"))
+	require.Equal(t, txInTransaction, sendQueryStatus(t, rw, "INSERT INTO users (id) VALUES ('u1')"))
+	require.Equal(t, txFailed, sendQueryStatus(t, rw, "SELECT * FROM missing"))
+
+	// Commands are rejected until the block ends
+	code, _ := sendQueryError(t, rw, "SELECT * FROM users")
+	require.Equal(t, "25P02", code)
+	msgType, body, err := readMessage(rw)
+	require.NoError(t, err)
+	require.Equal(t, msgReadyForQuery, msgType)
+	require.Equal(t, txFailed, body[0])
+
+	// COMMIT of a failed block rolls it back
+	_, tag := sendQuery(t, rw, "COMMIT")
+	require.Equal(t, "ROLLBACK", tag)
+	_, tag = sendQuery(t, rw, "SELECT * FROM users")
+	require.Equal(t, "SELECT 0", tag)
+}
+
+func TestPostgresService_DisconnectRollsBack(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:   "testdb",
+		Listen: "127.0.0.1:0",
+		Tables: []*config.TableConfig{
+			{
+				Name: "user",
+				Columns: []*config.ColumnConfig{
+					{Name: "id", Type: "uuid"},
+				},
+			},
+		},
+	}
+
+	svc, addr := startTestService(t, cfg)
+	rw := connectPG(t, addr, "test", "testdb", "")
+
+	sendQueryStatus(t, rw, "BEGIN")
+	sendQueryStatus(t, rw, "INSERT INTO users (id) VALUES ('u1')")
+	writeMessage(rw, msgTerminate, nil)
+	rw.Flush()
+
+	require.Eventually(t, func() bool {
+		items, err := svc.store.List("user")
+		return err == nil && len(items) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestPostgresService_MaxConnections(t *testing.T) {
+	cfg := &configpg.Service{
+		Name:           "testdb",
+		Listen:         "127.0.0.1:0",
+		MaxConnections: 2,
+	}
+
+	svc, addr := startTestService(t, cfg)
+	connectPG(t, addr, "test", "testdb", "")
+	second := connectPG(t, addr, "test", "testdb", "")
+
+	// The third connection is rejected after its startup message
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	params := "user\x00test\x00database\x00testdb\x00\x00"
+	binary.Write(rw, binary.BigEndian, int32(4+4+len(params)))
+	binary.Write(rw, binary.BigEndian, protocolVersion)
+	rw.WriteString(params)
+	rw.Flush()
+
+	msgType, body, err := readMessage(rw)
+	require.NoError(t, err)
+	require.Equal(t, msgErrorResponse, msgType)
+	require.Contains(t, string(body), "53300")
+	require.Equal(t, "sorry, too many clients already", parseErrorMessage(body))
+
+	// Disconnecting frees a slot
+	writeMessage(second, msgTerminate, nil)
+	second.Flush()
+	require.Eventually(t, func() bool {
+		return svc.connections.Load() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	connectPG(t, addr, "test", "testdb", "")
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+)
+
+// transaction tracks a connection's transaction block. Changes made inside
+// the block are applied to the store immediately, so other connections see
+// them, and an undo log reverts them on ROLLBACK.
+type transaction struct {
+	status byte
+	undo   []func() error
+}
+
+// newTransaction creates the idle transaction state for a new connection
+func newTransaction() *transaction {
+	return &transaction{status: txIdle}
+}
+
+// Begin starts a transaction block. A BEGIN inside a block is a no-op, as
+// in PostgreSQL.
+func (tx *transaction) Begin() {
+	if tx.status == txIdle {
+		tx.status = txInTransaction
+	}
+}
+
+// Commit ends the block, keeping its changes. Committing a failed block
+// rolls it back instead, and reports false.
+func (tx *transaction) Commit() (bool, error) {
+	if tx.status == txFailed {
+		return false, tx.Rollback()
+	}
+	tx.status = txIdle
+	tx.undo = nil
+	return true, nil
+}
+
+// Rollback reverts the block's changes in reverse order and ends it
+func (tx *transaction) Rollback() error {
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	tx.status = txIdle
+	tx.undo = nil
+	return errors.Join(errs...)
+}
+
+// Fail marks an open block as failed after an error
+func (tx *transaction) Fail() {
+	if tx.status == txInTransaction {
+		tx.status = txFailed
+	}
+}
+
+// Record adds a change's inverse to the undo log while a block is open.
+// Outside a block changes are committed immediately and nothing is kept.
+func (tx *transaction) Record(undo func() error) {
+	if tx != nil && tx.status == txInTransaction {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+// endsTransaction reports whether a query is COMMIT or ROLLBACK (or their
+// END and ABORT aliases)
+func endsTransaction(query string) bool {
+	words := strings.Fields(normalizeSQL(query))
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "commit", "end", "rollback", "abort":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// maxLoggedBodySize caps the bytes of each body written to the debug log
+const maxLoggedBodySize = 4 * 1024
+
+// peekBody reads the start of a body for logging and returns a body that
+// replays it ahead of the rest, so the exchange is forwarded unchanged
+func peekBody(body io.ReadCloser) (io.ReadCloser, string, bool) {
+	if body == nil || body == http.NoBody {
+		return body, "", false
+	}
+
+	prefix, _ := io.ReadAll(io.LimitReader(body, maxLoggedBodySize+1))
+	replay := struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+
+	if len(prefix) > maxLoggedBodySize {
+		return replay, string(prefix[:maxLoggedBodySize]), true
+	}
+	return replay, string(prefix), false
+}
+
+// redactedHeaders are credentials that are never written to the log
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactHeaders returns a copy of headers with credential values replaced
+func redactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, name := range redactedHeaders {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = []string{"[REDACTED]"}
+		}
+	}
+	return redacted
+}
+
+// logRequest logs a request as it is forwarded upstream. The body is only
+// buffered when debug logging is enabled.
+func logRequest(logger *slog.Logger, req *http.Request) {
+	if !logger.Enabled(req.Context(), slog.LevelDebug) {
+		return
+	}
+	var body string
+	var truncated bool
+	req.Body, body, truncated = peekBody(req.Body)
+	logger.Debug("proxy request",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"headers", redactHeaders(req.Header),
+		"body", body,
+		"truncated", truncated,
+	)
+}
+
+// logResponse logs an upstream response as it is returned to the client.
+// The body is only buffered when debug logging is enabled.
+func logResponse(logger *slog.Logger, resp *http.Response) {
+	ctx := context.Background()
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	var body string
+	var truncated bool
+	resp.Body, body, truncated = peekBody(resp.Body)
+	logger.Debug("proxy response",
+		"status", resp.StatusCode,
+		"headers", redactHeaders(resp.Header),
+		"body", body,
+		"truncated", truncated,
+	)
+}
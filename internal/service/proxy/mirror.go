@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+
+	configproxy "github.com/jumppad-labs/polymorph/internal/config/proxy"
+)
+
+const (
+	// mirrorTimeout bounds each mirrored request, which outlives the client's
+	mirrorTimeout = 30 * time.Second
+	// maxMirrorBodySize caps the request bodies that are buffered for the
+	// mirror; larger requests are proxied but not mirrored
+	maxMirrorBodySize = 1 << 20
+	// maxMirrorInFlight bounds the mirrored requests in flight; requests
+	// that arrive while it is full are not mirrored
+	maxMirrorInFlight = 64
+)
+
+// mirror sends copies of proxied requests to a shadow target
+type mirror struct {
+	target     *url.URL
+	sampleRate float64
+	client     *http.Client
+	logger     *slog.Logger
+
+	slots  chan struct{} // semaphore of in-flight mirrored requests
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newMirror creates a mirror from config, evaluating its target
+func newMirror(cfg *configproxy.Mirror, evalCtx *hcl.EvalContext, logger *slog.Logger) (*mirror, error) {
+	targetVal, diags := cfg.TargetExpr.Value(evalCtx)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to evaluate mirror target: %s", diags.Error())
+	}
+	target, err := url.Parse(targetVal.AsString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid mirror target URL: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &mirror{
+		target:     target,
+		sampleRate: 1,
+		client:     &http.Client{Timeout: mirrorTimeout},
+		logger:     logger,
+		slots:      make(chan struct{}, maxMirrorInFlight),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	if cfg.SampleRate != nil {
+		m.sampleRate = *cfg.SampleRate
+	}
+	return m, nil
+}
+
+// send copies a sampled request to the mirror target in the background. The
+// body is buffered so the original request can still be proxied. Requests
+// with bodies over maxMirrorBodySize, or that arrive while maxMirrorInFlight
+// mirrors are running, are not mirrored.
+func (m *mirror) send(r *http.Request) {
+	if m.sampleRate < 1 && rand.Float64() >= m.sampleRate {
+		return
+	}
+	if r.ContentLength > maxMirrorBodySize {
+		m.logger.Debug("mirror skipped request body over size limit", "size", r.ContentLength)
+		return
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		m.logger.Debug("mirror dropped request, too many in flight")
+		return
+	}
+	release := func() { <-m.slots }
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxMirrorBodySize+1))
+		// Replay what was read ahead of the rest, so the proxied request
+		// is unchanged
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		if err != nil {
+			release()
+			m.logger.Warn("mirror failed to read request body", "error", err)
+			return
+		}
+		if len(body) > maxMirrorBodySize {
+			release()
+			m.logger.Debug("mirror skipped request body over size limit", "size", len(body))
+			return
+		}
+	}
+
+	u := *m.target
+	u.Path = strings.TrimSuffix(m.target.Path, "/") + r.URL.Path
+	u.RawPath = ""
+	u.RawQuery = r.URL.RawQuery
+
+	// Detach from the client's request so the mirror isn't cancelled with
+	// it, but cancel it when the mirror is closed
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), mirrorTimeout)
+	stop := context.AfterFunc(m.ctx, cancel)
+	req, err := http.NewRequestWithContext(ctx, r.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		stop()
+		cancel()
+		release()
+		m.logger.Warn("mirror failed to create request", "error", err)
+		return
+	}
+	req.Header = r.Header.Clone()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer release()
+		defer stop()
+		defer cancel()
+		resp, err := m.client.Do(req)
+		if err != nil {
+			m.logger.Debug("mirror request failed", "url", u.String(), "error", err)
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// close cancels mirrored requests in flight and waits for them to finish
+func (m *mirror) close() {
+	m.cancel()
+	m.wg.Wait()
+}
@@ -0,0 +1,31 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	configproxy "github.com/jumppad-labs/polymorph/internal/config/proxy"
+)
+
+// rewritePath applies a path_rewrite to a request's URL. The prefix is only
+// stripped at a segment boundary, so /api/v1 does not match /api/v10.
+func rewritePath(rw *configproxy.PathRewrite, req *http.Request) {
+	req.URL.Path = rewrite(rw, req.URL.Path)
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = rewrite(rw, req.URL.RawPath)
+	}
+}
+
+func rewrite(rw *configproxy.PathRewrite, path string) string {
+	if prefix := strings.TrimSuffix(rw.StripPrefix, "/"); prefix != "" {
+		if path == prefix {
+			path = "/"
+		} else if strings.HasPrefix(path, prefix+"/") {
+			path = path[len(prefix):]
+		}
+	}
+	if prefix := strings.TrimSuffix(rw.AddPrefix, "/"); prefix != "" {
+		path = prefix + path
+	}
+	return path
+}
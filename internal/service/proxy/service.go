@@ -9,11 +9,9 @@ import (
 	"net/http/httputil"
 	"net/url"
 
-	"github.com/hashicorp/hcl/v2"
 	"github.com/jumppad-labs/polymorph/internal/config"
 	configproxy "github.com/jumppad-labs/polymorph/internal/config/proxy"
 	"github.com/jumppad-labs/polymorph/internal/service"
-	"github.com/zclconf/go-cty/cty"
 )
 
 // proxyRoute represents a route override
@@ -56,16 +54,19 @@ func (r *proxyRouter) match(method, path string) http.HandlerFunc {
 
 // ProxyService implements a reverse proxy service with transforms
 type ProxyService struct {
-	name        string
-	config      *configproxy.Service
-	logger      *slog.Logger
-	server      *http.Server
-	listener    net.Listener
-	proxy       *httputil.ReverseProxy
-	upstreamURL *url.URL
-	requestXfm  *Transform
-	responseXfm *Transform
-	router      *proxyRouter
+	name            string
+	config          *configproxy.Service
+	logger          *slog.Logger
+	server          *http.Server
+	listeners       []net.Listener
+	resolvedAddress string
+	readiness       *service.Readiness
+	proxy           *httputil.ReverseProxy
+	upstreamURL     *url.URL
+	requestXfm      *Transform
+	responseXfm     *Transform
+	router          *proxyRouter
+	mirror          *mirror
 }
 
 // NewProxyService creates a new proxy service
@@ -75,13 +76,7 @@ func NewProxyService(cfg *configproxy.Service, logger *slog.Logger) (*ProxyServi
 	}
 
 	// Evaluate target expression eagerly as a string (with service vars for service.* refs)
-	evalCtx := &hcl.EvalContext{
-		Functions: config.Functions(),
-		Variables: make(map[string]cty.Value),
-	}
-	if len(cfg.Vars) > 0 {
-		evalCtx.Variables["service"] = cty.ObjectVal(cfg.Vars)
-	}
+	evalCtx := config.NewEvalContext(cfg.Vars, cfg.Variables)
 	targetVal, diags := cfg.TargetExpr.Value(evalCtx)
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("failed to evaluate target: %s", diags.Error())
@@ -94,6 +89,18 @@ func NewProxyService(cfg *configproxy.Service, logger *slog.Logger) (*ProxyServi
 		return nil, fmt.Errorf("invalid target URL: %w", err)
 	}
 
+	// Evaluate the Host header override
+	var hostHeader string
+	if cfg.HostHeaderExpr != nil {
+		hostVal, diags := cfg.HostHeaderExpr.Value(evalCtx)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to evaluate host_header: %s", diags.Error())
+		}
+		if !hostVal.IsNull() {
+			hostHeader = hostVal.AsString()
+		}
+	}
+
 	// Parse request header transforms
 	var requestXfm *Transform
 	if cfg.RequestHeaders != nil {
@@ -112,6 +119,15 @@ func NewProxyService(cfg *configproxy.Service, logger *slog.Logger) (*ProxyServi
 		}
 	}
 
+	// Set up shadow traffic
+	var m *mirror
+	if cfg.Mirror != nil {
+		m, err = newMirror(cfg.Mirror, evalCtx, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create reverse proxy
 	proxy := httputil.NewSingleHostReverseProxy(upstreamURL)
 
@@ -127,6 +143,7 @@ func NewProxyService(cfg *configproxy.Service, logger *slog.Logger) (*ProxyServi
 		requestXfm:  requestXfm,
 		responseXfm: responseXfm,
 		router:      r,
+		mirror:      m,
 	}
 
 	// Add handle overrides to router
@@ -149,19 +166,43 @@ func NewProxyService(cfg *configproxy.Service, logger *slog.Logger) (*ProxyServi
 	// Customize proxy director to apply request transforms
 	defaultDirector := proxy.Director
 	proxy.Director = func(req *http.Request) {
-		// Apply default director (sets Host, URL, etc.)
+		// Map the path onto the target's before it is joined to the target URL
+		if cfg.PathRewrite != nil {
+			rewritePath(cfg.PathRewrite, req)
+		}
+
+		// Apply default director (sets URL, etc.)
 		defaultDirector(req)
 
+		// The default director keeps the client's Host; send the target's
+		// unless asked to preserve it or given an override
+		switch {
+		case hostHeader != "":
+			req.Host = hostHeader
+		case !cfg.PreserveHost:
+			req.Host = upstreamURL.Host
+		}
+
 		// Apply request transforms
 		if requestXfm != nil {
 			requestXfm.ApplyRequest(req)
 		}
+
+		// Log what is actually sent upstream
+		if cfg.LogBodies {
+			logRequest(logger, req)
+		}
 	}
 
 	// Customize proxy response modifier to apply response transforms
-	if responseXfm != nil {
+	if responseXfm != nil || cfg.LogBodies {
 		proxy.ModifyResponse = func(resp *http.Response) error {
-			responseXfm.ApplyResponse(resp)
+			if responseXfm != nil {
+				responseXfm.ApplyResponse(resp)
+			}
+			if cfg.LogBodies {
+				logResponse(logger, resp)
+			}
 			return nil
 		}
 	}
@@ -176,7 +217,7 @@ func (s *ProxyService) createHandlerOverride(handler *configproxy.Handler) http.
 		// TODO: Add step execution support if needed
 		if handler.Response != nil {
 			// Build evaluation context with functions
-			evalCtx := config.BuildEvalContext(r, nil, s.config.Vars)
+			evalCtx := config.BuildEvalContext(r, nil, s.config.Vars, s.config.Variables)
 
 			// Set status code
 			status := 200
@@ -227,6 +268,17 @@ func (s *ProxyService) Address() string {
 	return s.config.Listen
 }
 
+// ResolvedAddress returns the address the service bound to, which differs
+// from Address when listening on port 0. It is empty before Start.
+func (s *ProxyService) ResolvedAddress() string {
+	return s.resolvedAddress
+}
+
+// SetReadiness sets the readiness reported on the health endpoint
+func (s *ProxyService) SetReadiness(r *service.Readiness) {
+	s.readiness = r
+}
+
 // Upstreams returns the list of upstream service dependencies
 func (s *ProxyService) Upstreams() []string {
 	return s.config.Upstreams
@@ -234,29 +286,34 @@ func (s *ProxyService) Upstreams() []string {
 
 // Start starts the proxy server
 func (s *ProxyService) Start(ctx context.Context) error {
-	// Create listener
-	listener, err := net.Listen("tcp", s.config.Listen)
-	if err != nil {
-		return fmt.Errorf("failed to create listener: %w", err)
-	}
-
-	// Wrap with TLS if configured
-	listener, err = service.WrapListenerTLS(listener, s.config.TLS)
+	// Bind every listen address, wrapped with TLS if configured
+	addrs := append([]string{s.config.Listen}, s.config.ExtraListen...)
+	listeners, err := service.ListenAll(addrs, s.config.TLS, nil)
 	if err != nil {
-		listener.Close()
-		return fmt.Errorf("failed to configure TLS: %w", err)
+		return err
 	}
-	s.listener = listener
+	s.listeners = listeners
+	s.resolvedAddress = service.ListenerAddress(listeners[0])
 
 	// Create HTTP handler that checks router first, then proxies
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Serve readiness on the health path if one is set; otherwise
+		// health checks are forwarded like any other request
+		if s.config.HealthPath != "" && r.URL.Path == s.config.HealthPath {
+			s.readiness.ServeHTTP(w, r)
+			return
+		}
+
 		// Check if there's a handle override for this route
 		if handlerFn := s.router.match(r.Method, r.URL.Path); handlerFn != nil {
 			handlerFn(w, r)
 			return
 		}
 
-		// Otherwise, proxy to upstream
+		// Otherwise, proxy to upstream, copying the request to any mirror
+		if s.mirror != nil {
+			s.mirror.send(r)
+		}
 		s.proxy.ServeHTTP(w, r)
 	})
 
@@ -270,12 +327,14 @@ func (s *ProxyService) Start(ctx context.Context) error {
 	if s.config.TLS != nil {
 		proto = "Proxy (TLS)"
 	}
-	go func() {
-		s.logger.Info("service listening", "proto", proto, "addr", s.config.Listen, "target", s.upstreamURL.String())
-		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			s.logger.Error("server error", "error", err)
-		}
-	}()
+	for i, listener := range listeners {
+		go func() {
+			s.logger.Info("service listening", "proto", proto, "addr", addrs[i], "target", s.upstreamURL.String())
+			if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("server error", "error", err)
+			}
+		}()
+	}
 
 	return nil
 }
@@ -287,7 +346,11 @@ func (s *ProxyService) Stop(ctx context.Context) error {
 	}
 
 	s.logger.Info("stopping service")
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+	if s.mirror != nil {
+		s.mirror.close()
+	}
+	return err
 }
 
 // parseRoute parses a route string like "GET /path" into method and path
@@ -0,0 +1,429 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	configproxy "github.com/jumppad-labs/polymorph/internal/config/proxy"
+)
+
+// syncBuffer is a bytes.Buffer safe for use by a logger and a test at once
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// proxyConfig returns a proxy config listening on a free port in front of
+// target
+func proxyConfig(target string) *configproxy.Service {
+	return &configproxy.Service{
+		Name:       "proxy",
+		Listen:     "127.0.0.1:0",
+		TargetExpr: hcl.StaticExpr(cty.StringVal(target), hcl.Range{}),
+	}
+}
+
+// startProxy starts a proxy service and returns its base URL
+func startProxy(t *testing.T, cfg *configproxy.Service, logger *slog.Logger) string {
+	t.Helper()
+
+	svc, err := NewProxyService(cfg, logger)
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+
+	return "http://" + svc.ResolvedAddress()
+}
+
+func TestProxyService_LogBodies(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=upstream-secret")
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer upstream.Close()
+
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg := proxyConfig(upstream.URL)
+	cfg.LogBodies = true
+	cfg.RequestHeaders = hcl.StaticExpr(cty.ObjectVal(map[string]cty.Value{
+		"X-Proxy": cty.StringVal("polymorph"),
+	}), hcl.Range{})
+	baseURL := startProxy(t, cfg, logger)
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/users", strings.NewReader(`{"name":"ada"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer client-secret")
+	req.Header.Set("Cookie", "session=client-secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	// The exchange is forwarded unchanged
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"echo":{"name":"ada"}}`, string(body))
+
+	out := logs.String()
+	require.Contains(t, out, `msg="proxy request" method=POST`)
+	require.Contains(t, out, `body="{\"name\":\"ada\"}"`)
+	require.Contains(t, out, "X-Proxy:[polymorph]")
+	require.Contains(t, out, `msg="proxy response" status=200`)
+	require.Contains(t, out, `body="{\"echo\":{\"name\":\"ada\"}}"`)
+
+	// Credentials are redacted
+	require.Contains(t, out, "Authorization:[[REDACTED]]")
+	require.Contains(t, out, "Set-Cookie:[[REDACTED]]")
+	require.NotContains(t, out, "secret")
+}
+
+func TestLogRequest_SkipsBodyBelowDebug(t *testing.T) {
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	body := io.NopCloser(strings.NewReader("payload"))
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	req.Body = body
+	logRequest(logger, req)
+
+	// The body is left untouched and nothing is logged
+	require.Equal(t, body, req.Body)
+	require.Empty(t, logs.String())
+}
+
+func TestPeekBody(t *testing.T) {
+	large := strings.Repeat("x", maxLoggedBodySize+10)
+	body, logged, truncated := peekBody(io.NopCloser(strings.NewReader(large)))
+	require.True(t, truncated)
+	require.Len(t, logged, maxLoggedBodySize)
+
+	replayed, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, large, string(replayed))
+}
+
+func TestProxyService_HostHeader(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer upstream.Close()
+	upstreamHost := strings.TrimPrefix(upstream.URL, "http://")
+
+	tests := []struct {
+		name      string
+		configure func(cfg *configproxy.Service)
+		want      string
+	}{
+		{
+			name:      "target host by default",
+			configure: func(cfg *configproxy.Service) {},
+			want:      upstreamHost,
+		},
+		{
+			name:      "preserve host",
+			configure: func(cfg *configproxy.Service) { cfg.PreserveHost = true },
+			want:      "api.example.com",
+		},
+		{
+			name: "host header override",
+			configure: func(cfg *configproxy.Service) {
+				cfg.HostHeaderExpr = hcl.StaticExpr(cty.StringVal("api.internal"), hcl.Range{})
+			},
+			want: "api.internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := proxyConfig(upstream.URL)
+			tt.configure(cfg)
+			baseURL := startProxy(t, cfg, slog.Default())
+
+			req, err := http.NewRequest("GET", baseURL+"/", nil)
+			require.NoError(t, err)
+			req.Host = "api.example.com"
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(body))
+		})
+	}
+}
+
+func TestProxyService_HealthPath(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("upstream " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	get := func(url string) string {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// The upstream's health check is forwarded by default
+	baseURL := startProxy(t, proxyConfig(upstream.URL), slog.Default())
+	require.Equal(t, "upstream /healthz", get(baseURL+"/healthz"))
+
+	// With a health path the proxy answers with its own readiness
+	cfg := proxyConfig(upstream.URL)
+	cfg.HealthPath = "/healthz"
+	baseURL = startProxy(t, cfg, slog.Default())
+	require.JSONEq(t, `{"status":"ready"}`, get(baseURL+"/healthz"))
+	require.Equal(t, "upstream /ready", get(baseURL+"/ready"))
+}
+
+func TestProxyService_PathRewrite(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name    string
+		target  string
+		rewrite *configproxy.PathRewrite
+		path    string
+		want    string
+	}{
+		{
+			name:    "strip prefix",
+			target:  upstream.URL,
+			rewrite: &configproxy.PathRewrite{StripPrefix: "/api/v1"},
+			path:    "/api/v1/users?page=2",
+			want:    "/users?page=2",
+		},
+		{
+			name:    "strip whole path",
+			target:  upstream.URL,
+			rewrite: &configproxy.PathRewrite{StripPrefix: "/api/v1/"},
+			path:    "/api/v1",
+			want:    "/",
+		},
+		{
+			name:    "prefix must match a whole segment",
+			target:  upstream.URL,
+			rewrite: &configproxy.PathRewrite{StripPrefix: "/api/v1"},
+			path:    "/api/v10/users",
+			want:    "/api/v10/users",
+		},
+		{
+			name:    "add prefix",
+			target:  upstream.URL,
+			rewrite: &configproxy.PathRewrite{AddPrefix: "/internal"},
+			path:    "/users?q=a%20b",
+			want:    "/internal/users?q=a%20b",
+		},
+		{
+			name:    "strip and add under a target path",
+			target:  upstream.URL + "/base",
+			rewrite: &configproxy.PathRewrite{StripPrefix: "/api/v1", AddPrefix: "/internal"},
+			path:    "/api/v1/users/42",
+			want:    "/base/internal/users/42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := proxyConfig(tt.target)
+			cfg.PathRewrite = tt.rewrite
+			baseURL := startProxy(t, cfg, slog.Default())
+
+			resp, err := http.Get(baseURL + tt.path)
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(body))
+		})
+	}
+}
+
+func TestProxyService_Mirror(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("primary:" + string(body)))
+	}))
+	defer primary.Close()
+
+	type mirrored struct {
+		method, uri, header, body string
+	}
+	received := make(chan mirrored, 1)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- mirrored{r.Method, r.URL.RequestURI(), r.Header.Get("X-Test"), string(body)}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("shadow"))
+	}))
+	defer shadow.Close()
+
+	cfg := proxyConfig(primary.URL)
+	cfg.Mirror = &configproxy.Mirror{
+		TargetExpr: hcl.StaticExpr(cty.StringVal(shadow.URL), hcl.Range{}),
+	}
+	baseURL := startProxy(t, cfg, slog.Default())
+
+	req, err := http.NewRequest("POST", baseURL+"/orders?dry=1", strings.NewReader("order-1"))
+	require.NoError(t, err)
+	req.Header.Set("X-Test", "yes")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	// The client only sees the primary's response
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "primary:order-1", string(body))
+
+	select {
+	case got := <-received:
+		require.Equal(t, mirrored{"POST", "/orders?dry=1", "yes", "order-1"}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirror did not receive the request")
+	}
+}
+
+func TestProxyService_MirrorSkipsLargeBodies(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(strconv.Itoa(len(body))))
+	}))
+	defer primary.Close()
+
+	received := make(chan int, 2)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- len(body)
+	}))
+	defer shadow.Close()
+
+	cfg := proxyConfig(primary.URL)
+	cfg.Mirror = &configproxy.Mirror{
+		TargetExpr: hcl.StaticExpr(cty.StringVal(shadow.URL), hcl.Range{}),
+	}
+	baseURL := startProxy(t, cfg, slog.Default())
+
+	post := func(body io.Reader) string {
+		resp, err := http.Post(baseURL+"/upload", "text/plain", body)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(got)
+	}
+
+	// A chunked body over the cap is proxied whole but not mirrored
+	large := strings.Repeat("x", maxMirrorBodySize+1)
+	require.Equal(t, strconv.Itoa(len(large)), post(struct{ io.Reader }{strings.NewReader(large)}))
+	require.Equal(t, "5", post(strings.NewReader("small")))
+
+	select {
+	case got := <-received:
+		require.Equal(t, 5, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirror did not receive the request")
+	}
+	require.Empty(t, received)
+}
+
+func TestMirror_DropsWhenFullAndCancelsOnClose(t *testing.T) {
+	received := make(chan struct{}, 2)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer shadow.Close()
+
+	m, err := newMirror(&configproxy.Mirror{
+		TargetExpr: hcl.StaticExpr(cty.StringVal(shadow.URL), hcl.Range{}),
+	}, nil, slog.Default())
+	require.NoError(t, err)
+	m.slots = make(chan struct{}, 1)
+
+	m.send(httptest.NewRequest(http.MethodGet, "/first", nil))
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirror did not receive the request")
+	}
+
+	// The only slot is taken, so the second request is dropped
+	m.send(httptest.NewRequest(http.MethodGet, "/second", nil))
+
+	// Closing cancels the blocked request rather than waiting out its timeout
+	done := make(chan struct{})
+	go func() {
+		m.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("close did not cancel the mirrored request")
+	}
+	require.Empty(t, received)
+}
+
+func TestProxyService_MirrorFailure(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("primary"))
+	}))
+	defer primary.Close()
+
+	// Nothing listens on the mirror target
+	shadow := httptest.NewServer(http.NotFoundHandler())
+	shadow.Close()
+
+	cfg := proxyConfig(primary.URL)
+	cfg.Mirror = &configproxy.Mirror{
+		TargetExpr: hcl.StaticExpr(cty.StringVal(shadow.URL), hcl.Range{}),
+	}
+	baseURL := startProxy(t, cfg, slog.Default())
+
+	resp, err := http.Get(baseURL + "/")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "primary", string(body))
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"net/http"
+	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -12,12 +14,29 @@ type RateLimitConfig struct {
 	Status  int               // HTTP status code when limited (default 429)
 	Headers map[string]string // Response headers
 	Body    string            // Response body
+	PerIP   bool              // Give each client IP its own bucket
 }
 
+// minClientIdle is the shortest time a per-IP bucket is kept after its
+// client's last request
+const minClientIdle = time.Minute
+
 // RateLimiter limits requests using a token bucket.
 type RateLimiter struct {
 	limiter *rate.Limiter
 	config  RateLimitConfig
+
+	mu        sync.Mutex
+	clients   map[string]*clientBucket // per-IP buckets, when PerIP is set
+	idle      time.Duration            // how long an unused bucket is kept
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// clientBucket is a client's token bucket and when it was last used
+type clientBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
 }
 
 // NewRateLimiter creates a new rate limiter.
@@ -25,20 +44,67 @@ func NewRateLimiter(config RateLimitConfig) *RateLimiter {
 	if config.Status == 0 {
 		config.Status = http.StatusTooManyRequests
 	}
-	// Burst allows small spikes up to the RPS value
-	burst := int(config.RPS)
-	if burst < 1 {
-		burst = 1
+	limiter := newBucket(config.RPS)
+
+	// A bucket left idle long enough to refill is the same as a new one,
+	// so dropping it never lets a client through early
+	idle := minClientIdle
+	if config.RPS > 0 {
+		refill := time.Duration(float64(limiter.Burst()) / config.RPS * float64(time.Second))
+		idle = max(idle, refill)
 	}
 	return &RateLimiter{
-		limiter: rate.NewLimiter(rate.Limit(config.RPS), burst),
+		limiter: limiter,
 		config:  config,
+		clients: make(map[string]*clientBucket),
+		idle:    idle,
+		now:     time.Now,
 	}
 }
 
-// Allow checks if a request is allowed. Returns true if under the limit.
-func (r *RateLimiter) Allow() bool {
-	return r.limiter.Allow()
+// newBucket creates a token bucket whose burst allows small spikes up to
+// the RPS value
+func newBucket(rps float64) *rate.Limiter {
+	burst := int(rps)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(rps), burst)
+}
+
+// Allow checks if a request from clientIP is allowed. Returns true if under
+// the limit. The IP is ignored unless the limiter is per-IP.
+func (r *RateLimiter) Allow(clientIP string) bool {
+	if !r.config.PerIP {
+		return r.limiter.Allow()
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	r.sweep(now)
+	client, ok := r.clients[clientIP]
+	if !ok {
+		client = &clientBucket{limiter: newBucket(r.config.RPS)}
+		r.clients[clientIP] = client
+	}
+	client.lastSeen = now
+	r.mu.Unlock()
+	return client.limiter.Allow()
+}
+
+// sweep drops the buckets of clients idle for longer than r.idle, at most
+// once per idle period, so the map doesn't grow with every IP ever seen.
+// The caller holds r.mu.
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.idle {
+		return
+	}
+	r.lastSweep = now
+	for ip, client := range r.clients {
+		if now.Sub(client.lastSeen) > r.idle {
+			delete(r.clients, ip)
+		}
+	}
 }
 
 // WriteError writes a rate limit response.
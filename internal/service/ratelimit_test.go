@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +16,7 @@ func TestRateLimiter_AllowsUnderLimit(t *testing.T) {
 
 	// First burst of requests should all be allowed
 	for range 50 {
-		require.True(t, rl.Allow())
+		require.True(t, rl.Allow("10.0.0.1"))
 	}
 }
 
@@ -26,11 +27,44 @@ func TestRateLimiter_BlocksOverLimit(t *testing.T) {
 
 	// Exhaust the burst (burst = RPS = 5)
 	for range 5 {
-		require.True(t, rl.Allow())
+		require.True(t, rl.Allow("10.0.0.1"))
 	}
 
 	// Next request should be blocked
-	require.False(t, rl.Allow())
+	require.False(t, rl.Allow("10.0.0.1"))
+}
+
+func TestRateLimiter_PerIP(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{
+		RPS:   2,
+		PerIP: true,
+	})
+
+	require.True(t, rl.Allow("10.0.0.1"))
+	require.True(t, rl.Allow("10.0.0.1"))
+	require.False(t, rl.Allow("10.0.0.1"))
+
+	// Another client has its own bucket
+	require.True(t, rl.Allow("10.0.0.2"))
+}
+
+func TestRateLimiter_PerIPSweepsIdleClients(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{
+		RPS:   1,
+		PerIP: true,
+	})
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+
+	require.True(t, rl.Allow("10.0.0.1"))
+	require.False(t, rl.Allow("10.0.0.1"))
+	require.Len(t, rl.clients, 1)
+
+	// Once idle past the timeout, the next request sweeps the old bucket
+	now = now.Add(rl.idle + time.Second)
+	require.True(t, rl.Allow("10.0.0.2"))
+	require.Len(t, rl.clients, 1)
+	require.Contains(t, rl.clients, "10.0.0.2")
 }
 
 func TestRateLimiter_DefaultStatus(t *testing.T) {
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HealthPath is the path HTTP-based services serve readiness on
+const HealthPath = "/healthz"
+
+const (
+	// DefaultReadinessTimeout bounds how long a service waits for its upstreams
+	DefaultReadinessTimeout = 30 * time.Second
+	// DefaultReadinessInterval is the delay between upstream dial attempts
+	DefaultReadinessInterval = 100 * time.Millisecond
+)
+
+// Readiness reports whether a service's upstreams are reachable and whether
+// it is draining ahead of shutdown. A nil Readiness is always ready.
+type Readiness struct {
+	ready    atomic.Bool
+	draining atomic.Bool
+}
+
+// Ready returns true once all upstreams have accepted a connection, until
+// the service starts draining
+func (r *Readiness) Ready() bool {
+	if r == nil {
+		return true
+	}
+	return r.ready.Load() && !r.draining.Load()
+}
+
+// Draining returns true once Drain has been called
+func (r *Readiness) Draining() bool {
+	return r != nil && r.draining.Load()
+}
+
+// Drain flags the service as not ready so load balancers stop sending new
+// requests, while requests already in flight carry on
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// MarkReady flags the service as ready
+func (r *Readiness) MarkReady() {
+	r.ready.Store(true)
+}
+
+// State returns "starting" until ready, "ready", and "draining" once Drain
+// has been called
+func (r *Readiness) State() string {
+	switch {
+	case r.Draining():
+		return "draining"
+	case !r.Ready():
+		return "starting"
+	}
+	return "ready"
+}
+
+// ServeHTTP writes the readiness state, returning 503 until ready and once
+// draining
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	code := http.StatusOK
+	if !r.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": r.State()})
+}
+
+// WaitForAddresses dials each address until it accepts a connection,
+// retrying every interval until ctx is done.
+func WaitForAddresses(ctx context.Context, addrs []string, interval time.Duration) error {
+	for _, addr := range addrs {
+		network, target := splitNetwork(dialAddress(addr))
+		for {
+			conn, err := net.DialTimeout(network, target, interval)
+			if err == nil {
+				conn.Close()
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("upstream %s not reachable: %w", addr, err)
+			case <-time.After(interval):
+			}
+		}
+	}
+	return nil
+}
+
+// dialAddress rewrites an unspecified listen host (0.0.0.0, ::) to loopback
+func dialAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		return net.JoinHostPort("localhost", port)
+	}
+	return addr
+}
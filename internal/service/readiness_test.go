@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness_ServeHTTP(t *testing.T) {
+	readiness := &Readiness{}
+
+	rec := httptest.NewRecorder()
+	readiness.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.JSONEq(t, `{"status":"starting"}`, rec.Body.String())
+
+	readiness.MarkReady()
+
+	rec = httptest.NewRecorder()
+	readiness.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"ready"}`, rec.Body.String())
+
+	readiness.Drain()
+
+	rec = httptest.NewRecorder()
+	readiness.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.JSONEq(t, `{"status":"draining"}`, rec.Body.String())
+	require.False(t, readiness.Ready())
+
+	// A service without a registry is always ready
+	var unset *Readiness
+	require.True(t, unset.Ready())
+	require.False(t, unset.Draining())
+}
+
+func TestRegistry_ReadinessWaitsForUpstream(t *testing.T) {
+	// Reserve a free port for the backend, then release it so nothing listens yet
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	backendAddr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	backend := &mockService{name: "backend", typ: "http", resolved: backendAddr}
+	gateway := &mockService{name: "gateway", typ: "proxy", upstreams: []string{"backend"}}
+
+	registry := NewRegistry(nil)
+	registry.readinessInterval = 10 * time.Millisecond
+	registry.Register(backend)
+	registry.Register(gateway)
+
+	ctx := context.Background()
+	require.NoError(t, registry.Start(ctx))
+	defer registry.Stop(ctx)
+
+	require.True(t, registry.Readiness("backend").Ready())
+
+	// The gateway stays unready while the backend isn't accepting connections
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, registry.Readiness("gateway").Ready())
+
+	l, err = net.Listen("tcp", backendAddr)
+	require.NoError(t, err)
+	defer l.Close()
+
+	require.Eventually(t, func() bool {
+		return registry.Readiness("gateway").Ready()
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestWaitForAddresses_Timeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = WaitForAddresses(ctx, []string{addr}, 10*time.Millisecond)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not reachable")
+}
+
+func TestDialAddress(t *testing.T) {
+	require.Equal(t, "localhost:8080", dialAddress("0.0.0.0:8080"))
+	require.Equal(t, "localhost:8080", dialAddress(":8080"))
+	require.Equal(t, "localhost:8080", dialAddress("[::]:8080"))
+	require.Equal(t, "127.0.0.1:8080", dialAddress("127.0.0.1:8080"))
+}
@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// execute runs a command and writes its reply. It returns true when the
+// client asked to close the connection.
+func (s *RedisService) execute(w *bufio.Writer, args []string) bool {
+	name := strings.ToLower(args[0])
+	args = args[1:]
+
+	arity := func(min, max int) bool {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+			return false
+		}
+		return true
+	}
+
+	switch name {
+	case "ping":
+		if !arity(0, 1) {
+			break
+		}
+		if len(args) == 1 {
+			writeBulkString(w, args[0])
+		} else {
+			writeSimpleString(w, "PONG")
+		}
+
+	case "get":
+		if !arity(1, 1) {
+			break
+		}
+		if value, ok := s.keys.Get(args[0]); ok {
+			writeBulkString(w, value)
+		} else {
+			writeNull(w)
+		}
+
+	case "set":
+		if !arity(2, 4) {
+			break
+		}
+		ttl, err := parseSetOptions(args[2:])
+		if err != nil {
+			writeError(w, err.Error())
+			break
+		}
+		if err := s.keys.Set(args[0], args[1], ttl); err != nil {
+			writeError(w, "ERR "+err.Error())
+			break
+		}
+		writeSimpleString(w, "OK")
+
+	case "del":
+		if !arity(1, -1) {
+			break
+		}
+		var n int64
+		for _, key := range args {
+			if s.keys.Delete(key) {
+				n++
+			}
+		}
+		writeInteger(w, n)
+
+	case "exists":
+		if !arity(1, -1) {
+			break
+		}
+		var n int64
+		for _, key := range args {
+			if s.keys.Exists(key) {
+				n++
+			}
+		}
+		writeInteger(w, n)
+
+	case "incr":
+		if !arity(1, 1) {
+			break
+		}
+		n, err := s.keys.Incr(args[0])
+		if err != nil {
+			writeError(w, "ERR "+err.Error())
+			break
+		}
+		writeInteger(w, n)
+
+	case "expire":
+		if !arity(2, 2) {
+			break
+		}
+		seconds, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			writeError(w, "ERR "+errNotInteger.Error())
+			break
+		}
+		ok, err := s.keys.Expire(args[0], time.Duration(seconds)*time.Second)
+		if err != nil {
+			writeError(w, "ERR "+err.Error())
+			break
+		}
+		writeInteger(w, boolToInt(ok))
+
+	case "quit":
+		writeSimpleString(w, "OK")
+		return true
+
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", truncateName(name)))
+	}
+	return false
+}
+
+// parseSetOptions parses the EX/PX options of SET into a ttl
+func parseSetOptions(opts []string) (time.Duration, error) {
+	if len(opts) == 0 {
+		return 0, nil
+	}
+	if len(opts) != 2 {
+		return 0, fmt.Errorf("ERR syntax error")
+	}
+
+	n, err := strconv.ParseInt(opts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ERR %s", errNotInteger)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("ERR invalid expire time in 'set' command")
+	}
+
+	switch strings.ToUpper(opts[0]) {
+	case "EX":
+		return time.Duration(n) * time.Second, nil
+	case "PX":
+		return time.Duration(n) * time.Millisecond, nil
+	default:
+		return 0, fmt.Errorf("ERR syntax error")
+	}
+}
+
+// truncateName truncates a command name for echoing in an error
+func truncateName(name string) string {
+	if len(name) > 128 {
+		return name[:128]
+	}
+	return name
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/resource"
+)
+
+// keysTable is the resource store table holding the keyspace
+const keysTable = "keys"
+
+// errNotInteger is returned when INCR targets a non-integer value
+var errNotInteger = fmt.Errorf("value is not an integer or out of range")
+
+// keyspace stores string keys in a resource store. Keys with an expiry are
+// removed lazily when next accessed.
+type keyspace struct {
+	store *resource.Store
+	// mu serializes read-modify-write commands such as INCR
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// newKeyspace creates an empty keyspace
+func newKeyspace() (*keyspace, error) {
+	store := resource.NewStore()
+	schema := resource.Schema{
+		Name: keysTable,
+		Fields: []resource.Field{
+			{Name: "key", Type: resource.FieldTypeString, PrimaryKey: true},
+			{Name: "value", Type: resource.FieldTypeString},
+			{Name: "expires_at", Type: resource.FieldTypeInt},
+		},
+	}
+	if err := store.CreateTable(keysTable, schema); err != nil {
+		return nil, fmt.Errorf("failed to create keyspace: %w", err)
+	}
+	return &keyspace{store: store, now: time.Now}, nil
+}
+
+// lookup returns a live key's item, deleting it if it has expired
+func (ks *keyspace) lookup(key string) (map[string]any, bool) {
+	item, err := ks.store.Get(keysTable, key)
+	if err != nil {
+		return nil, false
+	}
+	if expiresAt := item["expires_at"].(int64); expiresAt != 0 && ks.now().UnixMilli() >= expiresAt {
+		ks.store.Delete(keysTable, key)
+		return nil, false
+	}
+	return item, true
+}
+
+// Get returns the value of a key
+func (ks *keyspace) Get(key string) (string, bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	item, ok := ks.lookup(key)
+	if !ok {
+		return "", false
+	}
+	return item["value"].(string), true
+}
+
+// Set stores a value, expiring it after ttl when ttl is positive
+func (ks *keyspace) Set(key, value string, ttl time.Duration) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.put(key, value, ks.expiry(ttl))
+}
+
+// put inserts or replaces a key
+func (ks *keyspace) put(key, value string, expiresAt int64) error {
+	return ks.store.Insert(keysTable, map[string]any{
+		"key":        key,
+		"value":      value,
+		"expires_at": expiresAt,
+	})
+}
+
+// expiry converts a ttl to an absolute expiry in unix milliseconds
+func (ks *keyspace) expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return ks.now().Add(ttl).UnixMilli()
+}
+
+// Delete removes a key, returning whether it existed
+func (ks *keyspace) Delete(key string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, ok := ks.lookup(key); !ok {
+		return false
+	}
+	return ks.store.Delete(keysTable, key) == nil
+}
+
+// Exists reports whether a key is set
+func (ks *keyspace) Exists(key string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	_, ok := ks.lookup(key)
+	return ok
+}
+
+// Incr increments an integer value, treating a missing key as 0. The key's
+// expiry is preserved.
+func (ks *keyspace) Incr(key string) (int64, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	var n, expiresAt int64
+	if item, ok := ks.lookup(key); ok {
+		var err error
+		n, err = strconv.ParseInt(item["value"].(string), 10, 64)
+		if err != nil || n == 1<<63-1 {
+			return 0, errNotInteger
+		}
+		expiresAt = item["expires_at"].(int64)
+	}
+
+	n++
+	if err := ks.put(key, strconv.FormatInt(n, 10), expiresAt); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Expire sets a key's time to live, returning whether the key exists. A
+// non-positive ttl deletes the key.
+func (ks *keyspace) Expire(key string, ttl time.Duration) (bool, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	item, ok := ks.lookup(key)
+	if !ok {
+		return false, nil
+	}
+	if ttl <= 0 {
+		return true, ks.store.Delete(keysTable, key)
+	}
+	return true, ks.put(key, item["value"].(string), ks.expiry(ttl))
+}
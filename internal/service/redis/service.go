@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	configredis "github.com/jumppad-labs/polymorph/internal/config/redis"
+	"github.com/jumppad-labs/polymorph/internal/service"
+)
+
+// RedisService implements a fake Redis server speaking RESP
+type RedisService struct {
+	name            string
+	config          *configredis.Service
+	logger          *slog.Logger
+	keys            *keyspace
+	listener        net.Listener
+	resolvedAddress string
+	wg              sync.WaitGroup
+	ctx             context.Context
+	cancel          context.CancelFunc
+}
+
+// NewRedisService creates a new Redis service with an empty keyspace
+func NewRedisService(cfg *configredis.Service, logger *slog.Logger) (*RedisService, error) {
+	keys, err := newKeyspace()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisService{
+		name:   cfg.Name,
+		config: cfg,
+		logger: logger,
+		keys:   keys,
+	}, nil
+}
+
+func (s *RedisService) Name() string        { return s.name }
+func (s *RedisService) Type() string        { return "redis" }
+func (s *RedisService) Address() string     { return s.config.Listen }
+func (s *RedisService) Upstreams() []string { return s.config.Upstreams }
+
+// ResolvedAddress returns the address the service bound to, or an empty
+// string before Start.
+func (s *RedisService) ResolvedAddress() string { return s.resolvedAddress }
+
+// Start begins listening for Redis client connections
+func (s *RedisService) Start(ctx context.Context) error {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	listener, err := service.Listen(s.config.Listen)
+	if err != nil {
+		return err
+	}
+
+	wrapped, err := service.WrapListenerTLS(listener, s.config.TLS)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	s.listener = wrapped
+	s.resolvedAddress = service.ListenerAddress(wrapped)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.acceptLoop()
+	}()
+
+	proto := "Redis"
+	if s.config.TLS != nil {
+		proto = "Redis (TLS)"
+	}
+	s.logger.Info("service listening", "proto", proto, "addr", s.config.Listen)
+	return nil
+}
+
+// Stop closes the listener and all client connections
+func (s *RedisService) Stop(ctx context.Context) error {
+	if s.listener == nil {
+		return nil
+	}
+
+	s.logger.Info("stopping service")
+
+	// Cancel context first so accept loop sees shutdown before listener close error
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if err := s.listener.Close(); err != nil {
+		return fmt.Errorf("failed to close listener: %w", err)
+	}
+	s.wg.Wait()
+	return nil
+}
+
+func (s *RedisService) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.ctx.Done():
+				return
+			default:
+				s.logger.Error("accept error", "error", err)
+				continue
+			}
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConnection(conn)
+		}()
+	}
+}
+
+func (s *RedisService) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	// Clients hold connections open between commands, so close them on shutdown
+	stop := context.AfterFunc(s.ctx, func() { conn.Close() })
+	defer stop()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && s.ctx.Err() == nil {
+				s.logger.Debug("read error", "error", err)
+				writeError(w, "ERR Protocol error: "+err.Error())
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(w, args)
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// init registers the Redis service factory
+func init() {
+	service.RegisterFactory("redis", func(cfg config.Service, logger *slog.Logger) (service.Service, error) {
+		c, ok := cfg.(*configredis.Service)
+		if !ok {
+			return nil, fmt.Errorf("redis: unexpected config type %T", cfg)
+		}
+		return NewRedisService(c, logger)
+	})
+}
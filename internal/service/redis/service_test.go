@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	configredis "github.com/jumppad-labs/polymorph/internal/config/redis"
+)
+
+func startRedisService(t *testing.T) (*RedisService, *goredis.Client) {
+	t.Helper()
+
+	svc, err := NewRedisService(&configredis.Service{
+		Name:   "cache",
+		Listen: "127.0.0.1:0",
+	}, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+
+	client := goredis.NewClient(&goredis.Options{Addr: svc.ResolvedAddress()})
+	t.Cleanup(func() { client.Close() })
+	return svc, client
+}
+
+func TestRedisService_SetGetDel(t *testing.T) {
+	_, client := startRedisService(t)
+	ctx := context.Background()
+
+	require.Equal(t, "PONG", client.Ping(ctx).Val())
+
+	require.NoError(t, client.Set(ctx, "greeting", "hello world", 0).Err())
+	val, err := client.Get(ctx, "greeting").Result()
+	require.NoError(t, err)
+	require.Equal(t, "hello world", val)
+
+	n, err := client.Exists(ctx, "greeting", "missing").Result()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	n, err = client.Del(ctx, "greeting", "missing").Result()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	_, err = client.Get(ctx, "greeting").Result()
+	require.ErrorIs(t, err, goredis.Nil)
+}
+
+func TestRedisService_Incr(t *testing.T) {
+	_, client := startRedisService(t)
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		n, err := client.Incr(ctx, "counter").Result()
+		require.NoError(t, err)
+		require.Equal(t, i, n)
+	}
+
+	require.NoError(t, client.Set(ctx, "name", "alice", 0).Err())
+	err := client.Incr(ctx, "name").Err()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not an integer")
+}
+
+func TestRedisService_Expire(t *testing.T) {
+	svc, client := startRedisService(t)
+	ctx := context.Background()
+
+	// The server reads the clock from its connection goroutines
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	svc.keys.now = func() time.Time { return time.Unix(0, clock.Load()) }
+
+	require.NoError(t, client.Set(ctx, "session", "abc", time.Minute).Err())
+	require.NoError(t, client.Set(ctx, "token", "xyz", 0).Err())
+
+	ok, err := client.Expire(ctx, "token", 10*time.Second).Result()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = client.Expire(ctx, "missing", 10*time.Second).Result()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Keys disappear once their ttl has passed
+	clock.Add(int64(30 * time.Second))
+	require.Equal(t, "abc", client.Get(ctx, "session").Val())
+	require.ErrorIs(t, client.Get(ctx, "token").Err(), goredis.Nil)
+
+	clock.Add(int64(time.Minute))
+	require.ErrorIs(t, client.Get(ctx, "session").Err(), goredis.Nil)
+}
+
+func TestRedisService_UnknownCommand(t *testing.T) {
+	_, client := startRedisService(t)
+
+	err := client.Do(context.Background(), "FLUSHALL").Err()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown command")
+}
+
+func TestRedisService_TLSConfigError(t *testing.T) {
+	svc, err := NewRedisService(&configredis.Service{
+		Name:   "cache",
+		Listen: "127.0.0.1:0",
+		TLS:    &config.TLSConfig{Cert: "missing.crt", Key: "missing.key"},
+	}, slog.Default())
+	require.NoError(t, err)
+
+	// Fails cleanly rather than closing the nil TLS listener
+	err = svc.Start(context.Background())
+	require.ErrorContains(t, err, "failed to configure TLS")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/jumppad-labs/polymorph/internal/config"
 	"github.com/jumppad-labs/polymorph/internal/meta"
@@ -24,6 +25,9 @@ type Service interface {
 	Type() string
 	// Address returns the service listen address
 	Address() string
+	// ResolvedAddress returns the address bound by Start, e.g. the chosen
+	// port when listening on :0
+	ResolvedAddress() string
 	// Upstreams returns the list of upstream service dependencies
 	Upstreams() []string
 }
@@ -39,6 +43,11 @@ type Registry struct {
 	services           []Service
 	serfClient         *serf.Client
 	requestLogRegistry RequestLogRegistry
+	readiness          map[string]*Readiness
+	readinessTimeout   time.Duration
+	readinessInterval  time.Duration
+	probeCancel        context.CancelFunc
+	probes             sync.WaitGroup
 	mu                 sync.Mutex
 }
 
@@ -47,6 +56,9 @@ func NewRegistry(logRegistry RequestLogRegistry) *Registry {
 	return &Registry{
 		services:           make([]Service, 0),
 		requestLogRegistry: logRegistry,
+		readiness:          make(map[string]*Readiness),
+		readinessTimeout:   DefaultReadinessTimeout,
+		readinessInterval:  DefaultReadinessInterval,
 	}
 }
 
@@ -55,6 +67,20 @@ func (r *Registry) Register(svc Service) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.services = append(r.services, svc)
+
+	// Services that serve a health endpoint share the registry's readiness
+	readiness := &Readiness{}
+	r.readiness[svc.Name()] = readiness
+	if hs, ok := svc.(interface{ SetReadiness(*Readiness) }); ok {
+		hs.SetReadiness(readiness)
+	}
+}
+
+// Readiness returns the readiness of the named service, or nil if unknown
+func (r *Registry) Readiness(name string) *Readiness {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.readiness[name]
 }
 
 // Start starts all registered services and optionally joins Lattice mesh
@@ -62,9 +88,12 @@ func (r *Registry) Start(ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Start all services first
-	for _, svc := range r.services {
+	// Start all services first, stopping those already up if one fails
+	for i, svc := range r.services {
 		if err := svc.Start(ctx); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				r.services[j].Stop(ctx)
+			}
 			return fmt.Errorf("failed to start service %q: %w", svc.Name(), err)
 		}
 	}
@@ -80,9 +109,75 @@ func (r *Registry) Start(ctx context.Context) error {
 		}
 	}
 
+	r.startProbes(ctx)
+
 	return nil
 }
 
+// startProbes marks each service ready once every upstream accepts
+// connections. Services without upstreams are ready immediately.
+func (r *Registry) startProbes(ctx context.Context) {
+	byName := make(map[string]Service, len(r.services))
+	for _, svc := range r.services {
+		byName[svc.Name()] = svc
+	}
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	r.probeCancel = cancel
+
+	for _, svc := range r.services {
+		readiness := r.readiness[svc.Name()]
+
+		var addrs []string
+		for _, name := range svc.Upstreams() {
+			if upstream, ok := byName[name]; ok {
+				addrs = append(addrs, upstream.ResolvedAddress())
+			}
+		}
+		if len(addrs) == 0 {
+			readiness.MarkReady()
+			continue
+		}
+
+		r.probes.Add(1)
+		go func(name string) {
+			defer r.probes.Done()
+
+			waitCtx, cancel := context.WithTimeout(probeCtx, r.readinessTimeout)
+			defer cancel()
+
+			if err := WaitForAddresses(waitCtx, addrs, r.readinessInterval); err != nil {
+				slog.Warn("service upstreams not ready", "service", name, "error", err)
+				return
+			}
+			readiness.MarkReady()
+		}(svc.Name())
+	}
+}
+
+// Drain marks every service as draining so health checks fail while
+// in-flight requests complete
+func (r *Registry) Drain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, readiness := range r.readiness {
+		readiness.Drain()
+	}
+}
+
+// Shutdown drains every service, keeps serving for drainDelay so load
+// balancers see the failing health checks and stop routing new requests,
+// then stops the services, giving in-flight requests gracePeriod to complete
+func (r *Registry) Shutdown(drainDelay, gracePeriod time.Duration) error {
+	r.Drain()
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	return r.Stop(ctx)
+}
+
 // Stop stops all registered services in reverse order and leaves Lattice mesh
 func (r *Registry) Stop(ctx context.Context) error {
 	r.mu.Lock()
@@ -90,6 +185,12 @@ func (r *Registry) Stop(ctx context.Context) error {
 
 	var errs []error
 
+	// Abandon readiness probes still waiting on upstreams
+	if r.probeCancel != nil {
+		r.probeCancel()
+		r.probes.Wait()
+	}
+
 	// Leave Lattice mesh first
 	if r.serfClient != nil {
 		if err := r.serfClient.Stop(); err != nil {
@@ -83,6 +83,23 @@ func TestRegistry_StartStopWithoutLattice(t *testing.T) {
 	require.True(t, mockSvc.stopped)
 }
 
+func TestRegistry_StartFailureStopsStarted(t *testing.T) {
+	registry := NewRegistry(nil)
+
+	first := &mockService{name: "first", typ: "http"}
+	second := &mockService{name: "second", typ: "http", startErr: ErrAddressInUse}
+	registry.Register(first)
+	registry.Register(second)
+
+	err := registry.Start(context.Background())
+	require.ErrorIs(t, err, ErrAddressInUse)
+	require.Contains(t, err.Error(), `failed to start service "second"`)
+
+	// The service that did start is not left running
+	require.True(t, first.started)
+	require.True(t, first.stopped)
+}
+
 func TestRegistry_Services(t *testing.T) {
 	registry := NewRegistry(nil)
 
@@ -100,12 +117,16 @@ func TestRegistry_Services(t *testing.T) {
 
 // mockService is a mock implementation of the Service interface
 type mockService struct {
-	name     string
-	typ      string
-	started  bool
-	stopped  bool
-	startErr error
-	stopErr  error
+	name      string
+	typ       string
+	upstreams []string
+	resolved  string // overrides the address returned by ResolvedAddress
+	started   bool
+	stopped   bool
+	startErr  error
+	stopErr   error
+	// onStart is called with the service name when Start succeeds
+	onStart func(name string)
 }
 
 func (m *mockService) Start(ctx context.Context) error {
@@ -113,6 +134,9 @@ func (m *mockService) Start(ctx context.Context) error {
 		return m.startErr
 	}
 	m.started = true
+	if m.onStart != nil {
+		m.onStart(m.name)
+	}
 	return nil
 }
 
@@ -136,6 +160,13 @@ func (m *mockService) Address() string {
 	return "localhost:8080"
 }
 
+func (m *mockService) ResolvedAddress() string {
+	if m.resolved != "" {
+		return m.resolved
+	}
+	return "localhost:8080"
+}
+
 func (m *mockService) Upstreams() []string {
-	return []string{}
+	return m.upstreams
 }
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"net"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+)
+
+// socketListener applies socket options to each TCP connection it accepts
+type socketListener struct {
+	net.Listener
+	keepAlive *time.Duration
+	noDelay   *bool
+}
+
+// WrapListenerSocket wraps a net.Listener so accepted TCP connections get
+// the configured keep-alive and no-delay options. Returns the original
+// listener unchanged if no socket options are configured.
+func WrapListenerSocket(ln net.Listener, cfg *config.SocketConfig) (net.Listener, error) {
+	if cfg == nil {
+		return ln, nil
+	}
+
+	keepAlive, err := cfg.KeepAliveInterval()
+	if err != nil {
+		return nil, err
+	}
+	return &socketListener{Listener: ln, keepAlive: keepAlive, noDelay: cfg.NoDelay}, nil
+}
+
+func (l *socketListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	// Unix sockets have no TCP options to set
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		l.configure(tcp)
+	}
+	return conn, nil
+}
+
+// configure sets the options on a connection. It's best effort: failing
+// Accept would stop the server, and setting an option only fails on a
+// connection the peer has already closed.
+func (l *socketListener) configure(conn *net.TCPConn) {
+	if l.keepAlive != nil {
+		if *l.keepAlive == 0 {
+			conn.SetKeepAlive(false)
+		} else {
+			conn.SetKeepAliveConfig(net.KeepAliveConfig{
+				Enable:   true,
+				Idle:     *l.keepAlive,
+				Interval: *l.keepAlive,
+			})
+		}
+	}
+	if l.noDelay != nil {
+		conn.SetNoDelay(*l.noDelay)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptWithOptions accepts one connection through a socket listener with
+// the given options and returns the server side
+func acceptWithOptions(t *testing.T, cfg *config.SocketConfig) *net.TCPConn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	wrapped, err := WrapListenerSocket(ln, cfg)
+	require.NoError(t, err)
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	conn, err := wrapped.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn.(*net.TCPConn)
+}
+
+// sockopt reads an integer socket option from a connection
+func sockopt(t *testing.T, conn *net.TCPConn, level, opt int) int {
+	t.Helper()
+	raw, err := conn.SyscallConn()
+	require.NoError(t, err)
+	var val int
+	var serr error
+	require.NoError(t, raw.Control(func(fd uintptr) {
+		val, serr = syscall.GetsockoptInt(int(fd), level, opt)
+	}))
+	require.NoError(t, serr)
+	return val
+}
+
+func TestSocketListener_Options(t *testing.T) {
+	noDelay := false
+	conn := acceptWithOptions(t, &config.SocketConfig{KeepAlive: "30s", NoDelay: &noDelay})
+	require.Equal(t, 0, sockopt(t, conn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY))
+	require.Equal(t, 1, sockopt(t, conn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE))
+	require.Equal(t, 30, sockopt(t, conn, syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE))
+	require.Equal(t, 30, sockopt(t, conn, syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL))
+
+	// keep_alive of zero turns keep-alive off
+	conn = acceptWithOptions(t, &config.SocketConfig{KeepAlive: "0s"})
+	require.Equal(t, 0, sockopt(t, conn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE))
+	require.Equal(t, 1, sockopt(t, conn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY))
+}
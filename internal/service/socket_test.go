@@ -0,0 +1,26 @@
+package service
+
+import (
+	"net"
+	"testing"
+
+	"github.com/jumppad-labs/polymorph/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapListenerSocket(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// No socket block leaves the listener alone
+	wrapped, err := WrapListenerSocket(ln, nil)
+	require.NoError(t, err)
+	require.Same(t, ln, wrapped)
+
+	_, err = WrapListenerSocket(ln, &config.SocketConfig{KeepAlive: "soon"})
+	require.ErrorContains(t, err, `invalid keep_alive "soon"`)
+
+	_, err = WrapListenerSocket(ln, &config.SocketConfig{KeepAlive: "-1s"})
+	require.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jumppad-labs/polymorph/internal/metrics"
+)
+
+// StatusPath is the path the aggregated status is served on
+const StatusPath = "/status"
+
+// ServiceStatus is one service's entry in the aggregated status
+type ServiceStatus struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Address  string `json:"address"`
+	Status   string `json:"status"`
+	InFlight int    `json:"in_flight"`
+}
+
+// Status reports each registered service's bound address, readiness and
+// the number of requests it is handling, in start order
+func (r *Registry) Status() []ServiceStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]ServiceStatus, 0, len(r.services))
+	for _, svc := range r.services {
+		statuses = append(statuses, ServiceStatus{
+			Name:     svc.Name(),
+			Type:     svc.Type(),
+			Address:  svc.ResolvedAddress(),
+			Status:   r.readiness[svc.Name()].State(),
+			InFlight: metrics.InFlight(svc.Name()),
+		})
+	}
+	return statuses
+}
+
+// StatusHandler serves the aggregated status of every service as JSON. The
+// overall status is ready, returning 200, only when every service is ready.
+func (r *Registry) StatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+StatusPath, func(w http.ResponseWriter, _ *http.Request) {
+		services := r.Status()
+
+		overall, code := "ready", http.StatusOK
+		for _, s := range services {
+			if s.Status != "ready" {
+				overall, code = "degraded", http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]any{
+			"status":   overall,
+			"services": services,
+		})
+	})
+	return mux
+}
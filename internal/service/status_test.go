@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jumppad-labs/polymorph/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Status(t *testing.T) {
+	// Nothing listens on the database's address, so the api waits on it
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dbAddr := closed.Addr().String()
+	closed.Close()
+
+	registry := NewRegistry(nil)
+	registry.readinessInterval = 10 * time.Millisecond
+	registry.Register(&mockService{name: "status-db", typ: "postgres", resolved: dbAddr})
+	registry.Register(&mockService{name: "status-api", typ: "http", resolved: "127.0.0.1:8080", upstreams: []string{"status-db"}})
+
+	ctx := context.Background()
+	require.NoError(t, registry.Start(ctx))
+	defer registry.Stop(ctx)
+
+	done := metrics.TrackInFlight("status-api")
+	defer done()
+
+	require.Equal(t, []ServiceStatus{
+		{Name: "status-db", Type: "postgres", Address: dbAddr, Status: "ready", InFlight: 0},
+		{Name: "status-api", Type: "http", Address: "127.0.0.1:8080", Status: "starting", InFlight: 1},
+	}, registry.Status())
+
+	rec := httptest.NewRecorder()
+	registry.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", StatusPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var body struct {
+		Status   string          `json:"status"`
+		Services []ServiceStatus `json:"services"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "degraded", body.Status)
+	require.Len(t, body.Services, 2)
+
+	// Once the api is ready so is the whole set; draining reports each service
+	registry.Readiness("status-api").MarkReady()
+	rec = httptest.NewRecorder()
+	registry.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", StatusPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	registry.Drain()
+	for _, s := range registry.Status() {
+		require.Equal(t, "draining", s.Status)
+	}
+}
@@ -0,0 +1,52 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Framing modes for the tcp service
+const (
+	FramingLine           = "line"
+	FramingLengthPrefixed = "length_prefixed"
+)
+
+// lengthPrefixSize is the size of the big-endian length header
+const lengthPrefixSize = 4
+
+// splitLengthPrefixed is a bufio.SplitFunc for messages framed with a
+// 4-byte big-endian length prefix. Frames longer than max are rejected
+// with bufio.ErrTooLong without waiting for their payload.
+func splitLengthPrefixed(max int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) < lengthPrefixSize {
+			if atEOF && len(data) > 0 {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+
+		size := binary.BigEndian.Uint32(data)
+		if uint64(size) > uint64(max) {
+			return 0, nil, bufio.ErrTooLong
+		}
+
+		end := lengthPrefixSize + int(size)
+		if len(data) < end {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		return end, data[lengthPrefixSize:end], nil
+	}
+}
+
+// frameLengthPrefixed prepends the 4-byte big-endian length to payload
+func frameLengthPrefixed(payload []byte) []byte {
+	framed := make([]byte, lengthPrefixSize+len(payload))
+	binary.BigEndian.PutUint32(framed, uint32(len(payload)))
+	copy(framed[lengthPrefixSize:], payload)
+	return framed
+}
@@ -3,10 +3,13 @@ package tcp
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/jumppad-labs/polymorph/internal/config"
@@ -16,14 +19,17 @@ import (
 
 // TCPService implements a TCP service with pattern matching
 type TCPService struct {
-	name     string
-	config   *configtcp.Service
-	logger   *slog.Logger
-	matcher  *Matcher
-	listener net.Listener
-	wg       sync.WaitGroup
-	ctx      context.Context
-	cancel   context.CancelFunc
+	name            string
+	config          *configtcp.Service
+	logger          *slog.Logger
+	matcher         *Matcher
+	readTimeout     time.Duration // Idle time before a connection is closed (0 = none)
+	maxMessageSize  int           // Largest message accepted on a connection
+	listener        net.Listener
+	resolvedAddress string
+	wg              sync.WaitGroup
+	ctx             context.Context
+	cancel          context.CancelFunc
 }
 
 // NewTCPService creates a new TCP service
@@ -55,10 +61,30 @@ func NewTCPService(cfg *configtcp.Service, logger *slog.Logger) (*TCPService, er
 	}
 
 	svc := &TCPService{
-		name:    cfg.Name,
-		config:  cfg,
-		logger:  logger,
-		matcher: matcher,
+		name:           cfg.Name,
+		config:         cfg,
+		logger:         logger,
+		matcher:        matcher,
+		maxMessageSize: bufio.MaxScanTokenSize,
+	}
+
+	if cfg.ReadTimeout != "" {
+		timeout, err := service.ParseDuration(cfg.ReadTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse read_timeout: %w", err)
+		}
+		svc.readTimeout = timeout
+	}
+
+	if cfg.MaxMessageSize != "" {
+		size, err := config.ParseMemorySize(cfg.MaxMessageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse max_message_size: %w", err)
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("max_message_size must be positive")
+		}
+		svc.maxMessageSize = int(size)
 	}
 
 	return svc, nil
@@ -79,6 +105,12 @@ func (s *TCPService) Address() string {
 	return s.config.Listen
 }
 
+// ResolvedAddress returns the address the service bound to, which differs
+// from Address when listening on port 0. It is empty before Start.
+func (s *TCPService) ResolvedAddress() string {
+	return s.resolvedAddress
+}
+
 // Upstreams returns the list of upstream service dependencies
 func (s *TCPService) Upstreams() []string {
 	return s.config.Upstreams
@@ -90,18 +122,24 @@ func (s *TCPService) Start(ctx context.Context) error {
 	s.ctx, s.cancel = context.WithCancel(ctx)
 
 	// Create listener
-	listener, err := net.Listen("tcp", s.config.Listen)
+	listener, err := service.Listen(s.config.Listen)
 	if err != nil {
-		return fmt.Errorf("failed to create TCP listener: %w", err)
+		return err
 	}
 
-	// Wrap with TLS if configured
-	listener, err = service.WrapListenerTLS(listener, s.config.TLS)
+	// Apply socket options, then wrap with TLS if configured
+	withOptions, err := service.WrapListenerSocket(listener, s.config.Socket)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	wrapped, err := service.WrapListenerTLS(withOptions, s.config.TLS)
 	if err != nil {
 		listener.Close()
 		return fmt.Errorf("failed to configure TLS: %w", err)
 	}
-	s.listener = listener
+	s.listener = wrapped
+	s.resolvedAddress = service.ListenerAddress(wrapped)
 
 	// Start accepting connections in background
 	s.wg.Add(1)
@@ -126,16 +164,16 @@ func (s *TCPService) Stop(ctx context.Context) error {
 
 	s.logger.Info("stopping service")
 
+	// Cancel context to signal the accept loop and all connections to close
+	if s.cancel != nil {
+		s.cancel()
+	}
+
 	// Close listener to stop accepting new connections
 	if err := s.listener.Close(); err != nil {
 		return fmt.Errorf("failed to close listener: %w", err)
 	}
 
-	// Cancel context to signal all connections to close
-	if s.cancel != nil {
-		s.cancel()
-	}
-
 	// Wait for all connections to finish
 	s.wg.Wait()
 
@@ -170,8 +208,22 @@ func (s *TCPService) acceptLoop() {
 func (s *TCPService) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
+	bufSize := s.maxMessageSize
 	scanner := bufio.NewScanner(conn)
-	for scanner.Scan() {
+	if s.lengthPrefixed() {
+		bufSize += lengthPrefixSize
+		scanner.Split(splitLengthPrefixed(s.maxMessageSize))
+	}
+	scanner.Buffer(make([]byte, 0, min(4096, bufSize)), bufSize)
+	for {
+		// Reset the idle deadline before each read
+		if s.readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.readTimeout))
+		}
+		if !scanner.Scan() {
+			break
+		}
+
 		// Check if context is cancelled
 		select {
 		case <-s.ctx.Done():
@@ -179,7 +231,7 @@ func (s *TCPService) handleConnection(conn net.Conn) {
 		default:
 		}
 
-		// Read incoming line
+		// Read incoming line or frame payload
 		line := scanner.Text()
 
 		// Match against patterns
@@ -187,22 +239,69 @@ func (s *TCPService) handleConnection(conn net.Conn) {
 
 		// Send response
 		if response != "" {
-			if _, err := conn.Write([]byte(response)); err != nil {
+			if _, err := conn.Write(s.encode(response)); err != nil {
 				s.logger.Error("write error", "error", err)
 				return
 			}
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		// Only log if not due to connection close
-		select {
-		case <-s.ctx.Done():
-			return
-		default:
-			s.logger.Error("scan error", "error", err)
-		}
+	err := scanner.Err()
+	if err == nil {
+		return
+	}
+
+	// Close clients that exceed a limit, replying first if configured
+	var netErr net.Error
+	switch {
+	case errors.Is(err, bufio.ErrTooLong):
+		s.logger.Warn("closing connection: message too large", "remote", conn.RemoteAddr(), "max", s.maxMessageSize)
+		s.writeLimitResponse(conn)
+		return
+	case errors.As(err, &netErr) && netErr.Timeout():
+		s.logger.Warn("closing connection: read timeout", "remote", conn.RemoteAddr(), "timeout", s.readTimeout)
+		s.writeLimitResponse(conn)
+		return
+	}
+
+	// Only log if not due to connection close
+	select {
+	case <-s.ctx.Done():
+		return
+	default:
+		s.logger.Error("scan error", "error", err)
+	}
+}
+
+// lengthPrefixed reports whether messages are framed with a length prefix
+func (s *TCPService) lengthPrefixed() bool {
+	return s.config.Framing == FramingLengthPrefixed
+}
+
+// encode frames a response for writing
+func (s *TCPService) encode(response string) []byte {
+	if s.lengthPrefixed() {
+		return frameLengthPrefixed([]byte(response))
+	}
+	return []byte(response)
+}
+
+// writeLimitResponse sends the configured limit_response, if any. Unread
+// client data is drained briefly after the reply so closing the connection
+// doesn't reset it before the client reads the response.
+func (s *TCPService) writeLimitResponse(conn net.Conn) {
+	if s.config.LimitResponse == "" {
+		return
+	}
+	conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Write(s.encode(s.config.LimitResponse)); err != nil {
+		s.logger.Error("write error", "error", err)
+		return
+	}
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
 	}
+	io.Copy(io.Discard, io.LimitReader(conn, int64(s.maxMessageSize)))
 }
 
 // init registers the TCP service factory
@@ -0,0 +1,151 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/jumppad-labs/polymorph/internal/config"
+	configtcp "github.com/jumppad-labs/polymorph/internal/config/tcp"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func startTCPService(t *testing.T, cfg *configtcp.Service) *TCPService {
+	t.Helper()
+
+	cfg.Name = "tcp-test"
+	cfg.Listen = "127.0.0.1:0"
+	if cfg.Handlers == nil {
+		cfg.Handlers = []*configtcp.Handler{staticHandler("default", "", "OK\n")}
+	}
+
+	svc, err := NewTCPService(cfg, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+	return svc
+}
+
+func staticHandler(name, pattern, body string) *configtcp.Handler {
+	return &configtcp.Handler{
+		Name:    name,
+		Pattern: pattern,
+		Response: &config.ResponseConfig{
+			BodyExpr: hcl.StaticExpr(cty.StringVal(body), hcl.Range{}),
+		},
+	}
+}
+
+func TestTCPService_MaxMessageSize(t *testing.T) {
+	svc := startTCPService(t, &configtcp.Service{
+		MaxMessageSize: "16B",
+		LimitResponse:  "ERR message too large\n",
+	})
+
+	conn, err := net.Dial("tcp", svc.ResolvedAddress())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Messages within the limit are answered
+	_, err = conn.Write([]byte("PING\n"))
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "OK\n", line)
+
+	// An oversized message gets the limit response and a closed connection
+	_, err = conn.Write([]byte(strings.Repeat("x", 64) + "\n"))
+	require.NoError(t, err)
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "ERR message too large\n", string(rest))
+}
+
+func TestTCPService_ReadTimeout(t *testing.T) {
+	svc := startTCPService(t, &configtcp.Service{
+		ReadTimeout: "50ms",
+	})
+
+	conn, err := net.Dial("tcp", svc.ResolvedAddress())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// An idle client is disconnected without a reply
+	start := time.Now()
+	rest, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+	require.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestNewTCPService_InvalidLimits(t *testing.T) {
+	_, err := NewTCPService(&configtcp.Service{Name: "tcp-test", ReadTimeout: "soon"}, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "read_timeout")
+
+	_, err = NewTCPService(&configtcp.Service{Name: "tcp-test", MaxMessageSize: "0"}, slog.Default())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "max_message_size")
+}
+
+func TestTCPService_LengthPrefixed(t *testing.T) {
+	svc := startTCPService(t, &configtcp.Service{
+		Framing:        "length_prefixed",
+		MaxMessageSize: "1KB",
+		Handlers: []*configtcp.Handler{
+			staticHandler("ping", "PING*", "PONG"),
+			staticHandler("default", "", "ERR unknown"),
+		},
+	})
+
+	conn, err := net.Dial("tcp", svc.ResolvedAddress())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	readFrame := func() string {
+		header := make([]byte, 4)
+		_, err := io.ReadFull(conn, header)
+		require.NoError(t, err)
+		payload := make([]byte, binary.BigEndian.Uint32(header))
+		_, err = io.ReadFull(conn, payload)
+		require.NoError(t, err)
+		return string(payload)
+	}
+
+	// Payloads may contain newlines; the frame, not the line, is matched.
+	// Both frames are sent in one write to exercise reassembly.
+	_, err = conn.Write(append(frameLengthPrefixed([]byte("PING\nwith newline")), frameLengthPrefixed([]byte("HELLO"))...))
+	require.NoError(t, err)
+	require.Equal(t, "PONG", readFrame())
+	require.Equal(t, "ERR unknown", readFrame())
+
+	// A frame split across writes is reassembled
+	framed := frameLengthPrefixed([]byte("PING again"))
+	_, err = conn.Write(framed[:3])
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = conn.Write(framed[3:])
+	require.NoError(t, err)
+	require.Equal(t, "PONG", readFrame())
+
+	// A frame declaring a length over the limit closes the connection
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, 1<<20)
+	_, err = conn.Write(header)
+	require.NoError(t, err)
+	rest, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -15,9 +16,11 @@ type TimingConfig struct {
 	Variance float64       // Variance factor (0.0-1.0)
 }
 
-// LatencyInjector injects latency based on percentile distribution
+// LatencyInjector injects latency based on percentile distribution. It is
+// safe for concurrent use.
 type LatencyInjector struct {
 	config TimingConfig
+	mu     sync.Mutex
 	rng    *rand.Rand
 }
 
@@ -29,6 +32,15 @@ func NewLatencyInjector(config TimingConfig) *LatencyInjector {
 	}
 }
 
+// Config returns the injector's timing, or the zero timing for a nil
+// injector
+func (l *LatencyInjector) Config() TimingConfig {
+	if l == nil {
+		return TimingConfig{}
+	}
+	return l.config
+}
+
 // Inject adds latency based on percentile distribution
 func (l *LatencyInjector) Inject(ctx context.Context) {
 	delay := l.calculateDelay()
@@ -45,6 +57,9 @@ func (l *LatencyInjector) Inject(ctx context.Context) {
 // This uses a simple approach: generate a random percentile, then interpolate
 // between the configured percentile values
 func (l *LatencyInjector) calculateDelay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	// Generate random percentile (0-100)
 	percentile := l.rng.Float64() * 100
 
@@ -0,0 +1,250 @@
+// Package shell is an interactive REPL for exploring running services:
+// requests typed as "GET /pets" are sent to a service and the response is
+// printed, and "resources" lists generated data via the meta service.
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"connectrpc.com/connect"
+	metav1 "github.com/jumppad-labs/polymorph/pkg/api/meta/v1"
+)
+
+// Prompt is printed before each command is read
+const Prompt = "polymorph> "
+
+// ResourceLister lists the resources of running services, as the meta
+// service's GetResources does
+type ResourceLister interface {
+	GetResources(context.Context, *connect.Request[metav1.GetResourcesRequest]) (*connect.Response[metav1.GetResourcesResponse], error)
+}
+
+// help describes the commands the shell accepts
+const help = `Commands:
+  <METHOD> <path> [body]  send a request to the current service, e.g. GET /users
+  use <service>           send later requests to another service
+  services                list the services requests can be sent to
+  resources               list the generated resources of every service
+  resources <name>        print a resource's generated data, e.g. resources users
+  help                    show this help
+  exit                    leave the shell
+`
+
+// Shell reads commands and prints their results
+type Shell struct {
+	// Targets maps service names to their base URLs
+	Targets map[string]string
+	Meta    ResourceLister
+	Client  *http.Client
+
+	out     io.Writer
+	current string
+}
+
+// New creates a shell for services at the given base URLs that writes to
+// out. Requests go to the first service by name until another is chosen
+// with use.
+func New(targets map[string]string, meta ResourceLister, out io.Writer) *Shell {
+	sh := &Shell{
+		Targets: targets,
+		Meta:    meta,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		out:     out,
+	}
+	if names := sh.serviceNames(); len(names) > 0 {
+		sh.current = names[0]
+	}
+	return sh
+}
+
+// Run reads commands from in until it ends, exit is typed or ctx is done.
+// Lines are read in the background so an interrupt at an idle prompt ends
+// the shell straight away; that reader is left blocked on in.
+func (sh *Shell) Run(ctx context.Context, in io.Reader) error {
+	lines := make(chan string)
+	done := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		done <- scanner.Err()
+	}()
+
+	for {
+		fmt.Fprint(sh.out, Prompt)
+		select {
+		case line := <-lines:
+			if !sh.Exec(ctx, line) {
+				return nil
+			}
+		case err := <-done:
+			fmt.Fprintln(sh.out)
+			return err
+		case <-ctx.Done():
+			fmt.Fprintln(sh.out)
+			return nil
+		}
+	}
+}
+
+// Exec runs one command line, printing its result or error. It returns
+// false when the line asks to leave the shell.
+func (sh *Shell) Exec(ctx context.Context, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+
+	var err error
+	switch strings.ToLower(fields[0]) {
+	case "exit", "quit":
+		return false
+	case "help":
+		fmt.Fprint(sh.out, help)
+	case "services":
+		sh.printServices()
+	case "use":
+		err = sh.use(fields[1:])
+	case "resources":
+		err = sh.resources(ctx, fields[1:])
+	default:
+		err = sh.request(ctx, line)
+	}
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+	}
+	return true
+}
+
+// serviceNames returns the names of the services requests can go to, sorted
+func (sh *Shell) serviceNames() []string {
+	names := make([]string, 0, len(sh.Targets))
+	for name := range sh.Targets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// printServices lists each service, marking the current one
+func (sh *Shell) printServices() {
+	tw := tabwriter.NewWriter(sh.out, 0, 0, 2, ' ', 0)
+	for _, name := range sh.serviceNames() {
+		marker := " "
+		if name == sh.current {
+			marker = "*"
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\n", marker, name, sh.Targets[name])
+	}
+	tw.Flush()
+}
+
+// use makes the named service the target of later requests
+func (sh *Shell) use(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: use <service>")
+	}
+	if _, ok := sh.Targets[args[0]]; !ok {
+		return fmt.Errorf("no http service named %q", args[0])
+	}
+	sh.current = args[0]
+	fmt.Fprintf(sh.out, "using %s\n", sh.current)
+	return nil
+}
+
+// request sends a line such as "POST /users {...}" to the current service
+func (sh *Shell) request(ctx context.Context, line string) error {
+	method, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	path, body, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("unknown command %q; type help for a list", method)
+	}
+	return sh.send(ctx, sh.current, strings.ToUpper(method), path, strings.TrimSpace(body))
+}
+
+// send makes a request to a service and prints the response
+func (sh *Shell) send(ctx context.Context, service, method, path, body string) error {
+	base, ok := sh.Targets[service]
+	if !ok {
+		return fmt.Errorf("no http service to send requests to")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != "" && json.Valid([]byte(body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := sh.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	fmt.Fprintln(sh.out, resp.Status)
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, data, "", "  ") == nil {
+		data = pretty.Bytes()
+	}
+	if len(data) > 0 {
+		sh.out.Write(data)
+		if data[len(data)-1] != '\n' {
+			fmt.Fprintln(sh.out)
+		}
+	}
+	return nil
+}
+
+// resources lists every generated resource or, given a name, prints that
+// resource's data
+func (sh *Shell) resources(ctx context.Context, args []string) error {
+	if sh.Meta == nil {
+		return fmt.Errorf("the meta service isn't available")
+	}
+	resp, err := sh.Meta.GetResources(ctx, connect.NewRequest(&metav1.GetResourcesRequest{}))
+	if err != nil {
+		return fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	if len(args) == 0 {
+		tw := tabwriter.NewWriter(sh.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SERVICE\tRESOURCE\tROWS\tPATH")
+		for _, svc := range resp.Msg.Services {
+			for _, res := range svc.Resources {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t/%s\n", svc.ServiceName, res.Name, res.RowCount, res.PluralName)
+			}
+		}
+		return tw.Flush()
+	}
+
+	// Accept the singular or plural name
+	for _, svc := range resp.Msg.Services {
+		for _, res := range svc.Resources {
+			if args[0] == res.Name || args[0] == res.PluralName {
+				return sh.send(ctx, svc.ServiceName, http.MethodGet, "/"+res.PluralName, "")
+			}
+		}
+	}
+	return fmt.Errorf("no resource named %q", args[0])
+}
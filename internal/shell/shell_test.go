@@ -0,0 +1,136 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	confighttp "github.com/jumppad-labs/polymorph/internal/config/http"
+	"github.com/jumppad-labs/polymorph/internal/config/parser"
+	"github.com/jumppad-labs/polymorph/internal/meta"
+	servicehttp "github.com/jumppad-labs/polymorph/internal/service/http"
+	"github.com/stretchr/testify/require"
+)
+
+// startShell starts an http service and returns a shell aimed at it that
+// writes to out
+func startShell(t *testing.T, out *bytes.Buffer) *Shell {
+	t.Helper()
+	cfg, err := parser.Parse([]byte(`
+service "http" "api" {
+  listen = "127.0.0.1:0"
+
+  handle "pets" {
+    route = "GET /pets"
+    response {
+      body = jsonencode([{ name = "Rex" }])
+    }
+  }
+
+  resource "user" {
+    rows = 2
+    field "id"   { type = "sequence" }
+    field "name" { type = "name" }
+  }
+}
+`), "test.hcl")
+	require.NoError(t, err)
+
+	svc, err := servicehttp.NewHTTPService(cfg.Services[0].(*confighttp.Service), slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start(context.Background()))
+	t.Cleanup(func() { svc.Stop(context.Background()) })
+
+	targets := map[string]string{"api": "http://" + svc.ResolvedAddress()}
+	return New(targets, meta.NewMetaService(cfg.Services, nil, nil), out)
+}
+
+func TestShell_Run(t *testing.T) {
+	var out bytes.Buffer
+	sh := startShell(t, &out)
+
+	script := strings.Join([]string{
+		"GET /pets",
+		`POST /users {"id":9,"name":"Ada"}`,
+		"get /users/9",
+		"resources",
+		"resources users",
+		"exit",
+		"GET /never-sent",
+	}, "\n")
+	require.NoError(t, sh.Run(context.Background(), strings.NewReader(script)))
+
+	printed := out.String()
+	// Responses are printed with their status, JSON indented
+	require.Contains(t, printed, "200 OK\n[\n  {\n    \"name\": \"Rex\"\n  }\n]\n")
+	require.Contains(t, printed, "201 Created\n{\n  \"id\": 9,\n  \"name\": \"Ada\"\n}\n")
+	require.Contains(t, printed, "200 OK\n{\n  \"id\": 9,\n  \"name\": \"Ada\"\n}\n")
+
+	// Resources come from the meta service and their data from the service
+	require.Regexp(t, `SERVICE\s+RESOURCE\s+ROWS\s+PATH\napi\s+user\s+2\s+/users\n`, printed)
+	require.Contains(t, printed, `"total": 3`)
+
+	// Nothing runs after exit
+	require.NotContains(t, printed, "never-sent")
+	require.Equal(t, 6, strings.Count(printed, Prompt))
+}
+
+func TestShell_RunInterrupted(t *testing.T) {
+	var out bytes.Buffer
+	sh := New(map[string]string{"api": "http://api"}, nil, &out)
+
+	// Nothing is ever typed; cancelling still ends the shell
+	in, _ := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sh.Run(ctx, in) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("shell kept waiting for input after cancel")
+	}
+	require.Equal(t, Prompt+"\n", out.String())
+}
+
+func TestShell_Errors(t *testing.T) {
+	var out bytes.Buffer
+	sh := startShell(t, &out)
+
+	for _, line := range []string{"pets", "use web", "resources dogs", "GET /missing"} {
+		require.True(t, sh.Exec(context.Background(), line))
+	}
+
+	printed := out.String()
+	require.Contains(t, printed, `error: unknown command "pets"; type help for a list`)
+	require.Contains(t, printed, `error: no http service named "web"`)
+	require.Contains(t, printed, `error: no resource named "dogs"`)
+	// A 404 is a response, not an error
+	require.Contains(t, printed, "404 Not Found\n")
+
+	require.False(t, sh.Exec(context.Background(), "quit"))
+}
+
+func TestShell_Use(t *testing.T) {
+	var out bytes.Buffer
+	sh := New(map[string]string{"web": "http://web", "api": "http://api"}, nil, &out)
+
+	// Requests go to the first service by name until another is chosen
+	sh.Exec(context.Background(), "services")
+	require.Equal(t, "* api  http://api\n  web  http://web\n", out.String())
+
+	out.Reset()
+	sh.Exec(context.Background(), "use web")
+	sh.Exec(context.Background(), "services")
+	require.Equal(t, "using web\n  api  http://api\n* web  http://web\n", out.String())
+
+	out.Reset()
+	sh.Exec(context.Background(), "resources")
+	require.Equal(t, "error: the meta service isn't available\n", out.String())
+}
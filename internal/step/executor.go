@@ -10,14 +10,16 @@ import (
 	"github.com/zclconf/go-cty/cty"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
 // Result contains the output from a step execution
 type Result struct {
-	Body   interface{}       // Parsed response body
-	Status int               // HTTP status code (for HTTP steps)
+	Body    interface{}       // Parsed response body
+	Status  int               // HTTP status code (for HTTP steps)
 	Headers map[string]string // Response headers (for HTTP steps)
-	Error  error             // Error if step failed
+	Error   error             // Error if step failed
+	Skipped bool              // True when the step's when guard was false
 }
 
 // Executor executes steps and builds context for expression evaluation
@@ -34,73 +36,166 @@ func NewExecutor(steps []*config.StepConfig) *Executor {
 	}
 }
 
-// Execute runs all steps in order, building up context for subsequent steps
-func (e *Executor) Execute(ctx context.Context, evalCtx *hcl.EvalContext) error {
-	for _, step := range e.steps {
-		// Create a tracing span for this step
-		tracer := tracing.Tracer("polymorph.step")
-		stepCtx, span := tracer.Start(ctx, "step."+step.Name,
-			trace.WithAttributes(attribute.String("step.name", step.Name)),
-		)
-
-		// Execute the step based on its type
-		result, err := e.executeStep(stepCtx, step, evalCtx)
-		span.End()
+// maxParallelSteps bounds how many steps of a parallel group run at once
+const maxParallelSteps = 8
 
-		if err != nil {
-			return fmt.Errorf("step %q failed: %w", step.Name, err)
+// Execute runs all steps in order, building up context for subsequent steps.
+// Consecutive steps marked parallel run concurrently as a group; they see the
+// context as it was before the group and their results are merged after it.
+func (e *Executor) Execute(ctx context.Context, evalCtx *hcl.EvalContext) error {
+	for i := 0; i < len(e.steps); {
+		if !e.steps[i].Parallel {
+			result, err := e.runStep(ctx, e.steps[i], evalCtx)
+			if err != nil {
+				return err
+			}
+			e.record(e.steps[i], result, evalCtx)
+			i++
+			continue
 		}
 
-		// Store the result
-		e.results[step.Name] = result
-
-		// Add step result to evaluation context for subsequent steps
-		if evalCtx.Variables == nil {
-			evalCtx.Variables = make(map[string]cty.Value)
+		j := i
+		for j < len(e.steps) && e.steps[j].Parallel {
+			j++
 		}
-
-		// Create step context object
-		stepVars := map[string]cty.Value{
-			"body":   interfaceToCty(result.Body),
-			"status": cty.NumberIntVal(int64(result.Status)),
+		if err := e.executeParallel(ctx, e.steps[i:j], evalCtx); err != nil {
+			return err
 		}
+		i = j
+	}
 
-		// Add step to context
-		if _, ok := evalCtx.Variables["step"]; !ok {
-			evalCtx.Variables["step"] = cty.ObjectVal(make(map[string]cty.Value))
-		}
+	return nil
+}
 
-		// Get existing step map
-		stepMap := make(map[string]cty.Value)
-		if stepObj, ok := evalCtx.Variables["step"]; ok && stepObj.Type().IsObjectType() {
-			for key, val := range stepObj.AsValueMap() {
-				stepMap[key] = val
+// executeParallel runs a group of steps concurrently. The first failure
+// cancels the remaining steps and is returned.
+func (e *Executor) executeParallel(ctx context.Context, steps []*config.StepConfig, evalCtx *hcl.EvalContext) error {
+	results := make([]*Result, len(steps))
+
+	g, groupCtx := errgroup.WithContext(ctx)
+	g.SetLimit(maxParallelSteps)
+	for i, step := range steps {
+		g.Go(func() error {
+			result, err := e.runStep(groupCtx, step, evalCtx)
+			if err != nil {
+				return err
 			}
-		}
-
-		// Add this step's result
-		stepMap[step.Name] = cty.ObjectVal(stepVars)
-		evalCtx.Variables["step"] = cty.ObjectVal(stepMap)
+			results[i] = result
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
 	}
 
+	for i, step := range steps {
+		e.record(step, results[i], evalCtx)
+	}
 	return nil
 }
 
+// runStep executes a single step within its own tracing span
+func (e *Executor) runStep(ctx context.Context, step *config.StepConfig, evalCtx *hcl.EvalContext) (*Result, error) {
+	// Create a tracing span for this step
+	tracer := tracing.Tracer("polymorph.step")
+	stepCtx, span := tracer.Start(ctx, "step."+step.Name,
+		trace.WithAttributes(attribute.String("step.name", step.Name)),
+	)
+	defer span.End()
+
+	// Execute the step based on its type
+	result, err := e.executeStep(stepCtx, step, evalCtx)
+	if err != nil {
+		return nil, fmt.Errorf("step %q failed: %w", step.Name, err)
+	}
+	return result, nil
+}
+
+// record stores a step result and adds it to the evaluation context for
+// subsequent steps
+func (e *Executor) record(step *config.StepConfig, result *Result, evalCtx *hcl.EvalContext) {
+	e.results[step.Name] = result
+
+	if evalCtx.Variables == nil {
+		evalCtx.Variables = make(map[string]cty.Value)
+	}
+
+	// Create step context object
+	stepVars := map[string]cty.Value{
+		"body":    interfaceToCty(result.Body),
+		"status":  cty.NumberIntVal(int64(result.Status)),
+		"headers": headersToCty(result.Headers),
+	}
+
+	// Get existing step map
+	stepMap := make(map[string]cty.Value)
+	if stepObj, ok := evalCtx.Variables["step"]; ok && stepObj.Type().IsObjectType() {
+		for key, val := range stepObj.AsValueMap() {
+			stepMap[key] = val
+		}
+	}
+
+	// Add this step's result
+	stepMap[step.Name] = cty.ObjectVal(stepVars)
+	evalCtx.Variables["step"] = cty.ObjectVal(stepMap)
+}
+
 // executeStep executes a single step based on its type
 func (e *Executor) executeStep(ctx context.Context, step *config.StepConfig, evalCtx *hcl.EvalContext) (*Result, error) {
-	// For now, only HTTP steps are supported
-	if step.HTTP != nil {
+	// A false when guard skips the step, leaving a null body
+	if step.WhenExpr != nil {
+		run, err := evaluateWhen(step.WhenExpr, evalCtx)
+		if err != nil {
+			return nil, err
+		}
+		if !run {
+			return &Result{Skipped: true}, nil
+		}
+	}
+
+	switch {
+	case step.HTTP != nil:
 		return executeHTTPStep(ctx, step.HTTP, evalCtx)
+	case step.Mock != nil:
+		return executeMockStep(step.Mock, evalCtx)
 	}
 
 	return nil, fmt.Errorf("unknown step type for step %q", step.Name)
 }
 
+// evaluateWhen evaluates a step's when guard, which must be a bool. An unset
+// guard (null) always runs the step.
+func evaluateWhen(expr hcl.Expression, evalCtx *hcl.EvalContext) (bool, error) {
+	val, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return false, fmt.Errorf("failed to evaluate when: %s", diags.Error())
+	}
+	if val.IsNull() {
+		return true, nil
+	}
+	if !val.IsKnown() || !val.Type().Equals(cty.Bool) {
+		return false, fmt.Errorf("when must be a bool, got %s", val.Type().FriendlyName())
+	}
+	return val.True(), nil
+}
+
 // Results returns all step results
 func (e *Executor) Results() map[string]*Result {
 	return e.results
 }
 
+// headersToCty converts step response headers to a map of strings
+func headersToCty(headers map[string]string) cty.Value {
+	if len(headers) == 0 {
+		return cty.MapValEmpty(cty.String)
+	}
+	m := make(map[string]cty.Value, len(headers))
+	for k, v := range headers {
+		m[k] = cty.StringVal(v)
+	}
+	return cty.MapVal(m)
+}
+
 // interfaceToCty converts a Go interface{} to a cty.Value
 func interfaceToCty(v interface{}) cty.Value {
 	if v == nil {
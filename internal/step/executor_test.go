@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -180,3 +182,235 @@ func TestExecutor_StepContextAvailability(t *testing.T) {
 	statusInt, _ := firstMap["status"].AsBigFloat().Int64()
 	require.Equal(t, int64(200), statusInt)
 }
+
+func TestExecutor_RequestID(t *testing.T) {
+	var got []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(RequestIDHeader))
+	}))
+	defer upstream.Close()
+
+	steps := []*config.StepConfig{
+		{
+			Name: "forwarded",
+			HTTP: &config.HTTPStepConfig{
+				URLExpr: mustParseExpr(`"` + upstream.URL + `"`),
+			},
+		},
+		{
+			Name: "overridden",
+			HTTP: &config.HTTPStepConfig{
+				URLExpr:     mustParseExpr(`"` + upstream.URL + `"`),
+				HeadersExpr: mustParseExpr(`{ "X-Request-Id" = "custom" }`),
+			},
+		},
+	}
+
+	evalCtx := &hcl.EvalContext{
+		Variables: make(map[string]cty.Value),
+		Functions: config.Functions(),
+	}
+	ctx := WithRequestID(context.Background(), "req-123")
+	require.NoError(t, NewExecutor(steps).Execute(ctx, evalCtx))
+
+	// A step's own header wins over the forwarded id
+	require.Equal(t, []string{"req-123", "custom"}, got)
+}
+
+func TestExecutor_MockStep(t *testing.T) {
+	steps := []*config.StepConfig{
+		{
+			Name: "auth",
+			Mock: &config.MockStepConfig{
+				SetExpr: mustParseExpr(`{ token = "abc123", scopes = ["read", "write"] }`),
+			},
+		},
+		{
+			Name: "session",
+			Mock: &config.MockStepConfig{
+				SetExpr: mustParseExpr(`{ id = "s-${step.auth.body.token}" }`),
+			},
+		},
+	}
+
+	executor := NewExecutor(steps)
+	evalCtx := &hcl.EvalContext{
+		Variables: make(map[string]cty.Value),
+		Functions: config.Functions(),
+	}
+
+	err := executor.Execute(context.Background(), evalCtx)
+	require.NoError(t, err)
+
+	results := executor.Results()
+	require.Equal(t, 200, results["auth"].Status)
+	require.Equal(t, map[string]any{
+		"token":  "abc123",
+		"scopes": []any{"read", "write"},
+	}, results["auth"].Body)
+
+	// The mock values feed into a response body
+	body, diags := mustParseExpr(`jsonencode({ token = step.auth.body.token, session = step.session.body.id })`).Value(evalCtx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.JSONEq(t, `{"token":"abc123","session":"s-abc123"}`, body.AsString())
+}
+
+func TestExecutor_MockStepError(t *testing.T) {
+	steps := []*config.StepConfig{
+		{
+			Name: "broken",
+			Mock: &config.MockStepConfig{
+				SetExpr: mustParseExpr(`step.missing.body`),
+			},
+		},
+	}
+
+	err := NewExecutor(steps).Execute(context.Background(), &hcl.EvalContext{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `step "broken" failed: failed to evaluate set`)
+}
+
+func TestExecutor_WhenGuard(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"tier":"gold"}`))
+	}))
+	defer upstream.Close()
+
+	steps := []*config.StepConfig{
+		{
+			Name:     "enrich",
+			WhenExpr: mustParseExpr(`request.query.enrich == "true"`),
+			HTTP: &config.HTTPStepConfig{
+				URLExpr: mustParseExpr(`"` + upstream.URL + `/enrich"`),
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCalls int32
+		wantBody  string
+	}{
+		{name: "executed", query: "?enrich=true", wantCalls: 1, wantBody: `{"tier":"gold"}`},
+		{name: "skipped", query: "?enrich=false", wantCalls: 0, wantBody: `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			r := httptest.NewRequest("GET", "/users"+tt.query, nil)
+			evalCtx := config.BuildEvalContext(r, nil, nil, nil)
+
+			executor := NewExecutor(steps)
+			require.NoError(t, executor.Execute(context.Background(), evalCtx))
+			require.Equal(t, tt.wantCalls, calls.Load())
+			require.Equal(t, tt.wantCalls == 0, executor.Results()["enrich"].Skipped)
+
+			// A skipped step still resolves, with a null body
+			body, diags := mustParseExpr(`jsonencode(step.enrich.body)`).Value(evalCtx)
+			require.False(t, diags.HasErrors(), diags.Error())
+			require.JSONEq(t, tt.wantBody, body.AsString())
+		})
+	}
+}
+
+func TestExecutor_WhenNotBool(t *testing.T) {
+	steps := []*config.StepConfig{
+		{
+			Name:     "enrich",
+			WhenExpr: mustParseExpr(`"yes"`),
+			Mock: &config.MockStepConfig{
+				SetExpr: mustParseExpr(`{}`),
+			},
+		},
+	}
+
+	err := NewExecutor(steps).Execute(context.Background(), &hcl.EvalContext{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "when must be a bool, got string")
+}
+
+func TestExecutor_ParallelSteps(t *testing.T) {
+	const delay = 200 * time.Millisecond
+
+	slow := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+	}
+	users := slow(`{"name":"Ada"}`)
+	defer users.Close()
+	orders := slow(`{"count":3}`)
+	defer orders.Close()
+
+	steps := []*config.StepConfig{
+		{
+			Name:     "user",
+			Parallel: true,
+			HTTP:     &config.HTTPStepConfig{URLExpr: mustParseExpr(`"` + users.URL + `"`)},
+		},
+		{
+			Name:     "orders",
+			Parallel: true,
+			HTTP:     &config.HTTPStepConfig{URLExpr: mustParseExpr(`"` + orders.URL + `"`)},
+		},
+		{
+			// Runs after the group and sees both results
+			Name: "summary",
+			Mock: &config.MockStepConfig{
+				SetExpr: mustParseExpr(`{ text = "${step.user.body.name} has ${step.orders.body.count} orders" }`),
+			},
+		},
+	}
+
+	evalCtx := &hcl.EvalContext{
+		Variables: make(map[string]cty.Value),
+		Functions: config.Functions(),
+	}
+
+	start := time.Now()
+	executor := NewExecutor(steps)
+	require.NoError(t, executor.Execute(context.Background(), evalCtx))
+	elapsed := time.Since(start)
+
+	// Closer to max(step) than sum(steps)
+	require.Less(t, elapsed, delay*3/2)
+	require.Equal(t, map[string]any{"text": "Ada has 3 orders"}, executor.Results()["summary"].Body)
+}
+
+func TestExecutor_ParallelStepError(t *testing.T) {
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer hang.Close()
+
+	steps := []*config.StepConfig{
+		{
+			Name:     "slow",
+			Parallel: true,
+			HTTP:     &config.HTTPStepConfig{URLExpr: mustParseExpr(`"` + hang.URL + `"`)},
+		},
+		{
+			Name:     "broken",
+			Parallel: true,
+			Mock:     &config.MockStepConfig{SetExpr: mustParseExpr(`step.missing.body`)},
+		},
+	}
+
+	start := time.Now()
+	err := NewExecutor(steps).Execute(context.Background(), &hcl.EvalContext{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `step "broken" failed`)
+
+	// The failure cancels the hanging request rather than waiting it out
+	require.Less(t, time.Since(start), 2*time.Second)
+}
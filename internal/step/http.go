@@ -50,6 +50,11 @@ func executeHTTPStep(ctx context.Context, httpCfg *config.HTTPStepConfig, evalCt
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Forward the inbound request id; a step's own headers may override it
+	if id := RequestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
 	// Evaluate and add headers if present
 	if httpCfg.HeadersExpr != nil {
 		val, diags := httpCfg.HeadersExpr.Value(evalCtx)
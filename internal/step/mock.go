@@ -0,0 +1,35 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/jumppad-labs/polymorph/internal/config"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// executeMockStep evaluates a mock step's set expression and uses the
+// value as the step body, so later steps and the response can read it
+func executeMockStep(mockCfg *config.MockStepConfig, evalCtx *hcl.EvalContext) (*Result, error) {
+	val, diags := mockCfg.SetExpr.Value(evalCtx)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to evaluate set: %s", diags.Error())
+	}
+
+	// Round-trip through JSON so the body has the same shape as an HTTP step's
+	data, err := ctyjson.SimpleJSONValue{Value: val}.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert set value: %w", err)
+	}
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to convert set value: %w", err)
+	}
+
+	return &Result{
+		Body:   body,
+		Status: http.StatusOK,
+	}, nil
+}
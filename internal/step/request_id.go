@@ -0,0 +1,22 @@
+package step
+
+import "context"
+
+// RequestIDHeader carries the id that correlates a request across services,
+// on inbound requests and every outbound step request
+const RequestIDHeader = "X-Request-Id"
+
+// requestIDKey is the context key for the inbound request id
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the inbound request id, which
+// HTTP steps forward to upstreams
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}